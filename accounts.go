@@ -0,0 +1,756 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Adds user accounts: registration and login with argon2id password hashing, email
+// verification and password reset token flows, and the mailer sending their links
+// Usage: gogo add accounts [--totp]
+func addAccounts(p project, args []string) ([]generatedFile, []string) {
+	fs := flag.NewFlagSet("gogo add accounts", flag.ExitOnError)
+	withTOTP := fs.Bool("totp", false, "add TOTP two-factor authentication with recovery codes")
+	parseFlags(fs, args)
+
+	// Running again with --totp on a project that has accounts only adds the 2FA module
+	if _, err := os.Stat(filepath.Join(p.dir, "internal", "services", "account_service.go")); err == nil && *withTOTP {
+		return totpFiles(p), totpSteps()
+	}
+
+	files := p.migration("create_accounts", accountsMigrationUpContent(), accountsMigrationDownContent())
+	files = append(files,
+		generatedFile{path: filepath.Join("internal", "models", "db", "account.go"), content: accountModelGoContent()},
+		generatedFile{path: filepath.Join("internal", "auth", "password.go"), content: passwordGoContent()},
+		generatedFile{path: filepath.Join("internal", "auth", "password_test.go"), content: passwordTestGoContent()},
+		generatedFile{path: filepath.Join("pkg", "mailer", "mailer.go"), content: mailerGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "repository", "errors.go"), content: repositoryErrorsGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "repository", "account_repository.go"), content: accountRepositoryGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "services", "account_service.go"), content: accountServiceGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "handlers", "respond.go"), content: respondGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "handlers", "account_handler.go"), content: accountHandlerGoContent(p.modulePath)},
+	)
+
+	steps := []string{
+		"Fetch the new dependency: go get " + goGetArgs("golang.org/x/crypto") + " && go mod tidy",
+		"Run the migration: make migrate DB_URL=postgres://...",
+		"Pick a mailer: mailer.NewLogMailer(logger) in development, mailer.NewSMTPMailer(addr, from, username, password) elsewhere",
+		"Create the service: accountSvc := services.NewAccountService(repository.NewAccountRepository(db), m, \"https://app.example.com\")",
+		"Mount the endpoints in internal/handlers/router.go: handlers.NewAccountHandler(accountSvc).Register(mux)",
+		"Issue a session or token for the account returned by Login in the login handler",
+	}
+	if *withTOTP {
+		files = append(files, totpFiles(p)...)
+		steps = append(steps, totpSteps()...)
+	}
+	return files, steps
+}
+
+// Returns the content for the accounts up migration
+func accountsMigrationUpContent() string {
+	return `CREATE TABLE IF NOT EXISTS accounts (
+    id                BIGSERIAL PRIMARY KEY,
+    email             TEXT NOT NULL,
+    password_hash     TEXT NOT NULL,
+    email_verified_at TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS accounts_email_idx ON accounts (lower(email));
+
+-- Single-use tokens mailed for email verification and password reset. Only their
+-- SHA-256 hash is stored.
+CREATE TABLE IF NOT EXISTS account_tokens (
+    token_hash BYTEA PRIMARY KEY,
+    account_id BIGINT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    purpose    TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS account_tokens_account_idx ON account_tokens (account_id, purpose);
+`
+}
+
+// Returns the content for the accounts down migration
+func accountsMigrationDownContent() string {
+	return `DROP TABLE IF EXISTS account_tokens;
+DROP TABLE IF EXISTS accounts;
+`
+}
+
+// Returns the content for internal/models/db/account.go
+func accountModelGoContent() string {
+	return `package db
+
+import "time"
+
+// Account is a row of the accounts table
+type Account struct {
+	ID              int64
+	Email           string
+	PasswordHash    string
+	EmailVerifiedAt *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+`
+}
+
+// Returns the content for internal/auth/password.go
+func passwordGoContent() string {
+	return `package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrInvalidHash is returned when a stored hash is not in the expected format
+var ErrInvalidHash = errors.New("auth: invalid password hash")
+
+// Params are the argon2id cost parameters. The defaults follow the OWASP
+// recommendation and can be raised as hardware allows: existing hashes keep
+// verifying since their parameters are stored alongside them.
+type Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams are used by HashPassword
+var DefaultParams = Params{Memory: 19 * 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// HashPassword hashes password with argon2id and a random salt, in the PHC string
+// format: $argon2id$v=19$m=...,t=...,p=...$<salt>$<hash>
+func HashPassword(password string) (string, error) {
+	p := DefaultParams
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// VerifyPassword reports whether password matches encoded, in constant time
+func VerifyPassword(password, encoded string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, ErrInvalidHash
+	}
+	var p Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return false, ErrInvalidHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+
+	got := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+`
+}
+
+// Returns the content for internal/auth/password_test.go
+func passwordTestGoContent() string {
+	return `package auth
+
+import "testing"
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := VerifyPassword("correct horse battery staple", hash); err != nil || !ok {
+		t.Errorf("VerifyPassword(correct) = %v, %v; want true, nil", ok, err)
+	}
+	if ok, err := VerifyPassword("wrong", hash); err != nil || ok {
+		t.Errorf("VerifyPassword(wrong) = %v, %v; want false, nil", ok, err)
+	}
+
+	other, _ := HashPassword("correct horse battery staple")
+	if other == hash {
+		t.Error("hashing twice gave the same hash, the salt is not random")
+	}
+}
+
+func TestVerifyPasswordRejectsMalformedHash(t *testing.T) {
+	for _, hash := range []string{"", "plain", "$argon2i$v=19$m=1,t=1,p=1$c2FsdA$aGFzaA", "$argon2id$v=19$m=x$c2FsdA$aGFzaA"} {
+		if _, err := VerifyPassword("password", hash); err != ErrInvalidHash {
+			t.Errorf("VerifyPassword(%q) error = %v, want ErrInvalidHash", hash, err)
+		}
+	}
+}
+`
+}
+
+// Returns the content for pkg/mailer/mailer.go
+func mailerGoContent() string {
+	return `package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails. Services depend on this interface so the transport can be
+// swapped (SMTP, a provider API, or a fake in tests).
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer writes emails to the log instead of sending them, for local development
+type LogMailer struct {
+	logger *zerolog.Logger
+}
+
+// NewLogMailer creates a new LogMailer
+func NewLogMailer(logger *zerolog.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+// Send implements Mailer
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Info().Str("to", msg.To).Str("subject", msg.Subject).Str("body", msg.Body).Msg("Email not sent (log mailer)")
+	return nil
+}
+
+// SMTPMailer sends emails through an SMTP server, authenticating with PLAIN auth
+// when a username is set
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a mailer for the server at addr (host:port)
+func NewSMTPMailer(addr, from, username, password string) *SMTPMailer {
+	m := &SMTPMailer{addr: addr, from: from}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Send implements Mailer
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("mailer: invalid header value")
+	}
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		m.from, msg.To, msg.Subject, msg.Body)
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, []byte(body))
+}
+`
+}
+
+// Returns the content for internal/repository/account_repository.go
+func accountRepositoryGoContent(modulePath string) string {
+	return fmt.Sprintf(`package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	dbmodels "%s/internal/models/db"
+)
+
+// ErrEmailTaken is returned when registering an email that already has an account
+var ErrEmailTaken = errors.New("email already registered")
+
+const accountColumns = "id, email, password_hash, email_verified_at, created_at, updated_at"
+
+// AccountRepository stores accounts and their single-use tokens
+type AccountRepository struct {
+	db *sql.DB
+}
+
+// NewAccountRepository creates a new AccountRepository
+func NewAccountRepository(db *sql.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+// Create inserts a new account
+func (r *AccountRepository) Create(ctx context.Context, a *dbmodels.Account) error {
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO accounts (email, password_hash) VALUES ($1, $2) RETURNING id, created_at, updated_at",
+		a.Email, a.PasswordHash,
+	).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil && strings.Contains(err.Error(), "accounts_email_idx") {
+		return ErrEmailTaken
+	}
+	return err
+}
+
+// FindByEmail returns the account registered with email, ignoring case
+func (r *AccountRepository) FindByEmail(ctx context.Context, email string) (*dbmodels.Account, error) {
+	return r.findOne(ctx, "SELECT "+accountColumns+" FROM accounts WHERE lower(email) = lower($1)", email)
+}
+
+// FindByID returns the account with the given ID
+func (r *AccountRepository) FindByID(ctx context.Context, id int64) (*dbmodels.Account, error) {
+	return r.findOne(ctx, "SELECT "+accountColumns+" FROM accounts WHERE id = $1", id)
+}
+
+func (r *AccountRepository) findOne(ctx context.Context, query string, arg any) (*dbmodels.Account, error) {
+	var a dbmodels.Account
+	err := r.db.QueryRowContext(ctx, query, arg).
+		Scan(&a.ID, &a.Email, &a.PasswordHash, &a.EmailVerifiedAt, &a.CreatedAt, &a.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// MarkEmailVerified records that the account owns its email address
+func (r *AccountRepository) MarkEmailVerified(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE accounts SET email_verified_at = COALESCE(email_verified_at, now()), updated_at = now() WHERE id = $1", id)
+	return err
+}
+
+// UpdatePasswordHash replaces the password of an account
+func (r *AccountRepository) UpdatePasswordHash(ctx context.Context, id int64, hash string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE accounts SET password_hash = $1, updated_at = now() WHERE id = $2", hash, id)
+	return err
+}
+
+// CreateToken stores the hash of a single-use token for purpose
+func (r *AccountRepository) CreateToken(ctx context.Context, accountID int64, purpose string, hash []byte, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO account_tokens (token_hash, account_id, purpose, expires_at) VALUES ($1, $2, $3, $4)",
+		hash, accountID, purpose, expiresAt)
+	return err
+}
+
+// ConsumeToken marks an unused, unexpired token as used and returns its account ID.
+// A single statement does both, so a token cannot be used twice concurrently.
+func (r *AccountRepository) ConsumeToken(ctx context.Context, purpose string, hash []byte) (int64, error) {
+	var accountID int64
+	err := r.db.QueryRowContext(ctx,
+		"UPDATE account_tokens SET used_at = now() WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now() RETURNING account_id",
+		hash, purpose,
+	).Scan(&accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return accountID, err
+}
+
+// DeleteTokens removes the tokens of an account for purpose, e.g. outstanding
+// reset links once the password has changed
+func (r *AccountRepository) DeleteTokens(ctx context.Context, accountID int64, purpose string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM account_tokens WHERE account_id = $1 AND purpose = $2", accountID, purpose)
+	return err
+}
+`, modulePath)
+}
+
+// Returns the content for internal/services/account_service.go
+func accountServiceGoContent(modulePath string) string {
+	return fmt.Sprintf(`package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"%[1]s/internal/auth"
+	dbmodels "%[1]s/internal/models/db"
+	"%[1]s/internal/repository"
+	"%[1]s/pkg/mailer"
+)
+
+const (
+	tokenPurposeVerifyEmail   = "verify_email"
+	tokenPurposeResetPassword = "reset_password"
+
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+
+	minPasswordLength = 8
+)
+
+var (
+	// ErrInvalidEmail is returned when registering with a malformed email address
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrWeakPassword is returned for passwords shorter than minPasswordLength
+	ErrWeakPassword = fmt.Errorf("password must be at least %%d characters", minPasswordLength)
+	// ErrInvalidCredentials is returned by Login for an unknown email or a wrong password
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidToken is returned for unknown, used or expired tokens
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// AccountService implements registration, login, email verification and password reset
+type AccountService struct {
+	repo    *repository.AccountRepository
+	mailer  mailer.Mailer
+	baseURL string
+
+	// dummyHash is verified against when the email is unknown, so Login takes
+	// the same time whether or not an account exists
+	dummyHash string
+}
+
+// NewAccountService creates a new AccountService. baseURL is the address of the
+// frontend, used to build the links sent by email.
+func NewAccountService(repo *repository.AccountRepository, m mailer.Mailer, baseURL string) *AccountService {
+	dummyHash, _ := auth.HashPassword("dummy password")
+	return &AccountService{repo: repo, mailer: m, baseURL: strings.TrimRight(baseURL, "/"), dummyHash: dummyHash}
+}
+
+// Register creates an account and emails its verification link
+func (s *AccountService) Register(ctx context.Context, email, password string) (*dbmodels.Account, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return nil, ErrInvalidEmail
+	}
+	if len(password) < minPasswordLength {
+		return nil, ErrWeakPassword
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	account := &dbmodels.Account{Email: email, PasswordHash: hash}
+	if err := s.repo.Create(ctx, account); err != nil {
+		return nil, err
+	}
+
+	if err := s.sendVerification(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Login returns the account matching email and password
+func (s *AccountService) Login(ctx context.Context, email, password string) (*dbmodels.Account, error) {
+	account, err := s.repo.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		auth.VerifyPassword(password, s.dummyHash)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := auth.VerifyPassword(password, account.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return account, nil
+}
+
+// Get returns the account with the given ID
+func (s *AccountService) Get(ctx context.Context, id int64) (*dbmodels.Account, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// ResendVerification emails a new verification link if the account is not verified yet
+func (s *AccountService) ResendVerification(ctx context.Context, email string) error {
+	account, err := s.repo.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil || account.EmailVerifiedAt != nil {
+		return err
+	}
+	return s.sendVerification(ctx, account)
+}
+
+// VerifyEmail consumes a verification token
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	accountID, err := s.repo.ConsumeToken(ctx, tokenPurposeVerifyEmail, hashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.MarkEmailVerified(ctx, accountID)
+}
+
+// RequestPasswordReset emails a reset link. It succeeds for unknown emails too, so
+// callers cannot find out which emails have an account.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	account, err := s.repo.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := s.issueToken(ctx, account.ID, tokenPurposeResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      account.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your account. Open this link within an hour to choose a new one:\n\n%%s/reset-password?token=%%s\n\nIf it was not you, ignore this email.",
+			s.baseURL, token),
+	})
+}
+
+// ResetPassword consumes a reset token and sets a new password. Other outstanding
+// reset links stop working.
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	if len(password) < minPasswordLength {
+		return ErrWeakPassword
+	}
+	accountID, err := s.repo.ConsumeToken(ctx, tokenPurposeResetPassword, hashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePasswordHash(ctx, accountID, hash); err != nil {
+		return err
+	}
+	return s.repo.DeleteTokens(ctx, accountID, tokenPurposeResetPassword)
+}
+
+func (s *AccountService) sendVerification(ctx context.Context, account *dbmodels.Account) error {
+	token, err := s.issueToken(ctx, account.ID, tokenPurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      account.Email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Open this link to verify your email address:\n\n%%s/verify-email?token=%%s", s.baseURL, token),
+	})
+}
+
+// issueToken stores a new random token and returns it in plaintext, to be mailed
+func (s *AccountService) issueToken(ctx context.Context, accountID int64, purpose string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if err := s.repo.CreateToken(ctx, accountID, purpose, hashToken(token), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+`, modulePath)
+}
+
+// Returns the content for internal/handlers/account_handler.go
+func accountHandlerGoContent(modulePath string) string {
+	return fmt.Sprintf(`package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	dbmodels "%[1]s/internal/models/db"
+	"%[1]s/internal/repository"
+	"%[1]s/internal/services"
+)
+
+// AccountHandler exposes the account flows over HTTP
+type AccountHandler struct {
+	svc *services.AccountService
+}
+
+// NewAccountHandler creates a new AccountHandler
+func NewAccountHandler(svc *services.AccountService) *AccountHandler {
+	return &AccountHandler{svc: svc}
+}
+
+// Register mounts the account routes on mux
+func (h *AccountHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /accounts", h.register)
+	mux.HandleFunc("POST /accounts/login", h.login)
+	mux.HandleFunc("POST /accounts/verify-email", h.verifyEmail)
+	mux.HandleFunc("POST /accounts/verify-email/resend", h.resendVerification)
+	mux.HandleFunc("POST /accounts/password-reset", h.requestPasswordReset)
+	mux.HandleFunc("POST /accounts/password-reset/confirm", h.resetPassword)
+}
+
+type accountRequest struct {
+	Email    string `+"`"+`json:"email"`+"`"+`
+	Password string `+"`"+`json:"password"`+"`"+`
+	Token    string `+"`"+`json:"token"`+"`"+`
+}
+
+type accountResponse struct {
+	ID            int64     `+"`"+`json:"id"`+"`"+`
+	Email         string    `+"`"+`json:"email"`+"`"+`
+	EmailVerified bool      `+"`"+`json:"email_verified"`+"`"+`
+	CreatedAt     time.Time `+"`"+`json:"created_at"`+"`"+`
+}
+
+func toAccountResponse(a *dbmodels.Account) accountResponse {
+	return accountResponse{ID: a.ID, Email: a.Email, EmailVerified: a.EmailVerifiedAt != nil, CreatedAt: a.CreatedAt}
+}
+
+func decodeAccountRequest(w http.ResponseWriter, r *http.Request) (accountRequest, bool) {
+	var req accountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return req, false
+	}
+	return req, true
+}
+
+func (h *AccountHandler) register(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAccountRequest(w, r)
+	if !ok {
+		return
+	}
+	account, err := h.svc.Register(r.Context(), req.Email, req.Password)
+	switch {
+	case errors.Is(err, services.ErrInvalidEmail), errors.Is(err, services.ErrWeakPassword):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repository.ErrEmailTaken):
+		writeError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	default:
+		writeJSON(w, http.StatusCreated, toAccountResponse(account))
+	}
+}
+
+func (h *AccountHandler) login(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAccountRequest(w, r)
+	if !ok {
+		return
+	}
+	account, err := h.svc.Login(r.Context(), req.Email, req.Password)
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
+		writeError(w, http.StatusUnauthorized, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	default:
+		// Issue a session cookie or an access token for the account here
+		writeJSON(w, http.StatusOK, toAccountResponse(account))
+	}
+}
+
+func (h *AccountHandler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAccountRequest(w, r)
+	if !ok {
+		return
+	}
+	h.writeTokenResult(w, h.svc.VerifyEmail(r.Context(), req.Token))
+}
+
+func (h *AccountHandler) resendVerification(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAccountRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.ResendVerification(r.Context(), req.Email); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AccountHandler) requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAccountRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	// Always accepted, so the response does not reveal whether the email has an account
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AccountHandler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAccountRequest(w, r)
+	if !ok {
+		return
+	}
+	h.writeTokenResult(w, h.svc.ResetPassword(r.Context(), req.Token, req.Password))
+}
+
+func (h *AccountHandler) writeTokenResult(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidToken), errors.Is(err, services.ErrWeakPassword):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+`, modulePath)
+}
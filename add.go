@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// project describes an existing generated project that components are added to
+type project struct {
+	dir        string
+	modulePath string
+	slog       bool // logs with log/slog rather than zerolog, see stdlib.go
+	minimal    bool // generated with --minimal, whose config has no database settings
+}
+
+// generatedFile is a file written by a component, relative to the project root.
+// Shared files (helpers used by several components) are only written when missing.
+// With a marker, content is inserted at that marker of the existing file instead:
+// applied is printed when it was, and step when the file has no such marker.
+type generatedFile struct {
+	path    string
+	content string
+	shared  bool
+	marker  string
+	id      string // block ID of a shared insertion, e.g. "database"
+	step    string
+	applied string
+	mode    os.FileMode // overrides fileMode
+}
+
+// component returns the files to add to an existing project and the wiring
+// instructions to print afterwards
+type component func(p project, args []string) ([]generatedFile, []string)
+
+// Components available to `gogo add`
+var components = map[string]component{
+	"accounts":  addAccounts,
+	"adr":       addADR,
+	"aggregate": addAggregate,
+	"apikeys":   addAPIKeys,
+	"audit":     addAudit,
+	"cqrs":      addCQRS,
+	"example":   addExample,
+	"import":    addImport,
+	"module":    addModule,
+	"payments":  addPayments,
+	"reports":   addReports,
+	"resource":  addResource,
+	"search":    addSearch,
+}
+
+// Runs `gogo add <component> [args]` in the current directory
+func runAdd(args []string) {
+	if len(args) < 1 {
+		fatalf(exitUsage, "Please provide a component to add (available: %s).", strings.Join(componentNames(), ", "))
+	}
+
+	add, ok := components[args[0]]
+	if !ok {
+		fatalf(exitUsage, "Unknown component %q (available: %s)", args[0], strings.Join(componentNames(), ", "))
+	}
+
+	p := loadProject(".")
+	generatedModule = p.modulePath
+	addInflections(p.loadManifest().Inflections)
+	// New files and directories follow the mode the project was generated with
+	if info, err := os.Stat(p.dir); err == nil {
+		dirMode = info.Mode().Perm()
+	}
+	steps := addComponent(p, add, args)
+
+	printStyled(styleGreen, "Component %s has been added successfully!\n", args[0])
+	if len(steps) > 0 {
+		printf("\nTo finish wiring it up:\n")
+		for i, step := range steps {
+			printf("  %d. %s\n", i+1, step)
+		}
+	}
+}
+
+// Adds the component add, named args[0] and given args[1:], to the project in p
+// and records it in the manifest. Returns the steps left to wire it up.
+func addComponent(p project, add component, args []string) []string {
+	files, steps := add(p, args[1:])
+	if p.slog {
+		files, steps = slogComponent(files, steps)
+	}
+	files = append(files, packageDocFiles(p, files)...)
+	owner := manifestEntry{Component: args[0], Args: args[1:]}.id()
+
+	// Check every file and apply the insertions in memory before writing anything,
+	// so a conflict leaves the project untouched
+	var pending []generatedFile
+	var reused []string
+	var edits []manifestEdit
+	edited := map[string]string{}
+	var wiring []string
+	// Imports go last, into the files the code needing them was inserted into
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].marker != markerImports && files[j].marker == markerImports
+	})
+	for _, f := range files {
+		if f.marker != "" {
+			content, ok := edited[f.path]
+			if f.marker == markerImports && !ok {
+				continue
+			}
+			if !ok {
+				data, err := os.ReadFile(filepath.Join(p.dir, f.path))
+				if err != nil {
+					wiring = append(wiring, f.step)
+					continue
+				}
+				content = string(data)
+			}
+			id := f.id
+			if id == "" {
+				id = owner
+			}
+			var err error
+			var skipped []string
+			if strings.HasSuffix(f.path, ".go") {
+				content, ok, err = insertGo(content, f.marker, id, f.content)
+			} else {
+				content, ok, skipped = mergeText(f.path, content, f.marker, id, f.content)
+			}
+			for _, name := range skipped {
+				printStyled(styleYellow, "Warning: %s already defines %s, keeping its definition\n", f.path, name)
+			}
+			if err != nil {
+				printStyled(styleYellow, "Warning: could not edit %s: %v\n", f.path, err)
+			}
+			if !ok {
+				wiring = append(wiring, f.step)
+				continue
+			}
+			edited[f.path] = content
+			if f.applied != "" {
+				wiring = append(wiring, f.applied)
+			}
+			// Imports need no block: gogo remove drops the ones left unused
+			if f.marker != markerImports {
+				edits = append(edits, manifestEdit{Path: filepath.ToSlash(f.path), ID: id})
+			}
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(p.dir, f.path)); err == nil {
+			if f.shared {
+				reused = append(reused, f.path)
+				continue
+			}
+			fatalf(exitExists, "File %s already exists, not overwriting it", f.path)
+		}
+		pending = append(pending, f)
+	}
+	for _, f := range pending {
+		mode := f.mode
+		if mode == 0 {
+			mode = fileMode(f.path)
+		}
+		createFileMode(filepath.Join(p.dir, f.path), f.content, mode)
+	}
+	for path, content := range edited {
+		writeEditedFile(filepath.Join(p.dir, path), content)
+	}
+	p.recordComponent(args[0], args[1:], pending, reused, edits)
+
+	// Steps depending on the insertions come last
+	var fallback []string
+	for _, step := range wiring {
+		if step != "" && !slices.Contains(fallback, step) {
+			fallback = append(fallback, step)
+		}
+	}
+	return append(steps, fallback...)
+}
+
+// Writes content over the existing file at path, keeping its mode
+func writeEditedFile(path, content string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		fatalf(exitFailure, "Failed to edit %s: %v", path, err)
+	}
+	debugf("edit %s (%d bytes)", path, len(content))
+	if err := os.WriteFile(path, []byte(content), info.Mode().Perm()); err != nil {
+		fatalf(exitFailure, "Failed to edit %s: %v", path, err)
+	}
+}
+
+// Returns the sorted names of all components
+func componentNames() []string {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Loads the project in dir, reading the module path from go.mod and falling
+// back to the directory name like the generated imports do
+func loadProject(dir string) project {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		fatalf(exitFailure, "Failed to resolve project directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(abs, "internal")); err != nil {
+		fatalf(exitUsage, "%s does not look like a gogo project (no internal directory)", abs)
+	}
+
+	p := project{dir: abs, modulePath: filepath.Base(abs)}
+	if f, err := os.Open(filepath.Join(abs, "go.mod")); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "module ") {
+				p.modulePath = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`)
+				break
+			}
+		}
+	}
+	// Minimal projects and the ones generated with --stdlib=logger log with log/slog
+	if data, err := os.ReadFile(filepath.Join(abs, "pkg", "logger", "logger.go")); err == nil {
+		p.slog = strings.Contains(string(data), `"log/slog"`)
+	}
+	if data, err := os.ReadFile(filepath.Join(abs, "pkg", "config", "config.go")); err == nil {
+		p.minimal = !strings.Contains(string(data), "DBHost")
+	}
+	return p
+}
+
+// Time of the last migration generated by this run
+var lastMigrationAt time.Time
+
+// Returns the up and down files of a new golang-migrate migration, refusing to
+// add a migration whose name is already used
+func (p project) migration(name, up, down string) []generatedFile {
+	existing, _ := filepath.Glob(filepath.Join(p.dir, "migrations", "*_"+name+".up.sql"))
+	if len(existing) > 0 {
+		fatalf(exitExists, "Migration %s already exists: %s", name, existing[0])
+	}
+
+	// Migrations added by the same run get increasing versions, as golang-migrate
+	// requires them to be unique
+	at := time.Now().UTC().Truncate(time.Second)
+	if !at.After(lastMigrationAt) {
+		at = lastMigrationAt.Add(time.Second)
+	}
+	lastMigrationAt = at
+	version := at.Format("20060102150405")
+	return []generatedFile{
+		{path: filepath.Join("migrations", version+"_"+name+".up.sql"), content: up},
+		{path: filepath.Join("migrations", version+"_"+name+".down.sql"), content: down},
+	}
+}
+
+// modelField is a field of the db model of an existing resource
+type modelField struct {
+	goName string
+	goType string // as written in the model, e.g. "float64" or "*time.Time"
+	column string // snake_case column and JSON name
+}
+
+// model is the db model of an existing resource, read from internal/models/db
+type model struct {
+	name     string // snake_case singular, as passed to gogo add resource
+	typeName string
+	table    string
+	idType   string
+	idImport string       // package providing idType, if any
+	fields   []modelField // every field but ID
+}
+
+// Reads the db model generated for the resource name, so components built on top
+// of a resource follow its current fields rather than the original command line
+func (p project) model(name string) model {
+	m := model{name: singularize(snakeCase(name))}
+	m.typeName = exportedName(m.name)
+	m.table = tableName(m.name)
+
+	path := filepath.Join(p.dir, "internal", "models", "db", m.name+".go")
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		fatalf(exitUsage, "Failed to read the %s model (add it first with gogo add resource %s): %v", m.name, m.name, err)
+	}
+
+	obj := file.Scope.Lookup(m.typeName)
+	if obj == nil {
+		fatalf(exitUsage, "%s does not declare type %s", path, m.typeName)
+	}
+	st, ok := obj.Decl.(*ast.TypeSpec).Type.(*ast.StructType)
+	if !ok {
+		fatalf(exitUsage, "%s.%s is not a struct", path, m.typeName)
+	}
+	for _, f := range st.Fields.List {
+		goType := types.ExprString(f.Type)
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			if n.Name == "ID" {
+				m.idType = goType
+				continue
+			}
+			m.fields = append(m.fields, modelField{goName: n.Name, goType: goType, column: snakeCase(n.Name)})
+		}
+	}
+	if m.idType == "" {
+		fatalf(exitUsage, "%s.%s has no ID field", path, m.typeName)
+	}
+
+	if pkg, _, ok := strings.Cut(m.idType, "."); ok {
+		for _, imp := range file.Imports {
+			importPath, _ := strconv.Unquote(imp.Path.Value)
+			if importName(imp, importPath) == pkg {
+				m.idImport = importPath
+			}
+		}
+	}
+	return m
+}
+
+// Returns the name an import is referred to by: its alias, or the last element of
+// its path ignoring a major version suffix
+func importName(imp *ast.ImportSpec, importPath string) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+	return name
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// addon is a component of gogo add that --with adds when generating a project
+type addon struct {
+	component   string   // in components
+	args        []string // given to the component
+	requires    []string // add-ons it is built on, added before it
+	description string
+}
+
+// Add-ons of --with
+var addons = map[string]addon{
+	"auth":     {component: "accounts", description: "accounts with password login, email verification and password reset"},
+	"totp":     {component: "accounts", args: []string{"--totp"}, requires: []string{"auth"}, description: "TOTP two-factor authentication of the accounts"},
+	"apikeys":  {component: "apikeys", description: "API keys with scopes"},
+	"audit":    {component: "audit", description: "append-only audit log"},
+	"payments": {component: "payments", description: "Stripe checkout and webhooks"},
+	"reports":  {component: "reports", description: "PDF, Excel and CSV reports"},
+	"cqrs":     {component: "cqrs", description: "command and query buses"},
+}
+
+// Returns the names of the add-ons, sorted
+func addonNames() []string {
+	names := make([]string, 0, len(addons))
+	for name := range addons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parses --with, a comma-separated list of add-ons, and returns them in the
+// order they are added: each after the add-ons it requires, which are added
+// even when not listed, and once
+func parseWith(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var order []string
+	added := map[string]bool{}
+	var visit func(name string, stack []string)
+	visit = func(name string, stack []string) {
+		if added[name] {
+			return
+		}
+		a, ok := addons[name]
+		if !ok {
+			fatalf(exitUsage, "Unsupported --with value %q (supported: %s)", name, strings.Join(addonNames(), ", "))
+		}
+		for _, s := range stack {
+			if s == name {
+				fatalf(exitFailure, "Add-on %s requires itself (%s)", name, strings.Join(append(stack, name), " -> "))
+			}
+		}
+		for _, required := range a.requires {
+			visit(required, append(stack, name))
+		}
+		added[name] = true
+		order = append(order, name)
+	}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			visit(name, nil)
+		}
+	}
+	return order
+}
+
+// addonWiring is what is left to wire up an add-on once it is added
+type addonWiring struct {
+	name  string
+	steps []string
+}
+
+// Adds the add-ons of --with to the project p, in the order of parseWith, each
+// recorded in the manifest as gogo add would. Returns the steps left to wire
+// them up.
+func addAddons(p project, with []string) []addonWiring {
+	var wiring []addonWiring
+	for _, name := range with {
+		a := addons[name]
+		steps := addComponent(p, components[a.component], append([]string{a.component}, a.args...))
+		if len(steps) > 0 {
+			wiring = append(wiring, addonWiring{name, steps})
+		}
+	}
+	return wiring
+}
+
+// Prints the steps left to wire up the add-ons, after the next steps
+func printAddonWiring(wiring []addonWiring) {
+	for _, w := range wiring {
+		printf("\n")
+		printStyled(styleBold, "To finish wiring up %s (%s):", w.name, addons[w.name].description)
+		printf("\n")
+		for i, step := range w.steps {
+			printf("  %d. %s\n", i+1, step)
+		}
+	}
+}
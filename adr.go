@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Directory of the architecture decision records of generated projects
+var adrDir = filepath.Join("docs", "adr")
+
+// File names of ADRs, e.g. 0002-serve-http-with-the-standard-library.md
+var adrFileName = regexp.MustCompile(`^(\d{4})-.*\.md$`)
+
+// adr is an architecture decision record, in Michael Nygard's format
+type adr struct {
+	title        string
+	context      string
+	decision     string
+	consequences string
+}
+
+// Returns the content of the accepted ADR numbered n, dated date
+func (a adr) content(n int, date string) string {
+	return fmt.Sprintf("# %d. %s\n\nDate: %s\n\n## Status\n\nAccepted\n\n## Context\n\n%s\n\n## Decision\n\n%s\n\n## Consequences\n\n%s\n",
+		n, a.title, date, a.context, a.decision, a.consequences)
+}
+
+// Returns the path of the ADR numbered n with the given title, relative to the project
+func adrPath(n int, title string) string {
+	return filepath.Join(adrDir, fmt.Sprintf("%04d-%s.md", n, adrSlug(title)))
+}
+
+// Returns the file name part of an ADR title: its letters and digits in lower
+// case, runs of anything else becoming a dash
+func adrSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(title) {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(c)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// Returns the files of docs/adr for a new project: the README, the template and
+// one accepted ADR per stack choice made while generating it
+func adrFiles(opts options) []generatedFile {
+	date := time.Now().Format(time.DateOnly)
+	files := []generatedFile{
+		{path: filepath.Join(adrDir, "README.md"), content: adrReadmeContent()},
+		{path: filepath.Join(adrDir, "template.md"), content: adrTemplateContent()},
+	}
+	for i, a := range scaffoldingDecisions(opts) {
+		files = append(files, generatedFile{path: adrPath(i+1, a.title), content: a.content(i+1, date)})
+	}
+	return files
+}
+
+// Returns the decisions made by generating the project opts describes
+func scaffoldingDecisions(opts options) []adr {
+	decisions := []adr{{
+		title:        "Record architecture decisions",
+		context:      "Decisions shaping the architecture are made once and questioned for years; without a record, their reasons are lost with the people who made them.",
+		decision:     "Significant decisions are recorded as architecture decision records (ADRs) in docs/adr, one numbered Markdown file each, created from template.md with `gogo add adr \"<title>\"`. An ADR is never rewritten once accepted: a new one supersedes it.",
+		consequences: "The reasons behind the architecture are reviewed with the code, in pull requests. The first ADRs record the choices made when the project was generated with gogo.",
+	}}
+
+	if opts.minimal {
+		return append(decisions, adr{
+			title:        "Depend on the standard library only",
+			context:      "The service is meant to be read and understood in full, e.g. for teaching, and every dependency is code to audit and upgrade.",
+			decision:     "The service uses the Go standard library only: routes on a net/http ServeMux wrapped in func(http.Handler) http.Handler middlewares, structured JSON logs with log/slog, and settings read from environment variables, falling back to configs/<APP_ENV>.env and then to defaults in pkg/config.",
+			consequences: "go.mod has no requirements and there is nothing to upgrade but Go itself. Database access, caching and config formats other than KEY=VALUE are added by hand, or by generating the project without --minimal.",
+		})
+	}
+
+	switch opts.archetype {
+	case "nats":
+		decisions = append(decisions, adr{
+			title:        "Communicate through NATS JetStream",
+			context:      "The service reacts to events and answers requests from other services rather than serving clients over HTTP.",
+			decision:     "The service consumes events from a JetStream stream through a durable consumer, both provisioned at startup, and serves request-reply endpoints with the NATS services API.",
+			consequences: "Events survive restarts and are redelivered until acknowledged, so handlers must be idempotent. Every environment needs a JetStream-enabled NATS server.",
+		})
+	case "batch":
+		decisions = append(decisions, adr{
+			title:        "Run the job as an extract-transform-load pipeline",
+			context:      "The service processes data in runs rather than serving requests.",
+			decision:     "The job is an ETL pipeline (internal/etl) reading from CSV, SQL or JSON Lines sources, with the job-specific transform in internal/jobs, a configurable number of workers and checkpoints to resume interrupted runs.",
+			consequences: "Transforms stay free of I/O concerns and can be tested on their own. Runs can be retried safely as long as the load step is idempotent.",
+		})
+	default:
+		logLibrary := "zerolog"
+		if opts.stdlibLogger {
+			logLibrary = "log/slog"
+		}
+		decisions = append(decisions, adr{
+			title:        "Serve HTTP with the standard library",
+			context:      "The service exposes an HTTP API. Web frameworks add routing features and dependencies that Go's net/http covers since Go 1.22.",
+			decision:     "Routes are registered on a net/http ServeMux with method and path patterns, wrapped in plain func(http.Handler) http.Handler middlewares (request ID, access log). Logs are structured JSON written with " + logLibrary + ".",
+			consequences: "There is no framework to learn or upgrade, and any net/http middleware or tool fits in. Features such as request binding or validation are written by hand or added as small libraries.",
+		})
+	}
+
+	decisions = append(decisions, adr{
+		title:        "Store data in PostgreSQL",
+		context:      "The service needs a relational database, locally and in every environment.",
+		decision:     "Data is stored in PostgreSQL, reached through database/sql with the pgx driver. The schema evolves through golang-migrate migrations in migrations/, and docker-compose.yml runs Postgres for development.",
+		consequences: "Queries are plain SQL in the repository layer, with no ORM. Schema changes are reviewed as migration files and applied with make migrate.",
+	}, adr{
+		title:        fmt.Sprintf("Configure the service with %s files per environment", strings.ToUpper(opts.configFormat)),
+		context:      "The service runs in several environments (dev, staging, prod) that differ in their settings and secrets.",
+		decision:     fmt.Sprintf("Settings are read from configs/<env>.%s, selected by APP_ENV. Environment variables override any value from the file, and the command line flags of the service override both.", opts.configFormat),
+		consequences: "The settings of every environment are visible in one place, while secrets are given through environment variables in deployed environments.",
+	})
+	if opts.stdlibLogger || opts.stdlibConfig {
+		var packages, decision []string
+		if opts.stdlibLogger {
+			packages = append(packages, "pkg/logger")
+			decision = append(decision, "pkg/logger writes structured JSON with log/slog, and the other packages log through *slog.Logger.")
+		}
+		if opts.stdlibConfig {
+			packages = append(packages, "pkg/config")
+			decision = append(decision, "pkg/config parses the flags with the flag package, reads the environment with os.Getenv and the config files itself, and polls them for changes. Settings keep their order of precedence: flag, environment variable, config file, default.")
+		}
+		decisions = append(decisions, adr{
+			title:        "Write " + strings.Join(packages, " and ") + " with the standard library",
+			context:      "The dependency policy of the team restricts third-party runtime dependencies: each one is code to audit, license and upgrade.",
+			decision:     strings.Join(decision, " "),
+			consequences: "go.mod does not require the libraries these packages are otherwise generated with. The features those libraries bring are written by hand or left to the platform, e.g. log file rotation or config formats other than KEY=VALUE.",
+		})
+	}
+
+	if opts.archetype == "api" {
+		if opts.layout == "modular-monolith" {
+			decisions = append(decisions, adr{
+				title:        "Organize the code as a modular monolith",
+				context:      "The application covers several business areas that should stay independent, without the operational cost of one service per area.",
+				decision:     "The code is split by module under internal/modules/<module>, each a vertical slice with its own handler, service and repo packages, wired in its root package and registered by internal/modules/modules.go. Modules use each other only through their root package, and depguard keeps each repo the innermost layer.",
+				consequences: "A module can be understood, tested and later extracted as a service on its own. Code shared by modules needs a deliberate home in pkg/ or a module's API.",
+			})
+		} else {
+			decisions = append(decisions, adr{
+				title:        "Organize the code by layer",
+				context:      "The code of the service needs a structure that keeps transport, business rules and storage apart.",
+				decision:     "The code is split by layer: HTTP handlers in internal/handlers, business rules in internal/services and storage in internal/repository, with dependencies going handlers -> services -> repository, enforced by depguard rules in .golangci.yml.",
+				consequences: "Each layer can be tested on its own and the place of new code is predictable. A feature touches several packages.",
+			})
+		}
+	}
+
+	if opts.dbTopology == "primary-replica" {
+		decisions = append(decisions, adr{
+			title:        "Serve reads from a database replica",
+			context:      "Reads outnumber writes, and the primary database should keep its capacity for the writes.",
+			decision:     "A read replica streams the changes of the primary. Repositories take a repository.Router, which sends SELECTs that lock no rows to the replica and writes and transactions to the primary.",
+			consequences: "Reads scale apart from writes. A read may miss a write made just before it, so a request reading its own writes uses repository.ReadPrimary, and the replica is one more database to run.",
+		})
+	}
+	if opts.cache == "redis" {
+		decisions = append(decisions, adr{
+			title:        "Cache and rate limit with Redis",
+			context:      "Several instances of the service need a shared cache and shared rate limits.",
+			decision:     "Redis is added to the stack (pkg/cache), and requests are rate limited with a sliding window kept in Redis (pkg/ratelimit).",
+			consequences: "Limits hold across instances. Redis becomes a dependency of every environment, and the service must decide how to behave when it is down.",
+		})
+	}
+	if opts.workflow == "temporal" {
+		decisions = append(decisions, adr{
+			title:        "Run long-running processes as Temporal workflows",
+			context:      "Some processes span several steps and outlive a request, and must resume after failures.",
+			decision:     "Such processes are Temporal workflows and activities (internal/workflows), run by a dedicated worker (cmd/worker).",
+			consequences: "Retries, timeouts and state are handled by Temporal. Workflow code must stay deterministic, and every environment needs a Temporal cluster.",
+		})
+	}
+	switch {
+	case opts.deploy == "cloudrun":
+		decisions = append(decisions, adr{
+			title:        "Deploy to Google Cloud Run",
+			context:      "The service needs a managed platform running its container.",
+			decision:     "The container is deployed to Cloud Run (deploy/cloudrun), with Postgres on Cloud SQL and secrets in Secret Manager.",
+			consequences: "Scaling and TLS are managed by the platform. The service must be stateless and start quickly.",
+		})
+	case opts.iac == "terraform":
+		decisions = append(decisions, adr{
+			title:        "Run the container on AWS ECS",
+			context:      "The service needs a managed platform running its container.",
+			decision:     "The container runs on ECS with Fargate, with Postgres on RDS and secrets in Secrets Manager.",
+			consequences: "There are no servers to manage, but the network (VPC, subnets) must be provided.",
+		})
+	}
+	if opts.iac == "terraform" {
+		decisions = append(decisions, adr{
+			title:        "Manage the infrastructure with Terraform",
+			context:      "The infrastructure of each environment must be reproducible and reviewed like code.",
+			decision:     "The infrastructure is a Terraform module (infra/modules/service) called by one root module per environment (infra/environments/<env>).",
+			consequences: "Infrastructure changes go through pull requests and make tf-plan. The Terraform state must be stored remotely and locked before the team shares it.",
+		})
+	}
+	return decisions
+}
+
+// Adds an architecture decision record to docs/adr, numbered after the existing
+// ones and created from docs/adr/template.md.
+// Usage: gogo add adr "<title>"
+func addADR(p project, args []string) ([]generatedFile, []string) {
+	title := strings.TrimSpace(strings.Join(args, " "))
+	if adrSlug(title) == "" {
+		fatalf(exitUsage, `Please provide the title of the decision, e.g. gogo add adr "Use Kafka for events"`)
+	}
+
+	last := 0
+	entries, err := os.ReadDir(filepath.Join(p.dir, adrDir))
+	if err != nil && !os.IsNotExist(err) {
+		fatalf(exitFailure, "Failed to read %s: %v", adrDir, err)
+	}
+	for _, e := range entries {
+		if m := adrFileName.FindStringSubmatch(e.Name()); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			last = max(last, n)
+		}
+	}
+
+	template := adrTemplateContent()
+	if data, err := os.ReadFile(filepath.Join(p.dir, adrDir, "template.md")); err == nil {
+		template = string(data)
+	}
+	content := strings.NewReplacer("NUMBER", strconv.Itoa(last+1), "TITLE", title, "DATE", time.Now().Format(time.DateOnly), "STATUS", "Proposed").Replace(template)
+	path := adrPath(last+1, title)
+	return []generatedFile{{path: path, content: content}}, []string{
+		"Describe the context, decision and consequences in " + filepath.ToSlash(path),
+		"Once the decision is agreed on, change its status to Accepted",
+	}
+}
+
+// Returns the content for docs/adr/README.md
+func adrReadmeContent() string {
+	return "# Architecture decision records\n\n" +
+		"This directory records the decisions that shape the architecture of the project, one numbered file per decision, in Michael Nygard's format: context, decision and consequences.\n\n" +
+		"Create a new record with `gogo add adr \"<title>\"`, which numbers it after the existing ones and fills in `template.md`, then open it in a pull request with status Proposed. Accepted records are not rewritten: to change a decision, add a record superseding it and set the status of the old one to \"Superseded by <number>\".\n\n" +
+		"The first records were written by gogo when the project was generated, from the options it was generated with.\n"
+}
+
+// Returns the content for docs/adr/template.md, whose placeholders gogo add adr fills in
+func adrTemplateContent() string {
+	return `# NUMBER. TITLE
+
+Date: DATE
+
+## Status
+
+STATUS
+
+## Context
+
+What is the issue motivating this decision, and what forces are at play?
+
+## Decision
+
+What is the change being proposed or made?
+
+## Consequences
+
+What becomes easier or harder because of this change?
+`
+}
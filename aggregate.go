@@ -0,0 +1,282 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// aggregate holds what the aggregate generator needs to render its files
+type aggregate struct {
+	name     string // snake_case, used for the directory and file names
+	pkg      string // Go package name
+	typeName string // exported aggregate root type name
+	events   []string
+}
+
+// Adds an event-sourced aggregate root with its domain events, repository interface and tests
+// Usage: gogo generate aggregate <Name> [--events=Created,Renamed]
+func addAggregate(p project, args []string) ([]generatedFile, []string) {
+	fs := flag.NewFlagSet("gogo generate aggregate", flag.ExitOnError)
+	events := fs.String("events", "", "comma-separated domain events, e.g. OrderPlaced,OrderCancelled")
+	positional := parseFlags(fs, args)
+	if len(positional) < 1 {
+		fatalf(exitUsage, "Please provide an aggregate name, e.g. gogo generate aggregate Order --events=OrderPlaced,OrderCancelled")
+	}
+
+	a := newAggregate(positional[0], *events)
+	dir := filepath.Join("internal", "domain", a.name)
+
+	return []generatedFile{
+		{path: filepath.Join(dir, a.name+".go"), content: aggregateGoContent(a)},
+		{path: filepath.Join(dir, "events.go"), content: aggregateEventsGoContent(a)},
+		{path: filepath.Join(dir, "repository.go"), content: aggregateRepositoryGoContent(a)},
+		{path: filepath.Join(dir, a.name+"_test.go"), content: aggregateTestGoContent(a)},
+	}, []string{
+		fmt.Sprintf("Add behaviour methods to %s that check invariants and call raise(...) with a new event", a.typeName),
+		fmt.Sprintf("Implement %s.Repository on top of your event store or database", a.pkg),
+	}
+}
+
+// Builds an aggregate from its name and the comma-separated event names
+func newAggregate(name, events string) aggregate {
+	snake := snakeCase(name)
+	a := aggregate{
+		name:     snake,
+		pkg:      strings.ReplaceAll(snake, "_", ""),
+		typeName: exportedName(snake),
+	}
+
+	for _, e := range strings.Split(events, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if !token.IsIdentifier(e) || !token.IsExported(e) {
+			fatalf(exitUsage, "Invalid event name %q: events must be exported Go identifiers, e.g. %sCreated", e, a.typeName)
+		}
+		a.events = append(a.events, e)
+	}
+	if len(a.events) == 0 {
+		a.events = []string{a.typeName + "Created"}
+	}
+	return a
+}
+
+// Returns the status an aggregate is in after the given event, e.g. "placed" for OrderPlaced
+func (a aggregate) status(event string) string {
+	return snakeCase(strings.TrimPrefix(event, a.typeName))
+}
+
+// Returns the content for internal/domain/<name>/<name>.go
+func aggregateGoContent(a aggregate) string {
+	var cases, handlers strings.Builder
+	for _, e := range a.events {
+		fmt.Fprintf(&cases, "\tcase %s:\n\t\t%s.on%s(e)\n", e, a.receiver(), e)
+		fmt.Fprintf(&handlers, `
+func (%[1]s *%[2]s) on%[3]s(e %[3]s) {
+	%[1]s.status = %[4]q
+}
+`, a.receiver(), a.typeName, e, a.status(e))
+	}
+
+	return formatGo(fmt.Sprintf(`package %[1]s
+
+import "fmt"
+
+// %[2]s is the aggregate root. Its state only changes by applying events, so it can
+// be rebuilt from its history and every change is recorded.
+type %[2]s struct {
+	id      string
+	version int
+	changes []Event
+
+	status string
+}
+
+// New creates an empty %[2]s with the given ID
+func New(id string) *%[2]s {
+	return &%[2]s{id: id}
+}
+
+// Load rebuilds %[4]s from its stored events
+func Load(id string, history []Event) (*%[2]s, error) {
+	%[3]s := New(id)
+	for _, e := range history {
+		if err := %[3]s.apply(e); err != nil {
+			return nil, err
+		}
+	}
+	return %[3]s, nil
+}
+
+// ID returns the aggregate ID
+func (%[3]s *%[2]s) ID() string {
+	return %[3]s.id
+}
+
+// Version returns the number of events applied, used for optimistic concurrency
+func (%[3]s *%[2]s) Version() int {
+	return %[3]s.version
+}
+
+// Status returns the state reached by the last applied event
+func (%[3]s *%[2]s) Status() string {
+	return %[3]s.status
+}
+
+// Changes returns the events raised since the aggregate was loaded, for the repository to save
+func (%[3]s *%[2]s) Changes() []Event {
+	return %[3]s.changes
+}
+
+// ClearChanges forgets the raised events once they have been saved
+func (%[3]s *%[2]s) ClearChanges() {
+	%[3]s.changes = nil
+}
+
+// raise applies a new event and records it as a change. Behaviour methods check
+// their invariants first, then call raise.
+func (%[3]s *%[2]s) raise(e Event) {
+	if err := %[3]s.apply(e); err != nil {
+		panic(err)
+	}
+	%[3]s.changes = append(%[3]s.changes, e)
+}
+
+// apply mutates the state for a single event
+func (%[3]s *%[2]s) apply(e Event) error {
+	switch e := e.(type) {
+%[5]s	default:
+		return fmt.Errorf("%[1]s: unknown event %%T", e)
+	}
+	%[3]s.version++
+	return nil
+}
+%[6]s`, a.pkg, a.typeName, a.receiver(), withArticle(humanize(a.name)), cases.String(), handlers.String()))
+}
+
+// Returns the content for internal/domain/<name>/events.go
+func aggregateEventsGoContent(a aggregate) string {
+	var events strings.Builder
+	for _, e := range a.events {
+		fmt.Fprintf(&events, `
+// %[1]s is a domain event of %[2]s
+type %[1]s struct {
+	%[2]sID    string
+	OccurredAt time.Time
+}
+
+// EventName implements Event
+func (e %[1]s) EventName() string { return %[1]q }
+
+// AggregateID implements Event
+func (e %[1]s) AggregateID() string { return e.%[2]sID }
+`, e, a.typeName)
+	}
+
+	return formatGo(fmt.Sprintf(`package %s
+
+import "time"
+
+// Event is a fact that happened to %s, named in the past tense
+type Event interface {
+	EventName() string
+	AggregateID() string
+}
+%s`, a.pkg, withArticle(humanize(a.name)), events.String()))
+}
+
+// Returns the content for internal/domain/<name>/repository.go
+func aggregateRepositoryGoContent(a aggregate) string {
+	return fmt.Sprintf(`package %[1]s
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNotFound is returned when no %[3]s has the requested ID
+	ErrNotFound = errors.New("%[3]s not found")
+	// ErrConcurrentUpdate is returned when the stored version changed since the aggregate was loaded
+	ErrConcurrentUpdate = errors.New("%[3]s was modified concurrently")
+)
+
+// Repository loads and saves %[2]s aggregates. Implementations live in the
+// infrastructure layer (e.g. internal/repository) and depend on this package, not
+// the other way around.
+type Repository interface {
+	// Load returns the aggregate rebuilt from its events, or ErrNotFound
+	Load(ctx context.Context, id string) (*%[2]s, error)
+	// Save appends the aggregate's changes, failing with ErrConcurrentUpdate when
+	// the stored version differs from the loaded one
+	Save(ctx context.Context, aggregate *%[2]s) error
+}
+`, a.pkg, a.typeName, humanize(a.name))
+}
+
+// Returns the content for internal/domain/<name>/<name>_test.go
+func aggregateTestGoContent(a aggregate) string {
+	var history strings.Builder
+	for _, e := range a.events {
+		fmt.Fprintf(&history, "\t\t%s{%sID: \"1\"},\n", e, a.typeName)
+	}
+	last := a.events[len(a.events)-1]
+
+	return formatGo(fmt.Sprintf(`package %[1]s
+
+import "testing"
+
+func TestRaiseRecordsChanges(t *testing.T) {
+	%[3]s := New("1")
+	%[3]s.raise(%[4]s{%[2]sID: "1"})
+
+	if got := %[3]s.Version(); got != 1 {
+		t.Errorf("Version() = %%d, want 1", got)
+	}
+	if got := len(%[3]s.Changes()); got != 1 {
+		t.Fatalf("len(Changes()) = %%d, want 1", got)
+	}
+	if got := %[3]s.Status(); got != %[5]q {
+		t.Errorf("Status() = %%q, want %%q", got, %[5]q)
+	}
+
+	%[3]s.ClearChanges()
+	if got := len(%[3]s.Changes()); got != 0 {
+		t.Errorf("len(Changes()) after ClearChanges = %%d, want 0", got)
+	}
+}
+
+func TestLoadReplaysHistory(t *testing.T) {
+	history := []Event{
+%[6]s	}
+
+	%[3]s, err := Load("1", history)
+	if err != nil {
+		t.Fatalf("Load: %%v", err)
+	}
+	if got := %[3]s.Version(); got != len(history) {
+		t.Errorf("Version() = %%d, want %%d", got, len(history))
+	}
+	if got := %[3]s.Status(); got != %[7]q {
+		t.Errorf("Status() = %%q, want %%q", got, %[7]q)
+	}
+	if got := len(%[3]s.Changes()); got != 0 {
+		t.Errorf("loading recorded %%d changes, want 0", got)
+	}
+}
+`, a.pkg, a.typeName, a.receiver(), a.events[0], a.status(a.events[0]), history.String(), a.status(last)))
+}
+
+// Returns the receiver name used in the generated methods, avoiding the names
+// of the event variable and of *testing.T
+func (a aggregate) receiver() string {
+	r := strings.ToLower(a.typeName[:1])
+	if r == "e" || r == "t" {
+		return "agg"
+	}
+	return r
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// annotation explains why a file of --annotated projects exists and how it
+// relates to the rest of the project
+type annotation struct {
+	pattern string // path.Match pattern of the slash path relative to the project, or of the base name when it has no slash
+	text    string
+}
+
+// Returns the explanations of the files generated for opts, the first matching
+// pattern applying to a file
+func fileAnnotations(opts options) []annotation {
+	config := "The configuration layer: Config holds every setting of the service, typed, and LoadConfig resolves each one from the command line flags, the environment variables, the config file of APP_ENV (configs/<env>." + opts.configFormat + ") and its default, in that order. main loads it once and hands the values to the packages that need them, which never read the environment themselves: a setting is added here, with its default, and then to the config files."
+	logger := "The logging package: it builds the structured JSON logger main passes to every layer, writing to stdout and optionally to a rotated file. Components log through the logger they are given, or through logger.FromContext(ctx) in request code to get the request and trace IDs attached by the middlewares, so that every line of a request can be found with one ID."
+	router := "The table of contents of the HTTP layer: every route of the API is registered here on a net/http ServeMux with a method and path pattern (e.g. \"GET /items/{id}\"), then wrapped in the middlewares shared by all routes. Handlers translate between HTTP and calls to internal/services; they hold no business rules and never reach the database directly."
+	if opts.stdlibLogger {
+		logger = "The logging package, written with log/slog: it builds the structured JSON logger main passes to every layer, writing to stdout and optionally appending to a file. Components log through the logger they are given, or through logger.FromContext(ctx) in request code to get the request and trace IDs attached by the middlewares, so that every line of a request can be found with one ID."
+	}
+	if opts.stdlibConfig {
+		config = "The configuration layer, written with the standard library: Config holds every setting of the service, typed, and LoadConfig resolves each one from the command line flags, the environment variables, configs/<APP_ENV>.env and its default, in that order, decoding it by the type of its field. main loads it once and hands the values to the packages that need them, which never read the environment themselves: a setting is added here, with its default, and then to the config files."
+	}
+	if opts.minimal {
+		config = "The configuration layer, written with the standard library: Config holds every setting of the service, typed, and LoadConfig resolves each one from the environment variables, then configs/<APP_ENV>.env, then its default. main loads it once and hands the values to the packages that need them, which never read the environment themselves."
+		logger = "The logging package: New builds the log/slog JSON logger main passes to the other layers. Request code logs through logger.FromContext(ctx), which returns the logger the AccessLog middleware scoped to the request with its ID."
+		router = "The table of contents of the HTTP layer: every route of the API is registered here on a net/http ServeMux with a method and path pattern (e.g. \"GET /items/{id}\"), then wrapped in the middlewares shared by all routes. Handlers translate between HTTP and the code doing the work, and hold no business rules themselves."
+	}
+
+	return []annotation{
+		// Documented by their package comment
+		{"doc.go", ""},
+
+		// Commands
+		{"cmd/worker/main.go", "The Temporal worker, a second binary next to the API: it connects to the Temporal server, registers the workflows and activities of internal/workflows and runs them as Temporal schedules them. Scale it separately from the API; both share pkg/config and pkg/logger."},
+		{"cmd/starter/main.go", "A small command starting a workflow on the Temporal server, for trying the worker out (make start-workflow). In the service itself, workflows are started by the code needing them, e.g. a handler or a service, through a Temporal client."},
+		{"cmd/lambda-api/main.go", "The AWS Lambda entry point of the HTTP API: it builds the same router as the server binary and serves it through internal/lambdahttp, which converts API Gateway events to net/http requests. Only the way requests arrive changes, so handlers and services are shared by both deployments."},
+		{"cmd/lambda-sqs/main.go", "The AWS Lambda entry point consuming SQS messages: it hands each batch to internal/consumers and reports the messages that failed, so that only those are retried."},
+		{"cmd/*/main.go", "The entry point and composition root of the service: main loads the configuration, creates the logger, opens the dependencies and hands them to the packages of internal/ and pkg/, then runs until it is interrupted and shuts down gracefully. It holds no business logic, only construction and lifecycle, so that every other package can be tested without it."},
+
+		// HTTP layer
+		{"internal/handlers/router.go", router},
+		{"internal/handlers/golden_test.go", "Test helpers of the HTTP layer: serve sends a request through a handler without a network, and assertGolden compares the JSON response with tests/golden/<name>.json. When a response changes on purpose, run go test ./internal/handlers -update and review the diff of the golden files."},
+		{"internal/handlers/health.go", "The liveness endpoint (/healthz) that load balancers and orchestrators poll to know whether the process is up."},
+		{"internal/handlers/events.go", "The event handlers of the service: each JetStream message is decoded and handed to the business code, then acknowledged, or left unacknowledged to be redelivered when it failed. Handlers must be idempotent, as a message can arrive twice."},
+		{"internal/handlers/service.go", "The request-reply endpoints of the service, served with the NATS services API: other services call them by subject, like HTTP routes."},
+		{"internal/handlers/*_test.go", "Tests of the handlers, run through the real router with httptest so that routing, middlewares and encoding are covered too. Expected responses live in tests/golden."},
+		{"internal/handlers/*", "The HTTP layer: handlers decode requests, call internal/services and encode the responses. Keep them thin, since rules written here cannot be reused by other transports or tested without HTTP."},
+		{"internal/middlewares/chain.go", "Middlewares are plain func(http.Handler) http.Handler wrappers; Chain applies a list of them in order, the first one seeing the request first. Any net/http middleware fits in."},
+		{"internal/middlewares/request_id.go", "Gives every request an ID, taken from the X-Request-ID header of the caller or generated, returned in the response and stored in the request context. The logs of a request carry it, so that one ID finds every line of a request across services."},
+		{"internal/middlewares/access_log.go", "Writes one log line per request, with its method, path, status, size and latency, once the handler has answered."},
+		{"internal/middlewares/logger.go", "Scopes a logger to each request, carrying its request and trace IDs, and stores it in the request context where handlers and services find it with logger.FromContext."},
+		{"internal/middlewares/recover.go", "Turns a panic in a handler into a 500 response and an error log reported to Sentry, instead of a dropped connection."},
+		{"internal/middlewares/*", "Middlewares wrap the handlers of every route with concerns shared by all of them, such as request IDs and logging, so that handlers only deal with their own route."},
+
+		// Business and data layers
+		{"internal/services/*", "The business layer: services hold the rules of the application, called by the handlers and calling the repository or external APIs. They know nothing about HTTP, so the same rules serve every transport and are tested with plain Go calls. Dependencies go handlers -> services -> repository, which depguard checks (make arch-lint)."},
+		{"internal/repository/db.go", "Opens the connection pool of the PostgreSQL database, shared by every repository. main opens it once, checks it at startup through pkg/bootstrap and closes it on exit."},
+		{"internal/repository/*", "The data layer: repositories run the SQL queries of the application and map rows to models. They are the only code talking to the database, so storage can change without touching services or handlers."},
+		{"internal/modules/modules.go", "The list of the modules of the monolith: each one registers its routes on the router here. Adding a module means adding it to this list."},
+		{"internal/modules/*/module.go", "The public API of a module: it wires the module's handler, service and repo together. Other modules use a module only through this package, never through its inner packages, so that it can later be extracted as a service."},
+		{"internal/modules/*/handler/*", "The HTTP layer of the module: it decodes requests, calls the module's service and encodes the responses."},
+		{"internal/modules/*/service/*", "The business rules of the module, free of HTTP concerns and called by its handler."},
+		{"internal/modules/*/repo/*", "The storage of the module, its innermost layer: nothing else in the module depends on how the data is kept."},
+		{"internal/workflows/*", "Temporal workflows and activities. Workflows orchestrate the steps of a long-running process and must stay deterministic (no I/O, clocks or randomness of their own); activities do the actual work and are retried by Temporal when they fail."},
+		{"internal/etl/*", "The extract-transform-load pipeline of the job: sources read records, a pool of workers transforms them and sinks load the results, with checkpoints to resume an interrupted run. It knows nothing of the job itself, which lives in internal/jobs."},
+		{"internal/jobs/*", "The job-specific code: the transform applied to each record by the pipeline of internal/etl. It is pure data processing, tested without any source or sink."},
+		{"internal/messaging/*", "Connects to NATS and provisions the JetStream stream and durable consumer the service reads its events from, so that every environment gets them at startup."},
+		{"internal/consumers/*", "Processes the SQS messages handed over by cmd/lambda-sqs, reporting the ones that failed so that only those are retried."},
+		{"internal/lambdahttp/*", "Converts API Gateway events to net/http requests and the responses back, so that the router runs unchanged on AWS Lambda."},
+
+		// Shared packages
+		{"pkg/config/*", config},
+		{"pkg/logger/*", logger},
+		{"pkg/httpclient/*", "The client for calling other HTTP APIs, with timeouts, retries and a circuit breaker, so that a slow or failing dependency does not take the service down with it. Services use it rather than http.DefaultClient."},
+		{"pkg/health/*", "The health checks of the dependencies (database, cache, disk...) served on /healthz: each check runs with a timeout and the endpoint answers 503 when one fails, with the status and latency of each one."},
+		{"pkg/bootstrap/*", "Starts the dependencies in order at startup, retrying each with backoff, and gates the traffic on them: /readyz and every route answer 503 until they are all up, so that orchestrators only route requests to ready instances."},
+		{"pkg/cache/*", "The Redis client of the service, shared by the cache and the rate limiter."},
+		{"pkg/ratelimit/*", "Rate limits requests with a sliding window kept in Redis, so that the limits hold across every instance of the service."},
+		{"pkg/errorreport/*", "Reports the errors logged and the panics to Sentry, tagged with the environment and release. Code reports errors simply by logging them."},
+		{"pkg/profiling/*", "Serves the profiles of the running service (net/http/pprof) on a listener of its own, never exposed publicly, and optionally pushes them for continuous profiling."},
+		{"web/*", "Static files embedded in the binary (favicon, robots.txt), so that the service ships as a single file."},
+
+		// Tests and data
+		{"tests/contract/*", "Provider contract tests: they replay the interactions recorded by the consumers of the API (tests/contract/pacts) against the real router, failing when a change would break a consumer."},
+		{"*_test.go", "Tests of the package, next to the code they cover. Run them all with make test."},
+
+		// Configuration and documentation
+		{"configs/*", "The settings of one environment, selected by APP_ENV. Environment variables override them, so secrets are given that way in deployed environments rather than written here."},
+		{"docs/*.http", "The routes of the API documented by example: send these requests to make run from the editor to try a change by hand."},
+		{"docs/adr/README.md", "Architecture decision records explain why the project is built the way it is. Read them before changing a decision, and record a new one with gogo add adr when you do."},
+		// Copied into every new record by gogo add adr
+		{"docs/adr/template.md", ""},
+		{"docs/adr/*", "An architecture decision record: it is never rewritten once accepted, a new record supersedes it."},
+		{"docs/architecture/*", "Diagrams of the architecture, kept next to the code so that they are updated in the same pull requests."},
+		{"README.md", "The entry point of the documentation: how to run, test and ship the service. The files of this project carry comments like this one, explaining why they exist, because it was generated with gogo --annotated."},
+
+		// Build, run and ship
+		{"go.mod", "The module of the service and the versions of the modules its code imports, pinned by gogo (--dep overrides them). go mod tidy adds the modules these depend on, and go.sum records their checksums."},
+		{"Makefile", "The commands of the project in one place (make run, make test, make lint...), used the same way by developers and CI, so that nobody has to remember the flags."},
+		{"Dockerfile", "Builds the container image in two stages: the first compiles the service with the Go toolchain, the second copies the binary alone onto a small base image running as a non-root user."},
+		{"goreleaser.Dockerfile", "The image built by GoReleaser from the binaries it compiled, so release images and archives hold the same binary."},
+		{".dockerignore", "Files kept out of the Docker build context, for faster builds and so that no secrets or binaries end up in the image."},
+		// Explained by their own header
+		{".env", ""},
+		{".env.example", ""},
+		{"docker-compose.yml", "The dependencies of the service for local development and integration tests (make up, make down), so that the service runs against the same kind of servers as in production."},
+		{".golangci.yml", "The linters run by make lint and CI. Besides style and bugs, depguard enforces the direction of the dependencies between layers (make arch-lint)."},
+		{".goreleaser.yaml", "Builds the release archives, checksums and images when a version tag is pushed."},
+		{".github/workflows/ci.yml", "Continuous integration: every push and pull request is vetted, linted, architecture-checked and tested, and checked for known vulnerabilities."},
+		{".github/workflows/release.yml", "Runs GoReleaser when a version tag (v*) is pushed."},
+		{".github/*", "Settings of the GitHub repository, shaping how people contribute to the project."},
+		{".github/ISSUE_TEMPLATE/*", "A form of the GitHub issue chooser, asking for what the maintainers need to act on an issue."},
+		{".githooks/*", "A Git hook, enabled with make hooks, checking the commit messages that the changelog is generated from."},
+		{"cliff.toml", "The configuration of git-cliff, generating CHANGELOG.md from the conventional commit messages (make changelog)."},
+		{".chglog/config.yml", "The configuration of git-chglog, generating CHANGELOG.md from the conventional commit messages (make changelog)."},
+		{"cloudbuild.yaml", "Builds the image with Cloud Build and deploys it to Cloud Run (make deploy)."},
+		{"deploy/cloudrun/*", "The Cloud Run service: its image, resources, probes and settings, deployed by make deploy."},
+		{"template.yaml", "The AWS SAM template deploying the Lambda functions of cmd/lambda-api and cmd/lambda-sqs."},
+		{"infra/modules/service/*", "The Terraform module of the service, instantiated once per environment by infra/environments."},
+		{"infra/environments/*/*", "The infrastructure of one environment, made of the modules of infra/modules (make tf-plan, make tf-apply)."},
+		{"infra/.gitignore", "Keeps Terraform state, caches and variable files with secrets out of Git."},
+		{".gitignore", "Files Git never tracks: binaries, build outputs and local settings."},
+		{".gitattributes", "Line endings and diff settings Git applies to the files of the project on every platform."},
+		{".editorconfig", "Indentation and line endings for editors, so that every contributor writes files the same way."},
+		{"robots.txt", "Tells crawlers not to index the API."},
+	}
+}
+
+// Returns the comment syntax of a generated file: the prefix of its comment lines
+// and, for formats with block comments only, the lines opening and closing the
+// block. Files whose format has no comments (JSON, CSV, images) get none.
+func commentSyntax(rel string) (prefix, open, close string, ok bool) {
+	base := path.Base(rel)
+	switch {
+	case strings.HasSuffix(base, ".go"), rel == "go.mod":
+		return "// ", "", "", true
+	case strings.HasSuffix(base, ".md"):
+		return "", "<!--", "-->", true
+	case strings.HasPrefix(rel, "tests/golden/"), strings.HasPrefix(rel, ".chglog/CHANGELOG"):
+		// Compared byte for byte, or rendered as is
+		return "", "", "", false
+	}
+	switch path.Ext(base) {
+	case ".yml", ".yaml", ".toml", ".tf", ".env", ".http", ".txt", ".example", "":
+		return "# ", "", "", true
+	}
+	switch base {
+	case "Dockerfile", "goreleaser.Dockerfile", ".dockerignore", ".gitignore", ".gitattributes", ".editorconfig":
+		return "# ", "", "", true
+	}
+	return "", "", "", false
+}
+
+// Adds the explanation of fileAnnotations to each file of the project in dir
+// whose format has comments
+func annotateProject(opts options, dir string) {
+	annotations := fileAnnotations(opts)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			if d != nil && d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		text, ok := annotationFor(annotations, rel)
+		if !ok {
+			return nil
+		}
+		prefix, open, close, ok := commentSyntax(rel)
+		if !ok {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(p, []byte(annotate(string(content), text, prefix, open, close)), info.Mode().Perm())
+	})
+	if err != nil {
+		fatalf(exitFailure, "Failed to annotate the generated files: %v", err)
+	}
+}
+
+// Returns the text of the first annotation matching the slash path rel. Files
+// matching an annotation without text are left as they are.
+func annotationFor(annotations []annotation, rel string) (string, bool) {
+	for _, a := range annotations {
+		name := rel
+		if !strings.Contains(a.pattern, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(a.pattern, name); ok {
+			return a.text, a.text != ""
+		}
+	}
+	return "", false
+}
+
+// Returns content with text as a comment at its top, after the lines that must
+// stay first: build constraints, shebangs and Dockerfile parser directives
+func annotate(content, text, prefix, open, close string) string {
+	var comment strings.Builder
+	if open != "" {
+		comment.WriteString(open + "\n")
+	}
+	for _, line := range wrapWords(text, 80-len(prefix)) {
+		comment.WriteString(strings.TrimRight(prefix+line, " ") + "\n")
+	}
+	if close != "" {
+		comment.WriteString(close + "\n")
+	}
+	comment.WriteString("\n")
+
+	head := 0
+	for head < len(content) {
+		end := strings.IndexByte(content[head:], '\n')
+		if end < 0 {
+			break
+		}
+		line := content[head : head+end]
+		if !strings.HasPrefix(line, "//go:build") && !strings.HasPrefix(line, "#!") && !strings.HasPrefix(line, "# syntax=") {
+			break
+		}
+		head += end + 1
+		// A build constraint is separated from the package clause by a blank line
+		if strings.HasPrefix(content[head:], "\n") {
+			head++
+		}
+	}
+	return content[:head] + comment.String() + content[head:]
+}
+
+// Splits text into lines of at most width characters, breaking between words
+func wrapWords(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
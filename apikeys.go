@@ -0,0 +1,488 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Adds API key management: migration, model, repository, hashing/verification service,
+// issuing endpoints and an authentication middleware with scopes
+func addAPIKeys(p project, args []string) ([]generatedFile, []string) {
+	files := p.migration("create_api_keys", apiKeysMigrationUpContent(), apiKeysMigrationDownContent())
+	files = append(files,
+		generatedFile{path: filepath.Join("internal", "models", "db", "api_key.go"), content: apiKeyModelGoContent()},
+		generatedFile{path: filepath.Join("internal", "repository", "errors.go"), content: repositoryErrorsGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "repository", "api_key_repository.go"), content: apiKeyRepositoryGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "services", "api_key_service.go"), content: apiKeyServiceGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "middlewares", "api_key.go"), content: apiKeyMiddlewareGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "handlers", "respond.go"), content: respondGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "handlers", "api_key_handler.go"), content: apiKeyHandlerGoContent(p.modulePath)},
+	)
+
+	var rateLimit []string
+	if _, err := os.Stat(filepath.Join(p.dir, "pkg", "ratelimit", "ratelimit.go")); err == nil {
+		rateLimit = append(rateLimit, "Limit the protected routes per API key rather than per client IP with a rate limiter running after APIKeyAuth: ratelimit.Middleware(limiter, ratelimit.Config{Default: limit, APIKey: middlewares.APIKeyID})")
+	}
+	return files, append([]string{
+		"Run the migration: make migrate DB_URL=postgres://...",
+		"Create the service: apiKeySvc := services.NewAPIKeyService(repository.NewAPIKeyRepository(db))",
+		"Protect routes in internal/handlers/router.go: middlewares.Chain(h, middlewares.APIKeyAuth(apiKeySvc), middlewares.RequireScope(\"orders:write\"))",
+		"Mount the management endpoints: handlers.NewAPIKeyHandler(apiKeySvc).Register(mux, middlewares.APIKeyAuth(apiKeySvc))",
+		"Issue the first key with the apikeys:manage scope from a one-off program: apiKeySvc.Issue(ctx, \"admin\", []string{services.ScopeManageAPIKeys}, 0)",
+	}, rateLimit...)
+}
+
+// Returns the content for the api_keys up migration
+func apiKeysMigrationUpContent() string {
+	return `CREATE TABLE IF NOT EXISTS api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    key_hash     BYTEA NOT NULL UNIQUE,
+    scopes       TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+`
+}
+
+// Returns the content for the api_keys down migration
+func apiKeysMigrationDownContent() string {
+	return `DROP TABLE IF EXISTS api_keys;
+`
+}
+
+// Returns the content for internal/models/db/api_key.go
+func apiKeyModelGoContent() string {
+	return `package db
+
+import "time"
+
+// APIKey is a row of the api_keys table. Only the SHA-256 hash of the key is
+// stored; Prefix is kept to help users recognise their keys.
+type APIKey struct {
+	ID         int64
+	Name       string
+	Prefix     string
+	KeyHash    []byte
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+`
+}
+
+// Returns the content for internal/repository/api_key_repository.go
+func apiKeyRepositoryGoContent(modulePath string) string {
+	return fmt.Sprintf(`package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	dbmodels "%s/internal/models/db"
+)
+
+const apiKeyColumns = "id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+// APIKeyRepository stores hashed API keys in the api_keys table
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Insert stores a new key
+func (r *APIKeyRepository) Insert(ctx context.Context, k *dbmodels.APIKey) error {
+	return r.db.QueryRowContext(ctx,
+		"INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		k.Name, k.Prefix, k.KeyHash, strings.Join(k.Scopes, " "), k.ExpiresAt,
+	).Scan(&k.ID, &k.CreatedAt)
+}
+
+// FindByHash returns the key with the given hash, revoked or not
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash []byte) (*dbmodels.APIKey, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", hash)
+	k, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return k, err
+}
+
+// List returns all keys, newest first
+func (r *APIKeyRepository) List(ctx context.Context) ([]dbmodels.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []dbmodels.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// TouchLastUsed records that the key was just used
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = now() WHERE id = $1", id)
+	return err
+}
+
+// Revoke disables a key. Revoked keys are kept so their use can still be audited.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*dbmodels.APIKey, error) {
+	var k dbmodels.APIKey
+	var scopes string
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.KeyHash, &scopes, &k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+		return nil, err
+	}
+	k.Scopes = strings.Fields(scopes)
+	return &k, nil
+}
+`, modulePath)
+}
+
+// Returns the content for internal/services/api_key_service.go
+func apiKeyServiceGoContent(modulePath string) string {
+	return fmt.Sprintf(`package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"time"
+
+	dbmodels "%s/internal/models/db"
+	"%s/internal/repository"
+)
+
+// ScopeManageAPIKeys allows issuing, listing and revoking API keys
+const ScopeManageAPIKeys = "apikeys:manage"
+
+// apiKeyPrefix marks the keys issued by this service, which helps secret scanners find leaked keys
+const apiKeyPrefix = "sk_"
+
+// ErrInvalidAPIKey is returned for unknown, revoked or expired keys
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyService issues and verifies API keys
+type APIKeyService struct {
+	repo *repository.APIKeyRepository
+	now  func() time.Time
+}
+
+// NewAPIKeyService creates a new APIKeyService
+func NewAPIKeyService(repo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo, now: time.Now}
+}
+
+// Issue creates a key with the given scopes, valid for ttl (0: no expiry). The
+// plaintext key is only returned here and cannot be recovered later.
+func (s *APIKeyService) Issue(ctx context.Context, name string, scopes []string, ttl time.Duration) (string, *dbmodels.APIKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	plaintext := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := &dbmodels.APIKey{
+		Name:    name,
+		Prefix:  plaintext[:len(apiKeyPrefix)+6],
+		KeyHash: hashAPIKey(plaintext),
+		Scopes:  scopes,
+	}
+	if ttl > 0 {
+		expires := s.now().Add(ttl)
+		key.ExpiresAt = &expires
+	}
+	if err := s.repo.Insert(ctx, key); err != nil {
+		return "", nil, err
+	}
+	return plaintext, key, nil
+}
+
+// Verify returns the key matching plaintext if it is active
+func (s *APIKeyService) Verify(ctx context.Context, plaintext string) (*dbmodels.APIKey, error) {
+	key, err := s.repo.FindByHash(ctx, hashAPIKey(plaintext))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && s.now().After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Usage tracking must not fail the request
+	_ = s.repo.TouchLastUsed(ctx, key.ID)
+	return key, nil
+}
+
+// List returns all keys, without their secrets
+func (s *APIKeyService) List(ctx context.Context) ([]dbmodels.APIKey, error) {
+	return s.repo.List(ctx)
+}
+
+// Revoke disables a key immediately
+func (s *APIKeyService) Revoke(ctx context.Context, id int64) error {
+	return s.repo.Revoke(ctx, id)
+}
+
+// HasScope reports whether key grants scope
+func HasScope(key *dbmodels.APIKey, scope string) bool {
+	return slices.Contains(key.Scopes, scope)
+}
+
+// hashAPIKey hashes a key for storage. Keys are 256-bit random values, so a fast
+// hash is enough: unlike passwords they cannot be brute-forced.
+func hashAPIKey(plaintext string) []byte {
+	sum := sha256.Sum256([]byte(plaintext))
+	return sum[:]
+}
+`, modulePath, modulePath)
+}
+
+// Returns the content for internal/middlewares/api_key.go
+func apiKeyMiddlewareGoContent(modulePath string) string {
+	return fmt.Sprintf(`package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	dbmodels "%s/internal/models/db"
+	"%s/internal/services"
+)
+
+// APIKeyHeader carries the API key; "Authorization: Bearer <key>" is accepted too
+const APIKeyHeader = "X-API-Key"
+
+type apiKeyKey struct{}
+
+// APIKeyFromContext returns the API key that authenticated the request, if any
+func APIKeyFromContext(ctx context.Context) (*dbmodels.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(*dbmodels.APIKey)
+	return key, ok
+}
+
+// APIKeyID returns the ID of the API key that authenticated r, e.g. as the
+// APIKey of a rate limiter running after APIKeyAuth
+func APIKeyID(r *http.Request) (string, bool) {
+	key, ok := APIKeyFromContext(r.Context())
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatInt(key.ID, 10), true
+}
+
+// APIKeyAuth rejects requests without a valid API key with 401 Unauthorized
+func APIKeyAuth(svc *services.APIKeyService) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			plaintext := r.Header.Get(APIKeyHeader)
+			if plaintext == "" {
+				plaintext, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			}
+			if plaintext == "" {
+				writeAuthError(w, http.StatusUnauthorized, "missing API key")
+				return
+			}
+
+			key, err := svc.Verify(r.Context(), plaintext)
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				writeAuthError(w, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+			if err != nil {
+				writeAuthError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyKey{}, key)))
+		})
+	}
+}
+
+// RequireScope rejects requests whose API key lacks scope with 403 Forbidden.
+// It must run after APIKeyAuth.
+func RequireScope(scope string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := APIKeyFromContext(r.Context())
+			if !ok || !services.HasScope(key, scope) {
+				writeAuthError(w, http.StatusForbidden, "missing scope "+scope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeAuthError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+`, modulePath, modulePath)
+}
+
+// Returns the content for internal/handlers/api_key_handler.go
+func apiKeyHandlerGoContent(modulePath string) string {
+	return fmt.Sprintf(`package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"%[1]s/internal/middlewares"
+	dbmodels "%[1]s/internal/models/db"
+	"%[1]s/internal/repository"
+	"%[1]s/internal/services"
+)
+
+// APIKeyHandler exposes API key management over HTTP
+type APIKeyHandler struct {
+	svc *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler(svc *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{svc: svc}
+}
+
+// Register mounts the API key routes on mux, behind auth and the apikeys:manage scope
+func (h *APIKeyHandler) Register(mux *http.ServeMux, auth middlewares.Middleware) {
+	protect := func(f http.HandlerFunc) http.Handler {
+		return middlewares.Chain(f, auth, middlewares.RequireScope(services.ScopeManageAPIKeys))
+	}
+	mux.Handle("POST /api-keys", protect(h.issue))
+	mux.Handle("GET /api-keys", protect(h.list))
+	mux.Handle("DELETE /api-keys/{id}", protect(h.revoke))
+}
+
+type issueAPIKeyRequest struct {
+	Name   string   `+"`"+`json:"name"`+"`"+`
+	Scopes []string `+"`"+`json:"scopes"`+"`"+`
+	// TTL is a Go duration such as "720h"; empty means the key never expires
+	TTL string `+"`"+`json:"ttl"`+"`"+`
+}
+
+type apiKeyResponse struct {
+	ID         int64      `+"`"+`json:"id"`+"`"+`
+	Name       string     `+"`"+`json:"name"`+"`"+`
+	Prefix     string     `+"`"+`json:"prefix"`+"`"+`
+	Scopes     []string   `+"`"+`json:"scopes"`+"`"+`
+	CreatedAt  time.Time  `+"`"+`json:"created_at"`+"`"+`
+	ExpiresAt  *time.Time `+"`"+`json:"expires_at,omitempty"`+"`"+`
+	LastUsedAt *time.Time `+"`"+`json:"last_used_at,omitempty"`+"`"+`
+	RevokedAt  *time.Time `+"`"+`json:"revoked_at,omitempty"`+"`"+`
+	// Key is only set in the response to issuing a key
+	Key string `+"`"+`json:"key,omitempty"`+"`"+`
+}
+
+func (h *APIKeyHandler) issue(w http.ResponseWriter, r *http.Request) {
+	var req issueAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+			writeError(w, http.StatusBadRequest, "invalid ttl")
+			return
+		}
+	}
+
+	plaintext, key, err := h.svc.Issue(r.Context(), req.Name, req.Scopes, ttl)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to issue API key")
+		return
+	}
+	resp := toAPIKeyResponse(key)
+	resp.Key = plaintext
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *APIKeyHandler) list(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.svc.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list API keys")
+		return
+	}
+	resp := make([]apiKeyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, toAPIKeyResponse(&keys[i]))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *APIKeyHandler) revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if err := h.svc.Revoke(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toAPIKeyResponse(k *dbmodels.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+`, modulePath)
+}
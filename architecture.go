@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diagramNode is an element of an architecture diagram
+type diagramNode struct {
+	id          string
+	name        string
+	technology  string
+	description string
+	shape       string // Mermaid shape: "box" (default), "db" or "person"
+}
+
+// diagramEdge is a relationship of an architecture diagram
+type diagramEdge struct {
+	from, to, label string
+}
+
+// Returns the Mermaid declaration of the node
+func (n diagramNode) mermaid() string {
+	label := fmt.Sprintf("<b>%s</b>", n.name)
+	if n.technology != "" {
+		label += fmt.Sprintf("<br/>[%s]", n.technology)
+	}
+	if n.description != "" {
+		label += "<br/>" + n.description
+	}
+	switch n.shape {
+	case "db":
+		return fmt.Sprintf("%s[(\"%s\")]", n.id, label)
+	case "person":
+		return fmt.Sprintf("%s([\"%s\"])", n.id, label)
+	}
+	return fmt.Sprintf("%s[\"%s\"]", n.id, label)
+}
+
+// Writes a Mermaid flowchart of the nodes and edges, the inner nodes (if any)
+// grouped in a boundary named after the system
+func writeDiagram(b *strings.Builder, boundary string, outer, inner []diagramNode, edges []diagramEdge) {
+	b.WriteString("```mermaid\nflowchart LR\n")
+	for _, n := range outer {
+		fmt.Fprintf(b, "    %s\n", n.mermaid())
+	}
+	if len(inner) > 0 {
+		fmt.Fprintf(b, "    subgraph boundary[\"%s\"]\n", boundary)
+		for _, n := range inner {
+			fmt.Fprintf(b, "        %s\n", n.mermaid())
+		}
+		b.WriteString("    end\n")
+	}
+	for _, e := range edges {
+		fmt.Fprintf(b, "    %s -->|\"%s\"| %s\n", e.from, e.label, e.to)
+	}
+	b.WriteString("```\n")
+}
+
+// Returns the content for docs/architecture/README.md: C4 context, container and
+// component diagrams in Mermaid, reflecting the options the project was
+// generated with
+func architectureDocContent(opts options) string {
+	name := opts.projectName
+	var users diagramNode
+	var app diagramNode
+	switch opts.archetype {
+	case "nats":
+		users = diagramNode{id: "clients", name: "Other services", description: "Publish events and send requests", shape: "person"}
+		app = diagramNode{id: "app", name: name, technology: "Go, NATS services API", description: "Consumes events and answers requests"}
+	case "batch":
+		users = diagramNode{id: "clients", name: "Operator or scheduler", description: "Starts the job runs", shape: "person"}
+		app = diagramNode{id: "app", name: name, technology: "Go, ETL pipeline", description: "Extracts, transforms and loads data in runs"}
+	default:
+		users = diagramNode{id: "clients", name: "API clients", description: "Web and mobile apps, other services", shape: "person"}
+		app = diagramNode{id: "app", name: name + " API", technology: "Go, net/http", description: "Serves the HTTP API"}
+	}
+
+	outer := []diagramNode{users}
+	inner := []diagramNode{app}
+	var edges []diagramEdge
+	switch opts.archetype {
+	case "nats":
+		inner = append(inner, diagramNode{id: "nats", name: "NATS", technology: "JetStream", description: "Stream and durable consumer", shape: "db"})
+		edges = append(edges, diagramEdge{"clients", "nats", "Publishes events, sends requests"}, diagramEdge{"nats", "app", "Delivers events and requests"})
+	case "batch":
+		edges = append(edges, diagramEdge{"clients", "app", "Runs"})
+	default:
+		edges = append(edges, diagramEdge{"clients", "app", "HTTPS, JSON"})
+	}
+	inner = append(inner, diagramNode{id: "db", name: "Database", technology: "PostgreSQL", description: "Application data, golang-migrate schema", shape: "db"})
+	edges = append(edges, diagramEdge{"app", "db", "Reads and writes, SQL"})
+	if opts.dbTopology == "primary-replica" {
+		inner = append(inner, diagramNode{id: "replica", name: "Read replica", technology: "PostgreSQL, streaming replication", description: "Copy of the database serving reads", shape: "db"})
+		edges = append(edges, diagramEdge{"app", "replica", "Reads, SQL"}, diagramEdge{"db", "replica", "Streams changes"})
+	}
+	if opts.cache == "redis" {
+		inner = append(inner, diagramNode{id: "cache", name: "Cache", technology: "Redis", description: "Cache and rate limit windows", shape: "db"})
+		edges = append(edges, diagramEdge{"app", "cache", "Caches, rate limits"})
+	}
+	if opts.workflow == "temporal" {
+		inner = append(inner,
+			diagramNode{id: "worker", name: "Worker", technology: "Go, Temporal SDK", description: "Runs the workflows and activities"},
+			diagramNode{id: "temporal", name: "Temporal", technology: "Temporal server", description: "Workflow state and task queues", shape: "db"},
+		)
+		edges = append(edges, diagramEdge{"app", "temporal", "Starts workflows"}, diagramEdge{"temporal", "worker", "Dispatches tasks"})
+	}
+	switch opts.profiling {
+	case "pyroscope":
+		inner = append(inner, diagramNode{id: "pyroscope", name: "Pyroscope", technology: "Grafana Pyroscope", description: "Continuous profiles", shape: "db"})
+		edges = append(edges, diagramEdge{"app", "pyroscope", "Pushes profiles"})
+	case "grafana-agent":
+		inner = append(inner,
+			diagramNode{id: "agent", name: "Grafana Agent", technology: "Grafana Agent, flow mode", description: "Scrapes the pprof listener"},
+			diagramNode{id: "pyroscope", name: "Pyroscope", technology: "Grafana Pyroscope", description: "Continuous profiles", shape: "db"},
+		)
+		edges = append(edges, diagramEdge{"agent", "app", "Scrapes pprof"}, diagramEdge{"agent", "pyroscope", "Writes profiles"})
+	}
+	if opts.target == "lambda" {
+		inner = append(inner,
+			diagramNode{id: "lambda_api", name: "lambda-api", technology: "AWS Lambda, API Gateway", description: "The same router behind an HTTP API"},
+			diagramNode{id: "lambda_sqs", name: "lambda-sqs", technology: "AWS Lambda, SQS", description: "Handles queued messages"},
+		)
+		edges = append(edges, diagramEdge{"clients", "lambda_api", "HTTPS, JSON"}, diagramEdge{"lambda_api", "db", "Reads and writes, SQL"})
+	}
+	if opts.errors == "sentry" {
+		outer = append(outer, diagramNode{id: "sentry", name: "Sentry", technology: "SaaS or self-hosted", description: "Error and panic alerts"})
+		edges = append(edges, diagramEdge{"app", "sentry", "Reports errors, HTTPS"})
+	}
+	if opts.archetype == "api" {
+		outer = append(outer, diagramNode{id: "external", name: "External API", technology: "HTTP", description: "Called through pkg/httpclient"})
+		edges = append(edges, diagramEdge{"app", "external", "HTTPS, JSON"})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Architecture of %s\n\n", name)
+	b.WriteString("These diagrams follow the [C4 model](https://c4model.com) and are written in [Mermaid](https://mermaid.js.org), which GitHub and GitLab render in place. gogo drew them from the options the project was generated with: update them with the code as the architecture changes, and record why in `docs/adr`.\n\n")
+
+	b.WriteString("## System context\n\n")
+	context := []diagramNode{{id: "system", name: name, description: app.description}}
+	contextEdges := []diagramEdge{{"clients", "system", "Uses"}}
+	if opts.errors == "sentry" {
+		contextEdges = append(contextEdges, diagramEdge{"system", "sentry", "Reports errors"})
+	}
+	if opts.archetype == "api" {
+		contextEdges = append(contextEdges, diagramEdge{"system", "external", "Calls"})
+	}
+	writeDiagram(&b, "", append(outer, context...), nil, contextEdges)
+	b.WriteString("\n")
+
+	b.WriteString("## Containers\n\n")
+	writeDiagram(&b, name, outer, inner, edges)
+
+	if opts.archetype == "api" {
+		b.WriteString("\n## Components of the API\n\n")
+		database := diagramNode{id: "db", name: "Database", technology: "PostgreSQL", shape: "db"}
+		var components []diagramNode
+		var componentEdges []diagramEdge
+		componentOuter := []diagramNode{users, database}
+		if opts.layout == "modular-monolith" {
+			components = []diagramNode{
+				{id: "router", name: "Router", technology: "internal/handlers", description: "Middlewares, health, module registration"},
+				{id: "modules", name: "Modules", technology: "internal/modules", description: "One handler, service and repo per module"},
+			}
+			componentEdges = []diagramEdge{{"clients", "router", "HTTPS, JSON"}, {"router", "modules", "Registers"}, {"modules", "db", "SQL"}}
+		} else {
+			components = []diagramNode{
+				{id: "handlers", name: "Handlers", technology: "internal/handlers", description: "Routes, middlewares, JSON"},
+				{id: "services", name: "Services", technology: "internal/services", description: "Business rules"},
+				{id: "repository", name: "Repository", technology: "internal/repository", description: "SQL queries"},
+			}
+			componentEdges = []diagramEdge{{"clients", "handlers", "HTTPS, JSON"}, {"handlers", "services", "Calls"}, {"services", "repository", "Calls"}, {"repository", "db", "SQL"}, {"services", "external", "HTTPS, JSON"}}
+			componentOuter = append(componentOuter, outer[len(outer)-1])
+		}
+		writeDiagram(&b, name+" API", componentOuter, components, componentEdges)
+	}
+
+	if platform := deploymentPlatform(opts); platform != "" {
+		fmt.Fprintf(&b, "\n## Deployment\n\n%s\n", platform)
+	}
+	return b.String()
+}
+
+// Returns a sentence describing where the project is deployed, empty when no
+// platform was chosen
+func deploymentPlatform(opts options) string {
+	switch {
+	case opts.deploy == "cloudrun":
+		return "The API container runs on Google Cloud Run, with the database on Cloud SQL and the secrets in Secret Manager (see `deploy/cloudrun`)."
+	case opts.iac == "terraform":
+		return "The container runs on AWS ECS with Fargate, with the database on RDS and the secrets in Secrets Manager (see `infra/`)."
+	case opts.target == "lambda":
+		return "The Lambda functions are deployed with AWS SAM (see `template.yaml`)."
+	}
+	return ""
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Static files copied into generated projects: assets/<archetype>/ is copied to
+// the root of the project byte for byte, except that {{ }} actions in paths and
+// the content of .tmpl files are rendered with assetData and templateFuncs (the
+// suffix is dropped). As embed cannot hold symlinks, a file named <name>.symlink
+// creates a symlink <name> pointing at the path it contains.
+//
+//go:embed all:assets
+var assets embed.FS
+
+// Suffixes of the asset files describing a symlink, and rendered as templates
+const (
+	symlinkSuffix  = ".symlink"
+	templateSuffix = ".tmpl"
+)
+
+// assetData is what asset templates and templated paths refer to,
+// e.g. cmd/{{.ProjectName}}/main.go. Embedded names cannot hold pipes or
+// quotes: call helpers as functions in paths, e.g. {{kebab .ProjectName}}.
+type assetData struct {
+	ProjectName  string
+	ModulePath   string
+	Archetype    string
+	ConfigFormat string
+	Vars         map[string]string // variables of the --template, e.g. {{.Vars.team}}
+}
+
+// Helpers available in asset templates and paths
+var templateFuncs = template.FuncMap{
+	"snake":    snakeCase,
+	"camel":    unexportedName,
+	"pascal":   exportedName,
+	"kebab":    kebabCase,
+	"plural":   func(s string) string { return pluralize(snakeCase(s)) },
+	"singular": func(s string) string { return singularize(snakeCase(s)) },
+	"table":    tableName,
+}
+
+// Renders the template text named name with data
+func renderTemplate(name, text string, data assetData) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Renders the actions of an asset path, refusing paths leaving the project
+func renderAssetPath(rel string, data assetData) (string, error) {
+	if !strings.Contains(rel, "{{") {
+		return rel, nil
+	}
+	out, err := renderTemplate(rel, rel, data)
+	if err != nil {
+		return "", err
+	}
+	if out = path.Clean(out); out == "." || strings.HasPrefix(out, "../") || path.IsAbs(out) || strings.Contains(out, "//") {
+		return "", fmt.Errorf("path %s renders to %q", rel, out)
+	}
+	return out, nil
+}
+
+// Copies the embedded directory src into dir, rendering templated paths and
+// .tmpl files with data, and returns the paths created relative to dir
+func copyAssets(src, dir string, data assetData) []string {
+	if _, err := fs.Stat(assets, src); err != nil {
+		return nil
+	}
+	created, err := copyTree(assets, src, dir, data, nil)
+	if err != nil {
+		fatalf(exitTemplate, "Failed to copy the %s assets: %v", path.Base(src), err)
+	}
+	return created
+}
+
+// Copies the directory src of fsys into dir like copyAssets, overwriting files.
+// Files and directories for which skip (when not nil) returns true are left out,
+// given their path relative to src before rendering.
+func copyTree(fsys fs.FS, src, dir string, data assetData, skip func(string) bool) ([]string, error) {
+	var created []string
+	err := fs.WalkDir(fsys, src, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == src {
+			return err
+		}
+		srcRel := strings.TrimPrefix(name, src+"/")
+		if skip != nil && skip(srcRel) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := renderAssetPath(srcRel, data)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := checkInsideDir(dir, target); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), dirMode); err != nil {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasSuffix(rel, symlinkSuffix):
+			rel = strings.TrimSuffix(rel, symlinkSuffix)
+			link := filepath.Join(dir, filepath.FromSlash(rel))
+			linkTarget := strings.TrimSpace(string(content))
+			if err := checkSymlinkTarget(dir, link, linkTarget); err != nil {
+				return fmt.Errorf("%s: %w", srcRel, err)
+			}
+			debugf("symlink %s -> %s", link, linkTarget)
+			os.Remove(link)
+			if err := os.Symlink(linkTarget, link); err != nil {
+				return err
+			}
+		case strings.HasSuffix(rel, templateSuffix):
+			rel = strings.TrimSuffix(rel, templateSuffix)
+			rendered, err := renderTemplate(name, string(content), data)
+			if err != nil {
+				return err
+			}
+			createdPath := filepath.Join(dir, filepath.FromSlash(rel))
+			createFileMode(createdPath, rendered, copiedFileMode(createdPath, info.Mode()))
+		default:
+			debugf("copy %s to %s (%d bytes)", name, target, len(content))
+			if err := os.WriteFile(target, content, copiedFileMode(target, info.Mode())); err != nil {
+				return err
+			}
+		}
+		created = append(created, rel)
+		return nil
+	})
+	return created, err
+}
+
+// Returns an error when the file at target would be written outside dir once the
+// symlinks of its path are resolved, e.g. through a symlink a template created
+// earlier. The path is resolved up to its deepest existing directory, the ones
+// left to create holding no symlink.
+func checkInsideDir(dir, target string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	parent := filepath.Dir(target)
+	for {
+		if _, err := os.Lstat(parent); err == nil || parent == filepath.Dir(parent) {
+			break
+		}
+		parent = filepath.Dir(parent)
+	}
+	real, err := filepath.EvalSymlinks(parent)
+	if err != nil {
+		return err
+	}
+	if !withinDir(root, real) {
+		return fmt.Errorf("%s resolves to %s, outside the project", target, real)
+	}
+	return nil
+}
+
+// Returns an error unless the symlink link of dir, pointing at target, stays
+// inside dir: target is relative, its .. components all lead it (the kernel
+// applies the ones following a name to the target of that name when it is a
+// symlink, which filepath.Clean does not) and it resolves under dir from the
+// real directory of link.
+func checkSymlinkTarget(dir, link, target string) error {
+	if target == "" || filepath.IsAbs(target) {
+		return fmt.Errorf("the symlink target %q must be a relative path", target)
+	}
+	named := false
+	for _, part := range strings.Split(filepath.ToSlash(target), "/") {
+		if part == ".." && named {
+			return fmt.Errorf("the symlink target %q has .. after a name", target)
+		}
+		named = named || part != ".." && part != "." && part != ""
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(link))
+	if err != nil {
+		return err
+	}
+	if resolved := filepath.Join(parent, target); !withinDir(root, resolved) {
+		return fmt.Errorf("the symlink target %q resolves to %s, outside the project", target, resolved)
+	}
+	return nil
+}
+
+// Reports whether the clean path p is dir or under it
+func withinDir(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Returns the content for web/web.go, exposing the files of web/static
+func webGoContent() string {
+	return `package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed static
+var static embed.FS
+
+// Static returns the files of web/static, embedded in the binary
+func Static() fs.FS {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+`
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Writes the files of a template, name: content, into a new directory
+func writeTemplateFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	src := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return src
+}
+
+func TestCopyTreeMaliciousTemplate(t *testing.T) {
+	// Templated directories, named {{"z"}}, are walked after the symlinks
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{"absolute symlink", map[string]string{"z.symlink": "OUTSIDE", `{{"z"}}/owned.txt`: "owned"}, "must be a relative path"},
+		{"symlink climbing out", map[string]string{"a/z.symlink": "../../escape", `a/{{"z"}}/owned.txt`: "owned"}, "outside the project"},
+		{"symlink climbing after a symlink", map[string]string{"b.symlink": ".", "z.symlink": "b/../../escape", `{{"z"}}/owned.txt`: "owned"}, "has .. after a name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outside := t.TempDir()
+			files := map[string]string{}
+			for name, content := range tt.files {
+				files[name] = strings.ReplaceAll(content, "OUTSIDE", outside)
+			}
+			src := writeTemplateFiles(t, files)
+			dir := filepath.Join(t.TempDir(), "project")
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := copyTree(os.DirFS(src), ".", dir, assetData{ProjectName: "myapp"}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("copyTree returned %v, want an error saying %q", err, tt.wantErr)
+			}
+			for _, p := range []string{filepath.Join(outside, "owned.txt"), filepath.Join(filepath.Dir(dir), "escape", "owned.txt")} {
+				if _, err := os.Stat(p); err == nil {
+					t.Errorf("%s was written", p)
+				}
+			}
+		})
+	}
+}
+
+func TestCopyTreeThroughExistingSymlink(t *testing.T) {
+	outside := t.TempDir()
+	dir := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "z")); err != nil {
+		t.Fatal(err)
+	}
+	src := writeTemplateFiles(t, map[string]string{"z/owned.txt": "owned"})
+	if _, err := copyTree(os.DirFS(src), ".", dir, assetData{}, nil); err == nil {
+		t.Error("copyTree wrote through a symlink leaving the project")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "owned.txt")); err == nil {
+		t.Error("owned.txt was written outside the project")
+	}
+}
+
+func TestCopyTreeSymlinks(t *testing.T) {
+	src := writeTemplateFiles(t, map[string]string{
+		"README.md":                  "# myapp\n",
+		"docs/README.md.symlink":     "../README.md",
+		"docs/guide/notes.txt":       "notes\n",
+		"docs/latest.symlink":        "guide",
+		`{{"docs"}}/latest/more.txt`: "more\n",
+	})
+	dir := t.TempDir()
+	if _, err := copyTree(os.DirFS(src), ".", dir, assetData{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"docs/README.md": "# myapp\n", "docs/guide/more.txt": "more\n", "docs/latest/notes.txt": "notes\n"} {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Adds the audit trail component: migration, model, repository, service and middleware
+func addAudit(p project, args []string) ([]generatedFile, []string) {
+	files := p.migration("create_audit_log", auditMigrationUpContent(), auditMigrationDownContent())
+	files = append(files,
+		generatedFile{path: filepath.Join("internal", "models", "db", "audit_entry.go"), content: auditModelGoContent()},
+		generatedFile{path: filepath.Join("internal", "repository", "audit_repository.go"), content: auditRepositoryGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "services", "audit_service.go"), content: auditServiceGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "middlewares", "audit.go"), content: auditMiddlewareGoContent(p.modulePath)},
+	)
+
+	return files, []string{
+		"Run the migration: make migrate DB_URL=postgres://...",
+		"Create the service: auditSvc := services.NewAuditService(repository.NewAuditRepository(db))",
+		"Register the middleware in internal/handlers/router.go: middlewares.Audit(auditSvc, logger, nil)",
+		"Record domain events from services with auditSvc.Record(ctx, action, resource, resourceID, metadata)",
+	}
+}
+
+// Returns the content for the audit_log up migration
+func auditMigrationUpContent() string {
+	return `CREATE TABLE IF NOT EXISTS audit_log (
+    id          BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor       TEXT NOT NULL,
+    action      TEXT NOT NULL,
+    resource    TEXT NOT NULL,
+    resource_id TEXT NOT NULL DEFAULT '',
+    request_id  TEXT NOT NULL DEFAULT '',
+    metadata    JSONB
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_resource_idx ON audit_log (resource, resource_id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor, occurred_at);
+
+-- The audit trail is append-only: reject updates and deletes
+CREATE OR REPLACE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
+`
+}
+
+// Returns the content for the audit_log down migration
+func auditMigrationDownContent() string {
+	return `DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_immutable();
+DROP TABLE IF EXISTS audit_log;
+`
+}
+
+// Returns the content for internal/models/db/audit_entry.go
+func auditModelGoContent() string {
+	return `package db
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry is a single row of the append-only audit_log table
+type AuditEntry struct {
+	ID         int64
+	OccurredAt time.Time
+	Actor      string
+	Action     string
+	Resource   string
+	ResourceID string
+	RequestID  string
+	Metadata   json.RawMessage
+}
+`
+}
+
+// Returns the content for internal/repository/audit_repository.go
+func auditRepositoryGoContent(modulePath string) string {
+	return fmt.Sprintf(`package repository
+
+import (
+	"context"
+	"database/sql"
+
+	dbmodels "%s/internal/models/db"
+)
+
+// AuditRepository appends to and reads from the audit_log table.
+// It deliberately has no update or delete methods.
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a new AuditRepository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Insert appends an entry to the audit trail
+func (r *AuditRepository) Insert(ctx context.Context, e *dbmodels.AuditEntry) error {
+	return r.db.QueryRowContext(ctx,
+		`+"`"+`INSERT INTO audit_log (actor, action, resource, resource_id, request_id, metadata)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, occurred_at`+"`"+`,
+		e.Actor, e.Action, e.Resource, e.ResourceID, e.RequestID, e.Metadata,
+	).Scan(&e.ID, &e.OccurredAt)
+}
+
+// ListByResource returns the most recent entries for a resource, newest first
+func (r *AuditRepository) ListByResource(ctx context.Context, resource, resourceID string, limit int) ([]dbmodels.AuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`+"`"+`SELECT id, occurred_at, actor, action, resource, resource_id, request_id, metadata
+		 FROM audit_log
+		 WHERE resource = $1 AND resource_id = $2
+		 ORDER BY occurred_at DESC
+		 LIMIT $3`+"`"+`,
+		resource, resourceID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []dbmodels.AuditEntry
+	for rows.Next() {
+		var e dbmodels.AuditEntry
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.Actor, &e.Action, &e.Resource, &e.ResourceID, &e.RequestID, &e.Metadata); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+`, modulePath)
+}
+
+// Returns the content for internal/services/audit_service.go
+func auditServiceGoContent(modulePath string) string {
+	return fmt.Sprintf(`package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	dbmodels "%s/internal/models/db"
+	"%s/internal/repository"
+)
+
+// AuditInfo identifies who triggered the current request
+type AuditInfo struct {
+	Actor     string
+	RequestID string
+}
+
+type auditInfoKey struct{}
+
+// WithAuditInfo stores the actor and request ID used by AuditService.Record
+func WithAuditInfo(ctx context.Context, info AuditInfo) context.Context {
+	return context.WithValue(ctx, auditInfoKey{}, info)
+}
+
+// AuditInfoFromContext returns the AuditInfo stored in ctx, defaulting the actor to "system"
+func AuditInfoFromContext(ctx context.Context) AuditInfo {
+	info, _ := ctx.Value(auditInfoKey{}).(AuditInfo)
+	if info.Actor == "" {
+		info.Actor = "system"
+	}
+	return info
+}
+
+// AuditService records actions on resources to the audit trail
+type AuditService struct {
+	repo *repository.AuditRepository
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(repo *repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record appends an entry for action on resource, taking the actor and request ID from ctx.
+// Call it from other services after a change has been made successfully.
+func (s *AuditService) Record(ctx context.Context, action, resource, resourceID string, metadata any) error {
+	info := AuditInfoFromContext(ctx)
+	entry := &dbmodels.AuditEntry{
+		Actor:      info.Actor,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		RequestID:  info.RequestID,
+	}
+
+	if metadata != nil {
+		raw, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("encoding audit metadata: %%w", err)
+		}
+		entry.Metadata = raw
+	}
+
+	if err := s.repo.Insert(ctx, entry); err != nil {
+		return fmt.Errorf("recording audit entry: %%w", err)
+	}
+	return nil
+}
+
+// History returns the latest audit entries of a resource
+func (s *AuditService) History(ctx context.Context, resource, resourceID string, limit int) ([]dbmodels.AuditEntry, error) {
+	return s.repo.ListByResource(ctx, resource, resourceID, limit)
+}
+`, modulePath, modulePath)
+}
+
+// Returns the content for internal/middlewares/audit.go
+func auditMiddlewareGoContent(modulePath string) string {
+	return fmt.Sprintf(`package middlewares
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"%s/internal/services"
+)
+
+// ActorFunc extracts the acting user from a request
+type ActorFunc func(r *http.Request) string
+
+// HeaderActor reads the actor from the X-Actor header; replace it once authentication is in place
+func HeaderActor(r *http.Request) string {
+	return r.Header.Get("X-Actor")
+}
+
+// Audit makes the actor and request ID available to services, and records every
+// successful mutating request (POST, PUT, PATCH, DELETE) to the audit trail
+func Audit(svc *services.AuditService, logger *zerolog.Logger, actor ActorFunc) Middleware {
+	if actor == nil {
+		actor = HeaderActor
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := services.WithAuditInfo(r.Context(), services.AuditInfo{
+				Actor:     actor(r),
+				RequestID: RequestIDFromContext(r.Context()),
+			})
+			r = r.WithContext(ctx)
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if !isMutation(r.Method) || rec.status >= http.StatusBadRequest {
+				return
+			}
+			if err := svc.Record(ctx, r.Method, r.URL.Path, r.PathValue("id"), nil); err != nil {
+				logger.Error().Err(err).Str("path", r.URL.Path).Msg("Failed to record audit entry")
+			}
+		})
+	}
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+`, modulePath)
+}
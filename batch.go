@@ -0,0 +1,1081 @@
+package main
+
+import "fmt"
+
+// Settings added to the config of batch jobs, with the short flags of the job's CLI
+var batchConfigFields = []configField{
+	{goName: "BatchJob", goType: "string", key: "BATCH_JOB", value: "import", comment: "Batch job: name (the checkpoint key), source, sink and limits, also set by the flags of the job", flag: "job", usage: "job name, used as the checkpoint key"},
+	{goName: "BatchSource", goType: "string", key: "BATCH_SOURCE", value: "file:data/input.csv", flag: "source", usage: "records to read: file:<path.csv>, s3://<bucket>/<key.csv> or postgres:<table>"},
+	{goName: "BatchSink", goType: "string", key: "BATCH_SINK", value: "file:data/output.jsonl", flag: "sink", usage: "where to write the records: stdout, file:<path.jsonl> or postgres:<table>"},
+	{goName: "BatchWorkers", goType: "int", key: "BATCH_WORKERS", value: "4", flag: "workers", usage: "records transformed concurrently"},
+	{goName: "BatchSize", goType: "int", key: "BATCH_SIZE", value: "500", usage: "records written and checkpointed together"},
+	{goName: "BatchMaxFailures", goType: "int", key: "BATCH_MAX_FAILURES", value: "100", flag: "max-failures", usage: "failed records tolerated before the run is aborted (-1: no limit)"},
+	{goName: "BatchCheckpointDir", goType: "string", key: "BATCH_CHECKPOINT_DIR", value: "checkpoints"},
+	{goName: "PushgatewayURL", goType: "string", key: "PUSHGATEWAY_URL", comment: "Prometheus Pushgateway receiving the job metrics after each run (empty: metrics are only logged)"},
+}
+
+// Returns the Makefile targets of batch jobs
+func batchMakefileContent() string {
+	return `
+.PHONY: job job-dry-run
+
+# Run the job once, e.g. make job ARGS="--from-start --workers=8"
+job:
+	APP_ENV=$(APP_ENV) go run ./cmd/$(firstword $(BINARIES)) $(ARGS)
+
+job-dry-run:
+	APP_ENV=$(APP_ENV) go run ./cmd/$(firstword $(BINARIES)) --dry-run $(ARGS)
+`
+}
+
+// Returns the content for cmd/<name>/main.go of a batch job
+func batchMainGoContent(projectName string) string {
+	return fmt.Sprintf(`package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/rs/zerolog"
+
+	"%[1]s/internal/etl"
+	"%[1]s/internal/jobs"
+	"%[1]s/pkg/config"
+	"%[1]s/pkg/logger"
+)
+
+// Build information, injected at build time through -ldflags (see Makefile)
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+func main() {
+	// Flags of the run. Those of the settings (--source, --sink, --job, --workers,
+	// --batch-size, --max-failures, ...) are defined by LoadConfig, which parses them.
+	opts := etl.Options{}
+	flag.BoolVar(&opts.FromStart, "from-start", false, "ignore the checkpoint and process every record")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "read and transform the records without writing them or the checkpoint")
+
+	// Load configuration
+	cfg := config.LoadConfig()
+	opts.Job = cfg.BatchJob
+	opts.Workers = cfg.BatchWorkers
+	opts.BatchSize = cfg.BatchSize
+	opts.MaxFailures = cfg.BatchMaxFailures
+
+	// Initialize logger
+	appLog, err := logger.NewLogger(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %%v", err)
+	}
+
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		appLog.Warn().Err(err).Msg("Invalid log level, keeping the default")
+	}
+
+	appLog.Info().
+		Str("version", version).
+		Str("commit", commit).
+		Str("date", date).
+		Msg("Starting the job")
+	cfg.Print(os.Stderr)
+
+	// An interrupt stops the run after the current batch; the next run resumes from its checkpoint
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg, appLog, cfg.BatchSource, cfg.BatchSink, opts); err != nil {
+		appLog.Error().Err(err).Msg("Job failed")
+		os.Exit(1)
+	}
+}
+
+// Runs the job once and reports its metrics
+func run(ctx context.Context, cfg *config.Config, appLog *zerolog.Logger, source, sink string, opts etl.Options) error {
+	var db *sql.DB
+	if strings.HasPrefix(source, "postgres:") || strings.HasPrefix(sink, "postgres:") {
+		var err error
+		if db, err = openDB(ctx, cfg); err != nil {
+			return err
+		}
+		defer db.Close()
+	}
+
+	src, err := openSource(ctx, source, db)
+	if err != nil {
+		return err
+	}
+	dst, closeSink, err := openSink(sink, db)
+	if err != nil {
+		return err
+	}
+	defer closeSink()
+
+	opts.OnFailure = func(r etl.Record, err error) {
+		appLog.Warn().Err(err).Int64("offset", r.Offset).Msg("Record failed")
+	}
+	metrics := etl.NewMetrics()
+	pipeline := &etl.Pipeline{
+		Source:      src,
+		Sink:        dst,
+		Transform:   jobs.Transform,
+		Checkpoints: etl.FileCheckpoints{Dir: cfg.BatchCheckpointDir},
+		Metrics:     metrics,
+		Options:     opts,
+	}
+
+	// Log the progress of long runs
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				metrics.Log(appLog.Info()).Msg("Job progress")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	appLog.Info().Str("job", opts.Job).Str("source", source).Str("sink", sink).Bool("dry_run", opts.DryRun).Msg("Job started")
+	runErr := pipeline.Run(ctx)
+	metrics.Log(appLog.Info()).Bool("success", runErr == nil).Msg("Job finished")
+
+	if cfg.PushgatewayURL != "" {
+		if err := metrics.Push(context.Background(), cfg.PushgatewayURL, opts.Job, runErr == nil); err != nil {
+			appLog.Warn().Err(err).Msg("Failed to push the job metrics")
+		}
+	}
+	return runErr
+}
+
+// Returns the source described by spec
+func openSource(ctx context.Context, spec string, db *sql.DB) (etl.Source, error) {
+	switch {
+	case strings.HasPrefix(spec, "file:"):
+		return etl.CSVSource{Open: etl.OpenFile(strings.TrimPrefix(spec, "file:"))}, nil
+	case strings.HasPrefix(spec, "s3://"):
+		bucket, key, ok := strings.Cut(strings.TrimPrefix(spec, "s3://"), "/")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid source %%q: expected s3://<bucket>/<key>", spec)
+		}
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("load AWS config: %%w", err)
+		}
+		return etl.CSVSource{Open: etl.OpenS3(s3.NewFromConfig(awsCfg), bucket, key)}, nil
+	case strings.HasPrefix(spec, "postgres:"):
+		return etl.SQLSource{DB: db, Table: strings.TrimPrefix(spec, "postgres:")}, nil
+	}
+	return nil, fmt.Errorf("unsupported source %%q (supported: file:<path.csv>, s3://<bucket>/<key.csv>, postgres:<table>)", spec)
+}
+
+// Returns the sink described by spec and the function closing it
+func openSink(spec string, db *sql.DB) (etl.Sink, func() error, error) {
+	noop := func() error { return nil }
+	switch {
+	case spec == "stdout":
+		return etl.NewJSONLinesSink(os.Stdout), noop, nil
+	case strings.HasPrefix(spec, "file:"):
+		f, err := os.OpenFile(strings.TrimPrefix(spec, "file:"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return etl.NewJSONLinesSink(f), f.Close, nil
+	case strings.HasPrefix(spec, "postgres:"):
+		return etl.SQLSink{DB: db, Table: strings.TrimPrefix(spec, "postgres:")}, noop, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported sink %%q (supported: stdout, file:<path.jsonl>, postgres:<table>)", spec)
+}
+
+// Opens the Postgres database configured in cfg
+func openDB(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.DBUser, cfg.DBPassword),
+		Host:   fmt.Sprintf("%%s:%%d", cfg.DBHost, cfg.DBPort),
+		Path:   cfg.DBName,
+	}
+	db, err := sql.Open("pgx", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to the database: %%w", err)
+	}
+	return db, nil
+}
+`, projectName)
+}
+
+// Returns the content for internal/etl/etl.go
+func etlGoContent() string {
+	return `package etl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Record is one item flowing through a pipeline. Offsets order the records of a
+// source; checkpoints store the offset of the last record written, and a
+// resumed run reads the records after it.
+type Record struct {
+	Offset int64
+	Data   map[string]any
+}
+
+// Source reads records in offset order
+type Source interface {
+	// Read calls emit with every record whose offset is greater than after
+	Read(ctx context.Context, after int64, emit func(Record) error) error
+}
+
+// Sink stores records. A batch may be written again when a run stops between
+// writing it and saving its checkpoint, so writes should be idempotent (e.g.
+// upserts or inserts ignoring conflicts).
+type Sink interface {
+	Write(ctx context.Context, records []Record) error
+}
+
+// Transform turns a source record into the record written to the sink. It runs
+// on several workers at once, so it must be safe for concurrent use.
+type Transform func(ctx context.Context, r Record) (Record, error)
+
+// ErrSkip is returned by a Transform to drop a record without counting it as a failure
+var ErrSkip = errors.New("skip record")
+
+// ErrTooManyFailures aborts a run once more than Options.MaxFailures records failed
+var ErrTooManyFailures = errors.New("too many failed records")
+
+// Options tune a pipeline run
+type Options struct {
+	// Job names the run; it is the checkpoint key
+	Job string
+	// Workers is the number of records transformed concurrently
+	Workers int
+	// BatchSize is the number of records written and checkpointed together
+	BatchSize int
+	// MaxFailures is the number of failed records tolerated before the run is
+	// aborted; negative means no limit
+	MaxFailures int
+	// FromStart ignores the checkpoint
+	FromStart bool
+	// DryRun reads and transforms the records but writes neither them nor the checkpoint
+	DryRun bool
+	// OnFailure is called with every record whose transform failed
+	OnFailure func(r Record, err error)
+}
+
+// Pipeline reads a source, transforms its records with a pool of workers and
+// writes them to a sink in batches, saving a checkpoint after each batch
+type Pipeline struct {
+	Source      Source
+	Sink        Sink
+	Transform   Transform
+	Checkpoints CheckpointStore
+	Metrics     *Metrics
+	Options
+}
+
+// Run processes the records after the checkpoint of the job. Records are
+// transformed concurrently but written, and checkpointed, in source order.
+func (p *Pipeline) Run(ctx context.Context) error {
+	if p.Workers < 1 || p.BatchSize < 1 {
+		return fmt.Errorf("workers and batch size must be positive, got %d and %d", p.Workers, p.BatchSize)
+	}
+	if p.Metrics == nil {
+		p.Metrics = NewMetrics()
+	}
+
+	var after int64
+	if !p.FromStart {
+		var err error
+		if after, err = p.Checkpoints.Load(p.Job); err != nil {
+			return fmt.Errorf("load checkpoint: %w", err)
+		}
+	}
+	p.Metrics.Checkpoint.Store(after)
+
+	batch := make([]Record, 0, p.BatchSize)
+	err := p.Source.Read(ctx, after, func(r Record) error {
+		p.Metrics.Read.Add(1)
+		batch = append(batch, r)
+		if len(batch) < p.BatchSize {
+			return nil
+		}
+		err := p.process(ctx, batch)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return p.process(ctx, batch)
+	}
+	return nil
+}
+
+// Transforms, writes and checkpoints one batch
+func (p *Pipeline) process(ctx context.Context, batch []Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	out := make([]Record, len(batch))
+	errs := make([]error, len(batch))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(p.Workers, len(batch)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				out[i], errs[i] = p.Transform(ctx, batch[i])
+				out[i].Offset = batch[i].Offset
+			}
+		}()
+	}
+	for i := range batch {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	records := make([]Record, 0, len(batch))
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			records = append(records, out[i])
+		case errors.Is(err, ErrSkip):
+			p.Metrics.Skipped.Add(1)
+		default:
+			failed := p.Metrics.Failed.Add(1)
+			if p.OnFailure != nil {
+				p.OnFailure(batch[i], err)
+			}
+			if p.MaxFailures >= 0 && failed > int64(p.MaxFailures) {
+				return fmt.Errorf("%w: %d", ErrTooManyFailures, failed)
+			}
+		}
+	}
+
+	offset := batch[len(batch)-1].Offset
+	if p.DryRun {
+		p.Metrics.Checkpoint.Store(offset)
+		return nil
+	}
+	if len(records) > 0 {
+		if err := p.Sink.Write(ctx, records); err != nil {
+			return fmt.Errorf("write records %d to %d: %w", batch[0].Offset, offset, err)
+		}
+		p.Metrics.Written.Add(int64(len(records)))
+	}
+	if err := p.Checkpoints.Save(p.Job, offset); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	p.Metrics.Checkpoint.Store(offset)
+	return nil
+}
+`
+}
+
+// Returns the content for internal/etl/checkpoint.go
+func etlCheckpointGoContent() string {
+	return `package etl
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CheckpointStore remembers how far each job got. A finished run keeps its
+// checkpoint, so the next run only processes the records added since
+// (incremental loads); run with FromStart to process everything again.
+type CheckpointStore interface {
+	// Load returns the offset of the last record written by job, or 0
+	Load(job string) (int64, error)
+	Save(job string, offset int64) error
+}
+
+// FileCheckpoints stores one JSON file per job in Dir
+type FileCheckpoints struct {
+	Dir string
+}
+
+type checkpoint struct {
+	Offset    int64     ` + "`" + `json:"offset"` + "`" + `
+	UpdatedAt time.Time ` + "`" + `json:"updated_at"` + "`" + `
+}
+
+// Load returns the offset saved for job, or 0 when it has no checkpoint
+func (c FileCheckpoints) Load(job string) (int64, error) {
+	data, err := os.ReadFile(c.path(job))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return 0, err
+	}
+	return cp.Offset, nil
+}
+
+// Save replaces the checkpoint atomically, so a crash never leaves a partial file
+func (c FileCheckpoints) Save(job string, offset int64) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(checkpoint{Offset: offset, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.Dir, job+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(job))
+}
+
+func (c FileCheckpoints) path(job string) string {
+	return filepath.Join(c.Dir, job+".json")
+}
+`
+}
+
+// Returns the content for internal/etl/metrics.go
+func etlMetricsGoContent() string {
+	return `package etl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Metrics counts the records of a run. The counters are safe for concurrent use.
+type Metrics struct {
+	Read    atomic.Int64
+	Written atomic.Int64
+	Skipped atomic.Int64
+	Failed  atomic.Int64
+	// Checkpoint is the offset of the last record processed
+	Checkpoint atomic.Int64
+
+	started time.Time
+}
+
+// NewMetrics creates the metrics of a run starting now
+func NewMetrics() *Metrics {
+	return &Metrics{started: time.Now()}
+}
+
+// Log adds the metrics to a log event
+func (m *Metrics) Log(e *zerolog.Event) *zerolog.Event {
+	return e.
+		Int64("read", m.Read.Load()).
+		Int64("written", m.Written.Load()).
+		Int64("skipped", m.Skipped.Load()).
+		Int64("failed", m.Failed.Load()).
+		Int64("checkpoint", m.Checkpoint.Load()).
+		Dur("elapsed", time.Since(m.started))
+}
+
+// Push sends the metrics to a Prometheus Pushgateway under the job name. The
+// last success timestamp is only pushed by successful runs, so alerting on its
+// age catches jobs that keep failing or stopped running.
+func (m *Metrics) Push(ctx context.Context, gatewayURL, job string, success bool) error {
+	var body bytes.Buffer
+	metric := func(name, help string, value float64) {
+		fmt.Fprintf(&body, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	}
+	metric("batch_records_read", "Records read by the last run.", float64(m.Read.Load()))
+	metric("batch_records_written", "Records written by the last run.", float64(m.Written.Load()))
+	metric("batch_records_skipped", "Records skipped by the last run.", float64(m.Skipped.Load()))
+	metric("batch_records_failed", "Records that failed in the last run.", float64(m.Failed.Load()))
+	metric("batch_checkpoint_offset", "Offset of the last record processed.", float64(m.Checkpoint.Load()))
+	metric("batch_duration_seconds", "Duration of the last run.", time.Since(m.started).Seconds())
+	if success {
+		metric("batch_last_success_timestamp_seconds", "Time the job last succeeded.", float64(time.Now().Unix()))
+	}
+
+	// POST only replaces the metrics sent, keeping the last success of earlier runs
+	endpoint := strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pushgateway responded %s", resp.Status)
+	}
+	return nil
+}
+`
+}
+
+// Returns the content for internal/etl/csv.go
+func etlCSVGoContent() string {
+	return `package etl
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// CSVSource reads a CSV file with a header line. Each data row becomes a record
+// keyed by the header names, and its offset is its row number (starting at 1).
+type CSVSource struct {
+	Open func(ctx context.Context) (io.ReadCloser, error)
+}
+
+// Read calls emit with every row after the row numbered after
+func (s CSVSource) Read(ctx context.Context, after int64, emit func(Record) error) error {
+	rc, err := s.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	cr := csv.NewReader(bufio.NewReader(rc))
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read CSV header: %w", err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+
+	var offset int64
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read CSV: %w", err)
+		}
+		offset++
+		if offset <= after {
+			continue
+		}
+
+		data := make(map[string]any, len(header))
+		for i, name := range header {
+			data[name] = row[i]
+		}
+		if err := emit(Record{Offset: offset, Data: data}); err != nil {
+			return err
+		}
+	}
+}
+
+// OpenFile opens a local file for a CSVSource
+func OpenFile(path string) func(ctx context.Context) (io.ReadCloser, error) {
+	return func(ctx context.Context) (io.ReadCloser, error) {
+		return os.Open(path)
+	}
+}
+
+// OpenS3 streams an S3 object for a CSVSource
+func OpenS3(client *s3.Client, bucket, key string) func(ctx context.Context) (io.ReadCloser, error) {
+	return func(ctx context.Context) (io.ReadCloser, error) {
+		out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		if err != nil {
+			return nil, fmt.Errorf("get s3://%s/%s: %w", bucket, key, err)
+		}
+		return out.Body, nil
+	}
+}
+`
+}
+
+// Returns the content for internal/etl/sql.go
+func etlSQLGoContent() string {
+	return `package etl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Postgres accepts at most this many parameters in one statement
+const maxQueryParams = 65535
+
+// SQLSource reads a table page by page in the order of its integer id column,
+// which is the record offset
+type SQLSource struct {
+	DB       *sql.DB
+	Table    string
+	PageSize int // 1000 when zero
+}
+
+// Read calls emit with every row whose id is greater than after, a page at a time
+func (s SQLSource) Read(ctx context.Context, after int64, emit func(Record) error) error {
+	pageSize := s.PageSize
+	if pageSize == 0 {
+		pageSize = 1000
+	}
+	query := "SELECT * FROM " + quoteIdent(s.Table) + " WHERE id > $1 ORDER BY id LIMIT $2"
+	for {
+		n, last, err := s.readPage(ctx, query, after, pageSize, emit)
+		if err != nil {
+			return err
+		}
+		if n < pageSize {
+			return nil
+		}
+		after = last
+	}
+}
+
+// Reads one page and returns its size and last offset
+func (s SQLSource) readPage(ctx context.Context, query string, after int64, pageSize int, emit func(Record) error) (int, int64, error) {
+	rows, err := s.DB.QueryContext(ctx, query, after, pageSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	n := 0
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return 0, 0, err
+		}
+		data := make(map[string]any, len(columns))
+		for i, c := range columns {
+			data[c] = values[i]
+		}
+
+		var offset int64
+		switch id := data["id"].(type) {
+		case int64:
+			offset = id
+		case int32:
+			offset = int64(id)
+		default:
+			return 0, 0, fmt.Errorf("%s.id must be an integer column, got %T", s.Table, id)
+		}
+		if err := emit(Record{Offset: offset, Data: data}); err != nil {
+			return 0, 0, err
+		}
+		n, after = n+1, offset
+	}
+	return n, after, rows.Err()
+}
+
+// SQLSink inserts records into a table, one column per key of Record.Data.
+// Conflicting rows are ignored, so a batch written twice is only stored once
+// as long as the table has a unique key.
+type SQLSink struct {
+	DB    *sql.DB
+	Table string
+}
+
+// Write inserts the batch into Table in one transaction, skipping the rows that
+// already exist
+func (s SQLSink) Write(ctx context.Context, records []Record) error {
+	columns := make([]string, 0, len(records[0].Data))
+	for c := range records[0].Data {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdent(c)
+	}
+	prefix := "INSERT INTO " + quoteIdent(s.Table) + " (" + strings.Join(quoted, ", ") + ") VALUES "
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	perStatement := maxQueryParams / len(columns)
+	for start := 0; start < len(records); start += perStatement {
+		chunk := records[start:min(start+perStatement, len(records))]
+		var query strings.Builder
+		query.WriteString(prefix)
+		args := make([]any, 0, len(chunk)*len(columns))
+		for i, r := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("(")
+			for j, c := range columns {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+				fmt.Fprintf(&query, "$%d", len(args)+1)
+				args = append(args, r.Data[c])
+			}
+			query.WriteString(")")
+		}
+		query.WriteString(" ON CONFLICT DO NOTHING")
+		if _, err := tx.ExecContext(ctx, query.String(), args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func quoteIdent(name string) string {
+	return ` + "`" + `"` + "`" + ` + strings.ReplaceAll(name, ` + "`" + `"` + "`" + `, ` + "`" + `""` + "`" + `) + ` + "`" + `"` + "`" + `
+}
+`
+}
+
+// Returns the content for internal/etl/jsonl.go
+func etlJSONLinesGoContent() string {
+	return `package etl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// JSONLinesSink writes the data of each record as one JSON object per line
+type JSONLinesSink struct {
+	w *bufio.Writer
+}
+
+// NewJSONLinesSink creates a JSONLinesSink writing to w
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{w: bufio.NewWriter(w)}
+}
+
+// Write encodes the batch and flushes it, so a checkpointed batch is never left in the buffer
+func (s *JSONLinesSink) Write(ctx context.Context, records []Record) error {
+	enc := json.NewEncoder(s.w)
+	for _, r := range records {
+		if err := enc.Encode(r.Data); err != nil {
+			return err
+		}
+	}
+	return s.w.Flush()
+}
+`
+}
+
+// Returns the content for internal/etl/etl_test.go
+func etlTestGoContent() string {
+	return `package etl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type sliceSource []Record
+
+func (s sliceSource) Read(ctx context.Context, after int64, emit func(Record) error) error {
+	for _, r := range s {
+		if r.Offset <= after {
+			continue
+		}
+		if err := emit(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memorySink struct {
+	mu      sync.Mutex
+	batches [][]Record
+	fail    bool
+}
+
+func (s *memorySink) Write(ctx context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("sink unavailable")
+	}
+	s.batches = append(s.batches, append([]Record(nil), records...))
+	return nil
+}
+
+func (s *memorySink) offsets() []int64 {
+	var offsets []int64
+	for _, b := range s.batches {
+		for _, r := range b {
+			offsets = append(offsets, r.Offset)
+		}
+	}
+	return offsets
+}
+
+func records(n int) sliceSource {
+	var s sliceSource
+	for i := 1; i <= n; i++ {
+		s = append(s, Record{Offset: int64(i), Data: map[string]any{"n": i}})
+	}
+	return s
+}
+
+// Doubles n, skips multiples of 5 and fails on multiples of 7
+func transform(ctx context.Context, r Record) (Record, error) {
+	n := r.Data["n"].(int)
+	switch {
+	case n%5 == 0:
+		return Record{}, ErrSkip
+	case n%7 == 0:
+		return Record{}, fmt.Errorf("%d is unlucky", n)
+	}
+	return Record{Data: map[string]any{"n": n * 2}}, nil
+}
+
+func newPipeline(t *testing.T, src Source, sink Sink) *Pipeline {
+	return &Pipeline{
+		Source:      src,
+		Sink:        sink,
+		Transform:   transform,
+		Checkpoints: FileCheckpoints{Dir: t.TempDir()},
+		Options:     Options{Job: "test", Workers: 3, BatchSize: 4, MaxFailures: -1},
+	}
+}
+
+func TestRunWritesInOrderAndCheckpoints(t *testing.T) {
+	sink := &memorySink{}
+	p := newPipeline(t, records(10), sink)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := fmt.Sprint(sink.offsets())
+	if want := "[1 2 3 4 6 8 9]"; got != want {
+		t.Errorf("written offsets = %s, want %s", got, want)
+	}
+	if len(sink.batches) != 3 {
+		t.Errorf("wrote %d batches, want 3", len(sink.batches))
+	}
+	if n := sink.batches[0][1].Data["n"]; n != 4 {
+		t.Errorf("transformed value = %v, want 4", n)
+	}
+	if r, w, s, f := p.Metrics.Read.Load(), p.Metrics.Written.Load(), p.Metrics.Skipped.Load(), p.Metrics.Failed.Load(); r != 10 || w != 7 || s != 2 || f != 1 {
+		t.Errorf("metrics read=%d written=%d skipped=%d failed=%d", r, w, s, f)
+	}
+	if offset, _ := p.Checkpoints.Load("test"); offset != 10 {
+		t.Errorf("checkpoint = %d, want 10", offset)
+	}
+}
+
+func TestRunResumesFromCheckpoint(t *testing.T) {
+	sink := &memorySink{}
+	p := newPipeline(t, records(10), sink)
+	p.Checkpoints.Save("test", 8)
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(sink.offsets()); got != "[9]" {
+		t.Errorf("written offsets = %s, want [9]", got)
+	}
+
+	p.FromStart = true
+	sink.batches = nil
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(sink.offsets()); got != 7 {
+		t.Errorf("from start wrote %d records, want 7", got)
+	}
+}
+
+func TestRunKeepsCheckpointWhenSinkFails(t *testing.T) {
+	sink := &memorySink{fail: true}
+	p := newPipeline(t, records(10), sink)
+	if err := p.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "sink unavailable") {
+		t.Fatalf("err = %v, want the sink error", err)
+	}
+	if offset, _ := p.Checkpoints.Load("test"); offset != 0 {
+		t.Errorf("checkpoint = %d, want 0", offset)
+	}
+}
+
+func TestRunAbortsAfterMaxFailures(t *testing.T) {
+	sink := &memorySink{}
+	p := newPipeline(t, records(20), sink)
+	p.MaxFailures = 1
+	var failed []int64
+	p.OnFailure = func(r Record, err error) { failed = append(failed, r.Offset) }
+
+	err := p.Run(context.Background())
+	if !errors.Is(err, ErrTooManyFailures) {
+		t.Fatalf("err = %v, want ErrTooManyFailures", err)
+	}
+	if fmt.Sprint(failed) != "[7 14]" {
+		t.Errorf("failed offsets = %v", failed)
+	}
+	// The batch holding the second failure is neither written nor checkpointed
+	if offset, _ := p.Checkpoints.Load("test"); offset != 12 {
+		t.Errorf("checkpoint = %d, want 12", offset)
+	}
+}
+
+func TestDryRunWritesNothing(t *testing.T) {
+	sink := &memorySink{}
+	p := newPipeline(t, records(10), sink)
+	p.DryRun = true
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.batches) != 0 {
+		t.Errorf("dry run wrote %d batches", len(sink.batches))
+	}
+	if offset, _ := p.Checkpoints.Load("test"); offset != 0 {
+		t.Errorf("dry run saved checkpoint %d", offset)
+	}
+}
+
+func TestCSVSourceSkipsCheckpointedRows(t *testing.T) {
+	src := CSVSource{Open: func(ctx context.Context) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("\uFEFFid,name\n1,a\n2,b\n3,c\n")), nil
+	}}
+	var got []string
+	err := src.Read(context.Background(), 1, func(r Record) error {
+		got = append(got, fmt.Sprintf("%d:%s", r.Offset, r.Data["name"]))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[2:b 3:c]" {
+		t.Errorf("records = %v", got)
+	}
+}
+`
+}
+
+// Returns the content for internal/jobs/transform.go
+func batchTransformGoContent(projectName string) string {
+	return fmt.Sprintf(`package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"%s/internal/etl"
+)
+
+// Transform is the business logic of the job: it turns a source record into the
+// record written to the sink. It runs on several workers at once, so it must be
+// safe for concurrent use. Return etl.ErrSkip to drop a record; any other error
+// counts as a failed record.
+func Transform(ctx context.Context, r etl.Record) (etl.Record, error) {
+	// TODO: replace with the job's logic (validate, enrich, reshape). The example
+	// normalizes the column names, trims string values and drops empty rows.
+	out := make(map[string]any, len(r.Data))
+	empty := true
+	for k, v := range r.Data {
+		if s, ok := v.(string); ok {
+			v = strings.TrimSpace(s)
+			empty = empty && v == ""
+		} else {
+			empty = empty && v == nil
+		}
+		out[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+	if empty {
+		return etl.Record{}, etl.ErrSkip
+	}
+	if _, ok := out["id"]; !ok {
+		return etl.Record{}, fmt.Errorf("record %%d has no id", r.Offset)
+	}
+	return etl.Record{Data: out}, nil
+}
+`, projectName)
+}
+
+// Returns the content for data/input.csv, the sample input of a batch job
+func batchSampleInputContent() string {
+	return `id,name,email
+1, Ada Lovelace ,ada@example.com
+2,Alan Turing,alan@example.com
+,,
+3,Grace Hopper,grace@example.com
+`
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Settings added to the config of the api archetype, whose main.go waits for its
+// dependencies at startup
+var startupConfigFields = []configField{
+	{goName: "StartupTimeout", goType: "time.Duration", key: "STARTUP_TIMEOUT", value: "2m", comment: "How long to wait for the dependencies (database, cache) at startup before exiting. They are retried with backoff meanwhile"},
+}
+
+// Returns the imports of main.go, the setup code opening the dependencies and
+// registering their health checks, and the dependencies it starts in order, as
+// the last arguments of bootstrap.Start: the database with the layered layout
+// (the modules of a modular monolith keep their data in memory) and its read
+// replica with the primary-replica topology, then the Redis cache
+func dependencySetup(projectName, layout, dbTopology, cache, workflow string) (imports, setup, dependencies string) {
+	imports = fmt.Sprintf("\t\"%[1]s/pkg/bootstrap\"\n\t\"%[1]s/pkg/health\"\n", projectName)
+	var deps []string
+	if layout != "modular-monolith" {
+		imports += fmt.Sprintf("\t\"%s/internal/repository\"\n", projectName)
+		setup += `	// Connection pool of the database, connecting on first use
+	db, err := repository.Open(cfg)
+	if err != nil {
+		appLog.Fatal().Err(err).Msg("Failed to open the database")
+	}
+	defer db.Close()
+	health.Register("database", health.Ping(db))
+`
+		deps = append(deps, `bootstrap.Dependency{Name: "database", Check: health.Ping(db)},`)
+		if dbTopology == "primary-replica" {
+			setup += `	// Connection pool of the read replica, given with db to repository.NewRouter
+	// by the repositories whose reads it serves
+	replica, err := repository.OpenReplica(cfg)
+	if err != nil {
+		appLog.Fatal().Err(err).Msg("Failed to open the database replica")
+	}
+	defer replica.Close()
+	health.Register("database-replica", health.Ping(replica))
+`
+			deps = append(deps, `bootstrap.Dependency{Name: "database-replica", Check: health.Ping(replica)},`)
+		}
+	}
+	if cache == "redis" {
+		setup += "\thealth.Register(\"cache\", health.Redis(rdb))\n"
+		deps = append(deps, `bootstrap.Dependency{Name: "cache", Check: health.Redis(rdb)},`)
+	}
+	if workflow == "temporal" {
+		setup += "\thealth.Register(\"temporal\", health.Dial(cfg.TemporalHostPort))\n"
+	}
+	setup += `	// The log file is the only thing written to disk
+	if cfg.LogFile != "" {
+		health.Register("disk", health.DiskSpace(filepath.Dir(cfg.LogFile), minFreeDisk))
+	}
+
+`
+	if len(deps) == 0 {
+		return imports, setup, ""
+	}
+	return imports, setup, ",\n\t\t" + strings.Join(deps, "\n\t\t") + "\n\t"
+}
+
+// Returns the content for pkg/bootstrap/bootstrap.go
+func bootstrapGoContent() string {
+	return `package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Backoff between the attempts to reach a dependency: it doubles from
+// initialBackoff up to maxBackoff. Each attempt is given attemptTimeout.
+var (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+	attemptTimeout = 5 * time.Second
+)
+
+// Dependency is a service the application needs before serving traffic, e.g. its
+// database. Check returns nil once it is reachable.
+type Dependency struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Start checks the dependencies one after the other, in order, retrying each with
+// exponential backoff until it is up, then marks r ready. It returns an error
+// when ctx is done first, e.g. after the startup timeout.
+func Start(ctx context.Context, log *zerolog.Logger, r *Readiness, deps ...Dependency) error {
+	names := make([]string, len(deps))
+	for i, d := range deps {
+		names[i] = d.Name
+	}
+	r.setPending(names)
+
+	for _, d := range deps {
+		backoff := initialBackoff
+		for attempt := 1; ; attempt++ {
+			attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+			err := d.Check(attemptCtx)
+			cancel()
+			if err == nil {
+				break
+			}
+			log.Warn().Err(err).Str("dependency", d.Name).Int("attempt", attempt).Dur("retry_in", backoff).Msg("Dependency unavailable")
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%s unavailable after %d attempts: %w", d.Name, attempt, err)
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, maxBackoff)
+		}
+		log.Info().Str("dependency", d.Name).Msg("Dependency up")
+		r.done(d.Name)
+	}
+	r.setReady()
+	log.Info().Msg("Ready to serve traffic")
+	return nil
+}
+
+// Readiness tells whether the application is ready to serve traffic: it is not
+// until Start has brought up every dependency. Its zero value is not ready.
+type Readiness struct {
+	mu      sync.Mutex
+	pending []string
+	ready   bool
+}
+
+func (r *Readiness) setPending(names []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append([]string{}, names...)
+}
+
+func (r *Readiness) done(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, n := range r.pending {
+		if n == name {
+			r.pending = append(r.pending[:i], r.pending[i+1:]...)
+			break
+		}
+	}
+}
+
+func (r *Readiness) setReady() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = true
+}
+
+// Ready reports whether every dependency is up, and otherwise the ones still
+// awaited
+func (r *Readiness) Ready() (bool, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ready, append([]string{}, r.pending...)
+}
+
+// ServeHTTP is the readiness probe (/readyz): 200 once ready, 503 with the
+// dependencies still awaited before
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ready, pending := r.Ready()
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]any{"status": "starting", "pending": pending})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+}
+
+// Gate answers 503 with a Retry-After header until the application is ready,
+// then hands the requests to next
+func (r *Readiness) Gate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if ready, _ := r.Ready(); !ready {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "starting, retry later", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+`
+}
+
+// Returns the content for pkg/bootstrap/bootstrap_test.go
+func bootstrapTestGoContent() string {
+	return `package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	initialBackoff = time.Millisecond
+	maxBackoff = 4 * time.Millisecond
+}
+
+func readyz(r *Readiness) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	return rec
+}
+
+func TestStartInOrder(t *testing.T) {
+	logger := zerolog.Nop()
+	var r Readiness
+	if rec := readyz(&r); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status before Start = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	var order []string
+	failures := 2
+	deps := []Dependency{
+		{Name: "database", Check: func(ctx context.Context) error {
+			order = append(order, "database")
+			if failures > 0 {
+				failures--
+				return errors.New("connection refused")
+			}
+			return nil
+		}},
+		{Name: "cache", Check: func(ctx context.Context) error {
+			order = append(order, "cache")
+			if rec := readyz(&r); !strings.Contains(rec.Body.String(), "cache") {
+				t.Errorf("readyz while starting the cache = %s, want it pending", rec.Body.String())
+			}
+			return nil
+		}},
+	}
+	if err := Start(context.Background(), &logger, &r, deps...); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := strings.Join(order, ","), "database,database,database,cache"; got != want {
+		t.Errorf("checks = %s, want %s", got, want)
+	}
+	if rec := readyz(&r); rec.Code != http.StatusOK {
+		t.Errorf("status after Start = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestStartTimeout(t *testing.T) {
+	logger := zerolog.Nop()
+	var r Readiness
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := Start(ctx, &logger, &r, Dependency{Name: "database", Check: func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}})
+	if err == nil {
+		t.Fatal("Start succeeded, want the database unavailable")
+	}
+
+	h := r.Gate(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("gated status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+`
+}
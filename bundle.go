@@ -0,0 +1,397 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Bundles carry installed templates to machines that cannot clone them, e.g.
+// air-gapped ones: a gzipped tar holding bundleManifestName, then the files of
+// every template under templates/<name>/, without their Git metadata.
+const bundleManifestName = "gogo-bundle.json"
+
+// Written in each template a bundle installs, which has no Git metadata to tell
+// where it comes from
+const templateOriginName = ".gogo-origin.json"
+
+// templateOrigin is the source of a template installed from a bundle
+type templateOrigin struct {
+	Source   string    `json:"source,omitempty"`
+	Revision string    `json:"revision,omitempty"`
+	Bundle   string    `json:"bundle"` // the file it was imported from
+	At       time.Time `json:"imported_at"`
+}
+
+// bundleManifest lists the templates of a bundle with the checksums of their files
+type bundleManifest struct {
+	GogoVersion string           `json:"gogo_version,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Templates   []bundleTemplate `json:"templates"`
+}
+
+// bundleTemplate is an installed template in a bundle
+type bundleTemplate struct {
+	Name     string         `json:"name"`
+	Source   string         `json:"source,omitempty"`   // the Git URL it was installed from
+	Revision string         `json:"revision,omitempty"` // the commit it was at
+	Files    []manifestFile `json:"files"`
+}
+
+// Runs `gogo template bundle export|import`
+func runTemplateBundle(args []string) {
+	if len(args) == 0 {
+		fatalf(exitUsage, "Please provide a bundle command: export [name ...] [--output=<file>] or import <file> [--force]")
+	}
+	switch args[0] {
+	case "export":
+		exportBundle(args[1:])
+	case "import":
+		importBundle(args[1:])
+	default:
+		fatalf(exitUsage, "Unknown bundle command %q (supported: export, import)", args[0])
+	}
+}
+
+// Writes the named installed templates, or all of them, with the installed
+// templates they extend, to a bundle
+func exportBundle(args []string) {
+	flags := flag.NewFlagSet("gogo template bundle export", flag.ExitOnError)
+	output := flags.String("output", "gogo-templates.tar.gz", "file to write the bundle to")
+	names := parseFlags(flags, args)
+	if len(names) == 0 {
+		names = installedTemplates()
+	}
+	if len(names) == 0 {
+		fatalf(exitUsage, "No template installed. Install one with gogo template install <git-url>")
+	}
+
+	root, err := filepath.Abs(templatesDir())
+	if err != nil {
+		fatalf(exitFailure, "Failed to locate %s: %v", templatesDir(), err)
+	}
+	var bundled []string
+	for _, name := range names {
+		dir := filepath.Join(root, name)
+		if _, err := os.Stat(filepath.Join(dir, templateManifestName)); err != nil {
+			fatalf(exitUsage, "Template %s is not installed (installed: %s)", name, strings.Join(installedTemplates(), ", "))
+		}
+		layers, _, err := templateChain(name, dir, false)
+		if err != nil {
+			fatalf(exitTemplate, "Invalid template %s: %v", name, err)
+		}
+		for _, l := range layers {
+			abs, err := filepath.Abs(l.dir)
+			if err != nil {
+				fatalf(exitFailure, "Failed to locate %s: %v", l.dir, err)
+			}
+			rel, err := filepath.Rel(root, abs)
+			if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+				fatalf(exitTemplate, "Template %s extends %s, which is not an installed template and cannot be bundled", name, l.name)
+			}
+			if installed := strings.Split(filepath.ToSlash(rel), "/")[0]; !slices.Contains(bundled, installed) {
+				bundled = append(bundled, installed)
+			}
+		}
+	}
+
+	m := bundleManifest{GogoVersion: gogoVersion(), CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	files := map[string][]string{}
+	for _, name := range bundled {
+		dir := filepath.Join(root, name)
+		t := bundleTemplate{Name: name}
+		t.Source, t.Revision, _ = templateSource(dir)
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if d.IsDir() || p == filepath.Join(dir, templateOriginName) {
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return fmt.Errorf("%s is not a regular file", p)
+			}
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, p)
+			t.Files = append(t.Files, manifestFile{Path: filepath.ToSlash(rel), SHA256: contentHash(content)})
+			files[name] = append(files[name], p)
+			return nil
+		})
+		if err != nil {
+			fatalf(exitFailure, "Failed to read template %s: %v", name, err)
+		}
+		m.Templates = append(m.Templates, t)
+	}
+
+	sum, err := writeBundle(*output, m, files)
+	if err != nil {
+		os.Remove(*output)
+		fatalf(exitFailure, "Failed to write %s: %v", *output, err)
+	}
+	printStyled(styleGreen, "Bundle %s has been written with %s.\n", *output, strings.Join(bundled, ", "))
+	printf("Its SHA-256 is %s. Import it with gogo template bundle import --sha256=%s %s\n", sum, sum, filepath.Base(*output))
+}
+
+// Writes the bundle of m, with the files of each template, to file. Returns the
+// hex SHA-256 of the bundle.
+func writeBundle(file string, m bundleManifest, files map[string][]string) (string, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(f, hash))
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := writeTarFile(tw, bundleManifestName, append(data, '\n'), 0644, m.CreatedAt); err != nil {
+		return "", err
+	}
+	for _, t := range m.Templates {
+		for i, tf := range t.Files {
+			content, err := os.ReadFile(files[t.Name][i])
+			if err != nil {
+				return "", err
+			}
+			if contentHash(content) != tf.SHA256 {
+				return "", fmt.Errorf("%s changed while it was bundled", files[t.Name][i])
+			}
+			info, err := os.Stat(files[t.Name][i])
+			if err != nil {
+				return "", err
+			}
+			if err := writeTarFile(tw, path.Join("templates", t.Name, tf.Path), content, info.Mode().Perm(), m.CreatedAt); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	debugf("write %s (bundle of %d templates)", file, len(m.Templates))
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Writes a regular file to tw
+func writeTarFile(tw *tar.Writer, name string, content []byte, mode fs.FileMode, modTime time.Time) error {
+	err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(content)), Mode: int64(mode), ModTime: modTime})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(content)
+	return err
+}
+
+// Installs the templates of a bundle once their files match the checksums of
+// its manifest, and the bundle the SHA-256 given with --sha256. Templates
+// already installed are refused unless --force, which replaces them.
+func importBundle(args []string) {
+	flags := flag.NewFlagSet("gogo template bundle import", flag.ExitOnError)
+	force := flags.Bool("force", false, "replace the templates already installed")
+	sum := flags.String("sha256", "", "SHA-256 the bundle must have, as printed by gogo template bundle export")
+	positional := parseFlags(flags, args)
+	if len(positional) != 1 {
+		fatalf(exitUsage, "Please provide the bundle to import, e.g. gogo template bundle import gogo-templates.tar.gz")
+	}
+	if *sum != "" {
+		got, err := fileSHA256(positional[0])
+		if err != nil {
+			fatalf(exitFailure, "Failed to read %s: %v", positional[0], err)
+		}
+		if !strings.EqualFold(got, *sum) {
+			fatalf(exitTemplate, "Bundle %s has the SHA-256 %s, not %s: it was changed or damaged since it was exported", positional[0], got, *sum)
+		}
+	}
+
+	root := templatesDir()
+	if err := os.MkdirAll(root, 0755); err != nil {
+		fatalf(exitFailure, "Failed to create %s: %v", root, err)
+	}
+	tmp, err := os.MkdirTemp(root, ".import-")
+	if err != nil {
+		fatalf(exitFailure, "Failed to create a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	m, err := extractBundle(positional[0], tmp)
+	if err != nil {
+		os.RemoveAll(tmp)
+		fatalf(exitTemplate, "Invalid bundle %s: %v", positional[0], err)
+	}
+
+	for _, t := range m.Templates {
+		if _, err := os.Stat(filepath.Join(root, t.Name)); err == nil && !*force {
+			os.RemoveAll(tmp)
+			fatalf(exitExists, "Template %s is already installed, import with --force to replace it", t.Name)
+		}
+	}
+	bundle, err := filepath.Abs(positional[0])
+	if err != nil {
+		bundle = positional[0]
+	}
+	for _, t := range m.Templates {
+		origin := templateOrigin{Source: t.Source, Revision: t.Revision, Bundle: bundle, At: time.Now().UTC().Truncate(time.Second)}
+		data, _ := json.MarshalIndent(origin, "", "  ")
+		if err := os.WriteFile(filepath.Join(tmp, t.Name, templateOriginName), append(data, '\n'), 0644); err != nil {
+			os.RemoveAll(tmp)
+			fatalf(exitFailure, "Failed to install template %s: %v", t.Name, err)
+		}
+	}
+	for _, t := range m.Templates {
+		dir := filepath.Join(root, t.Name)
+		debugf("install %s from %s in %s", t.Name, positional[0], dir)
+		if err := os.RemoveAll(dir); err != nil {
+			os.RemoveAll(tmp)
+			fatalf(exitFailure, "Failed to replace template %s: %v", t.Name, err)
+		}
+		if err := os.Rename(filepath.Join(tmp, t.Name), dir); err != nil {
+			os.RemoveAll(tmp)
+			fatalf(exitFailure, "Failed to install template %s: %v", t.Name, err)
+		}
+	}
+	// The templates of the bundle may extend each other, so they are checked once
+	// all are installed
+	for _, t := range m.Templates {
+		if _, _, err := templateChain(t.Name, filepath.Join(root, t.Name), false); err != nil {
+			printStyled(styleYellow, "Warning: %s is invalid: %v\n", t.Name, err)
+		}
+	}
+	for _, t := range m.Templates {
+		from := t.Source
+		if t.Revision != "" {
+			from = strings.TrimSpace(from + " at " + t.Revision)
+		}
+		if from != "" {
+			printStyled(styleGreen, "Template %s has been installed (%s).\n", t.Name, from)
+		} else {
+			printStyled(styleGreen, "Template %s has been installed.\n", t.Name)
+		}
+	}
+}
+
+// Returns the hex SHA-256 of the file
+func fileSHA256(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Extracts the templates of the bundle file into dir, one directory per
+// template, checking every file against the checksums of the manifest. Returns
+// the manifest.
+func extractBundle(file, dir string) (bundleManifest, error) {
+	var m bundleManifest
+	f, err := os.Open(file)
+	if err != nil {
+		return m, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return m, err
+	}
+	tarReader := tar.NewReader(gz)
+
+	hdr, err := tarReader.Next()
+	if err != nil || hdr.Name != bundleManifestName {
+		return m, fmt.Errorf("it does not start with %s", bundleManifestName)
+	}
+	data, err := io.ReadAll(tarReader)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", bundleManifestName, err)
+	}
+	if len(m.Templates) == 0 {
+		return m, errors.New("it holds no template")
+	}
+	want := map[string]string{}
+	for _, t := range m.Templates {
+		if !templateNamePattern.MatchString(t.Name) {
+			return m, fmt.Errorf("invalid template name %q", t.Name)
+		}
+		// Created for every template, including one without files
+		if err := os.MkdirAll(filepath.Join(dir, t.Name), 0755); err != nil {
+			return m, err
+		}
+		for _, tf := range t.Files {
+			if !fs.ValidPath(tf.Path) || tf.Path == "." {
+				return m, fmt.Errorf("invalid path %q in template %s", tf.Path, t.Name)
+			}
+			want[path.Join("templates", t.Name, tf.Path)] = tf.SHA256
+		}
+	}
+
+	for {
+		hdr, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return m, err
+		}
+		sum, ok := want[hdr.Name]
+		if !ok || hdr.Typeflag != tar.TypeReg {
+			return m, fmt.Errorf("%s is not listed in %s", hdr.Name, bundleManifestName)
+		}
+		content, err := io.ReadAll(tarReader)
+		if err != nil {
+			return m, err
+		}
+		if contentHash(content) != sum {
+			return m, fmt.Errorf("%s does not match its checksum", hdr.Name)
+		}
+		delete(want, hdr.Name)
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(hdr.Name, "templates/")))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return m, err
+		}
+		mode := fs.FileMode(0644)
+		if hdr.Mode&0111 != 0 {
+			mode = 0755
+		}
+		if err := os.WriteFile(target, content, mode); err != nil {
+			return m, err
+		}
+	}
+	for name := range want {
+		return m, fmt.Errorf("%s is missing", name)
+	}
+	return m, nil
+}
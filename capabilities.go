@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// capability is a choice of the generation flags, named as given on the command
+// line, that other choices require or cannot be combined with
+type capability struct {
+	name      string
+	set       bool
+	requires  []string // capabilities it is built on
+	conflicts []string // capabilities it cannot be combined with, declared on one side only
+}
+
+// Returns the capabilities of opts and how they relate. Each generator declares
+// here what it requires and conflicts with rather than checking it itself, so
+// that parseGenerationFlags reports every problem at once, before any file is
+// written. The add-ons of --with require each other in addons.go instead, as
+// parseWith adds the ones required.
+func capabilityGraph(opts options) []capability {
+	api := []string{"--archetype=api"}
+	apiStandard := []string{"--archetype=api", "--layout=standard"}
+	graph := []capability{
+		{name: "--archetype=api", set: opts.archetype == "api"},
+		{name: "--layout=standard", set: opts.layout == "standard"},
+		{name: "--layout=modular-monolith", set: opts.layout == "modular-monolith", requires: api},
+		{name: "--config-format=env", set: opts.configFormat == "env"},
+		{name: "--cache=redis", set: opts.cache != ""},
+		{name: "--db-topology=primary-replica", set: opts.dbTopology == "primary-replica", requires: apiStandard},
+		{name: "--workflow=temporal", set: opts.workflow != ""},
+		{name: "--contract-tests=pact", set: opts.contract != "", requires: api},
+		{name: "--target=lambda", set: opts.target != "", requires: api},
+		{name: "--deploy=cloudrun", set: opts.deploy != "", requires: api},
+		{name: "--iac=terraform", set: opts.iac != ""},
+		{name: "--errors=sentry", set: opts.errors != "", requires: api},
+		{name: "--pprof", set: opts.pprof, requires: api},
+		{name: "--profiling", set: opts.profiling != "", requires: []string{"--pprof"}},
+		{name: "--example", set: opts.example != "", requires: apiStandard},
+		// Logging with zerolog themselves
+		{name: "--stdlib=logger", set: opts.stdlibLogger, requires: api, conflicts: []string{
+			"--workflow=temporal", "--target=lambda", "--deploy=cloudrun", "--errors=sentry", "--pprof",
+		}},
+		{name: "--stdlib=config", set: opts.stdlibConfig, requires: []string{"--config-format=env"}},
+		{name: "--github-community", set: opts.community, requires: []string{"--owner"}},
+		{name: "--owner", set: len(opts.owners) > 0, requires: []string{"--github-community"}},
+	}
+
+	// Adding dependencies
+	minimal := capability{name: "--minimal", set: opts.minimal, requires: []string{"--archetype=api", "--layout=standard", "--config-format=env"}, conflicts: []string{
+		"--cache=redis", "--db-topology=primary-replica", "--workflow=temporal", "--contract-tests=pact", "--target=lambda", "--deploy=cloudrun",
+		"--iac=terraform", "--errors=sentry", "--pprof", "--profiling", "--example", "--stdlib=logger", "--stdlib=config",
+	}}
+	for _, name := range addonNames() {
+		c := capability{name: "--with=" + name, requires: apiStandard}
+		for _, w := range opts.with {
+			c.set = c.set || w == name
+		}
+		graph = append(graph, c)
+		minimal.conflicts = append(minimal.conflicts, c.name)
+	}
+	return append(graph, minimal)
+}
+
+// Checks the capabilities of opts against what they require and conflict with.
+// Returns a problem for each capability set with what to change to solve it.
+func checkCapabilities(opts options) []string {
+	graph := capabilityGraph(opts)
+	set := map[string]bool{}
+	for _, c := range graph {
+		set[c.name] = c.set
+	}
+	isSet := func(name string) bool {
+		s, ok := set[name]
+		if !ok {
+			panic("unknown capability " + name)
+		}
+		return s
+	}
+
+	var problems []string
+	for _, c := range graph {
+		// Resolving the names of the capabilities not set too checks the graph
+		var missing, conflicting []string
+		for _, r := range c.requires {
+			if !isSet(r) {
+				missing = append(missing, r)
+			}
+		}
+		for _, o := range c.conflicts {
+			if isSet(o) {
+				conflicting = append(conflicting, o)
+			}
+		}
+		if !c.set {
+			continue
+		}
+		switch len(missing) {
+		case 0:
+		case 1:
+			problems = append(problems, fmt.Sprintf(tr("%s requires %s: set it or drop %s"), c.name, missing[0], c.name))
+		default:
+			problems = append(problems, fmt.Sprintf(tr("%s requires %s: set them or drop %s"), c.name, strings.Join(missing, ", "), c.name))
+		}
+		switch len(conflicting) {
+		case 0:
+		case 1:
+			problems = append(problems, fmt.Sprintf(tr("%s cannot be combined with %s: drop one of them"), c.name, conflicting[0]))
+		default:
+			problems = append(problems, fmt.Sprintf(tr("%s cannot be combined with %s: drop them or %s"), c.name, strings.Join(conflicting, ", "), c.name))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,160 @@
+package main
+
+import ()
+
+// Conventional commit types accepted by the commit-msg hook, grouped in the changelog
+const conventionalCommitTypes = "build|chore|ci|docs|feat|fix|perf|refactor|revert|style|test"
+
+// Returns the Makefile targets generating CHANGELOG.md with the chosen tool
+func changelogMakefileContent(tool string) string {
+	command := "git cliff --output CHANGELOG.md"
+	if tool == "chglog" {
+		command = "go run github.com/git-chglog/git-chglog/cmd/git-chglog@latest --output CHANGELOG.md"
+	}
+	return `
+.PHONY: changelog hooks
+
+# Regenerates CHANGELOG.md from the conventional commits
+changelog:
+	` + command + `
+
+# Enables the hooks of .githooks (done by gogo for new projects)
+hooks:
+	git config core.hooksPath .githooks
+`
+}
+
+// Returns the content for .githooks/commit-msg, rejecting messages that are not conventional commits
+func commitMsgHookContent() string {
+	return `#!/bin/sh
+# Rejects commit messages that do not follow https://www.conventionalcommits.org,
+# e.g. "feat(api): add pagination" or "fix!: reject empty names"
+subject=$(head -n 1 "$1")
+
+case "$subject" in
+	Merge\ *|Revert\ *|fixup!\ *|squash!\ *|amend!\ *) exit 0 ;;
+esac
+
+if ! printf '%s\n' "$subject" | grep -Eq '^(` + conventionalCommitTypes + `)(\([a-z0-9._/-]+\))?!?: .+'; then
+	echo "commit-msg: \"$subject\" is not a conventional commit" >&2
+	echo "expected <type>[(scope)][!]: <description>, with type one of: ` + conventionalCommitTypes + `" >&2
+	exit 1
+fi
+`
+}
+
+// Returns the content for cliff.toml, the git-cliff configuration
+func gitCliffConfigContent() string {
+	return `# git-cliff configuration, see https://git-cliff.org/docs/configuration
+
+[changelog]
+header = """
+# Changelog
+
+All notable changes to this project are documented in this file.
+"""
+body = """
+{% if version %}\
+    ## [{{ version | trim_start_matches(pat="v") }}] - {{ timestamp | date(format="%Y-%m-%d") }}
+{% else %}\
+    ## [Unreleased]
+{% endif %}\
+{% for group, commits in commits | group_by(attribute="group") %}
+    ### {{ group | striptags | trim | upper_first }}
+    {% for commit in commits %}
+        - {% if commit.scope %}**{{ commit.scope }}:** {% endif %}\
+          {% if commit.breaking %}[**breaking**] {% endif %}\
+          {{ commit.message | upper_first }}\
+    {% endfor %}
+{% endfor %}\n
+"""
+trim = true
+
+[git]
+conventional_commits = true
+filter_unconventional = true
+split_commits = false
+commit_parsers = [
+  { message = "^feat", group = "<!-- 0 -->Features" },
+  { message = "^fix", group = "<!-- 1 -->Bug fixes" },
+  { message = "^perf", group = "<!-- 2 -->Performance" },
+  { message = "^refactor", group = "<!-- 3 -->Refactoring" },
+  { message = "^doc", group = "<!-- 4 -->Documentation" },
+  { message = "^revert", group = "<!-- 5 -->Reverted" },
+  { message = "^chore\\(release\\)", skip = true },
+  { message = "^(build|chore|ci|style|test)", group = "<!-- 6 -->Miscellaneous" },
+]
+protect_breaking_commits = true
+tag_pattern = "v[0-9].*"
+sort_commits = "oldest"
+`
+}
+
+// Returns the content for .chglog/config.yml, the git-chglog configuration
+func chglogConfigContent(projectName string) string {
+	return `style: github
+template: CHANGELOG.tpl.md
+info:
+  title: CHANGELOG
+  repository_url: https://github.com/OWNER/` + projectName + `
+options:
+  commits:
+    filters:
+      Type: [feat, fix, perf, refactor, docs, revert]
+  commit_groups:
+    title_maps:
+      feat: Features
+      fix: Bug Fixes
+      perf: Performance Improvements
+      refactor: Code Refactoring
+      docs: Documentation
+      revert: Reverts
+  header:
+    pattern: "^(\\w*)(?:\\(([\\w\\$\\.\\-\\*\\s/]*)\\))?!?\\:\\s(.*)$"
+    pattern_maps:
+      - Type
+      - Scope
+      - Subject
+  notes:
+    keywords:
+      - BREAKING CHANGE
+`
+}
+
+// Returns the content for .chglog/CHANGELOG.tpl.md, the git-chglog template
+func chglogTemplateContent() string {
+	return `# Changelog
+{{ if .Unreleased.CommitGroups -}}
+
+## [Unreleased]
+{{ range .Unreleased.CommitGroups -}}
+
+### {{ .Title }}
+{{ range .Commits -}}
+- {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ .Subject }}
+{{ end }}
+{{- end -}}
+{{ end -}}
+
+{{ range .Versions }}
+## {{ if .Tag.Previous }}[{{ .Tag.Name }}]({{ $.Info.RepositoryURL }}/compare/{{ .Tag.Previous.Name }}...{{ .Tag.Name }}){{ else }}{{ .Tag.Name }}{{ end }} - {{ datetime "2006-01-02" .Tag.Date }}
+{{ range .CommitGroups -}}
+
+### {{ .Title }}
+{{ range .Commits -}}
+- {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ .Subject }}
+{{ end }}
+{{- end -}}
+
+{{- if .NoteGroups -}}
+{{ range .NoteGroups -}}
+
+### {{ .Title }}
+{{ range .Notes }}
+{{ .Body }}
+{{ end }}
+{{ end -}}
+{{ end -}}
+{{ end -}}
+`
+}
@@ -0,0 +1,65 @@
+package main
+
+import "strings"
+
+// Returns the content for .github/workflows/ci.yml. With goprivate, the GOPRIVATE
+// patterns of the private modules, the jobs can fetch them.
+func ciWorkflowContent(goprivate string) string {
+	content := `name: ci
+
+on:
+  push:
+    branches: [main]
+  pull_request:
+
+ENV
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+
+PRIVATE
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+
+      - name: Vet
+        run: go vet ./...
+
+      - name: Architecture
+        run: make arch-lint
+
+      - name: Lint
+        run: make lint
+
+      - name: Test
+        run: go test ./...
+
+  supply-chain:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+
+PRIVATE
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+
+      - name: Vulnerability check
+        run: make vulncheck
+
+      - name: Generate SBOM
+        run: make sbom
+
+      - uses: actions/upload-artifact@v4
+        with:
+          name: sbom
+          path: sbom.cdx.json
+`
+	env, private := "", ""
+	if goprivate != "" {
+		env, private = ciPrivateModulesContent(goprivate)
+	}
+	return strings.NewReplacer("ENV\n", env, "PRIVATE\n", private).Replace(content)
+}
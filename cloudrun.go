@@ -0,0 +1,196 @@
+package main
+
+import "fmt"
+
+// Returns the Makefile targets building the image with Cloud Build and deploying it to Cloud Run
+func cloudRunMakefileContent(projectName, image string) string {
+	return fmt.Sprintf(`
+GCP_PROJECT ?= $(shell gcloud config get-value project 2>/dev/null)
+GCP_REGION ?= europe-west1
+IMAGE ?= $(GCP_REGION)-docker.pkg.dev/$(GCP_PROJECT)/%[1]s/%[2]s:$(VERSION)
+
+.PHONY: image deploy
+
+# Builds the image remotely and pushes it to Artifact Registry
+image:
+	gcloud builds submit --project $(GCP_PROJECT) --config cloudbuild.yaml \
+		--substitutions _IMAGE=$(IMAGE),_VERSION=$(VERSION),_COMMIT=$(COMMIT) .
+
+# Applies deploy/cloudrun/service.yaml with the image just built
+deploy: image
+	@mkdir -p $(BIN_DIR)
+	sed -e 's|IMAGE|$(IMAGE)|' -e 's|PROJECT_ID|$(GCP_PROJECT)|g' -e 's|REGION|$(GCP_REGION)|g' \
+		deploy/cloudrun/service.yaml > $(BIN_DIR)/service.yaml
+	gcloud run services replace $(BIN_DIR)/service.yaml --project $(GCP_PROJECT) --region $(GCP_REGION)
+`, projectName, image)
+}
+
+// Returns the content for cloudbuild.yaml, building the image with BuildKit as the
+// Dockerfile's cache mounts require
+func cloudBuildContent() string {
+	return `# Build run by make image: gcloud builds submit --config cloudbuild.yaml
+steps:
+  - name: gcr.io/cloud-builders/docker
+    env:
+      - DOCKER_BUILDKIT=1
+    args:
+      - build
+      - --build-arg=VERSION=$_VERSION
+      - --build-arg=COMMIT=$_COMMIT
+      - --tag=$_IMAGE
+      - .
+images:
+  - $_IMAGE
+substitutions:
+  _VERSION: dev
+  _COMMIT: none
+`
+}
+
+// Returns the content for deploy/cloudrun/service.yaml, the Knative manifest of the Cloud Run service.
+// IMAGE, PROJECT_ID and REGION are substituted by make deploy.
+func cloudRunServiceContent(projectName string) string {
+	return fmt.Sprintf(`apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: %[1]s
+  labels:
+    cloud.googleapis.com/location: REGION
+  annotations:
+    run.googleapis.com/ingress: all
+spec:
+  template:
+    metadata:
+      annotations:
+        autoscaling.knative.dev/minScale: "0"
+        autoscaling.knative.dev/maxScale: "10"
+        run.googleapis.com/startup-cpu-boost: "true"
+        # Mounts the Cloud SQL instance as a unix socket under /cloudsql
+        run.googleapis.com/cloudsql-instances: PROJECT_ID:REGION:%[1]s
+    spec:
+      serviceAccountName: %[1]s-run@PROJECT_ID.iam.gserviceaccount.com
+      containerConcurrency: 80
+      timeoutSeconds: 300
+      containers:
+        - image: IMAGE
+          # Cloud Run sends requests to this port and sets PORT to it
+          ports:
+            - name: http1
+              containerPort: 8080
+          env:
+            - name: APP_ENV
+              value: prod
+            - name: DB_HOST
+              value: /cloudsql/PROJECT_ID:REGION:%[1]s
+            - name: DB_PASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: %[1]s-db-password
+                  key: latest
+          resources:
+            limits:
+              cpu: "1"
+              memory: 512Mi
+          # Ready once the database is reachable, which is retried for up to
+          # STARTUP_TIMEOUT (2m)
+          startupProbe:
+            httpGet:
+              path: /readyz
+            periodSeconds: 2
+            failureThreshold: 60
+          livenessProbe:
+            httpGet:
+              path: /healthz
+            periodSeconds: 30
+`, projectName)
+}
+
+// Returns the content for logger.go writing JSON in the shape Cloud Logging expects
+// (severity, message and an RFC 3339 time), to stdout only when running on Cloud Run
+func cloudRunLoggerGoContent(projectName string) string {
+	return fmt.Sprintf(`package logger
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"%s/pkg/config"
+)
+
+// NewLogger creates a new logger writing structured entries for Cloud Logging.
+// Cloud Run collects stdout, so the LOG_FILE of cfg is only written outside of
+// it, appended to and rotated (see rotatingFile).
+func NewLogger(cfg *config.Config) (*zerolog.Logger, error) {
+	var out io.Writer = os.Stdout
+	if os.Getenv("K_SERVICE") == "" && cfg.LogFile != "" {
+		file, err := rotatingFile(cfg)
+		if err != nil {
+			return nil, err
+		}
+		out = zerolog.MultiLevelWriter(os.Stdout, file)
+	}
+
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+	zerolog.TimestampFieldName = "time"
+	zerolog.MessageFieldName = "message"
+	zerolog.LevelFieldName = "severity"
+	zerolog.LevelFieldMarshalFunc = severity
+
+	logger := zerolog.New(out).With().Timestamp().Logger()
+	log.Logger = logger
+	return &logger, nil
+}
+
+// severity maps zerolog levels to Cloud Logging's LogSeverity names
+func severity(l zerolog.Level) string {
+	switch l {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return "DEBUG"
+	case zerolog.InfoLevel:
+		return "INFO"
+	case zerolog.WarnLevel:
+		return "WARNING"
+	case zerolog.ErrorLevel:
+		return "ERROR"
+	case zerolog.FatalLevel:
+		return "CRITICAL"
+	case zerolog.PanicLevel:
+		return "ALERT"
+	default:
+		return "DEFAULT"
+	}
+}
+
+// SetLevel changes the global log level (debug, info, warn, error, ...)
+func SetLevel(level string) error {
+	lvl, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(lvl)
+	return nil
+}
+
+// rotatingFile opens the LOG_FILE of cfg, rotated once it reaches LOG_MAX_SIZE_MB
+// into compressed backups, of which LOG_MAX_BACKUPS are kept for LOG_MAX_AGE_DAYS
+func rotatingFile(cfg *config.Config) (io.Writer, error) {
+	file := &lumberjack.Logger{
+		Filename:   cfg.LogFile,
+		MaxSize:    cfg.LogMaxSizeMB,
+		MaxBackups: cfg.LogMaxBackups,
+		MaxAge:     cfg.LogMaxAgeDays,
+		Compress:   true,
+	}
+	// lumberjack opens the file on the first write: fail now rather than then
+	if _, err := file.Write(nil); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+`, projectName)
+}
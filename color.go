@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SGR parameters of the styles gogo's output uses
+const (
+	styleBold   = "1"
+	styleRed    = "31"
+	styleGreen  = "32"
+	styleYellow = "33"
+	styleCyan   = "36"
+)
+
+// Value of --color: always, never or auto, coloring the output written to a
+// terminal unless NO_COLOR is set (https://no-color.org) or TERM is dumb
+var colorMode = "auto"
+
+// Removes --color=<mode> from args, which may be given to any command like
+// --debug, and sets colorMode. --color alone means always.
+func setupColor(args []string) []string {
+	var rest []string
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if name != "--color" && name != "-color" {
+			rest = append(rest, arg)
+			continue
+		}
+		colorMode = "always"
+		if hasValue {
+			colorMode = value
+		}
+	}
+	switch colorMode {
+	case "always", "never", "auto":
+	default:
+		fatalf(exitUsage, "Unsupported --color value %q (supported: always, never, auto)", colorMode)
+	}
+	return rest
+}
+
+// Reports whether the output written to f is colored
+func colorEnabled(f *os.File) bool {
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Returns s in style when the output written to f is colored, the trailing
+// newline outside the escape sequences
+func colorize(f *os.File, style, s string) string {
+	if !colorEnabled(f) {
+		return s
+	}
+	text, newline := strings.CutSuffix(s, "\n")
+	s = "\x1b[" + style + "m" + text + "\x1b[0m"
+	if newline {
+		s += "\n"
+	}
+	return s
+}
+
+// Prints the message format, translated like printf, in style
+func printStyled(style, format string, args ...any) {
+	fmt.Print(colorize(os.Stdout, style, fmt.Sprintf(tr(format), args...)))
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parses the comma-separated --owner value into CODEOWNERS handles (@user, @org/team or an email)
+func parseOwners(value string) []string {
+	var owners []string
+	for _, owner := range strings.Split(value, ",") {
+		owner = strings.TrimSpace(owner)
+		if owner == "" {
+			continue
+		}
+		if !strings.HasPrefix(owner, "@") && !strings.Contains(owner, "@") {
+			owner = "@" + owner
+		}
+		if strings.ContainsAny(owner, " \t") {
+			fatalf(exitUsage, "Invalid --owner handle %q", owner)
+		}
+		owners = append(owners, owner)
+	}
+	return owners
+}
+
+// Returns the content for .github/CODEOWNERS
+func codeownersContent(opts options) string {
+	all := strings.Join(opts.owners, " ")
+	var b strings.Builder
+	b.WriteString("# Owners are requested for review on the pull requests touching their files.\n")
+	b.WriteString("# Later matches take precedence; add narrower paths below the default.\n\n")
+	fmt.Fprintf(&b, "* %s\n\n", all)
+	b.WriteString("# Changes that are hard to roll back\n")
+	fmt.Fprintf(&b, "/migrations/ %s\n", all)
+	fmt.Fprintf(&b, "/configs/ %s\n", all)
+	fmt.Fprintf(&b, "/.github/ %s\n", all)
+	if opts.iac != "" {
+		fmt.Fprintf(&b, "/infra/ %s\n", all)
+	}
+	if opts.changelog != "" {
+		b.WriteString("\n# Hooks run on every contributor's machine\n")
+		fmt.Fprintf(&b, "/.githooks/ %s\n", all)
+	}
+	return b.String()
+}
+
+// Returns the content for .github/ISSUE_TEMPLATE/bug_report.yml
+func bugReportTemplateContent() string {
+	return `name: Bug report
+description: Something does not work as expected
+labels: [bug]
+body:
+  - type: textarea
+    id: what-happened
+    attributes:
+      label: What happened?
+      description: What did you do, what did you expect and what happened instead?
+    validations:
+      required: true
+  - type: textarea
+    id: reproduce
+    attributes:
+      label: Steps to reproduce
+      description: Requests, payloads or commands reproducing the problem.
+      render: shell
+  - type: input
+    id: version
+    attributes:
+      label: Version
+      description: Output of the binary's version log line, or the commit.
+    validations:
+      required: true
+  - type: dropdown
+    id: environment
+    attributes:
+      label: Environment
+      options: [dev, staging, prod]
+  - type: textarea
+    id: logs
+    attributes:
+      label: Relevant logs
+      description: Remove secrets and personal data first.
+      render: json
+`
+}
+
+// Returns the content for .github/ISSUE_TEMPLATE/feature_request.yml
+func featureRequestTemplateContent() string {
+	return `name: Feature request
+description: Suggest a change or a new capability
+labels: [enhancement]
+body:
+  - type: textarea
+    id: problem
+    attributes:
+      label: Problem
+      description: What are you trying to do, and why is it hard today?
+    validations:
+      required: true
+  - type: textarea
+    id: proposal
+    attributes:
+      label: Proposal
+      description: The change you would like, e.g. new endpoints, settings or events.
+    validations:
+      required: true
+  - type: textarea
+    id: alternatives
+    attributes:
+      label: Alternatives considered
+`
+}
+
+// Returns the content for .github/ISSUE_TEMPLATE/config.yml
+func issueTemplateConfigContent() string {
+	return `blank_issues_enabled: false
+`
+}
+
+// Returns the content for .github/pull_request_template.md, with a review
+// checklist following the layout of the generated project
+func pullRequestTemplateContent(opts options) string {
+	var checklist []string
+	switch opts.archetype {
+	case "nats":
+		checklist = append(checklist,
+			"Event handlers in `internal/handlers` are idempotent: JetStream redelivers unacknowledged messages",
+			"Subject, stream or consumer changes in `internal/messaging` are compatible with running consumers",
+		)
+	case "batch":
+		checklist = append(checklist,
+			"Changes to `internal/jobs` keep the transform deterministic, so resumed runs produce the same output",
+			"Checkpoint format changes in `internal/etl` still read existing checkpoints, or the run notes say `--from-start`",
+		)
+	default:
+		checklist = append(checklist,
+			"Handlers only decode, validate and respond; business rules live in `internal/services`",
+			"SQL stays in `internal/repository`, with parameterized queries",
+			"Handler golden files under `tests/golden/` were regenerated with `-update` and reviewed",
+		)
+	}
+	checklist = append(checklist,
+		"Migrations have a working down migration and are safe to run while the previous version serves traffic",
+		"New settings were added to every `configs/*` file, with secrets left empty outside dev",
+		"`make lint` and `make test` pass",
+	)
+	if opts.changelog != "" {
+		checklist = append(checklist, "The title is a conventional commit (`feat: ...`, `fix: ...`), as it ends up in the changelog")
+	}
+
+	var b strings.Builder
+	b.WriteString("## What and why\n\n<!-- What does this change do, and what problem does it solve? Link the issue. -->\n\n")
+	b.WriteString("## How it was tested\n\n<!-- Commands run, requests sent, or screenshots. -->\n\n")
+	b.WriteString("## Review checklist\n\n")
+	for _, item := range checklist {
+		fmt.Fprintf(&b, "- [ ] %s\n", item)
+	}
+	return b.String()
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configSetting is a single key/value pair of the generated config file
+type configSetting struct {
+	key   string
+	value string
+}
+
+// configField is an optional setting that a generated component adds to the
+// Config struct and to every config file
+type configField struct {
+	goName  string
+	goType  string
+	key     string
+	value   string // default value, also written to the config files
+	secret  bool
+	comment string // doc comment of the group, set on its first field
+	flag    string // command line flag, when not the key in kebab case
+	usage   string // help of the flag
+}
+
+// Settings of the rotation of the log file by lumberjack, added unless the logger
+// is written with log/slog, which does not rotate it
+var logRotationConfigFields = []configField{
+	{goName: "LogMaxSizeMB", goType: "int", key: "LOG_MAX_SIZE_MB", value: "100", comment: "Rotation of LogFile, rotated once it reaches LogMaxSizeMB: at most LogMaxBackups compressed files are kept, for LogMaxAgeDays. An empty LogFile logs to stdout only."},
+	{goName: "LogMaxBackups", goType: "int", key: "LOG_MAX_BACKUPS", value: "5"},
+	{goName: "LogMaxAgeDays", goType: "int", key: "LOG_MAX_AGE_DAYS", value: "28"},
+}
+
+// Environments that get their own config file under configs/
+var configEnvironments = []string{"dev", "staging", "prod"}
+
+// Per-environment overrides of configSettings and component settings. Secrets are
+// left empty so they have to be provided through environment variables: those of
+// dev are generated for each project into the .env file the Makefile and docker
+// compose read (see devSecrets). The containers of staging and prod log to stdout
+// only.
+var configOverrides = map[string]map[string]string{
+	"dev": {
+		"LOG_LEVEL":    "debug",
+		"CONFIG_WATCH": "true",
+	},
+	"staging": {
+		"LOG_FILE":                 "",
+		"DB_HOST":                  "postgres",
+		"DB_PASSWORD":              "",
+		"DB_REPLICA_HOST":          "postgres-replica",
+		"DB_REPLICA_PORT":          "5432",
+		"NATS_URL":                 "nats://nats:4222",
+		"TEMPORAL_HOST_PORT":       "temporal:7233",
+		"REDIS_ADDR":               "redis:6379",
+		"PYROSCOPE_SERVER_ADDRESS": "http://pyroscope:4040",
+	},
+	"prod": {
+		"LOG_LEVEL":                "warn",
+		"ACCESS_LOG_SAMPLE_RATE":   "0.1",
+		"LOG_FILE":                 "",
+		"DB_HOST":                  "postgres",
+		"DB_PASSWORD":              "",
+		"DB_REPLICA_HOST":          "postgres-replica",
+		"DB_REPLICA_PORT":          "5432",
+		"NATS_URL":                 "nats://nats:4222",
+		"TEMPORAL_HOST_PORT":       "temporal:7233",
+		"REDIS_ADDR":               "redis:6379",
+		"PYROSCOPE_SERVER_ADDRESS": "http://pyroscope:4040",
+	},
+}
+
+// Base settings written to every generated config file, in order
+var configSettings = []configSetting{
+	{"APP_NAME", "myapi"},
+	{"SERVER_PORT", "8080"},
+	{"LOG_FILE", "logs/myapi.log"},
+	{"LOG_LEVEL", "info"},
+	{"ACCESS_LOG_SAMPLE_RATE", "1"},
+	{"ACCESS_LOG_SLOW_THRESHOLD", "500ms"},
+	{"CONFIG_WATCH", "false"},
+	{"DB_USER", "root"},
+	{"DB_PASSWORD", ""},
+	{"DB_HOST", "localhost"},
+	{"DB_PORT", "5432"},
+	{"DB_NAME", "mydatabase"},
+	{"EXAMPLE_API_URL", "https://jsonplaceholder.typicode.com"},
+}
+
+// Returns the path of the generated config file for the given environment and format
+func configFileName(env, format string) string {
+	return filepath.Join("configs", env+"."+format)
+}
+
+// Returns the settings for the given environment, with its overrides applied
+func environmentSettings(env string, extra []configField) []configSetting {
+	settings := make([]configSetting, 0, len(configSettings)+len(extra)+1)
+	settings = append(settings, configSetting{"APP_ENV", env})
+	settings = append(settings, configSettings...)
+	for _, f := range extra {
+		settings = append(settings, configSetting{f.key, f.value})
+	}
+	for i, s := range settings {
+		if v, ok := configOverrides[env][s.key]; ok {
+			settings[i].value = v
+		}
+	}
+	return settings
+}
+
+// Returns the content for the config file of the given environment in the given format
+func configFileContent(env, format string, extra []configField) string {
+	settings := environmentSettings(env, extra)
+
+	var b strings.Builder
+	switch format {
+	case "env":
+		for _, s := range settings {
+			fmt.Fprintf(&b, "%s=%s\n", s.key, s.value)
+		}
+	case "yaml":
+		for _, s := range settings {
+			fmt.Fprintf(&b, "%s: %s\n", strings.ToLower(s.key), configValue(s.value))
+		}
+	case "toml":
+		for _, s := range settings {
+			fmt.Fprintf(&b, "%s = %s\n", strings.ToLower(s.key), configValue(s.value))
+		}
+	case "json":
+		b.WriteString("{\n")
+		for i, s := range settings {
+			sep := ","
+			if i == len(settings)-1 {
+				sep = ""
+			}
+			fmt.Fprintf(&b, "  %q: %s%s\n", strings.ToLower(s.key), configValue(s.value), sep)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// Returns value as a literal, quoting everything but numbers and booleans
+func configValue(value string) string {
+	if _, err := strconv.Atoi(value); err == nil {
+		return value
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// Returns the content for pkg/config/config.go, with the extra fields added to Config
+// portEnv names the variable the hosting platform sets to the port to listen on
+// (e.g. PORT on Cloud Run); SERVER_PORT falls back to it when non-empty.
+// With stdlib, the settings are read without viper (see stdlibConfigGoTemplate).
+func configGoContent(format string, extra []configField, portEnv string, stdlib bool) string {
+	var fields strings.Builder
+	for _, f := range extra {
+		if f.comment != "" {
+			fmt.Fprintf(&fields, "\n\t// %s\n", f.comment)
+		}
+		tag := fmt.Sprintf("mapstructure:%q", f.key)
+		if f.secret {
+			tag += ` secret:"true"`
+		} else if f.value != "" {
+			tag += fmt.Sprintf(" default:%q", f.value)
+		}
+		if f.flag != "" {
+			tag += fmt.Sprintf(" flag:%q", f.flag)
+		}
+		if f.usage != "" {
+			tag += fmt.Sprintf(" usage:%q", f.usage)
+		}
+		fmt.Fprintf(&fields, "\t%s %s `%s`\n", f.goName, f.goType, tag)
+	}
+
+	template := configGoTemplate(format)
+	if stdlib {
+		template = stdlibConfigGoTemplate()
+	}
+	content := strings.Replace(template, "\n\t// WatchConfig", fields.String()+"\n\t// WatchConfig", 1)
+	if portEnv != "" {
+		content = strings.Replace(content, `mapstructure:"SERVER_PORT"`, fmt.Sprintf(`mapstructure:"SERVER_PORT" env:%q`, portEnv), 1)
+		content = strings.Replace(content, "\t\tviper.BindEnv(key)\n", `		// The env tag names a fallback variable, e.g. the port set by the platform
+		if alias, ok := field.Tag.Lookup("env"); ok {
+			viper.BindEnv(key, key, alias)
+		} else {
+			viper.BindEnv(key)
+		}
+`, 1)
+	}
+	return formatGo(content)
+}
+
+func configGoTemplate(format string) string {
+	return `package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+` + configTypesGo(format) + `// LoadConfig reads, applies defaults to and validates the application configuration.
+// The config file is selected by APP_ENV (default dev).
+// Every setting is resolved in the following order of precedence:
+//  1. its command line flag (e.g. --server-port=9090)
+//  2. an environment variable with the setting's name (e.g. SERVER_PORT=9090)
+//  3. the config file of the current environment
+//  4. the default tag on the Config field
+//
+// LoadConfig parses the command line with flag.Parse, after defining the flags
+// of the settings: main defines its other flags before calling it. --help lists
+// them all.
+func LoadConfig() *Config {
+	registerKeys()
+	registerFlags(flag.CommandLine)
+	flag.Parse()
+	applyFlags(flag.CommandLine)
+
+	configFile := configFilePath()
+	viper.SetConfigFile(configFile)
+	viper.AutomaticEnv()
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatalf("Error loading config file %s: %v", configFile, err)
+	}
+
+	var cfg Config
+	if err := viper.Unmarshal(&cfg); err != nil {
+		log.Fatalf("Error unmarshalling config: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	return &cfg
+}
+
+` + configChecksGo() + `// configFilePath returns the config file for the environment named by APP_ENV,
+// from its flag or environment variable
+func configFilePath() string {
+	env := viper.GetString("APP_ENV")
+	if env == "" {
+		env = defaultEnv
+	}
+	return filepath.Join(configDir, env+"."+configExt)
+}
+
+// registerKeys binds every Config field to its environment variable and default value,
+// so environment variables apply even when the setting is missing from the config file
+func registerKeys() {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		viper.BindEnv(key)
+		if def, ok := field.Tag.Lookup("default"); ok {
+			viper.SetDefault(key, def)
+		}
+	}
+}
+
+` + configFlagsGo() + `// applyFlags overrides the settings whose flag is set on the command line
+func applyFlags(fs *flag.FlagSet) {
+	fs.Visit(func(f *flag.Flag) {
+		if s, ok := f.Value.(*settingFlag); ok {
+			viper.Set(s.key, s.value)
+		}
+	})
+}
+
+` + configSubscribeGo() + `// Watch starts watching the config file and notifies subscribers when it changes.
+// Reloaded configurations that fail validation are logged and ignored.
+func Watch() {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		var cfg Config
+		if err := viper.Unmarshal(&cfg); err != nil {
+			log.Printf("Error reloading config from %s: %v", e.Name, err)
+			return
+		}
+		if err := cfg.Validate(); err != nil {
+			log.Printf("Ignoring invalid config from %s:\n%v", e.Name, err)
+			return
+		}
+
+		mu.RLock()
+		subs := append([]func(*Config){}, subscribers...)
+		mu.RUnlock()
+
+		for _, fn := range subs {
+			fn(&cfg)
+		}
+	})
+	viper.WatchConfig()
+}
+`
+}
+
+// Returns the Config struct of pkg/config/config.go and the declarations next to
+// it, shared by the viper and standard library versions
+func configTypesGo(format string) string {
+	return `// Config holds the configuration for the application.
+// The default tag provides the value used when a setting is missing,
+// and settings tagged secret are redacted when the config is printed.
+// Each setting also has a command line flag, named after its key in kebab case
+// (SERVER_PORT: --server-port) unless its flag tag names it, with its usage tag
+// as help.
+type Config struct {
+	AppEnv     string ` + "`" + `mapstructure:"APP_ENV" default:"dev"` + "`" + `
+	AppName    string ` + "`" + `mapstructure:"APP_NAME" default:"myapi"` + "`" + `
+	ServerPort int    ` + "`" + `mapstructure:"SERVER_PORT" default:"8080"` + "`" + `
+	LogFile    string ` + "`" + `mapstructure:"LOG_FILE" default:"logs/app.log"` + "`" + `
+	LogLevel   string ` + "`" + `mapstructure:"LOG_LEVEL" default:"info"` + "`" + `
+
+	// Access log sampling: regular requests are logged with AccessLogSampleRate probability,
+	// errors and requests slower than AccessLogSlowThreshold always are
+	AccessLogSampleRate    float64       ` + "`" + `mapstructure:"ACCESS_LOG_SAMPLE_RATE" default:"1"` + "`" + `
+	AccessLogSlowThreshold time.Duration ` + "`" + `mapstructure:"ACCESS_LOG_SLOW_THRESHOLD" default:"500ms"` + "`" + `
+
+	DBUser     string ` + "`" + `mapstructure:"DB_USER"` + "`" + `
+	DBPassword string ` + "`" + `mapstructure:"DB_PASSWORD" secret:"true"` + "`" + `
+	DBHost     string ` + "`" + `mapstructure:"DB_HOST" default:"localhost"` + "`" + `
+	DBPort     int    ` + "`" + `mapstructure:"DB_PORT" default:"5432"` + "`" + `
+	DBName     string ` + "`" + `mapstructure:"DB_NAME"` + "`" + `
+
+	ExampleAPIURL string ` + "`" + `mapstructure:"EXAMPLE_API_URL" default:"https://jsonplaceholder.typicode.com"` + "`" + `
+
+	// WatchConfig enables reloading the config file at runtime
+	WatchConfig bool ` + "`" + `mapstructure:"CONFIG_WATCH" default:"false"` + "`" + `
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers []func(*Config)
+)
+
+// Config files live in configDir, one per environment (configs/dev.` + format + `, configs/prod.` + format + `, ...)
+const (
+	configDir  = "configs"
+	configExt  = "` + format + `"
+	defaultEnv = "dev"
+)
+
+`
+}
+
+// Returns the Validate and Print methods of Config
+func configChecksGo() string {
+	return `// Validate checks that required settings are present and values are within range
+func (c *Config) Validate() error {
+	var errs []error
+
+	required := []struct{ key, value string }{
+		{"APP_NAME", c.AppName},
+		{"DB_USER", c.DBUser},
+		{"DB_HOST", c.DBHost},
+		{"DB_NAME", c.DBName},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", r.key))
+		}
+	}
+
+	if c.ServerPort < 1 || c.ServerPort > 65535 {
+		errs = append(errs, fmt.Errorf("SERVER_PORT must be between 1 and 65535, got %d", c.ServerPort))
+	}
+	if c.DBPort < 1 || c.DBPort > 65535 {
+		errs = append(errs, fmt.Errorf("DB_PORT must be between 1 and 65535, got %d", c.DBPort))
+	}
+
+	if c.AccessLogSampleRate < 0 || c.AccessLogSampleRate > 1 {
+		errs = append(errs, fmt.Errorf("ACCESS_LOG_SAMPLE_RATE must be between 0 and 1, got %v", c.AccessLogSampleRate))
+	}
+
+	switch strings.ToLower(c.LogLevel) {
+	case "trace", "debug", "info", "warn", "error", "fatal", "panic", "disabled":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL %q is not a valid level", c.LogLevel))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Print writes the effective configuration as a table, with secrets redacted
+func (c *Config) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SETTING\tVALUE")
+
+	v := reflect.ValueOf(*c)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := fmt.Sprint(v.Field(i).Interface())
+		if field.Tag.Get("secret") == "true" && value != "" {
+			value = "********"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", field.Tag.Get("mapstructure"), value)
+	}
+
+	tw.Flush()
+}
+
+`
+}
+
+// Returns the command line flags of the settings
+func configFlagsGo() string {
+	return `// settingFlag is the command line flag of a setting. Its value is kept as given,
+// to be decoded like an environment variable.
+type settingFlag struct {
+	key    string
+	value  string
+	isBool bool
+}
+
+func (f *settingFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *settingFlag) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be set with a bare flag, e.g. --config-watch
+func (f *settingFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// registerFlags defines the flag of every Config field on fs, and a usage message
+// listing them with the order of precedence of the settings
+func registerFlags(fs *flag.FlagSet) {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		name := field.Tag.Get("flag")
+		if name == "" {
+			name = strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		}
+		usage := field.Tag.Get("usage")
+		if usage != "" {
+			usage += ", "
+		}
+		// The back quotes name the value in the help, e.g. -server-port SERVER_PORT
+		if field.Type.Kind() == reflect.Bool {
+			usage += "env " + key
+		} else {
+			usage += "env ` + "`" + `" + key + "` + "`" + `"
+		}
+		if field.Tag.Get("secret") == "true" {
+			usage += " (secret: prefer the environment variable, flags show in the process list)"
+		}
+		fs.Var(&settingFlag{key: key, value: field.Tag.Get("default"), isBool: field.Type.Kind() == reflect.Bool}, name, usage)
+	}
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s:\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(fs.Output(), "Every setting is read from, in order of precedence: its flag, its environment\n")
+		fmt.Fprintf(fs.Output(), "variable, the config file %s/<APP_ENV>.%s, and its default.\n\n", configDir, configExt)
+		fs.PrintDefaults()
+	}
+}
+
+`
+}
+
+// Returns Subscribe, registering the functions notified of the reloads
+func configSubscribeGo() string {
+	return `// Subscribe registers fn to be called with the new configuration after every reload
+func Subscribe(fn func(*Config)) {
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+`
+}
@@ -0,0 +1,111 @@
+package main
+
+import "fmt"
+
+// Returns the Makefile targets for running Pact contract tests
+func pactMakefileContent() string {
+	return `
+.PHONY: pact-install contract-test
+
+# Downloads the Pact FFI library the contract tests link against
+pact-install:
+	go run github.com/pact-foundation/pact-go/v2 install
+
+# Verifies the provider against the contracts in tests/contract/pacts, or against
+# the broker when PACT_BROKER_URL is set
+contract-test:
+	go test -tags=contract -count=1 ./tests/contract/...
+`
+}
+
+// Returns the content for tests/contract/provider_test.go
+func pactProviderTestGoContent(projectName string) string {
+	return fmt.Sprintf(`//go:build contract
+
+package contract
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/provider"
+	"github.com/rs/zerolog"
+
+	"%[1]s/internal/handlers"
+	"%[1]s/pkg/config"
+)
+
+// providerName must match the provider name used in the consumers' contracts
+const providerName = "%[1]s"
+
+func TestProvider(t *testing.T) {
+	logger := zerolog.Nop()
+	server := httptest.NewServer(handlers.NewRouter(&logger, &config.Config{AccessLogSampleRate: 1}))
+	defer server.Close()
+
+	req := provider.VerifyRequest{
+		Provider:        providerName,
+		ProviderBaseURL: server.URL,
+		StateHandlers: models.StateHandlers{
+			// Set up the data each provider state expects, e.g. by seeding a test database
+			"the service is running": func(setup bool, state models.ProviderState) (models.ProviderStateResponse, error) {
+				return nil, nil
+			},
+		},
+	}
+
+	if brokerURL := os.Getenv("PACT_BROKER_URL"); brokerURL != "" {
+		req.BrokerURL = brokerURL
+		req.BrokerToken = os.Getenv("PACT_BROKER_TOKEN")
+		req.ProviderVersion = os.Getenv("PACT_PROVIDER_VERSION")
+		req.PublishVerificationResults = req.ProviderVersion != ""
+	} else {
+		req.PactDirs = []string{filepath.Join(".", "pacts")}
+	}
+
+	if err := provider.NewVerifier().VerifyProvider(t, req); err != nil {
+		t.Fatal(err)
+	}
+}
+`, projectName)
+}
+
+// Returns the content for tests/contract/pacts/example-consumer-<name>.json
+func pactExampleContractContent(projectName string) string {
+	return fmt.Sprintf(`{
+  "consumer": {
+    "name": "example-consumer"
+  },
+  "provider": {
+    "name": "%s"
+  },
+  "interactions": [
+    {
+      "description": "a health check",
+      "providerState": "the service is running",
+      "request": {
+        "method": "GET",
+        "path": "/healthz"
+      },
+      "response": {
+        "status": 200,
+        "headers": {
+          "Content-Type": "application/json"
+        },
+        "body": {
+          "status": "ok"
+        }
+      }
+    }
+  ],
+  "metadata": {
+    "pactSpecification": {
+      "version": "2.0.0"
+    }
+  }
+}
+`, projectName)
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Adds the CQRS component: command and query buses plus an example task write path
+// (command → domain → repository) and read path (query → read model)
+func addCQRS(p project, args []string) ([]generatedFile, []string) {
+	files := p.migration("create_tasks", tasksMigrationUpContent(), tasksMigrationDownContent())
+	files = append(files,
+		generatedFile{path: filepath.Join("internal", "cqrs", "bus.go"), content: cqrsBusGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "domain", "task.go"), content: taskDomainGoContent()},
+		generatedFile{path: filepath.Join("internal", "repository", "errors.go"), content: repositoryErrorsGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "repository", "task_store.go"), content: taskStoreGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "commands", "tasks.go"), content: taskCommandsGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "queries", "tasks.go"), content: taskQueriesGoContent(p.modulePath)},
+	)
+
+	return files, []string{
+		"Run the migration: make migrate DB_URL=postgres://...",
+		"Create the buses: commandBus, queryBus := cqrs.NewCommandBus(), cqrs.NewQueryBus()",
+		"Register the handlers: commands.RegisterTaskHandlers(commandBus, repository.NewTaskStore(db)) and queries.RegisterTaskHandlers(queryBus, db)",
+		"Dispatch from handlers: commandBus.Dispatch(ctx, commands.CreateTask{Title: ...}) and cqrs.Ask[[]queries.TaskSummary](ctx, queryBus, queries.ListOpenTasks{Limit: 20})",
+	}
+}
+
+// Returns the content for the tasks up migration
+func tasksMigrationUpContent() string {
+	return `CREATE TABLE IF NOT EXISTS tasks (
+    id           BIGSERIAL PRIMARY KEY,
+    title        TEXT NOT NULL,
+    completed_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS tasks_open_idx ON tasks (created_at) WHERE completed_at IS NULL;
+`
+}
+
+// Returns the content for the tasks down migration
+func tasksMigrationDownContent() string {
+	return `DROP TABLE IF EXISTS tasks;
+`
+}
+
+// Returns the content for internal/cqrs/bus.go
+func cqrsBusGoContent() string {
+	return `package cqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrNoHandler is returned when a command or query has no registered handler
+var ErrNoHandler = errors.New("cqrs: no handler registered")
+
+// CommandHandler changes state in response to a command of type C
+type CommandHandler[C any] func(ctx context.Context, cmd C) error
+
+// QueryHandler answers a query of type Q with a result of type R, without changing state
+type QueryHandler[Q, R any] func(ctx context.Context, q Q) (R, error)
+
+// CommandBus routes each command to the single handler registered for its type
+type CommandBus struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type]func(context.Context, any) error
+}
+
+// NewCommandBus creates an empty CommandBus
+func NewCommandBus() *CommandBus {
+	return &CommandBus{handlers: make(map[reflect.Type]func(context.Context, any) error)}
+}
+
+// RegisterCommand registers the handler for commands of type C. It panics if one is
+// already registered, since a command must have exactly one handler.
+func RegisterCommand[C any](bus *CommandBus, h CommandHandler[C]) {
+	t := reflect.TypeFor[C]()
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if _, ok := bus.handlers[t]; ok {
+		panic(fmt.Sprintf("cqrs: command %s already has a handler", t))
+	}
+	bus.handlers[t] = func(ctx context.Context, cmd any) error {
+		return h(ctx, cmd.(C))
+	}
+}
+
+// Dispatch runs the handler registered for the type of cmd
+func (b *CommandBus) Dispatch(ctx context.Context, cmd any) error {
+	b.mu.RLock()
+	h, ok := b.handlers[reflect.TypeOf(cmd)]
+	b.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w for command %T", ErrNoHandler, cmd)
+	}
+	return h(ctx, cmd)
+}
+
+// QueryBus routes each query to the single handler registered for its type
+type QueryBus struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type]func(context.Context, any) (any, error)
+}
+
+// NewQueryBus creates an empty QueryBus
+func NewQueryBus() *QueryBus {
+	return &QueryBus{handlers: make(map[reflect.Type]func(context.Context, any) (any, error))}
+}
+
+// RegisterQuery registers the handler for queries of type Q. It panics if one is
+// already registered.
+func RegisterQuery[Q, R any](bus *QueryBus, h QueryHandler[Q, R]) {
+	t := reflect.TypeFor[Q]()
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if _, ok := bus.handlers[t]; ok {
+		panic(fmt.Sprintf("cqrs: query %s already has a handler", t))
+	}
+	bus.handlers[t] = func(ctx context.Context, q any) (any, error) {
+		return h(ctx, q.(Q))
+	}
+}
+
+// Ask runs the handler registered for the type of q and returns its result as R
+func Ask[R any](ctx context.Context, bus *QueryBus, q any) (R, error) {
+	var zero R
+	bus.mu.RLock()
+	h, ok := bus.handlers[reflect.TypeOf(q)]
+	bus.mu.RUnlock()
+	if !ok {
+		return zero, fmt.Errorf("%w for query %T", ErrNoHandler, q)
+	}
+
+	res, err := h(ctx, q)
+	if err != nil {
+		return zero, err
+	}
+	r, ok := res.(R)
+	if !ok {
+		return zero, fmt.Errorf("cqrs: query %T returns %T, not %T", q, res, zero)
+	}
+	return r, nil
+}
+`
+}
+
+// Returns the content for internal/domain/task.go
+func taskDomainGoContent() string {
+	return `package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrEmptyTitle is returned when a task is created without a title
+	ErrEmptyTitle = errors.New("task title is required")
+	// ErrTaskCompleted is returned when completing a task twice
+	ErrTaskCompleted = errors.New("task is already completed")
+)
+
+// Task is the write-side model of a task. It enforces its invariants; the
+// repository only persists it.
+type Task struct {
+	ID          int64
+	Title       string
+	CompletedAt *time.Time
+}
+
+// NewTask creates an open task
+func NewTask(title string) (*Task, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, ErrEmptyTitle
+	}
+	return &Task{Title: title}, nil
+}
+
+// Complete marks the task as done at the given time
+func (t *Task) Complete(at time.Time) error {
+	if t.CompletedAt != nil {
+		return ErrTaskCompleted
+	}
+	t.CompletedAt = &at
+	return nil
+}
+`
+}
+
+// Returns the content for internal/repository/task_store.go
+func taskStoreGoContent(modulePath string) string {
+	return fmt.Sprintf(`package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"%s/internal/domain"
+)
+
+// TaskStore persists domain.Task aggregates for the command side
+type TaskStore struct {
+	db *sql.DB
+}
+
+// NewTaskStore creates a new TaskStore
+func NewTaskStore(db *sql.DB) *TaskStore {
+	return &TaskStore{db: db}
+}
+
+// Get loads a task by ID
+func (s *TaskStore) Get(ctx context.Context, id int64) (*domain.Task, error) {
+	t := &domain.Task{}
+	err := s.db.QueryRowContext(ctx, "SELECT id, title, completed_at FROM tasks WHERE id = $1", id).
+		Scan(&t.ID, &t.Title, &t.CompletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return t, err
+}
+
+// Save inserts a new task or updates an existing one
+func (s *TaskStore) Save(ctx context.Context, t *domain.Task) error {
+	if t.ID == 0 {
+		return s.db.QueryRowContext(ctx, "INSERT INTO tasks (title, completed_at) VALUES ($1, $2) RETURNING id",
+			t.Title, t.CompletedAt).Scan(&t.ID)
+	}
+	_, err := s.db.ExecContext(ctx, "UPDATE tasks SET title = $1, completed_at = $2 WHERE id = $3",
+		t.Title, t.CompletedAt, t.ID)
+	return err
+}
+`, modulePath)
+}
+
+// Returns the content for internal/commands/tasks.go
+func taskCommandsGoContent(modulePath string) string {
+	return fmt.Sprintf(`package commands
+
+import (
+	"context"
+	"time"
+
+	"%[1]s/internal/cqrs"
+	"%[1]s/internal/domain"
+	"%[1]s/internal/repository"
+)
+
+// CreateTask opens a new task. ID is set once the command has been handled.
+type CreateTask struct {
+	Title string
+	ID    *int64
+}
+
+// CompleteTask marks a task as done
+type CompleteTask struct {
+	ID int64
+}
+
+// RegisterTaskHandlers registers the task command handlers on bus
+func RegisterTaskHandlers(bus *cqrs.CommandBus, store *repository.TaskStore) {
+	cqrs.RegisterCommand(bus, func(ctx context.Context, cmd CreateTask) error {
+		task, err := domain.NewTask(cmd.Title)
+		if err != nil {
+			return err
+		}
+		if err := store.Save(ctx, task); err != nil {
+			return err
+		}
+		if cmd.ID != nil {
+			*cmd.ID = task.ID
+		}
+		return nil
+	})
+
+	cqrs.RegisterCommand(bus, func(ctx context.Context, cmd CompleteTask) error {
+		task, err := store.Get(ctx, cmd.ID)
+		if err != nil {
+			return err
+		}
+		if err := task.Complete(time.Now()); err != nil {
+			return err
+		}
+		return store.Save(ctx, task)
+	})
+}
+`, modulePath)
+}
+
+// Returns the content for internal/queries/tasks.go
+func taskQueriesGoContent(modulePath string) string {
+	return fmt.Sprintf(`package queries
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"%s/internal/cqrs"
+)
+
+// TaskSummary is the read model of an open task, shaped for listing
+type TaskSummary struct {
+	ID        int64     `+"`"+`json:"id"`+"`"+`
+	Title     string    `+"`"+`json:"title"`+"`"+`
+	CreatedAt time.Time `+"`"+`json:"created_at"`+"`"+`
+}
+
+// ListOpenTasks returns the oldest open tasks first
+type ListOpenTasks struct {
+	Limit int
+}
+
+// RegisterTaskHandlers registers the task query handlers on bus. Queries read
+// straight from the database and never go through the domain model.
+func RegisterTaskHandlers(bus *cqrs.QueryBus, db *sql.DB) {
+	cqrs.RegisterQuery(bus, func(ctx context.Context, q ListOpenTasks) ([]TaskSummary, error) {
+		rows, err := db.QueryContext(ctx,
+			"SELECT id, title, created_at FROM tasks WHERE completed_at IS NULL ORDER BY created_at LIMIT $1", q.Limit)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		tasks := []TaskSummary{}
+		for rows.Next() {
+			var t TaskSummary
+			if err := rows.Scan(&t.ID, &t.Title, &t.CreatedAt); err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, t)
+		}
+		return tasks, rows.Err()
+	})
+}
+`, modulePath)
+}
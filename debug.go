@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// File the --debug trace is written to, in the current directory
+const debugLogName = "gogo-debug.log"
+
+// Trace of gogo's operations, nil unless --debug is given
+var debugLog *log.Logger
+
+// When gogo started, for the durations in the trace
+var debugStart time.Time
+
+// Environment variables recorded at the start of the trace
+var debugEnv = []string{"GOPROXY", "GOPRIVATE", "GOFLAGS", "GOPATH", "GOGO_HOME", "CI"}
+
+// Removes --debug from args, which may be given to any command, and when it is
+// there starts the trace with a summary of the environment
+func setupDebug(args []string) []string {
+	var rest []string
+	enabled := false
+	for _, arg := range args {
+		if arg == "--debug" || arg == "-debug" {
+			enabled = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	if !enabled {
+		return rest
+	}
+
+	f, err := os.Create(debugLogName)
+	if err != nil {
+		fatalf(exitFailure, "Failed to create %s: %v", debugLogName, err)
+	}
+	debugLog = log.New(f, "", log.Ltime|log.Lmicroseconds)
+	debugStart = time.Now()
+	abs, _ := filepath.Abs(debugLogName)
+	fmt.Fprintf(os.Stderr, tr("Writing a debug trace to %s\n"), abs)
+
+	version := gogoVersion()
+	if version == "" {
+		version = "(devel)"
+	}
+	debugf("gogo %s, %s %s/%s", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	debugf("args: %q", rest)
+	wd, _ := os.Getwd()
+	debugf("working directory: %s", wd)
+	for _, name := range debugEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			debugf("env %s=%s", name, value)
+		}
+	}
+	for _, tool := range []string{"git", "go"} {
+		path, err := exec.LookPath(tool)
+		if err != nil {
+			path = "not found"
+		}
+		debugf("tool %s: %s", tool, path)
+	}
+	return rest
+}
+
+// Adds a line to the --debug trace
+func debugf(format string, args ...any) {
+	if debugLog != nil {
+		debugLog.Printf(format, args...)
+	}
+}
+
+// Ends the --debug trace with how long gogo ran and its exit code
+func debugExit(code int) {
+	debugf("exit %d after %s", code, time.Since(debugStart).Round(time.Millisecond))
+}
+
+// Runs cmd like its CombinedOutput method, recording it in the --debug trace
+func runCombined(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	traceCommand(cmd, start, out, err)
+	return out, err
+}
+
+// Runs cmd like its Output method, recording it in the --debug trace
+func runOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.Output()
+	traceCommand(cmd, start, nil, err)
+	return out, err
+}
+
+// Records a command run in the --debug trace: its arguments, directory, duration
+// and outcome, with the output of a failing command
+func traceCommand(cmd *exec.Cmd, start time.Time, out []byte, err error) {
+	if debugLog == nil {
+		return
+	}
+	dir := cmd.Dir
+	if dir == "" {
+		dir = "."
+	}
+	debugf("run %q in %s: %s", cmd.Args, dir, time.Since(start).Round(time.Millisecond))
+	if err != nil {
+		debugf("  failed: %v", err)
+		if output := strings.TrimSpace(string(out)); output != "" {
+			debugf("  output:\n%s", output)
+		}
+	}
+}
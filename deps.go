@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Go version of the go directive of the generated go.mod, the one of the Dockerfile
+const goVersion = "1.22"
+
+// Versions of the third-party modules the generated code imports, required by
+// the generated go.mod and fetched by the next steps of gogo add. --dep and the
+// deps of gogo's config override them.
+var dependencyVersions = map[string]string{
+	"github.com/aws/aws-lambda-go":                                 "v1.47.0",
+	"github.com/aws/aws-sdk-go-v2":                                 "v1.32.7",
+	"github.com/aws/aws-sdk-go-v2/config":                          "v1.28.7",
+	"github.com/aws/aws-sdk-go-v2/service/s3":                      "v1.71.1",
+	"github.com/bwmarrin/snowflake":                                "v0.3.0",
+	"github.com/fsnotify/fsnotify":                                 "v1.8.0",
+	"github.com/getsentry/sentry-go":                               "v0.30.0",
+	"github.com/google/uuid":                                       "v1.6.0",
+	"github.com/grafana/pyroscope-go":                              "v1.2.7",
+	"github.com/jackc/pgx/v5":                                      "v5.7.2",
+	"github.com/jung-kurt/gofpdf":                                  "v1.16.2",
+	"github.com/nats-io/nats.go":                                   "v1.42.0",
+	"github.com/oklog/ulid/v2":                                     "v2.1.0",
+	"github.com/pact-foundation/pact-go/v2":                        "v2.0.10",
+	"github.com/pkg/errors":                                        "v0.9.1",
+	"github.com/pquerna/otp":                                       "v1.4.0",
+	"github.com/redis/go-redis/v9":                                 "v9.7.0",
+	"github.com/rs/zerolog":                                        "v1.33.0",
+	"github.com/sony/gobreaker":                                    "v1.0.0",
+	"github.com/spf13/viper":                                       "v1.19.0",
+	"github.com/stripe/stripe-go/v76":                              "v76.25.0",
+	"github.com/testcontainers/testcontainers-go":                  "v0.40.0",
+	"github.com/testcontainers/testcontainers-go/modules/postgres": "v0.40.0",
+	"github.com/xuri/excelize/v2":                                  "v2.9.0",
+	"go.temporal.io/sdk":                                           "v1.31.0",
+	"golang.org/x/crypto":                                          "v0.31.0",
+	"gopkg.in/natefinch/lumberjack.v2":                             "v2.2.1",
+}
+
+// Module versions accepted by --dep: semantic versions, optionally prerelease
+// or pseudo-versions
+var moduleVersionPattern = regexp.MustCompile(`^v\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// Parses repeated --dep module@version flags
+type dependencyPins map[string]string
+
+func (d dependencyPins) String() string { return "" }
+
+func (d dependencyPins) Set(s string) error {
+	module, version, ok := strings.Cut(s, "@")
+	if !ok || module == "" {
+		return fmt.Errorf("expected module@version, got %q", s)
+	}
+	d[module] = version
+	return nil
+}
+
+// Returns the versions of dependencyVersions with the ones of gogo's config and
+// then pins overriding them, checking that the modules are the ones of the
+// manifest and the versions are module versions
+func resolveDependencies(pins dependencyPins) map[string]string {
+	deps := make(map[string]string, len(dependencyVersions))
+	for module, version := range dependencyVersions {
+		deps[module] = version
+	}
+	sources := []struct {
+		name string
+		pins map[string]string
+	}{
+		{filepath.Join(gogoHome(), gogoConfigName), loadGogoConfig().Deps},
+		{"--dep", pins},
+	}
+	for _, src := range sources {
+		for module, version := range src.pins {
+			if _, ok := dependencyVersions[module]; !ok {
+				fatalf(exitUsage, "Unknown module %s in %s: the generated code does not import it", module, src.name)
+			}
+			if !moduleVersionPattern.MatchString(version) {
+				fatalf(exitUsage, "Unsupported version %q of %s in %s (a module version such as v1.2.3)", version, module, src.name)
+			}
+			deps[module] = version
+		}
+	}
+	return deps
+}
+
+// Returns the keys of m, sorted
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns the module of deps providing the package at importPath, the longest
+// matching module path, or false when none does
+func dependencyModule(deps map[string]string, importPath string) (string, bool) {
+	module := ""
+	for m := range deps {
+		if (importPath == m || strings.HasPrefix(importPath, m+"/")) && len(m) > len(module) {
+			module = m
+		}
+	}
+	return module, module != ""
+}
+
+// Returns the module@version arguments of go get for modules, at the versions of
+// dependencyVersions and gogo's config
+func goGetArgs(modules ...string) string {
+	deps := resolveDependencies(nil)
+	args := make([]string, len(modules))
+	for i, module := range modules {
+		args[i] = module + "@" + deps[module]
+	}
+	return strings.Join(args, " ")
+}
+
+// Writes the go.mod of the project generated in dir as the module projectName,
+// requiring the modules of deps its Go files import. A go.mod written by a
+// template is left as it is.
+func writeGoMod(dir, projectName string, deps map[string]string) {
+	goMod := filepath.Join(dir, "go.mod")
+	if _, err := os.Stat(goMod); err == nil {
+		return
+	}
+	required := map[string]bool{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), p, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, imp := range f.Imports {
+			importPath, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			if module, ok := dependencyModule(deps, importPath); ok {
+				required[module] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fatalf(exitFailure, "Failed to list the imports of the generated code: %v", err)
+	}
+	createFile(goMod, goModContent(projectName, required, deps))
+}
+
+// Returns the content for go.mod, requiring the required modules at their
+// version in deps
+func goModContent(projectName string, required map[string]bool, deps map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "module %s\n\ngo %s\n", projectName, goVersion)
+	if len(required) == 0 {
+		return b.String()
+	}
+	b.WriteString("\nrequire (\n")
+	for _, module := range sortedKeys(deps) {
+		if required[module] {
+			fmt.Fprintf(&b, "\t%s %s\n", module, deps[module])
+		}
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
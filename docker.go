@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// composeService is an extra docker-compose service the app depends on
+type composeService struct {
+	name       string
+	definition string   // service body, indented for the services section
+	devEnv     []string // environment of app-dev pointing the app at the service
+}
+
+// Returns the content for Dockerfile. With static, the binary is fully static
+// and runs on a distroless image rather than Alpine. With goprivate, the
+// GOPRIVATE patterns of the private modules, they are fetched with the
+// credentials of the netrc build secret.
+func dockerfileContent(binary string, static bool, goprivate string) string {
+	build := `# CGO is off so the binary does not depend on Alpine's musl C library. Packages
+# wrapping C libraries need --build-arg CGO_ENABLED=1 and, in this stage,
+# RUN apk add --no-cache gcc musl-dev; the binary then only runs on musl-based
+# images such as the Alpine one below.
+ARG CGO_ENABLED=0
+RUN --mount=type=cache,target=/go/pkg/mod \
+    --mount=type=cache,target=/root/.cache/go-build \
+    CGO_ENABLED=${CGO_ENABLED} go build -trimpath \
+        -ldflags "-s -w -X main.version=${VERSION} -X main.commit=${COMMIT}" \
+        -o /out/%[1]s ./cmd/%[1]s
+
+FROM alpine:3.20
+RUN addgroup -S app && adduser -S -G app -u 10001 app
+WORKDIR /app
+RUN mkdir -p logs && chown app:app logs
+COPY --from=build /out/%[1]s /usr/local/bin/%[1]s
+# The config files are only readable by their owner
+COPY --chown=app:app configs ./configs
+USER app
+`
+	if static {
+		build = `# Fully static binary (--static): pure Go DNS resolver and user lookups (netgo,
+# osusergo), no C library, so it runs on an image without libc. Packages wrapping
+# C libraries need CGO_ENABLED=1, RUN apk add --no-cache gcc musl-dev and
+# -linkmode external -extldflags "-static", linking musl statically.
+RUN --mount=type=cache,target=/go/pkg/mod \
+    --mount=type=cache,target=/root/.cache/go-build \
+    CGO_ENABLED=0 go build -trimpath -tags netgo,osusergo \
+        -ldflags "-s -w -X main.version=${VERSION} -X main.commit=${COMMIT}" \
+        -o /out/%[1]s ./cmd/%[1]s
+RUN mkdir -p /out/logs
+
+# Distroless static: CA certificates and time zones, no shell, non-root user
+FROM gcr.io/distroless/static-debian12:nonroot
+WORKDIR /app
+COPY --from=build --chown=nonroot:nonroot /out/logs ./logs
+COPY --from=build /out/%[1]s /usr/local/bin/%[1]s
+# The config files are only readable by their owner
+COPY --chown=nonroot:nonroot configs ./configs
+`
+	}
+	download := `RUN --mount=type=cache,target=/go/pkg/mod \
+    go mod download
+`
+	if goprivate != "" {
+		download = `# Private modules are fetched with Git, from their repositories, with the
+# credentials of the netrc secret: docker build --secret id=netrc,src=$HOME/.netrc .
+RUN apk add --no-cache git
+ENV GOPRIVATE=` + goprivate + ` GONOSUMDB=` + goprivate + `
+RUN --mount=type=cache,target=/go/pkg/mod \
+    --mount=type=secret,id=netrc,target=/root/.netrc \
+    go mod download
+`
+	}
+	return fmt.Sprintf(`# syntax=docker/dockerfile:1
+# BuildKit (the default builder of Docker Engine 23+) is required for the cache mounts
+
+FROM golang:1.22-alpine AS build
+WORKDIR /src
+
+# Dependencies get their own layer, rebuilt only when go.mod or go.sum change. A
+# module without dependencies has no go.sum, hence the wildcard.
+COPY go.mod go.sum* ./
+`+download+`
+# The module and build caches persist across builds, so only changed packages are recompiled
+COPY . .
+ARG VERSION=dev
+ARG COMMIT=none
+`+build+`ENTRYPOINT ["/usr/local/bin/%[1]s"]
+`, binary)
+}
+
+// Returns the content for .dockerignore, keeping out of the build context what
+// the image does not need: the build only reads the Go sources, go.mod, configs/
+// and the embedded web/static files
+func dockerignoreContent(opts options) string {
+	var b strings.Builder
+	b.WriteString(`# Version control and editors
+.git
+.gitignore
+.gitattributes
+.githooks
+.github
+.idea
+.vscode
+*.swp
+
+# Local settings and secrets
+.env
+.env.*
+gogo-debug.log
+
+# Build artifacts
+bin
+dist
+*.out
+sbom.cdx.json
+
+# Tests and documentation
+**/*_test.go
+tests
+docs
+*.md
+
+# Development tooling
+.editorconfig
+.golangci.yml
+.gogo.json
+Makefile
+Dockerfile
+docker-compose*.yml
+migrations
+`)
+	var layout []string
+	if opts.archetype == "batch" {
+		layout = append(layout, "data")
+	}
+	if opts.release == "goreleaser" {
+		layout = append(layout, ".goreleaser.yaml", "goreleaser.Dockerfile")
+	}
+	if opts.target == "lambda" {
+		layout = append(layout, "template.yaml", ".aws-sam")
+	}
+	if opts.deploy == "cloudrun" {
+		layout = append(layout, "deploy", "cloudbuild.yaml")
+	} else if opts.profiling == "grafana-agent" || opts.dbTopology == "primary-replica" {
+		layout = append(layout, "deploy")
+	}
+	if opts.iac == "terraform" {
+		layout = append(layout, "infra")
+	}
+	switch opts.changelog {
+	case "git-cliff":
+		layout = append(layout, "cliff.toml")
+	case "chglog":
+		layout = append(layout, ".chglog")
+	}
+	if len(layout) > 0 {
+		b.WriteString("\n# Files of the chosen options\n" + strings.Join(layout, "\n") + "\n")
+	}
+	return b.String()
+}
+
+// Returns the content for docker-compose.yml, with one profile per environment.
+// With replica, the postgres service accepts the replication connections of a
+// read replica, one of the extra services.
+func dockerComposeContent(image string, extra []composeService, replica bool) string {
+	var dependsOn, devEnv, services strings.Builder
+	for _, svc := range extra {
+		fmt.Fprintf(&dependsOn, "    - %s\n", svc.name)
+		for _, env := range svc.devEnv {
+			fmt.Fprintf(&devEnv, "      %s\n", env)
+		}
+		fmt.Fprintf(&services, "\n  %s:\n%s", svc.name, svc.definition)
+	}
+	postgresVolumes := ""
+	if replica {
+		postgresVolumes = "      - ./deploy/postgres/replication.sh:/docker-entrypoint-initdb.d/replication.sh:ro\n"
+	}
+
+	return fmt.Sprintf(`x-app: &app
+  build: .
+  image: %[1]s
+  ports:
+    - "8080:8080"
+  depends_on:
+    - postgres
+%[2]s
+services:
+  app-dev:
+    <<: *app
+    profiles: ["dev"]
+    environment:
+      APP_ENV: dev
+      DB_HOST: postgres
+      DB_PASSWORD: ${DB_PASSWORD:?DB_PASSWORD must be set, see .env.example}
+%[3]s    volumes:
+      - ./configs:/app/configs:ro
+
+  app-staging:
+    <<: *app
+    profiles: ["staging"]
+    environment:
+      APP_ENV: staging
+      DB_PASSWORD: ${DB_PASSWORD:?DB_PASSWORD must be set, see .env.example}
+
+  app-prod:
+    <<: *app
+    profiles: ["prod"]
+    environment:
+      APP_ENV: prod
+      DB_PASSWORD: ${DB_PASSWORD:?DB_PASSWORD must be set for prod}
+
+  postgres:
+    image: postgres:16-alpine
+    environment:
+      POSTGRES_USER: root
+      POSTGRES_PASSWORD: ${DB_PASSWORD:?DB_PASSWORD must be set, see .env.example}
+      POSTGRES_DB: mydatabase
+    ports:
+      - "5432:5432"
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+%[5]s%[4]s
+  # gogo:services
+
+volumes:
+  pgdata:
+  # gogo:volumes
+`, image, dependsOn.String(), devEnv.String(), services.String(), postgresVolumes)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Editors --open launches on the generated project. Terminal editors take over
+// gogo's terminal until they exit; the others open a window and return.
+var editors = map[string]struct {
+	command  string
+	terminal bool
+}{
+	"code":   {"code", false},
+	"goland": {"goland", false},
+	"vim":    {"vim", true},
+}
+
+// Returns the editor --open names: one of editors, "editor" or the value of
+// $EDITOR (when the shell expanded --open=$EDITOR) for the command in $VISUAL
+// or $EDITOR, or "" for none
+func parseOpen(value string) string {
+	switch {
+	case value == "" || value == "none":
+		return ""
+	case value == "editor" || value == "$EDITOR" || value == os.Getenv("EDITOR"):
+		if editorEnv() == "" {
+			fatalf(exitUsage, "--open=%s requires the VISUAL or EDITOR environment variable", value)
+		}
+		return "editor"
+	}
+	if _, ok := editors[value]; !ok {
+		fatalf(exitUsage, "Unsupported --open value %q (supported: code, goland, vim, editor for $EDITOR, none)", value)
+	}
+	return value
+}
+
+// Returns the command line of the user's editor, $VISUAL or else $EDITOR
+func editorEnv() string {
+	if visual := os.Getenv("VISUAL"); visual != "" {
+		return visual
+	}
+	return os.Getenv("EDITOR")
+}
+
+// Opens the project generated in dir with editor, as parsed by parseOpen. The
+// project is complete by then, so a failure is a warning.
+func openProject(dir, editor string) {
+	var args []string
+	terminal := true
+	if editor == "editor" {
+		args = strings.Fields(editorEnv())
+	} else {
+		args = []string{editors[editor].command}
+		terminal = editors[editor].terminal
+	}
+	if terminal && !stdinIsTerminal() {
+		printStyled(styleYellow, "Warning: not opening %s with %s, which needs a terminal\n", dir, args[0])
+		return
+	}
+	cmd := exec.Command(args[0], append(args[1:], dir)...)
+	if terminal {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	}
+	start := time.Now()
+	var err error
+	if terminal {
+		err = cmd.Run()
+	} else {
+		err = cmd.Start()
+	}
+	traceCommand(cmd, start, nil, err)
+	if err != nil {
+		printStyled(styleYellow, "Warning: could not open %s with %s: %v\n", dir, args[0], err)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import "fmt"
+
+// Settings added to the config by --errors=sentry
+var sentryConfigFields = []configField{
+	{goName: "SentryDSN", goType: "string", key: "SENTRY_DSN", secret: true, comment: "Sentry project the errors and panics are reported to. Empty disables the reporting"},
+}
+
+// Returns the imports and setup code of main.go reporting errors to Sentry, and
+// the middleware recovering from panics to pass to the router
+func errorReportingSetup(projectName string) (imports, setup, middleware string) {
+	imports = fmt.Sprintf("\t\"%[1]s/internal/middlewares\"\n\t\"%[1]s/pkg/errorreport\"\n", projectName)
+	setup = `	// Report the errors logged and the panics to Sentry, a no-op without SENTRY_DSN
+	flushErrors, err := errorreport.Init(cfg, version)
+	if err != nil {
+		appLog.Error().Err(err).Msg("Failed to initialize Sentry, errors are not reported")
+	}
+	defer flushErrors()
+	reportingLog := appLog.Hook(errorreport.LogHook{})
+	appLog = &reportingLog
+
+`
+	return imports, setup, "middlewares.Recover(appLog)"
+}
+
+// Returns the content for pkg/errorreport/sentry.go
+func sentryGoContent(projectName string) string {
+	return fmt.Sprintf(`package errorreport
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog"
+
+	"%s/pkg/config"
+)
+
+// How long to wait for the pending events to be sent on exit
+const flushTimeout = 2 * time.Second
+
+// Init initializes the Sentry client for the SENTRY_DSN of cfg, tagging the
+// events with the environment and release. The returned flush sends the pending
+// events, to defer in main. Without a DSN, nothing is reported and flush does
+// nothing.
+func Init(cfg *config.Config, release string) (flush func(), err error) {
+	if cfg.SentryDSN == "" {
+		return func() {}, nil
+	}
+	err = sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.SentryDSN,
+		Environment:      cfg.AppEnv,
+		Release:          release,
+		AttachStacktrace: true,
+	})
+	if err != nil {
+		return func() {}, err
+	}
+	return func() { sentry.Flush(flushTimeout) }, nil
+}
+
+// Sentry levels of the zerolog levels reported
+var levels = map[zerolog.Level]sentry.Level{
+	zerolog.ErrorLevel: sentry.LevelError,
+	zerolog.FatalLevel: sentry.LevelFatal,
+	zerolog.PanicLevel: sentry.LevelFatal,
+}
+
+// LogHook is a zerolog hook reporting the events logged at error level and above
+// to Sentry, with their message and the stack trace of the caller. Events logged
+// with Ctx(r.Context()) carry the request set by middlewares.Recover. Fatal
+// events are flushed before the logger exits.
+type LogHook struct{}
+
+// Run implements zerolog.Hook
+func (LogHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	sentryLevel, ok := levels[level]
+	if !ok {
+		return
+	}
+	hub := sentry.GetHubFromContext(e.GetCtx())
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	if hub.Client() == nil {
+		return
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentryLevel
+	event.Message = msg
+	event.Threads = []sentry.Thread{{Stacktrace: callerStacktrace(), Current: true, Crashed: level != zerolog.ErrorLevel}}
+	hub.CaptureEvent(event)
+	if level != zerolog.ErrorLevel {
+		hub.Flush(flushTimeout)
+	}
+}
+
+// Returns the stack trace of the code logging the event, without the frames of
+// zerolog and of this hook
+func callerStacktrace() *sentry.Stacktrace {
+	stacktrace := sentry.NewStacktrace()
+	frames := stacktrace.Frames[:0]
+	for _, f := range stacktrace.Frames {
+		if f.Module != "github.com/rs/zerolog" && f.Function != "LogHook.Run" {
+			frames = append(frames, f)
+		}
+	}
+	stacktrace.Frames = frames
+	return stacktrace
+}
+`, projectName)
+}
+
+// Returns the content for internal/middlewares/recover.go
+func recoverGoContent() string {
+	return `package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog"
+)
+
+// Recover gives each request a Sentry hub carrying the request and its ID, and
+// recovers from the panics of the next handlers: they are logged at error level
+// with the request context, which the log hook of pkg/errorreport reports to
+// Sentry, and answered with a 500.
+func Recover(logger *zerolog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hub := sentry.CurrentHub().Clone()
+			hub.Scope().SetRequest(r)
+			hub.Scope().SetTag("request_id", RequestIDFromContext(r.Context()))
+			r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				// Aborting a response is not a failure, let net/http handle it
+				if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(p)
+				}
+				logger.Error().Ctx(r.Context()).
+					Str("request_id", RequestIDFromContext(r.Context())).
+					Str("method", r.Method).
+					Str("path", r.URL.Path).
+					Bytes("stack", debug.Stack()).
+					Msgf("panic: %v", p)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+`
+}
+
+// Returns the content for internal/middlewares/recover_test.go
+func recoverTestGoContent() string {
+	return `package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRecover(t *testing.T) {
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs)
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), RequestID, Recover(&logger))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(logs.String(), "panic: boom") {
+		t.Errorf("logs = %s, want the panic", logs.String())
+	}
+}
+`
+}
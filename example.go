@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// example is a complete vertical slice, generated as a resource with seed rows
+// for the layers to have a reference implementation
+type example struct {
+	resource []string // arguments of gogo add resource
+	columns  []string // columns of the seed rows
+	rows     []string // seed rows, as SQL value lists
+}
+
+// Vertical slices available to gogo add example and --example
+var examples = map[string]example{
+	"todo": {
+		resource: []string{"--model-conventions=timestamps", "todo", "title:string", "done:bool"},
+		columns:  []string{"title", "done"},
+		rows: []string{
+			"('Read the README', true)",
+			"('Run the tests with make test', false)",
+			"('Copy this slice to add a resource of your own', false)",
+		},
+	},
+	"user": {
+		resource: []string{"--model-conventions=timestamps", "user", "name:string", "email:string"},
+		columns:  []string{"name", "email"},
+		rows: []string{
+			"('Ada Lovelace', 'ada@example.com')",
+			"('Alan Turing', 'alan@example.com')",
+			"('Grace Hopper', 'grace@example.com')",
+		},
+	},
+}
+
+// Returns the sorted names of the examples
+func exampleNames() []string {
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Adds an example vertical slice: the resource of the example (migration, models,
+// repository, service, handler and tests) and a script inserting its seed rows,
+// run by make seed.
+// Usage: gogo add example todo|user
+func addExample(p project, args []string) ([]generatedFile, []string) {
+	if len(args) != 1 {
+		fatalf(exitUsage, "Please provide the example to add (available: %s), e.g. gogo add example todo", strings.Join(exampleNames(), ", "))
+	}
+	ex, ok := examples[args[0]]
+	if !ok {
+		fatalf(exitUsage, "Unknown example %q (available: %s)", args[0], strings.Join(exampleNames(), ", "))
+	}
+
+	files, steps := addResource(p, ex.resource)
+	table := tableName(args[0])
+	files = append(files,
+		generatedFile{path: filepath.Join("scripts", "seed", table+".sql"), content: seedSQLContent(table, ex)},
+		generatedFile{path: "Makefile", marker: markerEnd, shared: true, id: "seed", content: seedMakefileContent(),
+			step: `Insert the seed rows: for f in scripts/seed/*.sql; do psql "$DB_URL" -f "$f"; done`},
+	)
+	return files, append(steps, "Insert the example "+table+": make seed DB_URL=postgres://...")
+}
+
+// Returns the content for scripts/seed/<table>.sql, inserting the rows of ex into
+// an empty table only, so that seeding twice changes nothing
+func seedSQLContent(table string, ex example) string {
+	columns := strings.Join(ex.columns, ", ")
+	return fmt.Sprintf(`-- Example %[1]s for development, inserted by make seed once the table exists
+-- (make migrate). Nothing is inserted when the table already has rows.
+INSERT INTO %[1]s (%[2]s)
+SELECT %[2]s FROM (VALUES
+    %[3]s
+) AS seed (%[2]s)
+WHERE NOT EXISTS (SELECT 1 FROM %[1]s);
+`, table, columns, strings.Join(ex.rows, ",\n    "))
+}
+
+// Returns the Makefile target running the seed scripts
+func seedMakefileContent() string {
+	return `# Inserts the example rows of scripts/seed into the database at DB_URL (requires psql)
+seed:
+	for f in scripts/seed/*.sql; do psql "$(DB_URL)" -v ON_ERROR_STOP=1 -f "$$f" || exit 1; done
+.PHONY: seed
+`
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+)
+
+// Exit codes of gogo, so that scripts can tell failures apart. Flag parsing
+// errors exit with exitUsage too, as flag.ExitOnError does.
+const (
+	exitFailure     = 1 // unexpected error, e.g. reading or writing a file
+	exitUsage       = 2 // invalid arguments, or arguments naming something that does not exist
+	exitExists      = 3 // the target of the command already exists
+	exitToolMissing = 4 // an external tool gogo runs (git, go) is not installed
+	exitTemplate    = 5 // a template is invalid or renders invalid code
+	exitSubprocess  = 6 // an external command failed, or generated projects failed to build
+)
+
+// Prints the message, translated (see messages.go) and in red (see color.go),
+// like log.Fatalf, then exits with code
+func fatalf(code int, format string, args ...any) {
+	log.Print(colorize(os.Stderr, styleRed, fmt.Sprintf(tr(format), args...)))
+	debugf(format, args...)
+	debugExit(code)
+	os.Exit(code)
+}
+
+// Returns the exit code for the error of running an external command
+func commandExitCode(err error) int {
+	if errors.Is(err, exec.ErrNotFound) {
+		return exitToolMissing
+	}
+	return exitSubprocess
+}
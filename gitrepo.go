@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// The Git repository of generated projects is written with go-git rather than
+// the git binary, so that projects can be generated in containers and other
+// minimal environments without git.
+
+// Message of the first commit, a conventional commit as the --changelog hook requires
+const initialCommitMessage = "chore: generate the project with gogo"
+
+// Branch of the new repository when init.defaultBranch is not configured
+const defaultGitBranch = "main"
+
+// Initializes the Git repository of the project, pointing it at .githooks when
+// hooks is set, and commits the generated files when commit is set and a Git
+// identity is configured
+func initGit(projectDir string, hooks, commit bool) {
+	global, err := config.LoadConfig(config.GlobalScope)
+	if err != nil {
+		printStyled(styleYellow, "Warning: the global Git config could not be read: %v\n", err)
+		global = config.NewConfig()
+	}
+	branch := global.Init.DefaultBranch
+	if branch == "" {
+		branch = defaultGitBranch
+	}
+
+	debugf("git init %s on %s", projectDir, branch)
+	repo, err := git.PlainInitWithOptions(projectDir, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName(branch)},
+	})
+	if err != nil {
+		fatalf(exitFailure, "Failed to initialize Git: %v", err)
+	}
+	if hooks {
+		cfg, err := repo.Config()
+		if err == nil {
+			cfg.Raw.Section("core").SetOption("hooksPath", ".githooks")
+			err = repo.SetConfig(cfg)
+		}
+		if err != nil {
+			fatalf(exitFailure, "Failed to configure the Git hooks: %v", err)
+		}
+	}
+	if !commit {
+		return
+	}
+
+	author := gitSignature("AUTHOR", global)
+	committer := gitSignature("COMMITTER", global)
+	if author == nil || committer == nil {
+		fmt.Println(colorize(os.Stdout, styleYellow, tr("Warning: no Git identity configured (user.name and user.email), the generated files are not committed")))
+		return
+	}
+	if err := commitFiles(repo, author, committer, initialCommitMessage); err != nil {
+		fatalf(exitFailure, "Failed to commit the generated files: %v", err)
+	}
+}
+
+// Returns the identity of the author or committer (role) as git does: from
+// GIT_<role>_NAME, GIT_<role>_EMAIL and GIT_<role>_DATE, else the user settings
+// of the global Git config and the current time; nil when the name or email is
+// missing
+func gitSignature(role string, global *config.Config) *object.Signature {
+	name := os.Getenv("GIT_" + role + "_NAME")
+	if name == "" {
+		name = global.User.Name
+	}
+	email := os.Getenv("GIT_" + role + "_EMAIL")
+	if email == "" {
+		email = global.User.Email
+	}
+	if name == "" || email == "" {
+		return nil
+	}
+	when := time.Now()
+	if date := os.Getenv("GIT_" + role + "_DATE"); date != "" {
+		parsed, err := parseGitDate(date)
+		if err != nil {
+			fatalf(exitUsage, "Unsupported GIT_%s_DATE value %q (e.g. 2024-05-01T12:00:00+02:00 or @1714557600 +0200)", role, date)
+		}
+		when = parsed
+	}
+	return &object.Signature{Name: name, Email: email, When: when}
+}
+
+// Parses a date of GIT_AUTHOR_DATE or GIT_COMMITTER_DATE, in the RFC 3339 or
+// internal ("@<unix seconds> <offset>") format
+func parseGitDate(date string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, nil
+	}
+	return time.Parse("@1136239445 -0700", strings.TrimSpace(date))
+}
+
+// Commits the files of the repository that .gitignore does not exclude. Text
+// files are stored with LF line endings, as the text attribute of
+// .gitattributes asks (see isGitText): go-git stores them as they are.
+func commitFiles(repo *git.Repository, author, committer *object.Signature, message string) error {
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return err
+	}
+	if err := normalizeLineEndings(repo, w); err != nil {
+		return err
+	}
+	commit, err := w.Commit(message, &git.CommitOptions{Author: author, Committer: committer})
+	if err != nil {
+		return err
+	}
+	debugf("commit %s", commit)
+	return nil
+}
+
+// Replaces the blobs of the index holding CRLF line endings by their LF version
+// for the text files of .gitattributes. The index keeps the size of the files of
+// the working tree, so git sees them unchanged.
+func normalizeLineEndings(repo *git.Repository, w *git.Worktree) error {
+	patterns, err := gitattributes.ReadPatterns(w.Filesystem, nil)
+	if err != nil {
+		return err
+	}
+	attributes := gitattributes.NewMatcher(patterns)
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, e := range idx.Entries {
+		if e.Mode != filemode.Regular && e.Mode != filemode.Executable {
+			continue
+		}
+		blob, err := repo.BlobObject(e.Hash)
+		if err != nil {
+			return err
+		}
+		r, err := blob.Reader()
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(content, []byte("\r\n")) {
+			continue
+		}
+		if !isGitText(content, attributes, e.Name) {
+			continue
+		}
+		obj := repo.Storer.NewEncodedObject()
+		obj.SetType(plumbing.BlobObject)
+		ow, err := obj.Writer()
+		if err != nil {
+			return err
+		}
+		ow.Write(bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n")))
+		if err := ow.Close(); err != nil {
+			return err
+		}
+		if e.Hash, err = repo.Storer.SetEncodedObject(obj); err != nil {
+			return err
+		}
+		debugf("normalize the line endings of %s", e.Name)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return repo.Storer.SetIndex(idx)
+}
+
+// Reports whether the file at the slash-separated path name is stored with LF
+// line endings, as text=auto does: it is text unless its attributes make it
+// binary (binary or -text), or it holds a NUL byte
+func isGitText(content []byte, attributes gitattributes.Matcher, name string) bool {
+	// Attributes are matched one at a time, the matcher giving the last line
+	// setting it only when asked for a single one
+	for _, attr := range []string{"binary", "text"} {
+		results, _ := attributes.Match(strings.Split(name, "/"), []string{attr})
+		if a, ok := results[attr]; ok && (attr == "binary" && a.IsSet() || attr == "text" && a.IsUnset()) {
+			return false
+		}
+	}
+	return bytes.IndexByte(content, 0) < 0
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Applies an insertion to the Go source src, working on its syntax tree so the
+// edit holds however the file was formatted or reordered: imports are added to
+// the import declaration (code is the quoted path), and statements go before the
+// marker comment, or before the return of the function creating the mux when the
+// marker was deleted. Reports false when there is nowhere to insert code.
+func insertGo(src, marker, id, code string) (string, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return src, false, err
+	}
+	if marker == markerImports {
+		path, err := strconv.Unquote(strings.TrimSpace(code))
+		if err != nil {
+			return src, false, fmt.Errorf("invalid import %s", code)
+		}
+		return addGoImport(fset, file, src, path)
+	}
+	if strings.Contains(src, "gogo:begin "+id+"\n") {
+		return src, true, nil
+	}
+
+	offset, ok := markerOffset(fset, file, marker)
+	if !ok {
+		if offset, ok = muxReturnOffset(fset, file); !ok {
+			return src, false, nil
+		}
+	}
+	block := "// gogo:begin " + id + "\n" + strings.TrimRight(code, "\n") + "\n// gogo:end " + id + "\n"
+	return formatGoSource(src[:offset] + block + src[offset:])
+}
+
+// Returns the offset of the line of the `// gogo:<marker>` comment, when it is
+// inside a function body
+func markerOffset(fset *token.FileSet, file *ast.File, marker string) (int, bool) {
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) != "gogo:"+marker {
+				continue
+			}
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if ok && fn.Body != nil && fn.Body.Lbrace < c.Pos() && c.End() < fn.Body.Rbrace {
+					return lineStart(fset, c.Pos()), true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// Returns the offset of the line of the last return statement of the function
+// calling http.NewServeMux, where routes can still be registered
+func muxReturnOffset(fset *token.FileSet, file *ast.File) (int, bool) {
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || len(fn.Body.List) == 0 {
+			continue
+		}
+		createsMux := false
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "NewServeMux" {
+				if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "http" {
+					createsMux = true
+				}
+			}
+			return !createsMux
+		})
+		if ret, ok := fn.Body.List[len(fn.Body.List)-1].(*ast.ReturnStmt); ok && createsMux {
+			return lineStart(fset, ret.Pos()), true
+		}
+	}
+	return 0, false
+}
+
+// Adds the import of path to the file, unless it is imported already
+func addGoImport(fset *token.FileSet, file *ast.File, src, path string) (string, bool, error) {
+	for _, imp := range file.Imports {
+		if existing, _ := strconv.Unquote(imp.Path.Value); existing == path {
+			return src, true, nil
+		}
+	}
+	spec := strconv.Quote(path)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Rparen.IsValid() {
+			// Last in the block, which is the group of the module's own packages
+			offset := lineStart(fset, gen.Rparen)
+			return formatGoSource(src[:offset] + "\t" + spec + "\n" + src[offset:])
+		}
+		start, end := fset.Position(gen.Pos()).Offset, fset.Position(gen.End()).Offset
+		return formatGoSource(src[:start] + "import (\n" + src[start+len("import"):end] + "\n" + spec + "\n)" + src[end:])
+	}
+	offset := fset.Position(file.Name.End()).Offset
+	return formatGoSource(src[:offset] + "\n\nimport " + spec + src[offset:])
+}
+
+// Removes the imports of the module's own packages that the Go source src no
+// longer refers to, e.g. after gogo remove took out the code using them
+func pruneModuleImports(src, modulePath string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return src, err
+	}
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	lines := strings.Split(src, "\n")
+	drop := map[int]bool{}
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if !strings.HasPrefix(path, modulePath+"/") || used[importName(imp, path)] || (imp.Name != nil && (imp.Name.Name == "_" || imp.Name.Name == ".")) {
+			continue
+		}
+		line := fset.Position(imp.Pos()).Line - 1
+		if strings.TrimSpace(lines[line]) != strings.TrimSpace(src[fset.Position(imp.Pos()).Offset:fset.Position(imp.End()).Offset]) {
+			continue // shares its line with other code, e.g. import "x"
+		}
+		drop[line] = true
+	}
+	if len(drop) == 0 {
+		return src, nil
+	}
+	var kept []string
+	for i, line := range lines {
+		if !drop[i] {
+			kept = append(kept, line)
+		}
+	}
+	out, _, err := formatGoSource(strings.Join(kept, "\n"))
+	return out, err
+}
+
+// Returns the offset of the start of the line of pos
+func lineStart(fset *token.FileSet, pos token.Pos) int {
+	p := fset.Position(pos)
+	return p.Offset - (p.Column - 1)
+}
+
+// Formats edited Go source, failing when the edit made it invalid
+func formatGoSource(src string) (string, bool, error) {
+	out, err := format.Source([]byte(src))
+	if err != nil {
+		return src, false, err
+	}
+	return string(out), true, nil
+}
+
+// Module path of the project being generated, whose packages form the last
+// import group
+var generatedModule string
+
+// Name the generators of gogo new import the project's packages under, moved
+// under generatedModule when it differs (see --module)
+var generatedProjectName string
+
+// Formats a generated Go file like gofmt and goimports, grouping its imports into
+// standard library, third-party and module packages, once the imports of
+// generatedProjectName are moved under the module path. Templates producing
+// invalid Go stop the generation.
+func formatGeneratedGo(path, src string) string {
+	if generatedProjectName != "" && generatedProjectName != generatedModule {
+		var err error
+		if src, err = moveModuleImports(src, generatedProjectName, generatedModule); err != nil {
+			fatalf(exitTemplate, "Generated invalid Go code in %s: %v", path, err)
+		}
+	}
+	organized, err := organizeImports(src, generatedModule)
+	if err != nil {
+		fatalf(exitTemplate, "Generated invalid Go code in %s: %v", path, err)
+	}
+	out, err := format.Source([]byte(organized))
+	if err != nil {
+		fatalf(exitTemplate, "Generated invalid Go code in %s: %v", path, err)
+	}
+	return string(out)
+}
+
+// Returns the Go source src with its imports of the module from, or of its
+// packages, moved under the module to
+func moveModuleImports(src, from, to string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return src, err
+	}
+	for i := len(file.Imports) - 1; i >= 0; i-- {
+		imp := file.Imports[i]
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if path != from && !strings.HasPrefix(path, from+"/") {
+			continue
+		}
+		start, end := fset.Position(imp.Path.Pos()).Offset, fset.Position(imp.Path.End()).Offset
+		src = src[:start] + strconv.Quote(to+strings.TrimPrefix(path, from)) + src[end:]
+	}
+	return src, nil
+}
+
+// Sorts and groups the imports of the Go source src. Import declarations holding
+// comments are left alone, as their comments may describe a grouping.
+func organizeImports(src, modulePath string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return src, err
+	}
+	if len(file.Decls) == 0 {
+		return src, nil
+	}
+	gen, ok := file.Decls[0].(*ast.GenDecl)
+	if !ok || gen.Tok != token.IMPORT || !gen.Rparen.IsValid() || len(file.Decls) > 1 {
+		return src, nil
+	}
+	for _, group := range file.Comments {
+		if group.Pos() > gen.Lparen && group.End() < gen.Rparen {
+			return src, nil
+		}
+	}
+
+	groups := make([][]string, 3)
+	for _, spec := range gen.Specs {
+		imp := spec.(*ast.ImportSpec)
+		path, _ := strconv.Unquote(imp.Path.Value)
+		group := 1
+		switch {
+		case modulePath != "" && (path == modulePath || strings.HasPrefix(path, modulePath+"/")):
+			group = 2
+		case !strings.Contains(strings.Split(path, "/")[0], "."):
+			group = 0
+		}
+		text := src[fset.Position(imp.Pos()).Offset:fset.Position(imp.End()).Offset]
+		groups[group] = append(groups[group], text)
+	}
+	var blocks []string
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return importPathOf(group[i]) < importPathOf(group[j]) })
+		blocks = append(blocks, "\t"+strings.Join(group, "\n\t"))
+	}
+	start, end := fset.Position(gen.Lparen).Offset+1, fset.Position(gen.Rparen).Offset
+	return src[:start] + "\n" + strings.Join(blocks, "\n\n") + "\n" + src[end:], nil
+}
+
+// Returns the quoted path of an import spec written as [name] "path"
+func importPathOf(spec string) string {
+	return spec[strings.Index(spec, `"`):]
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Module paths accepted by --module: slash-separated elements of letters,
+// digits and the punctuation Go allows, e.g. github.com/acme/payments
+var modulePathPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._~-]*(/[A-Za-z0-9._~-]+)*$`)
+
+// Patterns accepted by --goprivate: module path prefixes, possibly with globs
+var goprivatePattern = regexp.MustCompile(`^[A-Za-z0-9.*?\[\]_~-]+(/[A-Za-z0-9.*?\[\]_~-]+)*$`)
+
+// Returns the module path of the project named projectName: --module when set,
+// else the module_prefix of gogo's config followed by the name, else the name
+func resolveModulePath(module, projectName string) string {
+	if module == "" {
+		module = projectName
+		if prefix := strings.TrimSuffix(loadGogoConfig().ModulePrefix, "/"); prefix != "" {
+			module = prefix + "/" + projectName
+		}
+	}
+	if !modulePathPattern.MatchString(module) {
+		fatalf(exitUsage, "Unsupported module path %q (e.g. github.com/acme/payments)", module)
+	}
+	// The generators import the project's packages as <project name>/..., moved
+	// under the module path when they are written (see formatGeneratedGo)
+	if module != projectName {
+		if pkg, err := build.Default.Import(projectName, "", build.FindOnly); err == nil && pkg.Goroot {
+			fatalf(exitUsage, "The project name %s is a standard library package, whose imports could not be told apart from the project's: choose another name", projectName)
+		}
+	}
+	return module
+}
+
+// Parses --goprivate, comma-separated patterns of private module paths, and
+// returns it as GOPRIVATE reads it
+func parseGoprivate(value string) string {
+	if value == "" {
+		return ""
+	}
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSuffix(strings.TrimSpace(p), "/")
+		if !goprivatePattern.MatchString(p) {
+			fatalf(exitUsage, "Unsupported --goprivate pattern %q (a module path prefix such as github.com/acme, globs allowed)", p)
+		}
+		patterns = append(patterns, p)
+	}
+	return strings.Join(patterns, ",")
+}
+
+// Returns the hosts of the GOPRIVATE patterns, whose Git URLs need credentials.
+// Patterns with a glob in their host name no URL rewrite can match are left out.
+func goprivateHosts(goprivate string) []string {
+	var hosts []string
+	for _, p := range strings.Split(goprivate, ",") {
+		host, _, _ := strings.Cut(p, "/")
+		if !strings.ContainsAny(host, "*?[") && !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// Returns the content for the env section and the step of the CI jobs giving
+// Git the credentials of the private modules' hosts, from the GOPRIVATE_TOKEN
+// secret
+func ciPrivateModulesContent(goprivate string) (env, step string) {
+	env = fmt.Sprintf(`env:
+  # Private modules are fetched from their repositories, not the public proxy and
+  # checksum database
+  GOPRIVATE: %[1]s
+  GONOSUMDB: %[1]s
+
+`, goprivate)
+	var b strings.Builder
+	b.WriteString(`      - name: Private modules
+        env:
+          GOPRIVATE_TOKEN: ${{ secrets.GOPRIVATE_TOKEN }}
+        run: |
+`)
+	for _, host := range goprivateHosts(goprivate) {
+		fmt.Fprintf(&b, "          git config --global url.\"https://x-access-token:${GOPRIVATE_TOKEN}@%[1]s/\".insteadOf \"https://%[1]s/\"\n", host)
+	}
+	b.WriteString("\n")
+	return env, b.String()
+}
+
+// Returns the content for .devcontainer/devcontainer.json, set up for the
+// private modules of GOPRIVATE with the host's GOPRIVATE_TOKEN
+func devcontainerContent(projectName, goprivate string) string {
+	var setup []string
+	for _, host := range goprivateHosts(goprivate) {
+		setup = append(setup, fmt.Sprintf(`git config --global url.\"https://x-access-token:${GOPRIVATE_TOKEN}@%[1]s/\".insteadOf \"https://%[1]s/\"`, host))
+	}
+	return fmt.Sprintf(`{
+  "name": "%s",
+  "image": "mcr.microsoft.com/devcontainers/go:1.22",
+  "containerEnv": {
+    "GOPRIVATE": "%s",
+    "GONOSUMDB": "%[2]s"
+  },
+  "remoteEnv": {
+    "GOPRIVATE_TOKEN": "${localEnv:GOPRIVATE_TOKEN}"
+  },
+  "postCreateCommand": "if [ -n \"$GOPRIVATE_TOKEN\" ]; then %s; fi && go mod download",
+  "customizations": {
+    "vscode": {
+      "extensions": ["golang.go"]
+    }
+  }
+}
+`, projectName, goprivate, strings.Join(setup, " && "))
+}
+
+// Returns the section of the README explaining how to fetch the private
+// modules of GOPRIVATE
+func privateModulesReadmeContent(goprivate string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## Private modules\n\nModules matching `%s` are fetched from their repositories rather than the public proxy and checksum database. Tell Go about them once:\n\n```sh\ngo env -w GOPRIVATE=%[1]s GONOSUMDB=%[1]s\n```\n\n", goprivate)
+	hosts := goprivateHosts(goprivate)
+	b.WriteString("Then give Git credentials for their hosts, with a token that can read the repositories, either in `~/.netrc`:\n\n```\n")
+	for _, host := range hosts {
+		fmt.Fprintf(&b, "machine %s login x-access-token password <token>\n", host)
+	}
+	b.WriteString("```\n\nor by rewriting their URLs:\n\n```sh\n")
+	for _, host := range hosts {
+		fmt.Fprintf(&b, "git config --global url.\"https://x-access-token:<token>@%[1]s/\".insteadOf \"https://%[1]s/\"\n", host)
+	}
+	b.WriteString("```\n\nCI reads the token from the `GOPRIVATE_TOKEN` secret, and the devcontainer from the `GOPRIVATE_TOKEN` variable of your environment. The Docker build reads `~/.netrc` as a build secret: `docker build --secret id=netrc,src=$HOME/.netrc .`.\n")
+	return b.String()
+}
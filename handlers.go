@@ -0,0 +1,160 @@
+package main
+
+import "fmt"
+
+// Returns the content for internal/handlers/router.go, registering the modules
+// of the modular-monolith layout
+func routerGoContent(projectName, layout string) string {
+	var imports, modules string
+	if layout == "modular-monolith" {
+		imports = fmt.Sprintf("\t\"%s/internal/modules\"\n", projectName)
+		modules = "\tmodules.Register(mux)\n"
+	}
+	return fmt.Sprintf(`package handlers
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"%[1]s/internal/middlewares"
+%[2]s	"%[1]s/pkg/config"
+	"%[1]s/pkg/health"
+	"%[1]s/web"
+)
+
+// NewRouter registers all routes and wraps them with the common middlewares,
+// followed by the extra ones (e.g. rate limiting)
+func NewRouter(logger *zerolog.Logger, cfg *config.Config, extra ...middlewares.Middleware) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /healthz", health.Handler())
+	mux.Handle("GET /favicon.ico", http.FileServerFS(web.Static()))
+	mux.Handle("GET /robots.txt", http.FileServerFS(web.Static()))
+%[3]s
+	// Components added with gogo add are wired below
+	// gogo:providers
+	// gogo:routes
+
+	mws := []middlewares.Middleware{
+		middlewares.RequestID,
+		middlewares.ContextLogger(logger),
+		middlewares.AccessLog(logger, middlewares.AccessLogConfig{
+			SampleRate:    cfg.AccessLogSampleRate,
+			SlowThreshold: cfg.AccessLogSlowThreshold,
+		}),
+	}
+	return middlewares.Chain(mux, append(mws, extra...)...)
+}
+`, projectName, imports, modules)
+}
+
+// Returns the content for internal/handlers/golden_test.go
+func goldenTestGoContent() string {
+	return `package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Run "go test ./internal/handlers -update" to rewrite the golden files from the current responses
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenDir holds the expected JSON responses, relative to this package
+var goldenDir = filepath.Join("..", "..", "tests", "golden")
+
+// serve sends a request through h and returns the recorded response
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// assertGolden compares a JSON body with tests/golden/<name>.json, or rewrites it with -update
+func assertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+
+	var got bytes.Buffer
+	if err := json.Indent(&got, bytes.TrimSpace(body), "", "  "); err != nil {
+		t.Fatalf("response is not valid JSON: %v\n%s", err, body)
+	}
+	got.WriteByte('\n')
+
+	path := filepath.Join(goldenDir, name+".json")
+	if *update {
+		if err := os.WriteFile(path, got.Bytes(), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("response does not match %s\ngot:\n%s\nwant:\n%s", path, got.Bytes(), want)
+	}
+}
+`
+}
+
+// Returns the content for internal/handlers/health_test.go
+func healthTestGoContent(projectName string) string {
+	return fmt.Sprintf(`package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"%s/internal/middlewares"
+	"%s/pkg/config"
+)
+
+func newTestRouter() http.Handler {
+	logger := zerolog.Nop()
+	return NewRouter(&logger, &config.Config{AccessLogSampleRate: 1})
+}
+
+func TestHealth(t *testing.T) {
+	rec := serve(newTestRouter(), http.MethodGet, "/healthz", "")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %%d, want %%d", rec.Code, http.StatusOK)
+	}
+	if rec.Header().Get(middlewares.RequestIDHeader) == "" {
+		t.Error("response has no request ID")
+	}
+	assertGolden(t, "health", rec.Body.Bytes())
+}
+
+func TestUnknownRoute(t *testing.T) {
+	router := newTestRouter()
+
+	if rec := serve(router, http.MethodGet, "/does-not-exist", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("status = %%d, want %%d", rec.Code, http.StatusNotFound)
+	}
+	if rec := serve(router, http.MethodPost, "/healthz", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %%d, want %%d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+`, projectName, projectName)
+}
+
+// Returns the content for tests/golden/health.json
+func healthGoldenContent() string {
+	return `{
+  "status": "ok",
+  "checks": {}
+}
+`
+}
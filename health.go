@@ -0,0 +1,291 @@
+package main
+
+// Returns the content for pkg/health/health.go
+func healthGoContent() string {
+	return `package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Time given to each check before it is reported down
+const checkTimeout = 2 * time.Second
+
+// Checker checks a dependency, returning nil when it is healthy
+type Checker func(ctx context.Context) error
+
+// Result is the outcome of a check
+type Result struct {
+	Status    string  ` + "`" + `json:"status"` + "`" + `
+	LatencyMs float64 ` + "`" + `json:"latency_ms"` + "`" + `
+	Error     string  ` + "`" + `json:"error,omitempty"` + "`" + `
+}
+
+// Report is the outcome of every check, "ok" when they all passed and "down"
+// otherwise
+type Report struct {
+	Status string            ` + "`" + `json:"status"` + "`" + `
+	Checks map[string]Result ` + "`" + `json:"checks"` + "`" + `
+}
+
+// Registry holds named checkers, run together by Run and ServeHTTP
+type Registry struct {
+	mu       sync.RWMutex
+	checkers map[string]Checker
+}
+
+// Register adds a checker under name, replacing the one registered under it
+func (r *Registry) Register(name string, c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.checkers == nil {
+		r.checkers = map[string]Checker{}
+	}
+	r.checkers[name] = c
+}
+
+// Names returns the names of the registered checkers, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.checkers))
+	for name := range r.checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run runs the checkers concurrently, each with a timeout of its own
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checkers := make(map[string]Checker, len(r.checkers))
+	for name, c := range r.checkers {
+		checkers[name] = c
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: "ok", Checks: make(map[string]Result, len(checkers))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, c := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			start := time.Now()
+			err := c(checkCtx)
+			result := Result{Status: "ok", LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				result.Status = "down"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = "down"
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// ServeHTTP runs the checks and writes their report: 200 when they all passed,
+// 503 otherwise
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	report := r.Run(req.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// DefaultRegistry is the registry served on /healthz by the router
+var DefaultRegistry = &Registry{}
+
+// Register adds a checker to DefaultRegistry
+func Register(name string, c Checker) {
+	DefaultRegistry.Register(name, c)
+}
+
+// Handler returns the handler of DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry
+}
+`
+}
+
+// Returns the content for pkg/health/checkers.go
+func healthCheckersGoContent() string {
+	return `package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// Pinger is a connection pool that can be pinged, such as *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Ping checks a database by pinging its connection pool
+func Ping(db Pinger) Checker {
+	return db.PingContext
+}
+
+// Dial checks that a TCP connection can be opened to addr, e.g. the address of a
+// message broker or queue
+func Dial(addr string) Checker {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// DiskSpace checks that the file system holding path has at least minFree bytes
+// available
+func DiskSpace(path string, minFree uint64) Checker {
+	return func(ctx context.Context) error {
+		free, err := freeBytes(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%d MB free on %s, below %d MB", free>>20, path, minFree>>20)
+		}
+		return nil
+	}
+}
+`
+}
+
+// Returns the content for pkg/health/disk_unix.go
+func healthDiskUnixGoContent() string {
+	return `//go:build unix
+
+package health
+
+import "syscall"
+
+// Returns the bytes available to unprivileged users on the file system of path
+func freeBytes(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+`
+}
+
+// Returns the content for pkg/health/disk_other.go
+func healthDiskOtherGoContent() string {
+	return `//go:build !unix
+
+package health
+
+import (
+	"errors"
+	"runtime"
+)
+
+// Returns an error: free space is only checked on Unix systems
+func freeBytes(path string) (uint64, error) {
+	return 0, errors.New("disk space check not supported on " + runtime.GOOS)
+}
+`
+}
+
+// Returns the content for pkg/health/redis.go
+func healthRedisGoContent() string {
+	return `package health
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis checks a Redis server by pinging it
+func Redis(rdb *redis.Client) Checker {
+	return func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}
+}
+`
+}
+
+// Returns the content for pkg/health/health_test.go
+func healthPackageTestGoContent() string {
+	return `package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	var r Registry
+	r.Register("database", func(ctx context.Context) error { return nil })
+	r.Register("cache", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != "down" || report.Checks["database"].Status != "ok" || report.Checks["cache"].Error != "connection refused" {
+		t.Errorf("report = %+v, want the database ok and the cache down", report)
+	}
+}
+
+func TestDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	if err := Dial(addr)(context.Background()); err != nil {
+		t.Errorf("Dial(%s) = %v, want nil", addr, err)
+	}
+	ln.Close()
+	if err := Dial(addr)(context.Background()); err == nil {
+		t.Errorf("Dial(%s) after Close = nil, want an error", addr)
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	if err := DiskSpace(t.TempDir(), 1)(context.Background()); err != nil {
+		t.Skipf("disk space not available: %v", err)
+	}
+	if err := DiskSpace(t.TempDir(), 1<<62)(context.Background()); err == nil {
+		t.Error("DiskSpace with 4 EB required = nil, want an error")
+	}
+}
+`
+}
@@ -0,0 +1,291 @@
+package main
+
+import "fmt"
+
+// Returns the content for pkg/httpclient/httpclient.go
+func httpClientGoContent() string {
+	return `package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sony/gobreaker"
+)
+
+// errServerError marks 5xx responses as failures for the circuit breaker
+var errServerError = errors.New("httpclient: server error")
+
+// Config holds the timeout, retry and circuit breaker settings of a Client
+type Config struct {
+	Timeout            time.Duration
+	MaxRetries         int
+	RetryWaitMin       time.Duration
+	RetryWaitMax       time.Duration
+	BreakerName        string
+	BreakerMaxFailures uint32
+	BreakerOpenTimeout time.Duration
+}
+
+// DefaultConfig returns sensible defaults for calling external APIs
+func DefaultConfig() Config {
+	return Config{
+		Timeout:            10 * time.Second,
+		MaxRetries:         3,
+		RetryWaitMin:       100 * time.Millisecond,
+		RetryWaitMax:       2 * time.Second,
+		BreakerName:        "httpclient",
+		BreakerMaxFailures: 5,
+		BreakerOpenTimeout: 30 * time.Second,
+	}
+}
+
+// Hooks are called around every attempt, e.g. for logging or tracing
+type Hooks struct {
+	BeforeRequest func(req *http.Request)
+	AfterResponse func(req *http.Request, resp *http.Response, err error, duration time.Duration)
+}
+
+// Option customizes a Client
+type Option func(*Client)
+
+// WithHooks registers request hooks on the client
+func WithHooks(hooks Hooks) Option {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hooks)
+	}
+}
+
+// WithTransport replaces the underlying transport (e.g. with an otelhttp transport)
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.http.Transport = rt
+	}
+}
+
+// Client wraps net/http with retries, jittered backoff and circuit breaking
+type Client struct {
+	http    *http.Client
+	cfg     Config
+	breaker *gobreaker.CircuitBreaker
+	hooks   []Hooks
+}
+
+// New creates a new Client from the given configuration
+func New(cfg Config, opts ...Option) *Client {
+	c := &Client{
+		http: &http.Client{Timeout: cfg.Timeout},
+		cfg:  cfg,
+	}
+	c.breaker = gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:    cfg.BreakerName,
+		Timeout: cfg.BreakerOpenTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= cfg.BreakerMaxFailures
+		},
+	})
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Do sends the request, retrying idempotent requests on network errors, 429 and 5xx responses
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		r, err := c.prepare(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		for _, h := range c.hooks {
+			if h.BeforeRequest != nil {
+				h.BeforeRequest(r)
+			}
+		}
+		resp, err := c.send(r)
+		for _, h := range c.hooks {
+			if h.AfterResponse != nil {
+				h.AfterResponse(r, resp, err, time.Since(start))
+			}
+		}
+
+		if attempt >= c.cfg.MaxRetries || !c.shouldRetry(r, resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+// Get is a convenience wrapper around Do for GET requests
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// prepare clones the request and rewinds its body for retries
+func (c *Client) prepare(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("httpclient: request body cannot be replayed for retry")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.Body = body
+	return r, nil
+}
+
+// send executes a single attempt through the circuit breaker
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	res, err := c.breaker.Execute(func() (interface{}, error) {
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return resp, errServerError
+		}
+		return resp, nil
+	})
+	resp, _ := res.(*http.Response)
+	if errors.Is(err, errServerError) {
+		return resp, nil
+	}
+	return resp, err
+}
+
+func (c *Client) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return false
+	}
+	if !isIdempotent(req) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns an exponential delay with equal jitter, capped at RetryWaitMax
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.cfg.RetryWaitMin << attempt
+	if wait <= 0 || wait > c.cfg.RetryWaitMax {
+		wait = c.cfg.RetryWaitMax
+	}
+	half := int64(wait / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// LoggingHooks logs every outgoing request with its status and latency
+func LoggingHooks(logger *zerolog.Logger) Hooks {
+	return Hooks{
+		AfterResponse: func(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+			event := logger.Info()
+			if err != nil {
+				event = logger.Error().Err(err)
+			} else if resp.StatusCode >= http.StatusInternalServerError {
+				event = logger.Warn()
+			}
+			if resp != nil {
+				event = event.Int("status", resp.StatusCode)
+			}
+			event.
+				Str("method", req.Method).
+				Str("url", req.URL.Redacted()).
+				Dur("duration", duration).
+				Msg("outgoing request")
+		},
+	}
+}
+`
+}
+
+// Returns the content for internal/services/example_api_service.go
+func exampleAPIServiceGoContent(projectName string) string {
+	return fmt.Sprintf(`package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"%[1]s/pkg/httpclient"
+	"%[1]s/pkg/logger"
+)
+
+// Post is the resource returned by the example external API
+type Post struct {
+	ID    int    `+"`"+`json:"id"`+"`"+`
+	Title string `+"`"+`json:"title"`+"`"+`
+	Body  string `+"`"+`json:"body"`+"`"+`
+}
+
+// ExampleAPIService shows how to call an external API through pkg/httpclient
+type ExampleAPIService struct {
+	client  *httpclient.Client
+	baseURL string
+}
+
+// NewExampleAPIService creates a new ExampleAPIService
+func NewExampleAPIService(client *httpclient.Client, baseURL string) *ExampleAPIService {
+	return &ExampleAPIService{client: client, baseURL: baseURL}
+}
+
+// GetPost fetches a single post from the external API
+func (s *ExampleAPIService) GetPost(ctx context.Context, id int) (*Post, error) {
+	logger.FromContext(ctx).Debug().Int("post_id", id).Msg("Fetching post")
+	resp, err := s.client.Get(ctx, fmt.Sprintf("%%s/posts/%%d", s.baseURL, id))
+	if err != nil {
+		return nil, fmt.Errorf("fetching post %%d: %%w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching post %%d: unexpected status %%d", id, resp.StatusCode)
+	}
+
+	var post Post
+	if err := json.NewDecoder(resp.Body).Decode(&post); err != nil {
+		return nil, fmt.Errorf("decoding post %%d: %%w", id, err)
+	}
+	return &post, nil
+}
+`, projectName)
+}
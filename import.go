@@ -0,0 +1,783 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Postgres accepts at most this many parameters in one statement
+const maxQueryParams = 65535
+
+// Adds a bulk CSV import for an existing resource: a multipart upload endpoint,
+// streaming parsing with per-row validation, an error report and batched inserts
+// Usage: gogo add import <resource> [--batch-size=500]
+func addImport(p project, args []string) ([]generatedFile, []string) {
+	fs := flag.NewFlagSet("gogo add import", flag.ExitOnError)
+	batchSize := fs.Int("batch-size", 500, "rows inserted per statement")
+	positional := parseFlags(fs, args)
+	if len(positional) < 1 {
+		fatalf(exitUsage, "Please provide the resource to import, e.g. gogo add import product")
+	}
+
+	m := p.model(positional[0])
+	fields := importFields(m)
+	if len(fields) == 0 {
+		fatalf(exitUsage, "%s has no fields to import", m.typeName)
+	}
+	newID := p.repositoryNewID(m)
+	columns := len(fields)
+	if newID != "" {
+		columns++
+	}
+	if *batchSize < 1 || *batchSize*columns > maxQueryParams {
+		fatalf(exitUsage, "Invalid --batch-size value %d (must be between 1 and %d for %s)", *batchSize, maxQueryParams/columns, m.table)
+	}
+	path := strings.ReplaceAll(m.table, "_", "-")
+	routes := fmt.Sprintf("New%[1]sImportHandler(services.New%[1]sService(repository.New%[1]sRepository(db))).Register(mux)", m.typeName)
+
+	return append([]generatedFile{
+		{path: filepath.Join("internal", "imports", "imports.go"), content: importsGoContent(), shared: true},
+		{path: filepath.Join("internal", "imports", "imports_test.go"), content: importsTestGoContent(), shared: true},
+		{path: filepath.Join("internal", "repository", m.name+"_batch.go"), content: importBatchGoContent(p.modulePath, m, fields, newID)},
+		{path: filepath.Join("internal", "services", m.name+"_import.go"), content: importServiceGoContent(p.modulePath, m, fields, *batchSize)},
+		{path: filepath.Join("internal", "handlers", "respond.go"), content: respondGoContent(), shared: true},
+		{path: filepath.Join("internal", "handlers", "upload.go"), content: uploadGoContent(), shared: true},
+		{path: filepath.Join("internal", "handlers", m.name+"_import_handler.go"), content: importHandlerGoContent(p.modulePath, m, path)},
+	}, p.routerWiring([]string{"internal/services"}, true, routes, "Register the import route in internal/handlers/router.go: "+routes)...), []string{
+		fmt.Sprintf("Try it: curl -F file=@%s.csv 'localhost:8080/%s/import' (header line: %s)", m.table, path, strings.Join(importColumns(fields), ",")),
+		fmt.Sprintf("Add business rules to parse%sImportRow in internal/services/%s_import.go", m.typeName, m.name),
+	}
+}
+
+// Returns the fields of m filled from an import file: every field but the
+// timestamps, which the database sets
+func importFields(m model) []modelField {
+	var fields []modelField
+	for _, f := range m.fields {
+		switch f.column {
+		case "created_at", "updated_at", "deleted_at":
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// Returns the CSV header of an import
+func importColumns(fields []modelField) []string {
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.column
+	}
+	return columns
+}
+
+// Returns the expression the repository's Create uses to generate IDs, or "" when
+// the database generates them
+func (p project) repositoryNewID(m model) string {
+	path := filepath.Join(p.dir, "internal", "repository", m.name+"_repository.go")
+	f, err := os.Open(path)
+	if err != nil {
+		fatalf(exitFailure, "Failed to read the %s repository: %v", m.name, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if expr, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "m.ID = "); ok {
+			return expr
+		}
+	}
+	return ""
+}
+
+// Returns the imports.Row method reading a field of the given Go type
+func importRowMethod(f modelField) string {
+	switch f.goType {
+	case "string":
+		return "String"
+	case "int64":
+		return "Int"
+	case "float64":
+		return "Float"
+	case "bool":
+		return "Bool"
+	case "time.Time":
+		return "Time"
+	}
+	fatalf(exitUsage, "Field %s has type %s, which cannot be imported (supported: string, int64, float64, bool, time.Time)", f.goName, f.goType)
+	return ""
+}
+
+// Returns the content for internal/imports/imports.go
+func importsGoContent() string {
+	return `package imports
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxReportedErrors caps the errors kept in a Report, so a file that is wrong
+// on every line does not produce an error report larger than itself
+const MaxReportedErrors = 1000
+
+// ErrInvalidFile is returned when a file cannot be imported at all (no header,
+// missing columns)
+var ErrInvalidFile = errors.New("invalid import file")
+
+// RowError is a problem with one row of an import file
+type RowError struct {
+	Line    int    ` + "`" + `json:"line"` + "`" + `
+	Column  string ` + "`" + `json:"column,omitempty"` + "`" + `
+	Value   string ` + "`" + `json:"value,omitempty"` + "`" + `
+	Message string ` + "`" + `json:"message"` + "`" + `
+}
+
+// Report is the outcome of an import
+type Report struct {
+	// Rows is the number of data rows read, Imported the number inserted and
+	// Failed the number rejected
+	Rows     int ` + "`" + `json:"rows"` + "`" + `
+	Imported int ` + "`" + `json:"imported"` + "`" + `
+	Failed   int ` + "`" + `json:"failed"` + "`" + `
+	// Committed is false when the import was rolled back
+	Committed bool       ` + "`" + `json:"committed"` + "`" + `
+	Errors    []RowError ` + "`" + `json:"errors"` + "`" + `
+	// ErrorsTruncated is set when more than MaxReportedErrors errors were found
+	ErrorsTruncated bool ` + "`" + `json:"errors_truncated,omitempty"` + "`" + `
+}
+
+func (r *Report) addErrors(errs []RowError) {
+	for _, e := range errs {
+		if len(r.Errors) >= MaxReportedErrors {
+			r.ErrorsTruncated = true
+			return
+		}
+		r.Errors = append(r.Errors, e)
+	}
+}
+
+// WriteCSV writes the errors of the report as CSV: line, column, value, message
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"line", "column", "value", "message"}); err != nil {
+		return err
+	}
+	for _, e := range r.Errors {
+		if err := cw.Write([]string{strconv.Itoa(e.Line), e.Column, e.Value, e.Message}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Row is one data row of a CSV file. Its getters parse the value of a column
+// and record an error instead of failing, so every problem of the row is reported.
+type Row struct {
+	line    int
+	columns map[string]int
+	record  []string
+	errs    []RowError
+}
+
+// Line returns the line number of the row in the file
+func (r *Row) Line() int { return r.line }
+
+// Failed reports whether any error was recorded for the row
+func (r *Row) Failed() bool { return len(r.errs) > 0 }
+
+// Fail records a validation error for column
+func (r *Row) Fail(column, message string) {
+	r.errs = append(r.errs, RowError{Line: r.line, Column: column, Value: r.value(column), Message: message})
+}
+
+// String returns the value of column, with surrounding spaces removed
+func (r *Row) String(column string) string {
+	return r.value(column)
+}
+
+// Int parses the value of column as an integer
+func (r *Row) Int(column string) int64 {
+	v, ok := r.required(column)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		r.Fail(column, "must be an integer")
+	}
+	return n
+}
+
+// Float parses the value of column as a decimal number
+func (r *Row) Float(column string) float64 {
+	v, ok := r.required(column)
+	if !ok {
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		r.Fail(column, "must be a number")
+	}
+	return f
+}
+
+// Bool parses the value of column as true/false, yes/no or 1/0
+func (r *Row) Bool(column string) bool {
+	v, ok := r.required(column)
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(v) {
+	case "true", "yes", "y", "1":
+		return true
+	case "false", "no", "n", "0":
+		return false
+	}
+	r.Fail(column, "must be true or false")
+	return false
+}
+
+// Time parses the value of column as an RFC 3339 timestamp or a date (2006-01-02)
+func (r *Row) Time(column string) time.Time {
+	v, ok := r.required(column)
+	if !ok {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	r.Fail(column, "must be a date (2006-01-02) or an RFC 3339 timestamp")
+	return time.Time{}
+}
+
+func (r *Row) value(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+func (r *Row) required(column string) (string, bool) {
+	v := r.value(column)
+	if v == "" {
+		r.Fail(column, "is required")
+		return "", false
+	}
+	return v, true
+}
+
+// CSVReader reads a CSV file row by row. The first line is the header; columns
+// are matched by name, in any order, and unknown columns are ignored.
+type CSVReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+// NewCSVReader reads the header of a CSV file and checks that it has every
+// required column
+func NewCSVReader(r io.Reader, required []string) (*CSVReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\uFEFF") // byte order mark written by Excel
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var missing []string
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing columns %s", ErrInvalidFile, strings.Join(missing, ", "))
+	}
+	return &CSVReader{r: cr, columns: columns}, nil
+}
+
+// Next returns the next row, or io.EOF at the end of the file. Malformed lines
+// are returned as rows that already failed.
+func (c *CSVReader) Next() (*Row, error) {
+	record, err := c.r.Read()
+	row := &Row{columns: c.columns, record: record}
+
+	var parseErr *csv.ParseError
+	switch {
+	case err == nil:
+		row.line, _ = c.r.FieldPos(0)
+	case errors.As(err, &parseErr):
+		row.line = parseErr.Line
+		row.errs = append(row.errs, RowError{Line: parseErr.Line, Message: parseErr.Err.Error()})
+	default:
+		return nil, err
+	}
+	return row, nil
+}
+
+// Run reads every row of cr, turning it into an item with parse, and hands the
+// valid items to insert in batches of batchSize. Once a row has failed, later
+// batches are only inserted when partial is set: the remaining rows are still
+// validated so the report lists every error, but a rolled back import does not
+// need them.
+func Run[T any](ctx context.Context, cr *CSVReader, batchSize int, partial bool, parse func(*Row) T, insert func(context.Context, []T) error) (*Report, error) {
+	report := &Report{Errors: []RowError{}}
+	batch := make([]T, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if report.Failed == 0 || partial {
+			if err := insert(ctx, batch); err != nil {
+				return err
+			}
+			report.Imported += len(batch)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		row, err := cr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.Rows++
+
+		var item T
+		if !row.Failed() {
+			item = parse(row)
+		}
+		if row.Failed() {
+			report.Failed++
+			report.addErrors(row.errs)
+			continue
+		}
+		batch = append(batch, item)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+`
+}
+
+// Returns the content for internal/imports/imports_test.go
+func importsTestGoContent() string {
+	return `package imports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type item struct {
+	Name  string
+	Qty   int64
+	Price float64
+	Due   time.Time
+}
+
+func parseItem(row *Row) item {
+	it := item{Name: row.String("name"), Qty: row.Int("qty"), Price: row.Float("price"), Due: row.Time("due")}
+	if it.Price < 0 {
+		row.Fail("price", "must not be negative")
+	}
+	return it
+}
+
+func run(t *testing.T, file string, batchSize int, partial bool) (*Report, [][]item) {
+	t.Helper()
+	cr, err := NewCSVReader(strings.NewReader(file), []string{"name", "qty", "price", "due"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batches [][]item
+	insert := func(ctx context.Context, items []item) error {
+		batches = append(batches, append([]item(nil), items...))
+		return nil
+	}
+	report, err := Run(context.Background(), cr, batchSize, partial, parseItem, insert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report, batches
+}
+
+func TestRunInsertsInBatches(t *testing.T) {
+	file := "\uFEFFName,Qty,Price,Due,ignored\n" +
+		"a,1,1.5,2024-01-02,x\n" +
+		"b,2,2.5,2024-01-03T10:00:00Z,x\n" +
+		"c,3,3.5,2024-01-04,x\n"
+	report, batches := run(t, file, 2, false)
+
+	if report.Rows != 3 || report.Imported != 3 || report.Failed != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("batches = %v", batches)
+	}
+	if batches[0][1].Name != "b" || batches[0][1].Qty != 2 || batches[0][1].Due.Hour() != 10 {
+		t.Errorf("second item = %+v", batches[0][1])
+	}
+}
+
+func TestRunReportsEveryRowError(t *testing.T) {
+	file := "name,qty,price,due\n" +
+		"a,1,1.5,2024-01-02\n" +
+		"b,two,-1,soon\n" +
+		"c,,3.5,2024-01-04\n" +
+		"\"d,4,4.5,2024-01-05\n"
+	report, batches := run(t, file, 10, false)
+
+	if report.Rows != 4 || report.Failed != 3 || report.Imported != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(batches) != 0 {
+		t.Errorf("inserted %v although a row failed", batches)
+	}
+	want := []RowError{
+		{Line: 3, Column: "qty", Value: "two", Message: "must be an integer"},
+		{Line: 3, Column: "due", Value: "soon", Message: "must be a date (2006-01-02) or an RFC 3339 timestamp"},
+		{Line: 3, Column: "price", Value: "-1", Message: "must not be negative"},
+		{Line: 4, Column: "qty", Message: "is required"},
+	}
+	for i, w := range want {
+		if i >= len(report.Errors) || report.Errors[i] != w {
+			t.Errorf("error %d = %+v, want %+v", i, report.Errors, w)
+		}
+	}
+	if last := report.Errors[len(report.Errors)-1]; last.Line != 5 || last.Column != "" {
+		t.Errorf("malformed line error = %+v", last)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "line,column,value,message\n3,qty,two,must be an integer\n") {
+		t.Errorf("CSV report = %q", buf.String())
+	}
+}
+
+func TestRunPartialKeepsValidRows(t *testing.T) {
+	file := "name,qty,price,due\n" +
+		"a,x,1,2024-01-02\n" +
+		"b,2,2,2024-01-02\n" +
+		"c,3,3,2024-01-02\n"
+	report, batches := run(t, file, 1, true)
+
+	if report.Imported != 2 || report.Failed != 1 || len(batches) != 2 {
+		t.Errorf("report = %+v, batches = %v", report, batches)
+	}
+}
+
+func TestNewCSVReaderRejectsInvalidFiles(t *testing.T) {
+	for _, file := range []string{"", "name,qty\na,1\n"} {
+		_, err := NewCSVReader(strings.NewReader(file), []string{"name", "qty", "price"})
+		if !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("NewCSVReader(%q) error = %v, want ErrInvalidFile", file, err)
+		}
+	}
+}
+`
+}
+
+// Returns the content for internal/repository/<name>_batch.go
+func importBatchGoContent(modulePath string, m model, fields []modelField, newID string) string {
+	var columns, values []string
+	if newID != "" {
+		columns = append(columns, "id")
+		values = append(values, "m.ID")
+	}
+	for _, f := range fields {
+		columns = append(columns, f.column)
+		values = append(values, "m."+f.goName)
+	}
+	setID := ""
+	idImport := ""
+	if newID != "" {
+		setID = "\t\tm.ID = " + newID + "\n"
+		idImport = extraImport(m.idImport)
+	}
+
+	return formatGo(fmt.Sprintf(`package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+%[6]s
+	dbmodels "%[1]s/internal/models/db"
+)
+
+// %[2]sBatch inserts %[3]s in batches within a single transaction
+type %[2]sBatch struct {
+	tx *sql.Tx
+}
+
+// BeginBatch starts a transaction for a bulk insert. Call Rollback when done
+// (a no-op after Commit).
+func (r *%[2]sRepository) BeginBatch(ctx context.Context) (*%[2]sBatch, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &%[2]sBatch{tx: tx}, nil
+}
+
+// Insert adds items with a single multi-row INSERT. Generated IDs are not read back.
+func (b *%[2]sBatch) Insert(ctx context.Context, items []dbmodels.%[2]s) error {
+	const columns = %[4]d
+	var query strings.Builder
+	query.WriteString("INSERT INTO %[3]s (%[5]s) VALUES ")
+	args := make([]any, 0, len(items)*columns)
+	for i := range items {
+		m := &items[i]
+%[7]s		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for j := 1; j <= columns; j++ {
+			if j > 1 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%%d", i*columns+j)
+		}
+		query.WriteString(")")
+		args = append(args, %[8]s)
+	}
+	_, err := b.tx.ExecContext(ctx, query.String(), args...)
+	return err
+}
+
+// Commit makes the inserted rows visible
+func (b *%[2]sBatch) Commit() error {
+	return b.tx.Commit()
+}
+
+// Rollback discards the inserted rows unless the batch was committed
+func (b *%[2]sBatch) Rollback() error {
+	err := b.tx.Rollback()
+	if err == sql.ErrTxDone {
+		return nil
+	}
+	return err
+}
+`, modulePath, m.typeName, m.table, len(columns), strings.Join(columns, ", "), idImport, setID, strings.Join(values, ", ")))
+}
+
+// Returns the content for internal/services/<name>_import.go
+func importServiceGoContent(modulePath string, m model, fields []modelField, batchSize int) string {
+	var assign, quoted strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&assign, "\t\t%s: row.%s(%q),\n", f.goName, importRowMethod(f), f.column)
+		fmt.Fprintf(&quoted, "%q, ", f.column)
+	}
+	lowerType := camelCase(m.name)
+
+	return formatGo(fmt.Sprintf(`package services
+
+import (
+	"context"
+	"io"
+
+	dbmodels "%[1]s/internal/models/db"
+	"%[1]s/internal/imports"
+)
+
+// %[6]sImportBatchSize is the number of %[3]s inserted per statement
+const %[6]sImportBatchSize = %[7]d
+
+// %[6]sImportColumns are the columns an import file must have
+var %[6]sImportColumns = []string{%[5]s}
+
+// ImportCSV imports %[3]s from a CSV file with a header line, streaming it row
+// by row. Valid rows are inserted in batches inside one transaction, which is
+// only committed when every row is valid, or when partial is set.
+func (s *%[2]sService) ImportCSV(ctx context.Context, r io.Reader, partial bool) (*imports.Report, error) {
+	cr, err := imports.NewCSVReader(r, %[6]sImportColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.repo.BeginBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer batch.Rollback()
+
+	report, err := imports.Run(ctx, cr, %[6]sImportBatchSize, partial, parse%[2]sImportRow, batch.Insert)
+	if err != nil {
+		return nil, err
+	}
+	if report.Failed > 0 && !partial {
+		report.Imported = 0
+		return report, nil
+	}
+	if err := batch.Commit(); err != nil {
+		return nil, err
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// Builds %[4]s from a row of an import file. Business rules go here: reject a
+// value with row.Fail(column, message) and the row is reported instead of inserted.
+func parse%[2]sImportRow(row *imports.Row) dbmodels.%[2]s {
+	return dbmodels.%[2]s{
+%[8]s	}
+}
+`, modulePath, m.typeName, humanize(m.table), withArticle(humanize(m.name)), strings.TrimSuffix(quoted.String(), ", "), lowerType, batchSize, assign.String()))
+}
+
+// Returns the content for internal/handlers/<name>_import_handler.go
+func importHandlerGoContent(modulePath string, m model, path string) string {
+	return formatGo(fmt.Sprintf(`package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"%[1]s/internal/imports"
+	"%[1]s/internal/services"
+)
+
+// Largest accepted %[3]s import file
+const max%[2]sImportSize = 64 << 20
+
+// %[2]sImportHandler accepts bulk imports of %[3]s
+type %[2]sImportHandler struct {
+	svc *services.%[2]sService
+}
+
+// New%[2]sImportHandler creates a new %[2]sImportHandler
+func New%[2]sImportHandler(svc *services.%[2]sService) *%[2]sImportHandler {
+	return &%[2]sImportHandler{svc: svc}
+}
+
+// Register mounts the import route on mux: POST /%[4]s/import with the CSV file in
+// the "file" field of a multipart/form-data body. ?partial=true keeps the valid
+// rows when some fail; ?report=csv returns the errors as a CSV file instead of JSON.
+func (h *%[2]sImportHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /%[4]s/import", h.importCSV)
+}
+
+func (h *%[2]sImportHandler) importCSV(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, max%[2]sImportSize)
+	file, err := uploadedFile(r, "file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	partial, _ := strconv.ParseBool(r.URL.Query().Get("partial"))
+	report, err := h.svc.ImportCSV(r.Context(), file, partial)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, imports.ErrInvalidFile):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.As(err, &tooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, "file too large")
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to import %[3]s")
+		return
+	}
+
+	status := http.StatusOK
+	if !report.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+	if r.URL.Query().Get("report") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `+"`"+`attachment; filename="%[4]s-import-errors.csv"`+"`"+`)
+		w.WriteHeader(status)
+		report.WriteCSV(w)
+		return
+	}
+	writeJSON(w, status, report)
+}
+`, modulePath, m.typeName, humanize(m.table), path))
+}
+
+// Returns the content for internal/handlers/upload.go
+func uploadGoContent() string {
+	return `package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Returns the first file part named field of a multipart/form-data body. The
+// part is read straight from the request, without buffering the upload in
+// memory or on disk, so it must be consumed before reading other parts.
+func uploadedFile(r *http.Request, field string) (io.ReadCloser, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("expected a multipart/form-data upload")
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no file uploaded in the %q field", field)
+		}
+		if err != nil {
+			return nil, errors.New("invalid multipart body")
+		}
+		if part.FormName() == field && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+`
+}
@@ -0,0 +1,493 @@
+package main
+
+import "fmt"
+
+// Returns the Makefile targets building and deploying the Lambda functions
+func lambdaMakefileContent() string {
+	return `
+LAMBDA_FUNCTIONS := api sqs
+LAMBDA_DIR := $(BIN_DIR)/lambda
+
+.PHONY: lambda-build sam-local sam-deploy
+
+# One deployable zip per function: the bootstrap binary and the config files
+lambda-build:
+	@for fn in $(LAMBDA_FUNCTIONS); do \
+		echo "building lambda $$fn"; \
+		rm -rf $(LAMBDA_DIR)/$$fn && mkdir -p $(LAMBDA_DIR)/$$fn || exit 1; \
+		GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build -tags lambda.norpc -ldflags "$(LDFLAGS)" \
+			-o $(LAMBDA_DIR)/$$fn/bootstrap ./cmd/lambda-$$fn || exit 1; \
+		cp -r configs $(LAMBDA_DIR)/$$fn/ || exit 1; \
+		(cd $(LAMBDA_DIR)/$$fn && rm -f ../$$fn.zip && zip -qr ../$$fn.zip bootstrap configs) || exit 1; \
+	done
+
+sam-local: lambda-build
+	sam local start-api
+
+sam-deploy: lambda-build
+	sam deploy --guided
+`
+}
+
+// Returns the content for template.yaml, the AWS SAM template of the Lambda functions
+func samTemplateContent(projectName string) string {
+	return fmt.Sprintf(`AWSTemplateFormatVersion: "2010-09-09"
+Transform: AWS::Serverless-2016-10-31
+Description: %[1]s on AWS Lambda
+
+Parameters:
+  AppEnv:
+    Type: String
+    Default: prod
+    AllowedValues: [dev, staging, prod]
+
+Globals:
+  Function:
+    Runtime: provided.al2023
+    Architectures: [arm64]
+    Handler: bootstrap
+    MemorySize: 256
+    Timeout: 30
+    Environment:
+      Variables:
+        APP_ENV: !Ref AppEnv
+        # Only /tmp is writable on Lambda; logs also go to stdout, i.e. CloudWatch
+        LOG_FILE: /tmp/%[1]s.log
+
+Resources:
+  # The HTTP API, served by the same router as the server binary
+  ApiFunction:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: bin/lambda/api.zip
+      Events:
+        Http:
+          Type: HttpApi
+
+  # Event handler consuming the queue, reporting failed messages individually
+  SQSFunction:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: bin/lambda/sqs.zip
+      Events:
+        Queue:
+          Type: SQS
+          Properties:
+            Queue: !GetAtt Queue.Arn
+            BatchSize: 10
+            FunctionResponseTypes: [ReportBatchItemFailures]
+
+  Queue:
+    Type: AWS::SQS::Queue
+    Properties:
+      # At least six times the function timeout, as AWS recommends
+      VisibilityTimeout: 180
+      RedrivePolicy:
+        deadLetterTargetArn: !GetAtt DeadLetterQueue.Arn
+        maxReceiveCount: 5
+
+  DeadLetterQueue:
+    Type: AWS::SQS::Queue
+
+Outputs:
+  ApiURL:
+    Value: !Sub "https://${ServerlessHttpApi}.execute-api.${AWS::Region}.${AWS::URLSuffix}/"
+  QueueURL:
+    Value: !Ref Queue
+`, projectName)
+}
+
+// Returns the content for cmd/lambda-api/main.go
+func lambdaAPIMainGoContent(projectName, cache string) string {
+	imports, router, setup := routerSetup(projectName, cache, "")
+
+	return formatGo(fmt.Sprintf(`package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"%[1]s/internal/handlers"
+	"%[1]s/internal/lambdahttp"
+%[2]s	"%[1]s/pkg/config"
+	"%[1]s/pkg/logger"
+)
+
+// Build information, injected at build time through -ldflags (see Makefile)
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// Serves the HTTP API from AWS Lambda, behind an API Gateway HTTP API or a function URL
+func main() {
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Initialize logger
+	appLog, err := logger.NewLogger(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %%v", err)
+	}
+
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		appLog.Warn().Err(err).Msg("Invalid log level, keeping the default")
+	}
+
+	appLog.Info().
+		Str("version", version).
+		Str("commit", commit).
+		Str("date", date).
+		Msg("Starting the Lambda function")
+
+%[3]s	lambda.Start(lambdahttp.Handler(%[4]s))
+}
+`, projectName, imports, setup, router))
+}
+
+// Returns the content for cmd/lambda-sqs/main.go
+func lambdaSQSMainGoContent(projectName string) string {
+	return fmt.Sprintf(`package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"%[1]s/internal/consumers"
+	"%[1]s/pkg/config"
+	"%[1]s/pkg/logger"
+)
+
+// Build information, injected at build time through -ldflags (see Makefile)
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// Consumes SQS messages from AWS Lambda
+func main() {
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Initialize logger
+	appLog, err := logger.NewLogger(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %%v", err)
+	}
+
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		appLog.Warn().Err(err).Msg("Invalid log level, keeping the default")
+	}
+
+	appLog.Info().
+		Str("version", version).
+		Str("commit", commit).
+		Str("date", date).
+		Msg("Starting the Lambda function")
+
+	lambda.Start(consumers.HandleSQS(appLog))
+}
+`, projectName)
+}
+
+// Returns the content for internal/lambdahttp/adapter.go
+func lambdaHTTPAdapterGoContent() string {
+	return `package lambdahttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Handler adapts an http.Handler to API Gateway HTTP API events (payload format
+// 2.0), which Lambda function URLs use as well. Responses are buffered: API
+// Gateway does not stream and caps them at 6 MB.
+func Handler(h http.Handler) func(ctx context.Context, e events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, e events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		r, err := NewRequest(ctx, e)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{}, err
+		}
+		w := &responseWriter{header: http.Header{}}
+		h.ServeHTTP(w, r)
+		return w.response(), nil
+	}
+}
+
+// NewRequest converts an API Gateway HTTP API event to an *http.Request
+func NewRequest(ctx context.Context, e events.APIGatewayV2HTTPRequest) (*http.Request, error) {
+	body := []byte(e.Body)
+	if e.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(e.Body); err != nil {
+			return nil, fmt.Errorf("decode request body: %w", err)
+		}
+	}
+
+	target := e.RawPath
+	if target == "" {
+		target = "/"
+	}
+	if e.RawQueryString != "" {
+		target += "?" + e.RawQueryString
+	}
+	r, err := http.NewRequestWithContext(ctx, e.RequestContext.HTTP.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Payload 2.0 joins repeated headers with commas and moves cookies out of the headers
+	for k, v := range e.Headers {
+		r.Header.Set(k, v)
+	}
+	if len(e.Cookies) > 0 {
+		r.Header.Set("Cookie", strings.Join(e.Cookies, "; "))
+	}
+	r.Host = e.RequestContext.DomainName
+	r.RemoteAddr = e.RequestContext.HTTP.SourceIP
+	r.RequestURI = target
+	return r, nil
+}
+
+// responseWriter buffers the response of the handler
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header { return w.header }
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// Converts the buffered response to an API Gateway response, base64-encoding
+// bodies that are not text
+func (w *responseWriter) response() events.APIGatewayV2HTTPResponse {
+	w.WriteHeader(http.StatusOK)
+	if w.header.Get("Content-Type") == "" && w.body.Len() > 0 {
+		w.header.Set("Content-Type", http.DetectContentType(w.body.Bytes()))
+	}
+
+	resp := events.APIGatewayV2HTTPResponse{
+		StatusCode: w.status,
+		Headers:    make(map[string]string, len(w.header)),
+		Cookies:    w.header.Values("Set-Cookie"),
+	}
+	for k, v := range w.header {
+		if k != "Set-Cookie" {
+			resp.Headers[k] = strings.Join(v, ",")
+		}
+	}
+	if isText(w.header.Get("Content-Type")) {
+		resp.Body = w.body.String()
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		resp.IsBase64Encoded = true
+	}
+	return resp
+}
+
+func isText(contentType string) bool {
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	mediaType = strings.TrimSpace(mediaType)
+	if mediaType == "" || strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	for _, s := range []string{"json", "xml", "javascript", "x-www-form-urlencoded"} {
+		if strings.Contains(mediaType, s) {
+			return true
+		}
+	}
+	return false
+}
+`
+}
+
+// Returns the content for internal/lambdahttp/adapter_test.go
+func lambdaHTTPAdapterTestGoContent() string {
+	return `package lambdahttp
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func testEvent(method, path, query string) events.APIGatewayV2HTTPRequest {
+	e := events.APIGatewayV2HTTPRequest{
+		Version:        "2.0",
+		RawPath:        path,
+		RawQueryString: query,
+		Headers:        map[string]string{"x-request-id": "abc"},
+		Cookies:        []string{"a=1", "b=2"},
+	}
+	e.RequestContext.DomainName = "api.example.com"
+	e.RequestContext.HTTP.Method = method
+	e.RequestContext.HTTP.SourceIP = "203.0.113.7"
+	return e
+}
+
+func TestHandlerRoutesRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		cookie, _ := r.Cookie("b")
+		http.SetCookie(w, &http.Cookie{Name: "seen", Value: "1"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(` + "`" + `{"id":"` + "`" + ` + r.PathValue("id") + ` + "`" + `","q":"` + "`" + ` + r.URL.Query().Get("q") +
+			` + "`" + `","request_id":"` + "`" + ` + r.Header.Get("X-Request-Id") + ` + "`" + `","cookie":"` + "`" + ` + cookie.Value +
+			` + "`" + `","host":"` + "`" + ` + r.Host + ` + "`" + `"}` + "`" + `))
+	})
+
+	resp, err := Handler(mux)(context.Background(), testEvent("GET", "/items/42", "q=go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.IsBase64Encoded {
+		t.Fatalf("status = %d, base64 = %v", resp.StatusCode, resp.IsBase64Encoded)
+	}
+	want := ` + "`" + `{"id":"42","q":"go","request_id":"abc","cookie":"2","host":"api.example.com"}` + "`" + `
+	if resp.Body != want {
+		t.Errorf("body = %s, want %s", resp.Body, want)
+	}
+	if len(resp.Cookies) != 1 || resp.Cookies[0] != "seen=1" {
+		t.Errorf("cookies = %v", resp.Cookies)
+	}
+}
+
+func TestHandlerEncodesBinaryBodies(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00")
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+
+	e := testEvent("POST", "/echo", "")
+	e.Body = base64.StdEncoding.EncodeToString(png)
+	e.IsBase64Encoded = true
+	resp, err := Handler(mux)(context.Background(), e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusCreated || !resp.IsBase64Encoded {
+		t.Fatalf("status = %d, base64 = %v", resp.StatusCode, resp.IsBase64Encoded)
+	}
+	if got, _ := base64.StdEncoding.DecodeString(resp.Body); string(got) != string(png) {
+		t.Errorf("body = %q, want %q", got, png)
+	}
+	if ct := resp.Headers["Content-Type"]; ct != "image/png" {
+		t.Errorf("Content-Type = %q, want the detected image/png", ct)
+	}
+}
+
+func TestHandlerReturnsNotFound(t *testing.T) {
+	resp, err := Handler(http.NewServeMux())(context.Background(), testEvent("GET", "/missing", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+`
+}
+
+// Returns the content for internal/consumers/sqs.go
+func lambdaSQSConsumerGoContent() string {
+	return `package consumers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rs/zerolog"
+)
+
+// Message is the payload expected in the body of the SQS messages
+type Message struct {
+	Type string          ` + "`" + `json:"type"` + "`" + `
+	Data json.RawMessage ` + "`" + `json:"data"` + "`" + `
+}
+
+// HandleSQS returns the Lambda handler of an SQS event source. Failed messages
+// are reported individually (ReportBatchItemFailures), so only they are retried
+// and eventually moved to the dead-letter queue.
+func HandleSQS(logger *zerolog.Logger) func(ctx context.Context, e events.SQSEvent) (events.SQSEventResponse, error) {
+	return func(ctx context.Context, e events.SQSEvent) (events.SQSEventResponse, error) {
+		var resp events.SQSEventResponse
+		for _, m := range e.Records {
+			if err := handleMessage(ctx, m); err != nil {
+				logger.Error().Err(err).Str("message_id", m.MessageId).Msg("Failed to handle message")
+				resp.BatchItemFailures = append(resp.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: m.MessageId})
+			}
+		}
+		return resp, nil
+	}
+}
+
+func handleMessage(ctx context.Context, m events.SQSMessage) error {
+	var msg Message
+	if err := json.Unmarshal([]byte(m.Body), &msg); err != nil {
+		return err
+	}
+	// TODO: dispatch on msg.Type to the services. Messages can be delivered more
+	// than once, so handling them must be idempotent.
+	return nil
+}
+`
+}
+
+// Returns the content for internal/consumers/sqs_test.go
+func lambdaSQSConsumerTestGoContent() string {
+	return `package consumers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rs/zerolog"
+)
+
+func TestHandleSQSReportsFailedMessages(t *testing.T) {
+	logger := zerolog.Nop()
+	e := events.SQSEvent{Records: []events.SQSMessage{
+		{MessageId: "1", Body: ` + "`" + `{"type":"example","data":{}}` + "`" + `},
+		{MessageId: "2", Body: "not json"},
+	}}
+
+	resp, err := HandleSQS(&logger)(context.Background(), e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.BatchItemFailures) != 1 || resp.BatchItemFailures[0].ItemIdentifier != "2" {
+		t.Errorf("failures = %+v, want only message 2", resp.BatchItemFailures)
+	}
+}
+`
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// lintFinding is the output of a linter that reported issues in a generated
+// project
+type lintFinding struct {
+	tool   string
+	output string
+}
+
+// Runs go vet and golangci-lint (when installed) on a copy of the project
+// generated in dir, as the module projectName if it has no go.mod yet, leaving
+// the project itself untouched. Returns their findings, and the notes on the
+// checks that could not run. Dependencies go mod tidy cannot resolve are a
+// finding, as nothing could be linted.
+func lintProject(dir, projectName string) (findings []lintFinding, notes []string) {
+	tmp, err := os.MkdirTemp("", "gogo-lint-")
+	if err != nil {
+		fatalf(exitFailure, "Failed to create a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	copyDir := filepath.Join(tmp, projectName)
+	if err := copyProject(dir, copyDir); err != nil {
+		fatalf(exitFailure, "Failed to copy the project to lint it: %v", err)
+	}
+
+	// Paths of the findings are given in the project rather than in the copy
+	run := func(name string, args ...string) (string, error) {
+		cmd := exec.Command(name, args...)
+		cmd.Dir = copyDir
+		p := startProgress(0, "Running %s", strings.Join(cmd.Args, " "))
+		out, err := runCombined(cmd)
+		p.finish(err == nil)
+		return strings.TrimSpace(strings.ReplaceAll(string(out), copyDir, dir)), err
+	}
+
+	if _, err := os.Stat(filepath.Join(copyDir, "go.mod")); err != nil {
+		if out, err := run("go", "mod", "init", projectName); err != nil {
+			return []lintFinding{{"go mod init", out}}, nil
+		}
+	}
+	if out, err := run("go", "mod", "tidy"); err != nil {
+		return []lintFinding{{"go mod tidy", out}}, nil
+	}
+	if out, err := run("go", "vet", "./..."); err != nil {
+		findings = append(findings, lintFinding{"go vet", out})
+	}
+	if _, err := exec.LookPath("golangci-lint"); err != nil {
+		notes = append(notes, "golangci-lint is not installed, only go vet ran (see https://golangci-lint.run/welcome/install/)")
+	} else if out, err := run("golangci-lint", "run", "./..."); err != nil {
+		findings = append(findings, lintFinding{"golangci-lint", out})
+	}
+	return findings, notes
+}
+
+// Lints the project generated in dir like lintProject and prints the outcome.
+// Findings stop gogo with exitTemplate when mode is "fail", as the templates
+// produced them, and are warnings otherwise.
+func reportLint(dir, projectName, mode string) {
+	fmt.Println("\n" + tr("Linting the generated project..."))
+	findings, notes := lintProject(dir, projectName)
+	for _, note := range notes {
+		printStyled(styleYellow, "Warning: %s\n", note)
+	}
+	if len(findings) == 0 {
+		if len(notes) == 0 {
+			fmt.Println(tr("go vet and golangci-lint found no issues."))
+		}
+		return
+	}
+
+	var tools []string
+	for _, f := range findings {
+		fmt.Printf("\n%s:\n%s\n", f.tool, f.output)
+		tools = append(tools, f.tool)
+	}
+	if mode == "fail" {
+		fatalf(exitTemplate, "%s reported issues in the generated code", strings.Join(tools, tr(" and ")))
+	}
+	fmt.Println()
+	printStyled(styleYellow, "Warning: %s reported issues in the generated code\n", strings.Join(tools, tr(" and ")))
+}
+
+// Copies the project in src to dst, except its Git repository, keeping the modes
+// of the files and the symlinks as they are
+func copyProject(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Name of the file recording the components added to a project
+const manifestFileName = ".gogo.json"
+
+// manifest records how the project was generated, to reproduce it, and every
+// component added with gogo add, so gogo remove can find the files it generated
+// and the edits it made
+type manifest struct {
+	Generated   *manifestGeneration `json:"generated,omitempty"`
+	Components  []manifestEntry     `json:"components"`
+	Inflections map[string]string   `json:"inflections,omitempty"` // irregular plurals of the project, by singular
+}
+
+// manifestGeneration is the gogo run that generated the project
+type manifestGeneration struct {
+	Command string    `json:"command"`           // see reproducibleCommand
+	Version string    `json:"version,omitempty"` // of gogo, "" for development builds
+	At      time.Time `json:"at"`
+}
+
+// manifestEntry is one gogo add run
+type manifestEntry struct {
+	Component string         `json:"component"`
+	Args      []string       `json:"args,omitempty"`
+	AddedAt   time.Time      `json:"added_at"`
+	Files     []manifestFile `json:"files"`
+	Uses      []string       `json:"uses,omitempty"` // shared files that existed already
+	Edits     []manifestEdit `json:"edits,omitempty"`
+}
+
+// manifestFile is a file of a component, with the hash of its generated content
+// to tell whether it was edited since
+type manifestFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Shared bool   `json:"shared,omitempty"`
+}
+
+// manifestEdit is a block inserted at a marker of an existing file, between
+// gogo:begin and gogo:end comments naming ID
+type manifestEdit struct {
+	Path string `json:"path"`
+	ID   string `json:"id"`
+}
+
+// Returns the name identifying the component instance: its first positional
+// argument (e.g. the resource of gogo add import product), if any
+func (e manifestEntry) target() string {
+	for _, arg := range e.Args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
+
+// Returns the ID of the entry used in markers and messages, e.g. "resource product"
+func (e manifestEntry) id() string {
+	return strings.TrimSpace(e.Component + " " + e.target())
+}
+
+// Reads the manifest of the project, empty if no component was added yet
+func (p project) loadManifest() manifest {
+	var m manifest
+	data, err := os.ReadFile(filepath.Join(p.dir, manifestFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m
+	}
+	if err != nil {
+		fatalf(exitFailure, "Failed to read %s: %v", manifestFileName, err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		fatalf(exitFailure, "Failed to parse %s: %v", manifestFileName, err)
+	}
+	return m
+}
+
+// Writes the manifest of the project
+func (p project) saveManifest(m manifest) {
+	// An empty list rather than null, once there is no component
+	if m.Components == nil {
+		m.Components = []manifestEntry{}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		fatalf(exitFailure, "Failed to encode %s: %v", manifestFileName, err)
+	}
+	debugf("write %s (%d bytes)", filepath.Join(p.dir, manifestFileName), len(data)+1)
+	if err := os.WriteFile(filepath.Join(p.dir, manifestFileName), append(data, '\n'), 0644); err != nil {
+		fatalf(exitFailure, "Failed to write %s: %v", manifestFileName, err)
+	}
+}
+
+// Records a gogo add run in the manifest: the files it created, the shared
+// files it reused and the blocks it inserted at markers
+func (p project) recordComponent(component string, args []string, created []generatedFile, reused []string, edits []manifestEdit) {
+	entry := manifestEntry{Component: component, Args: args, AddedAt: time.Now().UTC().Truncate(time.Second), Edits: edits}
+	for _, f := range created {
+		entry.Files = append(entry.Files, manifestFile{Path: filepath.ToSlash(f.path), SHA256: contentHash([]byte(f.content)), Shared: f.shared})
+	}
+	for _, path := range reused {
+		entry.Uses = append(entry.Uses, filepath.ToSlash(path))
+	}
+	m := p.loadManifest()
+	m.Components = append(m.Components, entry)
+	p.saveManifest(m)
+}
+
+// Returns the hex SHA-256 of content
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
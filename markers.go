@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Markers are comments such as `// gogo:routes` that generated files carry where
+// gogo add inserts code. Every insertion is wrapped in gogo:begin and gogo:end
+// comments naming its owner, so gogo remove can take it out again. Go files are
+// edited through their syntax tree (see insertGo).
+const (
+	markerImports   = "imports"   // import declaration of a Go file, which needs no comment
+	markerProviders = "providers" // where the dependencies of the handlers are created
+	markerRoutes    = "routes"    // where the handlers register their routes
+	markerModules   = "modules"   // where the modules of a modular monolith are registered
+	markerServices  = "services"  // end of the services of docker-compose.yml
+	markerVolumes   = "volumes"   // end of the volumes of docker-compose.yml
+	markerEnd       = "end"       // end of a text file, which needs no comment
+)
+
+// Path of the router, which carries the providers and routes markers
+var routerPath = filepath.Join("internal", "handlers", "router.go")
+
+// Inserts code before the line of marker in content, wrapped in begin and end
+// comments for id and indented like the marker. Reports false when content has
+// no such marker; inserting a block that is already there changes nothing.
+func insertAtMarker(content, marker, id, code string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		prefix, found := strings.CutSuffix(trimmed, "gogo:"+marker)
+		if !found || (prefix != "// " && prefix != "# ") {
+			continue
+		}
+		if strings.Contains(content, "gogo:begin "+id+"\n") {
+			return content, true
+		}
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		block := []string{indent + prefix + "gogo:begin " + id}
+		for _, l := range strings.Split(strings.TrimRight(code, "\n"), "\n") {
+			if l == "" {
+				block = append(block, "")
+				continue
+			}
+			block = append(block, indent+l)
+		}
+		block = append(block, indent+prefix+"gogo:end "+id)
+
+		out := append(append(append([]string{}, lines[:i]...), block...), lines[i:]...)
+		return strings.Join(out, "\n"), true
+	}
+	return content, false
+}
+
+// Reports whether line is a marker comment ending with marker (so "gogo:end resource product"
+// does not match "gogo:end resource product_image")
+func markerIs(line, marker string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasSuffix(line, marker) && strings.Contains(line, "gogo:")
+}
+
+// Returns the insertions wiring a component into the router: the imports of the
+// module's packages it needs, the database connection pool when it uses one, and
+// its route registration. step is printed instead when the router has no markers.
+func (p project) routerWiring(packages []string, database bool, routes, step string) []generatedFile {
+	var files []generatedFile
+	if database {
+		packages = append(packages, "internal/repository")
+		db, open := repositoryDBGoContent(p.modulePath), "repository.Open(cfg)"
+		if p.minimal {
+			db, open = minimalDBGoContent(), "repository.Open()"
+		}
+		files = append(files,
+			generatedFile{path: filepath.Join("internal", "repository", "db.go"), content: db, shared: true},
+			generatedFile{path: routerPath, marker: markerProviders, shared: true, id: "database", content: `db, err := ` + open + `
+if err != nil {
+	logger.Fatal().Err(err).Msg("Failed to open the database")
+}
+`},
+		)
+	}
+	for _, pkg := range packages {
+		files = append(files, generatedFile{path: routerPath, marker: markerImports, content: fmt.Sprintf("%q", p.modulePath+"/"+pkg)})
+	}
+	return append(files, generatedFile{path: routerPath, marker: markerRoutes, content: routes, step: step})
+}
+
+// Returns the content for internal/repository/db.go
+func repositoryDBGoContent(modulePath string) string {
+	return fmt.Sprintf(`package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"%s/pkg/config"
+)
+
+// Open returns the connection pool of the Postgres database configured in cfg.
+// Connections are opened on first use.
+func Open(cfg *config.Config) (*sql.DB, error) {
+	// Keyword/value DSN, so DB_HOST can also be a unix socket directory
+	dsn := fmt.Sprintf("host=%%s port=%%d user=%%s password=%%s dbname=%%s",
+		quoteDSN(cfg.DBHost), cfg.DBPort, quoteDSN(cfg.DBUser), quoteDSN(cfg.DBPassword), quoteDSN(cfg.DBName))
+	return sql.Open("pgx", dsn)
+}
+
+// quoteDSN quotes a value of a keyword/value DSN
+func quoteDSN(v string) string {
+	return "'" + strings.NewReplacer(`+"`\\`, `\\\\`, `'`, `\\'`"+`).Replace(v) + "'"
+}
+`, modulePath)
+}
+
+// Returns the content for internal/repository/db.go of a minimal project, whose
+// config has no database settings
+func minimalDBGoContent() string {
+	return `package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Open returns the connection pool of the Postgres database given by the DB_HOST,
+// DB_PORT, DB_USER, DB_PASSWORD and DB_NAME environment variables. Connections
+// are opened on first use.
+func Open() (*sql.DB, error) {
+	// Keyword/value DSN, so DB_HOST can also be a unix socket directory
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s",
+		quoteDSN(getenv("DB_HOST", "localhost")), quoteDSN(getenv("DB_PORT", "5432")), quoteDSN(getenv("DB_USER", "root")),
+		quoteDSN(os.Getenv("DB_PASSWORD")), quoteDSN(getenv("DB_NAME", "mydatabase")))
+	return sql.Open("pgx", dsn)
+}
+
+// getenv returns the environment variable key, or def when it is empty
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// quoteDSN quotes a value of a keyword/value DSN
+func quoteDSN(v string) string {
+	return "'" + strings.NewReplacer(` + "`\\`, `\\\\`, `'`, `\\'`" + `).Replace(v) + "'"
+}
+`
+}
+
+// Inserts code into a text file like insertAtMarker, after dropping what the file
+// already has: .gitignore entries, Makefile rules whose target is defined and
+// docker-compose services or volumes already declared. Returns the dropped
+// Makefile targets and compose services, whose existing definition is kept.
+func mergeText(path, content, marker, id, code string) (string, bool, []string) {
+	if strings.Contains(content, "gogo:begin "+id+"\n") {
+		return content, true, nil
+	}
+
+	var kept []string
+	var skipped []string
+	switch name := filepath.Base(path); {
+	case name == ".gitignore":
+		existing := map[string]bool{}
+		for _, line := range strings.Split(content, "\n") {
+			existing[strings.TrimSpace(line)] = true
+		}
+		for _, line := range strings.Split(code, "\n") {
+			if entry := strings.TrimSpace(line); entry == "" || strings.HasPrefix(entry, "#") || !existing[entry] {
+				kept = append(kept, line)
+			}
+		}
+		if len(strings.TrimSpace(stripComments(kept))) == 0 {
+			return content, true, nil
+		}
+	case name == "Makefile" || strings.HasPrefix(name, "docker-compose"):
+		// Rules and services start at the first column and go on with indented lines
+		defined := map[string]bool{}
+		indent := ""
+		if name != "Makefile" {
+			indent = "  "
+		}
+		for _, line := range strings.Split(content, "\n") {
+			if key, ok := blockKey(strings.TrimPrefix(line, indent)); ok && (indent == "" || strings.HasPrefix(line, indent) && !strings.HasPrefix(line, indent+" ")) {
+				defined[key] = true
+			}
+		}
+		skipping := false
+		for _, line := range strings.Split(code, "\n") {
+			if key, ok := blockKey(line); ok {
+				skipping = defined[key]
+				if skipping {
+					skipped = append(skipped, key)
+				}
+			}
+			if !skipping {
+				kept = append(kept, line)
+			}
+		}
+		if len(strings.TrimSpace(stripComments(kept))) == 0 {
+			return content, true, skipped
+		}
+	default:
+		kept = strings.Split(code, "\n")
+	}
+	code = strings.Join(kept, "\n")
+
+	if marker != markerEnd {
+		out, ok := insertAtMarker(content, marker, id, code)
+		return out, ok, skipped
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "# gogo:begin " + id + "\n" + strings.TrimRight(code, "\n") + "\n# gogo:end " + id + "\n", true, skipped
+}
+
+// Returns the name a Makefile rule or YAML key line defines, e.g. lint for "lint: fmt"
+func blockKey(line string) (string, bool) {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '.' {
+		return "", false
+	}
+	key, _, ok := strings.Cut(line, ":")
+	if !ok || strings.ContainsAny(key, " =$") {
+		return "", false
+	}
+	return key, true
+}
+
+// Joins lines, leaving out comments
+func stripComments(lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Generation flags gogo matrix can combine, in the order they vary in the report
+var matrixDimensions = []string{
+	"archetype", "layout", "config-format", "db-topology", "cache", "workflow", "contract-tests", "release",
+	"target", "deploy", "iac", "changelog", "context-first", "static",
+	"pprof", "profiling", "errors", "with",
+}
+
+// matrixReport is the JSON report written by gogo matrix
+type matrixReport struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Dimensions  map[string][]string `json:"dimensions"`
+	Summary     map[string]int      `json:"summary"` // cases per status
+	Results     []caseResult        `json:"results"`
+}
+
+// Runs `gogo matrix --archetype=api,nats --cache=none,redis ...`: generates the
+// projects of every combination of the given flag values, compiles and vets them,
+// and writes a JSON report of which compile
+func runMatrix(args []string) {
+	fs := flag.NewFlagSet("matrix", flag.ExitOnError)
+	values := map[string]*string{}
+	for _, name := range matrixDimensions {
+		values[name] = fs.String(name, "", "comma-separated values of --"+name+` to combine ("none" omits the flag)`)
+	}
+	add := fs.String("add", "", "components added to every project, separated by semicolons (e.g. \"resource product name:string;audit\")")
+	reportPath := fs.String("report", "gogo-matrix.json", "path of the JSON report")
+	keep := fs.Bool("keep", false, "keep the generated projects")
+	parallel := fs.Int("parallel", runtime.NumCPU(), "number of combinations run at once")
+	parseFlags(fs, args)
+
+	dimensions := map[string][]string{}
+	for _, name := range matrixDimensions {
+		if *values[name] != "" {
+			dimensions[name] = strings.Split(*values[name], ",")
+		}
+	}
+	if len(dimensions) == 0 {
+		fatalf(exitUsage, "Please provide the values to combine, e.g. gogo matrix --archetype=api,nats --config-format=env,yaml (flags: --%s)", strings.Join(matrixDimensions, ", --"))
+	}
+	var components [][]string
+	for _, component := range strings.Split(*add, ";") {
+		if fields := strings.Fields(component); len(fields) > 0 {
+			components = append(components, fields)
+		}
+	}
+
+	cases := matrixCases(dimensions, components)
+	exe, err := os.Executable()
+	if err != nil {
+		fatalf(exitFailure, "Failed to locate the gogo binary: %v", err)
+	}
+	root, err := os.MkdirTemp("", "gogo-matrix-")
+	if err != nil {
+		fatalf(exitFailure, "Failed to create a temporary directory: %v", err)
+	}
+	if !*keep {
+		defer os.RemoveAll(root)
+	}
+
+	p := startProgress(len(cases), "Running %d combinations", len(cases))
+	results := map[string]caseResult{}
+	runCases(exe, root, cases, *parallel, func(r caseResult) {
+		results[r.Name] = r
+		p.above(func() { fmt.Printf("%-8s %s %s\n", r.Status, r.Name, strings.Join(r.Flags, " ")) })
+		p.step()
+	})
+
+	report := matrixReport{GeneratedAt: time.Now().UTC().Truncate(time.Second), Dimensions: dimensions, Summary: map[string]int{}}
+	for _, c := range cases {
+		r := results[c.name]
+		report.Results = append(report.Results, r)
+		report.Summary[r.Status]++
+	}
+	p.finish(report.Summary[caseFailed] == 0)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fatalf(exitFailure, "Failed to encode the report: %v", err)
+	}
+	if err := os.WriteFile(*reportPath, append(data, '\n'), 0644); err != nil {
+		fatalf(exitFailure, "Failed to write %s: %v", *reportPath, err)
+	}
+
+	printf("\n%d ok, %d failed, %d rejected by gogo. Report written to %s\n", report.Summary[caseOK], report.Summary[caseFailed], report.Summary[caseRejected], *reportPath)
+	if *keep {
+		printf("Projects kept in %s\n", root)
+	}
+	if report.Summary[caseFailed] > 0 {
+		if !*keep {
+			os.RemoveAll(root)
+		}
+		debugExit(exitSubprocess)
+		os.Exit(exitSubprocess)
+	}
+}
+
+// Returns a case for every combination of the dimension values, named after its
+// position and values (e.g. m03-nats-yaml)
+func matrixCases(dimensions map[string][]string, components [][]string) []selftestCase {
+	cases := []selftestCase{{}}
+	for _, name := range matrixDimensions {
+		var next []selftestCase
+		for _, c := range cases {
+			for _, value := range dimensions[name] {
+				value = strings.TrimSpace(value)
+				combined := selftestCase{flags: c.flags, name: c.name}
+				if value != "none" && value != "" {
+					combined.flags = append(append([]string{}, c.flags...), "--"+name+"="+value)
+					combined.name += "-" + value
+				}
+				next = append(next, combined)
+			}
+		}
+		if len(next) > 0 {
+			cases = next
+		}
+	}
+	for i := range cases {
+		cases[i].name = fmt.Sprintf("m%02d%s", i+1, cases[i].name)
+		cases[i].add = components
+	}
+	return cases
+}
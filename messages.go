@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// gogo's messages are written in English in the code, and translated when
+// printed by looking up their format in the catalog of the language GOGO_LANG
+// names, e.g. GOGO_LANG=fr. Messages missing from a catalog are printed in
+// English, so a catalog can be completed little by little.
+const langEnv = "GOGO_LANG"
+
+// Catalogs of the supported languages besides English, mapping the English
+// format of a message to its translation with the same verbs in the same order
+var messageCatalogs = map[string]map[string]string{
+	"fr": frenchMessages,
+}
+
+// Returns the languages GOGO_LANG accepts
+func supportedLanguages() []string {
+	langs := []string{"en"}
+	for lang := range messageCatalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// Returns the language of the value of GOGO_LANG: its prefix before a region or
+// encoding, e.g. fr for fr_FR.UTF-8, and en when it is empty
+func languageOf(value string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(value), ".")
+	lang, _, _ = strings.Cut(lang, "_")
+	lang, _, _ = strings.Cut(lang, "-")
+	if lang == "" || lang == "c" || lang == "posix" {
+		return "en"
+	}
+	return lang
+}
+
+// Stops gogo when GOGO_LANG names a language without a catalog, rather than
+// printing English silently
+func checkLanguage() {
+	value := os.Getenv(langEnv)
+	if lang := languageOf(value); lang != "en" && messageCatalogs[lang] == nil {
+		fatalf(exitUsage, "Unsupported %s value %q (supported: %s)", langEnv, value, strings.Join(supportedLanguages(), ", "))
+	}
+}
+
+// Returns the translation of the message format in the language of GOGO_LANG,
+// format itself when there is none
+func tr(format string) string {
+	if translated, ok := messageCatalogs[languageOf(os.Getenv(langEnv))][format]; ok {
+		return translated
+	}
+	return format
+}
+
+// Prints the message format, translated, like fmt.Printf
+func printf(format string, args ...any) {
+	fmt.Printf(tr(format), args...)
+}
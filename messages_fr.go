@@ -0,0 +1,268 @@
+package main
+
+// French catalog of gogo's messages (GOGO_LANG=fr)
+var frenchMessages = map[string]string{
+	// Generation
+	"Project %s has been created successfully!\n": "Le projet %s a été créé avec succès !\n",
+	"Template %s has been applied.\n":             "Le modèle %s a été appliqué.\n",
+	"Next steps:":                                 "Étapes suivantes :",
+	"These steps are also in README.md.":          "Ces étapes figurent aussi dans README.md.",
+	"Warning: go is not in PATH, install Go (https://go.dev/dl/) to build the project":                         "Attention : go n'est pas dans le PATH, installez Go (https://go.dev/dl/) pour compiler le projet",
+	"Warning: git is not in PATH, gogo creates the project's repository without it; install git to work on it": "Attention : git n'est pas dans le PATH, gogo crée le dépôt du projet sans lui ; installez git pour y travailler",
+	"Warning: no Git identity configured (user.name and user.email), the generated files are not committed":    "Attention : aucune identité Git configurée (user.name et user.email), les fichiers générés ne sont pas commités",
+	"Linting the generated project...":          "Analyse du projet généré...",
+	"go vet and golangci-lint found no issues.": "go vet et golangci-lint n'ont trouvé aucun problème.",
+	"Warning: %s\n": "Attention : %s\n",
+	"Warning: %s reported issues in the generated code\n": "Attention : %s a signalé des problèmes dans le code généré\n",
+	"%s reported issues in the generated code":            "%s a signalé des problèmes dans le code généré",
+	"Cloning %s":                "Clonage de %s",
+	"Updating %s":               "Mise à jour de %s",
+	"Running %s":                "Exécution de %s",
+	"Running %d combinations":   "Exécution de %d combinaisons",
+	"Running %d selftest cases": "Exécution de %d cas d'autotest",
+	"done":                      "terminé",
+	"failed":                    "échec",
+	" and ":                     " et ",
+	"Warning: the global Git config could not be read: %v\n": "Attention : la configuration Git globale n'a pas pu être lue : %v\n",
+	"Writing a debug trace to %s\n":                          "Écriture d'une trace de débogage dans %s\n",
+	"  A value is required":                                  "  Une valeur est requise",
+
+	// Next steps
+	"Fetch the dependencies at the versions go.mod requires":    "Récupérer les dépendances aux versions exigées par go.mod",
+	"Start the local dependencies":                              "Démarrer les dépendances locales",
+	"Try the job on the sample input without writing anything":  "Essayer le job sur l'entrée d'exemple sans rien écrire",
+	"Run it, with the flags in ARGS overriding the settings":    "Le lancer, les flags de ARGS remplaçant les réglages",
+	"Run the service, which provisions its stream and consumer": "Lancer le service, qui crée son stream et son consumer",
+	"Run the API":           "Lancer l'API",
+	"Check that it answers": "Vérifier qu'elle répond",
+	"Check that it is ready, once its dependencies are up": "Vérifier qu'elle est prête, une fois ses dépendances démarrées",
+	"List the items of the example module":                 "Lister les éléments du module d'exemple",
+	"Add a module":                                         "Ajouter un module",
+	"List them":                                            "Les lister",
+	"Add a resource, then create its table":                "Ajouter une ressource, puis créer sa table",
+	"Report the errors and panics to Sentry by giving the DSN of your Sentry project": "Envoyer les erreurs et les panics à Sentry en donnant le DSN de votre projet Sentry",
+	"Profile the running API (PROFILE=heap for its memory)":                           "Profiler l'API en cours d'exécution (PROFILE=heap pour sa mémoire)",
+	"Browse its continuous profiles in Pyroscope":                                     "Parcourir ses profils continus dans Pyroscope",
+	"Run the Temporal worker":                                                         "Lancer le worker Temporal",
+	"Start the sample workflow from another terminal":                                 "Démarrer le workflow d'exemple depuis un autre terminal",
+	"Run the tests": "Lancer les tests",
+	"Or run everything in Docker (APP_ENV=staging or prod for the other profiles)": "Ou tout lancer dans Docker (APP_ENV=staging ou prod pour les autres profils)",
+	"Verify the provider contracts":                                                                   "Vérifier les contrats du provider",
+	"Run the Lambda functions locally with SAM":                                                       "Lancer les fonctions Lambda en local avec SAM",
+	"Plan the infrastructure of the dev environment":                                                  "Planifier l'infrastructure de l'environnement de dev",
+	"Deploy to Cloud Run":                                                                             "Déployer sur Cloud Run",
+	"Release a version by pushing a tag":                                                              "Publier une version en poussant un tag",
+	"Commit with conventional commit messages, then write the changelog":                              "Commiter avec des messages conventional commits, puis écrire le changelog",
+	"Create the Git repository and enable the commit hooks":                                           "Créer le dépôt Git et activer les hooks de commit",
+	"Create the Git repository":                                                                       "Créer le dépôt Git",
+	"Review the generated files, then commit them":                                                    "Relire les fichiers générés, puis les commiter",
+	"Browse in Pyroscope the profiles Grafana Agent scrapes from the API running in Docker (make up)": "Parcourir dans Pyroscope les profils que Grafana Agent collecte sur l'API lancée dans Docker (make up)",
+
+	// Components
+	"Component %s has been added successfully!\n":                                                       "Le composant %s a été ajouté avec succès !\n",
+	"To finish wiring up %s (%s):":                                                                      "Pour finir de brancher %s (%s) :",
+	"Bundle %s has been written with %s.\n":                                                             "Le paquet %s a été écrit avec %s.\n",
+	"Its SHA-256 is %s. Import it with gogo template bundle import --sha256=%s %s\n":                    "Son SHA-256 est %s. Importez-le avec gogo template bundle import --sha256=%s %s\n",
+	"Template %s has been installed (%s).\n":                                                            "Le modèle %s a été installé (%s).\n",
+	"Template %s has been installed.\n":                                                                 "Le modèle %s a été installé.\n",
+	"\nTo finish wiring it up:\n":                                                                       "\nPour finir de le brancher :\n",
+	"Warning: %s already defines %s, keeping its definition\n":                                          "Attention : %s définit déjà %s, sa définition est conservée\n",
+	"Warning: could not edit %s: %v\n":                                                                  "Attention : impossible de modifier %s : %v\n",
+	"Warning: %s is built on %s and may not compile anymore\n":                                          "Attention : %s repose sur %s et risque de ne plus compiler\n",
+	"Component %s has been removed.\n":                                                                  "Le composant %s a été supprimé.\n",
+	"\nKept files edited since they were generated (delete them by hand, or rerun with --force):\n":     "\nFichiers conservés car modifiés depuis leur génération (supprimez-les à la main, ou relancez avec --force) :\n",
+	"\nIts migrations were deleted: if they were applied, roll them back with the versions from Git.\n": "\nSes migrations ont été supprimées : si elles ont été appliquées, annulez-les avec les versions de Git.\n",
+	"\nRun go build ./... to find references left in hand-written code.\n":                              "\nLancez go build ./... pour trouver les références restantes dans le code écrit à la main.\n",
+	"Warning: could not clean up the imports of %s: %v\n":                                               "Attention : impossible de nettoyer les imports de %s : %v\n",
+	"Warning: could not open %s with %s: %v\n":                                                          "Attention : impossible d'ouvrir %s avec %s : %v\n",
+	"Warning: not opening %s with %s, which needs a terminal\n":                                         "Attention : %s n'est pas ouvert avec %s, qui nécessite un terminal\n",
+	"No credentials found.\n":                                                                           "Aucun identifiant trouvé.\n",
+	"  deleted %s\n":                                                                                    "  supprimé %s\n",
+	"  unwired %s\n":                                                                                    "  débranché %s\n",
+
+	// Templates
+	"Template %s has been created in %s!\n":                                                 "Le modèle %s a été créé dans %s !\n",
+	"  1. Describe the template and its variables in %s\n":                                  "  1. Décrire le modèle et ses variables dans %s\n",
+	"  2. Add the files it lays over generated projects under %s\n":                         "  2. Ajouter sous %s les fichiers qu'il pose sur les projets générés\n",
+	"  3. Check that a project generated with it compiles: cd %s && make test\n":            "  3. Vérifier qu'un projet généré avec lui compile : cd %s && make test\n",
+	"  4. Push it to a Git repository and install it: gogo template install <git-url>\n":    "  4. Le pousser dans un dépôt Git et l'installer : gogo template install <git-url>\n",
+	"Template %s has been installed in %s. Use it with gogo --template=%s <project-name>\n": "Le modèle %s a été installé dans %s. Utilisez-le avec gogo --template=%s <nom-du-projet>\n",
+	"No template installed. Install one with gogo template install <git-url>\n":             "Aucun modèle installé. Installez-en un avec gogo template install <git-url>\n",
+	"No template installed. Install one with gogo template install <git-url>":               "Aucun modèle installé. Installez-en un avec gogo template install <git-url>",
+	"%s (invalid: %v)\n":                         "%s (invalide : %v)\n",
+	"  source: %s\n":                             "  source : %s\n",
+	"  extends: %s\n":                            "  étend : %s\n",
+	"  imported from a bundle, at revision %s\n": "  importé d'un paquet, à la révision %s\n",
+	" (required)":                                " (requise)",
+	" (default %q)":                              " (par défaut %q)",
+	"Failed to update %s: %v\n%s":                "Impossible de mettre à jour %s : %v\n%s",
+	"Updated %s from %s to %s\n":                 "%s mis à jour de %s vers %s\n",
+	"%s is up to date\n":                         "%s est à jour\n",
+	"Skipping %s: it was imported from a bundle, not cloned with Git. Import a newer bundle with gogo template bundle import --force\n": "%s est ignoré : il a été importé d'un paquet, pas cloné avec Git. Importez un paquet plus récent avec gogo template bundle import --force\n",
+	"Warning: %s is now invalid: %v\n":                             "Attention : %s est désormais invalide : %v\n",
+	"Warning: %s is invalid: %v\n":                                 "Attention : %s est invalide : %v\n",
+	"Warning: variable %s is used by no file, path or condition\n": "Attention : la variable %s n'est utilisée par aucun fichier, chemin ou condition\n",
+	"Template %s is invalid:\n  %s":                                "Le modèle %s est invalide :\n  %s",
+	"Template %s is valid: %d variables, %d files rendered.\n":     "Le modèle %s est valide : %d variables, %d fichiers rendus.\n",
+
+	// Selftest and matrix
+	"FAIL %s (%s)\n%s: %s\n":            "ÉCHEC %s (%s)\n%s : %s\n",
+	"ok   %s (%s)\n":                    "ok    %s (%s)\n",
+	"\nProjects kept in %s\n":           "\nProjets conservés dans %s\n",
+	"Projects kept in %s\n":             "Projets conservés dans %s\n",
+	"\nAll %d selftest cases passed.\n": "\nLes %d cas d'autotest ont réussi.\n",
+	"\n%d ok, %d failed, %d rejected by gogo. Report written to %s\n": "\n%d ok, %d en échec, %d rejetés par gogo. Rapport écrit dans %s\n",
+
+	// Errors
+	"Unsupported %s value %q (supported: %s)":                                 "Valeur de %s non prise en charge : %q (valeurs prises en charge : %s)",
+	"Please provide a project name as an argument.":                           "Veuillez donner un nom de projet en argument.",
+	"Invalid project name %q: the last segment of its path names the project": "Nom de projet invalide %q : le dernier segment de son chemin nomme le projet",
+	"Failed to create project directory: %v":                                  "Impossible de créer le répertoire du projet : %v",
+	"Failed to create directory %s: %v":                                       "Impossible de créer le répertoire %s : %v",
+	"Failed to create file %s: %v":                                            "Impossible de créer le fichier %s : %v",
+	"Failed to write to file %s: %v":                                          "Impossible d'écrire dans le fichier %s : %v",
+	"Failed to commit the generated files: %v":                                "Impossible de commiter les fichiers générés : %v",
+	"Failed to initialize Git: %v":                                            "Impossible d'initialiser Git : %v",
+	"Failed to read %s: %v":                                                   "Impossible de lire %s : %v",
+	"%s is not a directory":                                                   "%s n'est pas un répertoire",
+	"Unsupported --config-format value %q (supported: env, yaml, toml, json)": "Valeur de --config-format non prise en charge : %q (valeurs prises en charge : env, yaml, toml, json)",
+	"Unsupported --archetype value %q (supported: api, nats, batch)":          "Valeur de --archetype non prise en charge : %q (valeurs prises en charge : api, nats, batch)",
+	"Unsupported --layout value %q (supported: standard, modular-monolith)":   "Valeur de --layout non prise en charge : %q (valeurs prises en charge : standard, modular-monolith)",
+	"Unsupported --example value %q (supported: %s)":                          "Valeur de --example non prise en charge : %q (valeurs prises en charge : %s)",
+	"Unsupported --with value %q (supported: %s)":                             "Valeur de --with non prise en charge : %q (valeurs prises en charge : %s)",
+	"Template %s requires %s":                                                 "Le modèle %s nécessite %s",
+	"%s requires %s: set it or drop %s":                                       "%s nécessite %s : ajoutez-le ou retirez %s",
+	"%s requires %s: set them or drop %s":                                     "%s nécessite %s : ajoutez-les ou retirez %s",
+	"%s cannot be combined with %s: drop one of them":                         "%s ne peut pas être combiné avec %s : retirez l'un des deux",
+	"%s cannot be combined with %s: drop them or %s":                          "%s ne peut pas être combiné avec %s : retirez-les ou retirez %s",
+	"Incompatible options:\n  %s":                                             "Options incompatibles :\n  %s",
+	"--var requires --template":                                               "--var nécessite --template",
+	"%s contains what looks like credentials:\n%s\nRemove them, or add a %s comment to the lines that are not secrets":                                                                                            "%s contient ce qui ressemble à des identifiants :\n%s\nSupprimez-les, ou ajoutez un commentaire %s aux lignes qui ne sont pas des secrets",
+	"The variables of template %s contain what looks like credentials:\n%s\nPass secrets to the generated project through environment variables rather than template variables, which are written into its files": "Les variables du modèle %s contiennent ce qui ressemble à des identifiants :\n%s\nTransmettez les secrets au projet généré par des variables d'environnement plutôt que par des variables de modèle, qui sont écrites dans ses fichiers",
+	"File %s already exists, not overwriting it":                                           "Le fichier %s existe déjà, il n'est pas écrasé",
+	"Failed to edit %s: %v":                                                                "Impossible de modifier %s : %v",
+	"Failed to resolve project directory: %v":                                              "Impossible de résoudre le répertoire du projet : %v",
+	"%s does not look like a gogo project (no internal directory)":                         "%s ne ressemble pas à un projet gogo (pas de répertoire internal)",
+	"Migration %s already exists: %s":                                                      "La migration %s existe déjà : %s",
+	"Failed to read the %s model (add it first with gogo add resource %s): %v":             "Impossible de lire le modèle %s (ajoutez-le d'abord avec gogo add resource %s) : %v",
+	"%s does not declare type %s":                                                          "%s ne déclare pas le type %s",
+	"%s.%s is not a struct":                                                                "%s.%s n'est pas une struct",
+	"%s.%s has no ID field":                                                                "%s.%s n'a pas de champ ID",
+	"Please provide a component to add (available: %s).":                                   "Veuillez indiquer un composant à ajouter (disponibles : %s).",
+	"Unknown component %q (available: %s)":                                                 "Composant inconnu %q (disponibles : %s)",
+	"Add-on %s requires itself (%s)":                                                       "L'extension %s se requiert elle-même (%s)",
+	"Please provide the title of the decision, e.g. gogo add adr \"Use Kafka for events\"": "Veuillez indiquer le titre de la décision, par ex. gogo add adr \"Use Kafka for events\"",
+	"Please provide an aggregate name, e.g. gogo generate aggregate Order --events=OrderPlaced,OrderCancelled": "Veuillez indiquer un nom d'agrégat, par ex. gogo generate aggregate Order --events=OrderPlaced,OrderCancelled",
+	"Invalid event name %q: events must be exported Go identifiers, e.g. %sCreated":                            "Nom d'événement invalide %q : les événements doivent être des identifiants Go exportés, par ex. %sCreated",
+	"Failed to annotate the generated files: %v":                                                               "Impossible d'annoter les fichiers générés : %v",
+	"Failed to copy the %s assets: %v":                                                                         "Impossible de copier les ressources %s : %v",
+	"Failed to read template %s: %v":                                                                           "Impossible de lire le modèle %s : %v",
+	"Please provide the bundle to import, e.g. gogo template bundle import gogo-templates.tar.gz":              "Veuillez indiquer le paquet à importer, par ex. gogo template bundle import gogo-templates.tar.gz",
+	"Invalid bundle %s: %v": "Paquet invalide %s : %v",
+	"Template %s is already installed, import with --force to replace it":                                                              "Le modèle %s est déjà installé, importez avec --force pour le remplacer",
+	"Failed to replace template %s: %v":                                                                                                "Impossible de remplacer le modèle %s : %v",
+	"Failed to install template %s: %v":                                                                                                "Impossible d'installer le modèle %s : %v",
+	"Please provide a bundle command: export [name ...] [--output=<file>] or import <file> [--force]":                                  "Veuillez indiquer une commande de paquet : export [nom ...] [--output=<fichier>] ou import <fichier> [--force]",
+	"Unknown bundle command %q (supported: export, import)":                                                                            "Commande de paquet inconnue %q (prises en charge : export, import)",
+	"Failed to locate %s: %v":                                                                                                          "Impossible de localiser %s : %v",
+	"Template %s extends %s, which is not an installed template and cannot be bundled":                                                 "Le modèle %s étend %s, qui n'est pas un modèle installé et ne peut pas être empaqueté",
+	"Unsupported --color value %q (supported: always, never, auto)":                                                                    "Valeur de --color non prise en charge : %q (valeurs prises en charge : always, never, auto)",
+	"Invalid --owner handle %q":                                                                                                        "Identifiant --owner invalide %q",
+	"Failed to list the imports of the generated code: %v":                                                                             "Impossible de lister les imports du code généré : %v",
+	"Unknown module %s in %s: the generated code does not import it":                                                                   "Module inconnu %s dans %s : le code généré ne l'importe pas",
+	"Unsupported version %q of %s in %s (a module version such as v1.2.3)":                                                             "Version %q de %s non prise en charge dans %s (une version de module comme v1.2.3)",
+	"--open=%s requires the VISUAL or EDITOR environment variable":                                                                     "--open=%s nécessite la variable d'environnement VISUAL ou EDITOR",
+	"Unsupported --open value %q (supported: code, goland, vim, editor for $EDITOR, none)":                                             "Valeur de --open non prise en charge : %q (valeurs prises en charge : code, goland, vim, editor pour $EDITOR, none)",
+	"Please provide the example to add (available: %s), e.g. gogo add example todo":                                                    "Veuillez indiquer l'exemple à ajouter (disponibles : %s), par ex. gogo add example todo",
+	"Unknown example %q (available: %s)":                                                                                               "Exemple inconnu %q (disponibles : %s)",
+	"Failed to configure the Git hooks: %v":                                                                                            "Impossible de configurer les hooks Git : %v",
+	"Unsupported GIT_%s_DATE value %q (e.g. 2024-05-01T12:00:00+02:00 or @1714557600 +0200)":                                           "Valeur de GIT_%s_DATE non prise en charge : %q (par ex. 2024-05-01T12:00:00+02:00 ou @1714557600 +0200)",
+	"Generated invalid Go code in %s: %v":                                                                                              "Code Go invalide généré dans %s : %v",
+	"Generated invalid Go code: %v":                                                                                                    "Code Go invalide généré : %v",
+	"Unsupported module path %q (e.g. github.com/acme/payments)":                                                                       "Chemin de module non pris en charge : %q (par ex. github.com/acme/payments)",
+	"The project name %s is a standard library package, whose imports could not be told apart from the project's: choose another name": "Le nom de projet %s est un paquet de la bibliothèque standard, dont les imports ne pourraient pas être distingués de ceux du projet : choisissez un autre nom",
+	"Unsupported --goprivate pattern %q (a module path prefix such as github.com/acme, globs allowed)":                                 "Motif --goprivate non pris en charge : %q (un préfixe de chemin de module comme github.com/acme, jokers autorisés)",
+	"Field %s has type %s, which cannot be imported (supported: string, int64, float64, bool, time.Time)":                              "Le champ %s est de type %s, qui ne peut pas être importé (types pris en charge : string, int64, float64, bool, time.Time)",
+	"Please provide the resource to import, e.g. gogo add import product":                                                              "Veuillez indiquer la ressource à importer, par ex. gogo add import product",
+	"%s has no fields to import":                                                                                                       "%s n'a aucun champ à importer",
+	"Invalid --batch-size value %d (must be between 1 and %d for %s)":                                                                  "Valeur de --batch-size invalide : %d (doit être entre 1 et %d pour %s)",
+	"Failed to read the %s repository: %v":                                                                                             "Impossible de lire le repository %s : %v",
+	"Failed to copy the project to lint it: %v":                                                                                        "Impossible de copier le projet pour l'analyser : %v",
+	"Unsupported --release value %q (supported: goreleaser)":                                                                           "Valeur de --release non prise en charge : %q (valeurs prises en charge : goreleaser)",
+	"Unsupported --cache value %q (supported: redis)":                                                                                  "Valeur de --cache non prise en charge : %q (valeurs prises en charge : redis)",
+	"Unsupported --db-topology value %q (supported: single, primary-replica)":                                                          "Valeur de --db-topology non prise en charge : %q (valeurs prises en charge : single, primary-replica)",
+	"Unsupported --workflow value %q (supported: temporal)":                                                                            "Valeur de --workflow non prise en charge : %q (valeurs prises en charge : temporal)",
+	"Unsupported --contract-tests value %q (supported: pact)":                                                                          "Valeur de --contract-tests non prise en charge : %q (valeurs prises en charge : pact)",
+	"Unsupported --target value %q (supported: lambda)":                                                                                "Valeur de --target non prise en charge : %q (valeurs prises en charge : lambda)",
+	"Unsupported --deploy value %q (supported: cloudrun)":                                                                              "Valeur de --deploy non prise en charge : %q (valeurs prises en charge : cloudrun)",
+	"Unsupported --iac value %q (supported: terraform)":                                                                                "Valeur de --iac non prise en charge : %q (valeurs prises en charge : terraform)",
+	"Unsupported --errors value %q (supported: sentry)":                                                                                "Valeur de --errors non prise en charge : %q (valeurs prises en charge : sentry)",
+	"Unsupported --profiling value %q (supported: pyroscope, grafana-agent)":                                                           "Valeur de --profiling non prise en charge : %q (valeurs prises en charge : pyroscope, grafana-agent)",
+	"Unsupported --lint value %q (supported: warn, fail)":                                                                              "Valeur de --lint non prise en charge : %q (valeurs prises en charge : warn, fail)",
+	"Unsupported --changelog value %q (supported: git-cliff, chglog)":                                                                  "Valeur de --changelog non prise en charge : %q (valeurs prises en charge : git-cliff, chglog)",
+	"Unsupported --binary-name value %q (lowercase letters, digits, '.', '_' and '-', e.g. payments)":                                  "Valeur de --binary-name non prise en charge : %q (lettres minuscules, chiffres, '.', '_' et '-', par ex. payments)",
+	"--binary-name %s is the name of another generated command":                                                                        "--binary-name %s est le nom d'une autre commande générée",
+	"Unsupported --image value %q (a Docker image name without registry or tag, e.g. acme/payments)":                                   "Valeur de --image non prise en charge : %q (un nom d'image Docker sans registre ni tag, par ex. acme/payments)",
+	"Failed to encode %s: %v":                                                                                                          "Impossible d'encoder %s : %v",
+	"Please provide the values to combine, e.g. gogo matrix --archetype=api,nats --config-format=env,yaml (flags: --%s)":               "Veuillez indiquer les valeurs à combiner, par ex. gogo matrix --archetype=api,nats --config-format=env,yaml (flags : --%s)",
+	"Failed to encode the report: %v":                                                                                                  "Impossible d'encoder le rapport : %v",
+	"Failed to write %s: %v":                                                                                                           "Impossible d'écrire %s : %v",
+	"Please provide a module name, e.g. gogo add module billing":                                                                       "Veuillez indiquer un nom de module, par ex. gogo add module billing",
+	"Unsupported module name %q (a package name: lowercase letters and digits, e.g. billing)":                                          "Nom de module non pris en charge : %q (un nom de paquet : lettres minuscules et chiffres, par ex. billing)",
+	"gogo add module requires a project generated with --layout=modular-monolith (%s is missing)":                                      "gogo add module nécessite un projet généré avec --layout=modular-monolith (%s est absent)",
+	"Unsupported --provider value %q (supported: stripe)":                                                                              "Valeur de --provider non prise en charge : %q (valeurs prises en charge : stripe)",
+	"Unsupported --perm value %q (an octal directory mode giving the owner full access, e.g. 0755 or 0700)":                            "Valeur de --perm non prise en charge : %q (un mode de répertoire octal donnant tous les droits au propriétaire, par ex. 0755 ou 0700)",
+	"Failed to document the generated packages: %v":                                                                                    "Impossible de documenter les paquets générés : %v",
+	"Please provide the file to preview, e.g. gogo preview --archetype=nats Dockerfile":                                                "Veuillez indiquer le fichier à prévisualiser, par ex. gogo preview --archetype=nats Dockerfile",
+	"No generated file matches %s. Generated files:\n  %s":                                                                             "Aucun fichier généré ne correspond à %s. Fichiers générés :\n  %s",
+	"Several generated files match %s, please give more of the path:\n  %s":                                                            "Plusieurs fichiers générés correspondent à %s, veuillez préciser le chemin :\n  %s",
+	"Failed to list the generated files: %v":                                                                                           "Impossible de lister les fichiers générés : %v",
+	"Failed to clone %s: %v\n%s":                                                                                                       "Impossible de cloner %s : %v\n%s",
+	"Template %s is already installed, run gogo template update %s":                                                                    "Le modèle %s est déjà installé, lancez gogo template update %s",
+	"Failed to install the template: %v":                                                                                               "Impossible d'installer le modèle : %v",
+	"Template %s is not installed (installed: %s)":                                                                                     "Le modèle %s n'est pas installé (installés : %s)",
+	"Unsupported --template value %q (installed: %s)":                                                                                  "Valeur de --template non prise en charge : %q (installés : %s)",
+	"Please provide a template command: init <dir>, install <git-url> [--name=<name>], list, update [name ...], validate [dir] or bundle export|import": "Veuillez indiquer une commande de modèle : init <rép>, install <git-url> [--name=<nom>], list, update [nom ...], validate [rép] ou bundle export|import",
+	"Unknown template command %q (supported: init, install, list, update, validate, bundle)":                                                            "Commande de modèle inconnue %q (prises en charge : init, install, list, update, validate, bundle)",
+	"Please provide the Git URL of the template, e.g. gogo template install https://github.com/acme/gogo-api-template":                                  "Veuillez indiquer l'URL Git du modèle, par ex. gogo template install https://github.com/acme/gogo-api-template",
+	"Failed to create %s: %v":              "Impossible de créer %s : %v",
+	"%s has no %q line closing %q":         "%s n'a pas de ligne %q fermant %q",
+	"Failed to remove the edits of %s: %v": "Impossible de retirer les modifications de %s : %v",
+	"Please provide the component to remove, e.g. gogo remove resource product": "Veuillez indiquer le composant à supprimer, par ex. gogo remove resource product",
+	"%s is built on %s: remove it first, or use --force":                        "%s repose sur %s : supprimez-le d'abord, ou utilisez --force",
+	"No %s recorded in %s (only components added with gogo add are tracked)":    "Aucun %s enregistré dans %s (seuls les composants ajoutés avec gogo add sont suivis)",
+	"Failed to delete %s: %v":                                                            "Impossible de supprimer %s : %v",
+	"Unsupported --formats value %q (supported: pdf, xlsx, csv)":                         "Valeur de --formats non prise en charge : %q (valeurs prises en charge : pdf, xlsx, csv)",
+	"Please provide at least one report format in --formats (supported: pdf, xlsx, csv)": "Veuillez indiquer au moins un format de rapport dans --formats (valeurs prises en charge : pdf, xlsx, csv)",
+	"Unsupported --id-type %q (supported: serial, uuid, ulid, snowflake)":                "Valeur de --id-type non prise en charge : %q (valeurs prises en charge : serial, uuid, ulid, snowflake)",
+	"Unsupported type %q for field %s (supported: string, int, float, bool, time)":       "Type %q non pris en charge pour le champ %s (types pris en charge : string, int, float, bool, time)",
+	"Field %s is reserved": "Le champ %s est réservé",
+	"Unsupported model convention %q (supported: timestamps, soft-delete)":                 "Convention de modèle non prise en charge : %q (valeurs prises en charge : timestamps, soft-delete)",
+	"Please provide a resource name, e.g. gogo add resource product name:string price:int": "Veuillez indiquer un nom de ressource, par ex. gogo add resource product name:string price:int",
+	"Failed to apply template %s: %v":                                                      "Impossible d'appliquer le modèle %s : %v",
+	"Please provide at most one directory, e.g. gogo scan myapp":                           "Veuillez indiquer au plus un répertoire, par ex. gogo scan myapp",
+	"Failed to scan %s: %v": "Impossible d'analyser %s : %v",
+	"Please provide the resource to index, e.g. gogo add search product --engine=meilisearch": "Veuillez indiquer la ressource à indexer, par ex. gogo add search product --engine=meilisearch",
+	"Unsupported --engine value %q (supported: elasticsearch, opensearch, meilisearch)":       "Valeur de --engine non prise en charge : %q (valeurs prises en charge : elasticsearch, opensearch, meilisearch)",
+	"Failed to generate a secret: %v":                                                    "Impossible de générer un secret : %v",
+	"Failed to create a temporary directory: %v":                                         "Impossible de créer un répertoire temporaire : %v",
+	"No selftest case matches %q":                                                        "Aucun cas d'autotest ne correspond à %q",
+	"%d of %d selftest cases failed: %s":                                                 "%d cas d'autotest sur %d en échec : %s",
+	"Failed to locate the gogo binary: %v":                                               "Impossible de localiser le binaire gogo : %v",
+	"Unsupported --stdlib value %q (supported: %s)":                                      "Valeur de --stdlib non prise en charge : %q (valeurs prises en charge : %s)",
+	"Please provide the directory of the new template, e.g. gogo template init acme-api": "Veuillez indiquer le répertoire du nouveau modèle, par ex. gogo template init acme-api",
+	"Template name %q must be lower case letters, digits, dots, dashes and underscores, please provide one with --name": "Le nom de modèle %q doit être fait de lettres minuscules, chiffres, points, tirets et soulignés, veuillez en indiquer un avec --name",
+	"%s already exists and is not empty":                                                    "%s existe déjà et n'est pas vide",
+	"Failed to encode the manifest: %v":                                                     "Impossible d'encoder le manifeste : %v",
+	"Please provide at most one template directory, e.g. gogo template validate acme-api":   "Veuillez indiquer au plus un répertoire de modèle, par ex. gogo template validate acme-api",
+	"Invalid template %s: %v":                                                               "Modèle invalide %s : %v",
+	"Template %s has no variable %q":                                                        "Le modèle %s n'a pas de variable %q",
+	"Invalid --var %s: %v":                                                                  "--var invalide %s : %v",
+	"Invalid value for %s: %v":                                                              "Valeur invalide pour %s : %v",
+	"Template %s has no variable %q (see gogo template list)":                               "Le modèle %s n'a pas de variable %q (voir gogo template list)",
+	"Please provide at most one directory, e.g. gogo tree myapp":                            "Veuillez indiquer au plus un répertoire, par ex. gogo tree myapp",
+	"Failed to resolve %s: %v":                                                              "Impossible de résoudre %s : %v",
+	"Failed to locate the home directory: %v":                                               "Impossible de localiser le répertoire personnel : %v",
+	"Failed to parse %s: %v":                                                                "Impossible d'analyser %s : %v",
+	"Bundle %s has the SHA-256 %s, not %s: it was changed or damaged since it was exported": "Le paquet %s a le SHA-256 %s, et non %s : il a été modifié ou endommagé depuis son export",
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// Functions translating a message format, with the position of the format among
+// their arguments
+var translatingFuncs = map[string]int{"tr": 0, "printf": 0, "printStyled": 1, "fatalf": 1, "startProgress": 1}
+
+// Returns the messages of gogo's code that are translated: the constant formats
+// passed to translatingFuncs and the texts of the next steps, with their position
+func translatedMessages(t *testing.T) map[string]string {
+	t.Helper()
+	paths, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	messages := map[string]string{}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			var message ast.Expr
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if fun, ok := n.Fun.(*ast.Ident); ok {
+					if i, ok := translatingFuncs[fun.Name]; ok && i < len(n.Args) {
+						message = n.Args[i]
+					}
+				}
+			case *ast.CompositeLit:
+				if typ, ok := n.Type.(*ast.Ident); ok && typ.Name == "nextStep" && len(n.Elts) > 0 {
+					message = n.Elts[0]
+				}
+			}
+			if s, ok := stringConstant(message); ok {
+				messages[s] = fset.Position(message.Pos()).String()
+			}
+			return true
+		})
+	}
+	return messages
+}
+
+// Returns the value of expr when it is a string literal or a concatenation of them
+func stringConstant(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		return constant.StringVal(constant.MakeFromLiteral(e.Value, e.Kind, 0)), true
+	case *ast.BinaryExpr:
+		x, ok := stringConstant(e.X)
+		y, ok2 := stringConstant(e.Y)
+		return x + y, ok && ok2 && e.Op == token.ADD
+	case *ast.ParenExpr:
+		return stringConstant(e.X)
+	}
+	return "", false
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	messages := translatedMessages(t)
+	if len(messages) == 0 {
+		t.Fatal("no translated message found")
+	}
+	for lang, catalog := range messageCatalogs {
+		for _, message := range sortedKeys(messages) {
+			// Formats such as "%s" only pass on messages translated already
+			if !strings.ContainsFunc(formatVerbPattern.ReplaceAllString(message, ""), unicode.IsLetter) {
+				continue
+			}
+			if _, ok := catalog[message]; !ok {
+				t.Errorf("%s: %q has no %s translation", messages[message], message, lang)
+			}
+		}
+	}
+}
+
+// Verbs of a format, e.g. %s or %[1]q
+var formatVerbPattern = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+func TestCatalogsVerbs(t *testing.T) {
+	for lang, catalog := range messageCatalogs {
+		for message, translation := range catalog {
+			want := formatVerbPattern.FindAllString(message, -1)
+			if got := formatVerbPattern.FindAllString(translation, -1); !slices.Equal(got, want) {
+				t.Errorf("%s translation of %q has the verbs %v, want %v", lang, message, got, want)
+			}
+		}
+	}
+}
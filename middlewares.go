@@ -0,0 +1,233 @@
+package main
+
+import "fmt"
+
+// Returns the content for internal/middlewares/chain.go
+func chainGoContent() string {
+	return `package middlewares
+
+import "net/http"
+
+// Middleware wraps an http.Handler with additional behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with the given middlewares, the first one being the outermost
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+`
+}
+
+// Returns the content for internal/middlewares/request_id.go
+func requestIDGoContent() string {
+	return `package middlewares
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header used to propagate request IDs
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID reuses the incoming X-Request-ID header or generates a new ID,
+// stores it in the request context and echoes it in the response
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext returns the request ID stored by the RequestID middleware
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+`
+}
+
+// Returns the content for internal/middlewares/access_log.go
+func accessLogGoContent() string {
+	return `package middlewares
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// AccessLogConfig controls which requests are written to the access log
+type AccessLogConfig struct {
+	// SampleRate is the fraction (0 to 1) of regular requests that are logged.
+	// Server errors and slow requests are always logged.
+	SampleRate float64
+	// SlowThreshold is the latency above which a request is logged as slow (0 disables it)
+	SlowThreshold time.Duration
+}
+
+// AccessLog writes one structured log line per request
+func AccessLog(logger *zerolog.Logger, cfg AccessLogConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			latency := time.Since(start)
+
+			slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+			var event *zerolog.Event
+			switch {
+			case rec.status >= http.StatusInternalServerError:
+				event = logger.Error()
+			case slow:
+				event = logger.Warn()
+			case rand.Float64() < cfg.SampleRate:
+				event = logger.Info()
+			default:
+				return
+			}
+
+			event.
+				Str("request_id", RequestIDFromContext(r.Context())).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Int("status", rec.status).
+				Int("bytes", rec.bytes).
+				Dur("latency", latency).
+				Bool("slow", slow).
+				Str("user_agent", r.UserAgent()).
+				Msg("request")
+		})
+	}
+}
+
+// statusRecorder captures the status code and response size written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+`
+}
+
+// Returns the content for internal/middlewares/logger.go
+func contextLoggerGoContent(projectName string) string {
+	return fmt.Sprintf(`package middlewares
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"%s/pkg/logger"
+)
+
+// ContextLogger scopes a child of base to each request, carrying its request ID
+// and, for requests traced with a W3C traceparent header, its trace ID, and
+// stores it in the request context for logger.FromContext
+func ContextLogger(base *zerolog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fields := base.With().Str("request_id", RequestIDFromContext(r.Context()))
+			if id := traceID(r.Header.Get("traceparent")); id != "" {
+				fields = fields.Str("trace_id", id)
+			}
+			l := fields.Logger()
+			next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context(), &l)))
+		})
+	}
+}
+
+// traceID returns the trace ID of a traceparent header
+// (version-traceid-parentid-flags), or an empty string if it is not valid
+func traceID(traceparent string) string {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || strings.Trim(parts[1], "0") == "" {
+		return ""
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return ""
+	}
+	return parts[1]
+}
+`, projectName)
+}
+
+// Returns the content for internal/middlewares/logger_test.go
+func contextLoggerTestGoContent(projectName string) string {
+	return fmt.Sprintf(`package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"%s/pkg/logger"
+)
+
+func TestContextLogger(t *testing.T) {
+	var logs bytes.Buffer
+	base := zerolog.New(&logs)
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info().Msg("handled")
+	}), RequestID, ContextLogger(&base))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]string
+	if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+		t.Fatalf("log line %%q: %%v", logs.String(), err)
+	}
+	if line["request_id"] != "req-1" || line["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("log line = %%v, want the request and trace IDs", line)
+	}
+}
+`, projectName)
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Settings of the config files of minimal projects
+func minimalSettings(projectName, env string) []configSetting {
+	level := "debug"
+	if env == "prod" {
+		level = "info"
+	}
+	return []configSetting{
+		{"APP_ENV", env},
+		{"APP_NAME", projectName},
+		{"SERVER_PORT", "8080"},
+		{"LOG_LEVEL", level},
+	}
+}
+
+// Generates the files of a project using only the standard library into dir,
+// with the directory conventions of the api archetype, and returns the steps to
+// run it
+func generateMinimalProject(opts options, dir, binary, image string) []nextStep {
+	projectName := opts.projectName
+
+	createFile(filepath.Join(dir, "cmd", binary, "main.go"), minimalMainGoContent(projectName))
+	createFile(filepath.Join(dir, "pkg", "config", "config.go"), minimalConfigGoContent(projectName))
+	createFile(filepath.Join(dir, "pkg", "logger", "logger.go"), minimalLoggerGoContent())
+	createFile(filepath.Join(dir, "pkg", "logger", "context.go"), minimalLoggerContextGoContent())
+	createFile(filepath.Join(dir, "internal", "handlers", "router.go"), minimalRouterGoContent(projectName))
+	createFile(filepath.Join(dir, "internal", "handlers", "health.go"), minimalHealthGoContent())
+	createFile(filepath.Join(dir, "internal", "handlers", "golden_test.go"), goldenTestGoContent())
+	createFile(filepath.Join(dir, "internal", "handlers", "health_test.go"), minimalHealthTestGoContent(projectName))
+	createFile(filepath.Join(dir, "tests", "golden", "health.json"), "{\n  \"status\": \"ok\"\n}\n")
+	createFile(filepath.Join(dir, "internal", "middlewares", "chain.go"), chainGoContent())
+	createFile(filepath.Join(dir, "internal", "middlewares", "request_id.go"), requestIDGoContent())
+	createFile(filepath.Join(dir, "internal", "middlewares", "access_log.go"), minimalAccessLogGoContent(projectName))
+	createFile(filepath.Join(dir, "web", "web.go"), webGoContent())
+	for _, env := range configEnvironments {
+		var b strings.Builder
+		for _, s := range minimalSettings(projectName, env) {
+			fmt.Fprintf(&b, "%s=%s\n", s.key, s.value)
+		}
+		createFile(filepath.Join(dir, configFileName(env, "env")), b.String())
+	}
+
+	steps := []nextStep{
+		{"Run the API", "make run"},
+		{"Check that it answers", "curl localhost:8080/healthz"},
+		{"Run the tests", "make test"},
+		{"Build the Docker image", "docker build -t " + image + " ."},
+	}
+	if opts.release == "goreleaser" {
+		steps = append(steps, nextStep{"Release a version by pushing a tag", "git tag v0.1.0 && git push origin v0.1.0"})
+	}
+	if opts.changelog != "" {
+		steps = append(steps, nextStep{"Commit with conventional commit messages, then write the changelog", "make changelog"})
+	}
+	switch {
+	case opts.noGit && opts.changelog != "":
+		steps = append(steps, nextStep{"Create the Git repository and enable the commit hooks", "git init && make hooks"})
+	case opts.noGit:
+		steps = append(steps, nextStep{"Create the Git repository", "git init"})
+	case opts.noCommit:
+		steps = append(steps, nextStep{"Review the generated files, then commit them", "git add -A && git commit -m \"" + initialCommitMessage + "\""})
+	}
+
+	createFile(filepath.Join(dir, "README.md"), minimalReadmeContent(opts, steps))
+	createFile(filepath.Join(dir, ".gitignore"), gitignoreContent())
+	createFile(filepath.Join(dir, ".gitattributes"), gitattributesContent())
+	createFile(filepath.Join(dir, ".editorconfig"), editorconfigContent())
+	createFile(filepath.Join(dir, ".golangci.yml"), golangciConfigContent(opts))
+	makefile := makefileBaseContent([]string{binary}, opts.static)
+	if opts.release == "goreleaser" {
+		makefile += releaseMakefileContent()
+	}
+	if opts.changelog != "" {
+		makefile += changelogMakefileContent(opts.changelog)
+	}
+	createFile(filepath.Join(dir, "Makefile"), makefile)
+	createFile(filepath.Join(dir, "Dockerfile"), dockerfileContent(binary, opts.static, opts.goprivate))
+	createFile(filepath.Join(dir, ".dockerignore"), dockerignoreContent(opts))
+
+	for _, f := range adrFiles(opts) {
+		createFile(filepath.Join(dir, f.path), f.content)
+	}
+
+	data := assetData{ProjectName: projectName, ModulePath: opts.modulePath, Archetype: opts.archetype, ConfigFormat: opts.configFormat, Vars: opts.vars}
+	copyAssets(path.Join("assets", opts.archetype), dir, data)
+
+	generateTooling(opts, dir, binary, image)
+
+	if opts.template != "" {
+		applyTemplate(opts.template, opts.layers, dir, data)
+	}
+	return steps
+}
+
+// Returns the README of a minimal project
+func minimalReadmeContent(opts options, steps []nextStep) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", opts.projectName)
+	b.WriteString("An HTTP API written with the Go standard library only: `net/http` for routing, `log/slog` for logs and environment variables for settings.\n")
+	b.WriteString("\n## Getting started\n\n")
+	for i, step := range steps {
+		fmt.Fprintf(&b, "%d. %s:\n\n   ```sh\n   %s\n   ```\n\n", i+1, step.text, step.command)
+	}
+	b.WriteString("Settings are environment variables, falling back to `configs/<APP_ENV>.env` (`APP_ENV` defaults to `dev`) and then to the defaults of `pkg/config`.\n")
+	if opts.goprivate != "" {
+		b.WriteString(privateModulesReadmeContent(opts.goprivate))
+	}
+	b.WriteString(generatedByContent(opts))
+	return b.String()
+}
+
+// Returns the content for cmd/<name>/main.go of a minimal project
+func minimalMainGoContent(projectName string) string {
+	return fmt.Sprintf(`package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"%[1]s/internal/handlers"
+	"%[1]s/pkg/config"
+	"%[1]s/pkg/logger"
+)
+
+// Build information, injected at build time through -ldflags (see Makefile)
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load the configuration: %%v", err)
+	}
+
+	appLog, err := logger.New(cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %%v", err)
+	}
+	appLog.Info("Starting the application",
+		slog.String("version", version),
+		slog.String("commit", commit),
+		slog.String("date", date),
+		slog.String("env", cfg.AppEnv))
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%%d", cfg.ServerPort),
+		Handler:           handlers.NewRouter(appLog),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		appLog.Info("HTTP server listening", slog.String("addr", srv.Addr))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			appLog.Error("HTTP server failed", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}()
+
+	// Wait for an interrupt, then give in-flight requests time to finish
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		appLog.Error("Graceful shutdown failed", slog.Any("error", err))
+	}
+	appLog.Info("Server stopped")
+}
+`, projectName)
+}
+
+// Returns the content for pkg/config/config.go of a minimal project
+func minimalConfigGoContent(projectName string) string {
+	return fmt.Sprintf(`package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config holds the settings of the application
+type Config struct {
+	AppEnv     string
+	AppName    string
+	ServerPort int
+	LogLevel   string
+}
+
+// LoadConfig reads the settings. Each one is resolved in the following order of
+// precedence:
+//  1. an environment variable with the setting's name (e.g. SERVER_PORT=9090)
+//  2. the config file of the environment named by APP_ENV, configs/<APP_ENV>.env
+//  3. its default
+func LoadConfig() (*Config, error) {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "dev"
+	}
+	file, err := readEnvFile(filepath.Join("configs", env+".env"))
+	if err != nil {
+		return nil, err
+	}
+	get := func(key, def string) string {
+		if v, ok := os.LookupEnv(key); ok {
+			return v
+		}
+		if v, ok := file[key]; ok {
+			return v
+		}
+		return def
+	}
+
+	cfg := &Config{
+		AppEnv:   env,
+		AppName:  get("APP_NAME", %[1]q),
+		LogLevel: get("LOG_LEVEL", "info"),
+	}
+	port := get("SERVER_PORT", "8080")
+	cfg.ServerPort, err = strconv.Atoi(port)
+	if err != nil || cfg.ServerPort < 1 || cfg.ServerPort > 65535 {
+		return nil, fmt.Errorf("SERVER_PORT must be between 1 and 65535, got %%q", port)
+	}
+	return cfg, nil
+}
+
+// readEnvFile returns the KEY=VALUE lines of the file at path, skipping blank
+// lines and # comments. A missing file has no settings.
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	settings := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%%s:%%d: expected KEY=VALUE, got %%q", path, n, line)
+		}
+		settings[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return settings, scanner.Err()
+}
+`, projectName)
+}
+
+// Returns the content for pkg/logger/logger.go of a minimal project
+func minimalLoggerGoContent() string {
+	return `package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// New returns a logger writing JSON lines to stdout from level on (debug, info,
+// warn or error), and makes it the default logger of log/slog
+func New(level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: l}))
+	slog.SetDefault(logger)
+	return logger, nil
+}
+`
+}
+
+// Returns the content for pkg/logger/context.go of a minimal project, its own
+// file as in the other projects so that gogo add reuses it
+func minimalLoggerContextGoContent() string {
+	return `package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+// WithContext returns a copy of ctx carrying l, which FromContext returns
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger of ctx, e.g. the one the AccessLog middleware
+// scopes to each request with its request ID, or the default logger when ctx
+// has none
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+`
+}
+
+// Returns the content for internal/handlers/router.go of a minimal project
+func minimalRouterGoContent(projectName string) string {
+	return fmt.Sprintf(`package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"%[1]s/internal/middlewares"
+	"%[1]s/web"
+)
+
+// NewRouter registers all routes and wraps them with the common middlewares
+func NewRouter(logger *slog.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", Health)
+	mux.Handle("GET /favicon.ico", http.FileServerFS(web.Static()))
+	mux.Handle("GET /robots.txt", http.FileServerFS(web.Static()))
+
+	return middlewares.Chain(mux, middlewares.RequestID, middlewares.AccessLog(logger))
+}
+`, projectName)
+}
+
+// Returns the content for internal/handlers/health.go of a minimal project
+func minimalHealthGoContent() string {
+	return `package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Health reports that the service is up
+func Health(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+`
+}
+
+// Returns the content for internal/handlers/health_test.go of a minimal project
+func minimalHealthTestGoContent(projectName string) string {
+	return fmt.Sprintf(`package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"%s/internal/middlewares"
+)
+
+func newTestRouter() http.Handler {
+	return NewRouter(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestHealth(t *testing.T) {
+	rec := serve(newTestRouter(), http.MethodGet, "/healthz", "")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %%d, want %%d", rec.Code, http.StatusOK)
+	}
+	if rec.Header().Get(middlewares.RequestIDHeader) == "" {
+		t.Error("response has no request ID")
+	}
+	assertGolden(t, "health", rec.Body.Bytes())
+}
+
+func TestUnknownRoute(t *testing.T) {
+	if rec := serve(newTestRouter(), http.MethodGet, "/does-not-exist", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("status = %%d, want %%d", rec.Code, http.StatusNotFound)
+	}
+}
+`, projectName)
+}
+
+// Returns the content for internal/middlewares/access_log.go of a minimal project
+func minimalAccessLogGoContent(projectName string) string {
+	return fmt.Sprintf(`package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"%s/pkg/logger"
+)
+
+// AccessLog gives each request a logger carrying its request ID, for handlers
+// to log through logger.FromContext, and writes one log line per request
+func AccessLog(base *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			l := base.With(slog.String("request_id", RequestIDFromContext(r.Context())))
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(logger.WithContext(r.Context(), l)))
+
+			level := slog.LevelInfo
+			if rec.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			l.LogAttrs(r.Context(), level, "request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Int("bytes", rec.bytes),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("user_agent", r.UserAgent()))
+		})
+	}
+}
+
+// statusRecorder captures the status code and response size written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+`, projectName)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Path of the registry of the modules of a modular monolith, which carries the
+// modules marker
+var modulesPath = filepath.Join("internal", "modules", "modules.go")
+
+// Module generated with --layout=modular-monolith
+const exampleModule = "example"
+
+// Names accepted by gogo add module, which are also package names
+var moduleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// Adds a module to a modular monolith: its handler, service and repo packages
+// under internal/modules/<name>, registered in internal/modules/modules.go.
+// Usage: gogo add module <name>
+func addModule(p project, args []string) ([]generatedFile, []string) {
+	if len(args) < 1 {
+		fatalf(exitUsage, "Please provide a module name, e.g. gogo add module billing")
+	}
+	name := args[0]
+	if !moduleNamePattern.MatchString(name) {
+		fatalf(exitUsage, "Unsupported module name %q (a package name: lowercase letters and digits, e.g. billing)", name)
+	}
+	if _, err := os.Stat(filepath.Join(p.dir, modulesPath)); err != nil {
+		fatalf(exitUsage, "gogo add module requires a project generated with --layout=modular-monolith (%s is missing)", modulesPath)
+	}
+
+	files := moduleFiles(p.modulePath, name)
+	files = append(files,
+		generatedFile{path: modulesPath, marker: markerImports, content: fmt.Sprintf("%q", p.modulePath+"/internal/modules/"+name)},
+		generatedFile{path: modulesPath, marker: markerModules, content: name + ".Register(mux)", step: "Register the module in internal/modules/modules.go: " + name + ".Register(mux)"},
+	)
+	steps := []string{fmt.Sprintf("Try it: curl -d '{\"name\":\"first\"}' localhost:8080/%s/items", name)}
+	return files, steps
+}
+
+// Returns the files of the module name, relative to the project
+func moduleFiles(modulePath, name string) []generatedFile {
+	dir := filepath.Join("internal", "modules", name)
+	return []generatedFile{
+		{path: filepath.Join(dir, "module.go"), content: moduleGoContent(modulePath, name)},
+		{path: filepath.Join(dir, "handler", "handler.go"), content: moduleHandlerGoContent(modulePath, name)},
+		{path: filepath.Join(dir, "handler", "handler_test.go"), content: moduleHandlerTestGoContent(modulePath, name)},
+		{path: filepath.Join(dir, "service", "service.go"), content: moduleServiceGoContent(modulePath, name)},
+		{path: filepath.Join(dir, "repo", "repo.go"), content: moduleRepoGoContent(name)},
+	}
+}
+
+// Returns the content for internal/modules/modules.go, registering the example module
+func modulesGoContent(modulePath string) string {
+	return fmt.Sprintf(`package modules
+
+import (
+	"net/http"
+
+	"%[1]s/internal/modules/%[2]s"
+)
+
+// Register registers the routes of every module on mux
+func Register(mux *http.ServeMux) {
+	%[2]s.Register(mux)
+	// Modules added with gogo add module are registered below
+	// gogo:modules
+}
+`, modulePath, exampleModule)
+}
+
+// Returns the content for internal/modules/<name>/module.go
+func moduleGoContent(modulePath, name string) string {
+	return fmt.Sprintf(`package %[2]s
+
+import (
+	"net/http"
+
+	"%[1]s/internal/modules/%[2]s/handler"
+	"%[1]s/internal/modules/%[2]s/repo"
+	"%[1]s/internal/modules/%[2]s/service"
+)
+
+// Register wires the module's repo, service and handler, and registers its
+// routes on mux under /%[2]s/
+func Register(mux *http.ServeMux) {
+	handler.New(service.New(repo.NewMemory())).Register(mux)
+}
+`, modulePath, name)
+}
+
+// Returns the content for internal/modules/<name>/handler/handler.go
+func moduleHandlerGoContent(modulePath, name string) string {
+	return fmt.Sprintf(`package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"%[1]s/internal/modules/%[2]s/service"
+)
+
+// Handler serves the routes of the %[2]s module
+type Handler struct {
+	svc *service.Service
+}
+
+// New creates a Handler
+func New(svc *service.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Register registers the module's routes on mux
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /%[2]s/items", h.list)
+	mux.HandleFunc("POST /%[2]s/items", h.create)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `+"`json:\"name\"`"+`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	item, err := h.svc.Create(r.Context(), req.Name)
+	if errors.Is(err, service.ErrInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+`, modulePath, name)
+}
+
+// Returns the content for internal/modules/<name>/handler/handler_test.go
+func moduleHandlerTestGoContent(modulePath, name string) string {
+	return fmt.Sprintf(`package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"%[1]s/internal/modules/%[2]s/repo"
+	"%[1]s/internal/modules/%[2]s/service"
+)
+
+func TestCreateAndList(t *testing.T) {
+	mux := http.NewServeMux()
+	New(service.New(repo.NewMemory())).Register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/%[2]s/items", strings.NewReader(`+"`"+`{"name":"first"}`+"`"+`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %%d, want %%d", rec.Code, http.StatusCreated)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/%[2]s/items", nil))
+	if want := `+"`"+`[{"id":1,"name":"first"}]`+"`"+`; strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("list = %%s, want %%s", rec.Body.String(), want)
+	}
+}
+
+func TestCreateInvalid(t *testing.T) {
+	mux := http.NewServeMux()
+	New(service.New(repo.NewMemory())).Register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/%[2]s/items", strings.NewReader(`+"`"+`{"name":""}`+"`"+`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %%d, want %%d", rec.Code, http.StatusBadRequest)
+	}
+}
+`, modulePath, name)
+}
+
+// Returns the content for internal/modules/<name>/service/service.go
+func moduleServiceGoContent(modulePath, name string) string {
+	return fmt.Sprintf(`package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"%[1]s/internal/modules/%[2]s/repo"
+)
+
+// ErrInvalid is returned for input breaking the module's rules
+var ErrInvalid = errors.New("invalid %[2]s item")
+
+// Service implements the use cases of the %[2]s module
+type Service struct {
+	repo repo.Repository
+}
+
+// New creates a Service storing its items in r
+func New(r repo.Repository) *Service {
+	return &Service{repo: r}
+}
+
+// List returns every item
+func (s *Service) List(ctx context.Context) ([]repo.Item, error) {
+	return s.repo.List(ctx)
+}
+
+// Create adds an item, which must have a name
+func (s *Service) Create(ctx context.Context, name string) (repo.Item, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return repo.Item{}, errors.Join(ErrInvalid, errors.New("name is required"))
+	}
+	return s.repo.Create(ctx, name)
+}
+`, modulePath, name)
+}
+
+// Returns the content for internal/modules/<name>/repo/repo.go
+func moduleRepoGoContent(name string) string {
+	return fmt.Sprintf(`package repo
+
+import (
+	"context"
+	"sync"
+)
+
+// Item is a record of the %[1]s module
+type Item struct {
+	ID   int64  `+"`json:\"id\"`"+`
+	Name string `+"`json:\"name\"`"+`
+}
+
+// Repository stores the items of the module
+type Repository interface {
+	List(ctx context.Context) ([]Item, error)
+	Create(ctx context.Context, name string) (Item, error)
+}
+
+// Memory is an in-memory Repository, to replace with a database-backed one
+type Memory struct {
+	mu    sync.Mutex
+	items []Item
+}
+
+// NewMemory creates an empty Memory
+func NewMemory() *Memory {
+	return &Memory{items: []Item{}}
+}
+
+// List returns the items in creation order
+func (m *Memory) List(ctx context.Context) ([]Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Item{}, m.items...), nil
+}
+
+// Create stores an item with the next ID
+func (m *Memory) Create(ctx context.Context, name string) (Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item := Item{ID: int64(len(m.items) + 1), Name: name}
+	m.items = append(m.items, item)
+	return item, nil
+}
+`, name)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// Naming engine shared by the generators: every Go identifier, table, route and
+// file name derived from a name given on the command line goes through it, so a
+// resource is named the same way in its migration, model, handler and tests.
+
+// Initialisms kept upper case in Go identifiers, as in the standard library
+var commonInitialisms = map[string]bool{
+	"acl": true, "api": true, "ascii": true, "cpu": true, "css": true, "dns": true, "eof": true,
+	"guid": true, "html": true, "http": true, "https": true, "id": true, "ip": true, "json": true,
+	"qps": true, "ram": true, "rpc": true, "sla": true, "smtp": true, "sql": true, "ssh": true,
+	"tcp": true, "tls": true, "ttl": true, "udp": true, "ui": true, "uid": true, "uuid": true,
+	"uri": true, "url": true, "utf8": true, "vm": true, "xml": true, "xmpp": true, "xsrf": true,
+	"xss": true,
+}
+
+// Plurals the suffix rules get wrong, by singular. Projects add their own under
+// "inflections" in .gogo.json (see addInflections).
+var irregularPlurals = map[string]string{
+	"person":    "people",
+	"child":     "children",
+	"man":       "men",
+	"woman":     "women",
+	"mouse":     "mice",
+	"goose":     "geese",
+	"tooth":     "teeth",
+	"foot":      "feet",
+	"ox":        "oxen",
+	"criterion": "criteria",
+	"analysis":  "analyses",
+	"leaf":      "leaves",
+	"knife":     "knives",
+	"life":      "lives",
+	"cache":     "caches",
+	"movie":     "movies",
+	"cookie":    "cookies",
+	"quiz":      "quizzes",
+}
+
+// Nouns with the same singular and plural
+var uncountableNouns = map[string]bool{
+	"data": true, "equipment": true, "feedback": true, "information": true, "metadata": true,
+	"money": true, "news": true, "series": true, "software": true, "species": true,
+}
+
+// Registers a project's own irregular forms (singular to plural), overriding the
+// built-in ones; a noun mapped to itself is uncountable
+func addInflections(forms map[string]string) {
+	for singular, plural := range forms {
+		singular, plural = snakeCase(singular), snakeCase(plural)
+		if singular == plural {
+			uncountableNouns[singular] = true
+			continue
+		}
+		irregularPlurals[singular] = plural
+	}
+}
+
+// Converts CamelCase, kebab-case or space separated names to snake_case
+func snakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(strings.TrimSpace(s))
+	for i, c := range runes {
+		switch {
+		case c == '-' || c == ' ':
+			b.WriteRune('_')
+		case unicode.IsUpper(c):
+			if i > 0 && runes[i-1] != '_' && runes[i-1] != '-' && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(c))
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Converts a snake_case name to PascalCase, keeping common initialisms upper case
+func pascalCase(snake string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(snake, "_") {
+		if part == "" {
+			continue
+		}
+		if commonInitialisms[part] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// Converts a snake_case name to camelCase, e.g. api_key to apiKey
+func camelCase(snake string) string {
+	first, rest, _ := strings.Cut(strings.TrimLeft(snake, "_"), "_")
+	return first + pascalCase(rest)
+}
+
+// Converts CamelCase, snake_case or space separated names to kebab-case
+func kebabCase(s string) string {
+	return strings.ReplaceAll(snakeCase(s), "_", "-")
+}
+
+// Returns the exported Go identifier for a name in any case, e.g. api_key to APIKey
+func exportedName(name string) string {
+	id := pascalCase(snakeCase(name))
+	if id == "" || !unicode.IsLetter([]rune(id)[0]) {
+		id = "X" + id
+	}
+	return id
+}
+
+// Returns the unexported Go identifier for a name in any case, e.g. APIKey to
+// apiKey, avoiding keywords: a resource named package is held in aPackage
+func unexportedName(name string) string {
+	id := camelCase(snakeCase(name))
+	if token.IsKeyword(id) {
+		return "a" + exportedName(name)
+	}
+	return id
+}
+
+// Returns the table of a resource: the plural of its snake_case name
+func tableName(name string) string {
+	return pluralize(snakeCase(name))
+}
+
+// Returns the English plural of a snake_case singular noun (last word only)
+func pluralize(s string) string {
+	prefix, last := splitLastWord(s)
+	if plural, ok := irregularPlurals[last]; ok {
+		return prefix + plural
+	}
+	switch {
+	case uncountableNouns[last]:
+		return s
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "z"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	default:
+		return s + "s"
+	}
+}
+
+// Returns the singular of a snake_case noun (last word only), so a resource can be
+// named either way on the command line; singular nouns are returned unchanged
+func singularize(s string) string {
+	prefix, last := splitLastWord(s)
+	if uncountableNouns[last] {
+		return s
+	}
+	for singular, plural := range irregularPlurals {
+		if last == plural {
+			return prefix + singular
+		}
+		if last == singular {
+			return s
+		}
+	}
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "xes"), strings.HasSuffix(s, "zes"), strings.HasSuffix(s, "ches"), strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "uses") && len(s) > 4 && strings.ContainsRune("bcnprt", rune(s[len(s)-5])):
+		return s[:len(s)-2] // statuses, buses, bonuses
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss") && !strings.HasSuffix(s, "us") && !strings.HasSuffix(s, "is"):
+		return s[:len(s)-1]
+	default:
+		return s
+	}
+}
+
+// Reports whether two names given on the command line name the same thing,
+// e.g. Product and products
+func sameName(a, b string) bool {
+	return singularize(snakeCase(a)) == singularize(snakeCase(b))
+}
+
+// Splits a snake_case name before its last word, e.g. line_item to line_ and item
+func splitLastWord(s string) (string, string) {
+	i := strings.LastIndex(s, "_")
+	return s[:i+1], s[i+1:]
+}
+
+// Converts a snake_case name to lower case words for doc comments and messages
+func humanize(snake string) string {
+	return strings.ReplaceAll(snake, "_", " ")
+}
+
+// Prefixes a noun with "a" or "an"
+func withArticle(noun string) string {
+	if noun != "" && strings.ContainsRune("aeiou", rune(noun[0])) {
+		return "an " + noun
+	}
+	return "a " + noun
+}
@@ -0,0 +1,264 @@
+package main
+
+import "fmt"
+
+// Settings added to the config of NATS JetStream services
+var natsConfigFields = []configField{
+	{goName: "NatsURL", goType: "string", key: "NATS_URL", value: "nats://localhost:4222", comment: "NATS connection and the JetStream stream/durable consumer provisioned at startup"},
+	{goName: "NatsStream", goType: "string", key: "NATS_STREAM", value: "EVENTS"},
+	{goName: "NatsSubject", goType: "string", key: "NATS_SUBJECT", value: "events.>"},
+	{goName: "NatsConsumer", goType: "string", key: "NATS_CONSUMER", value: "myapi"},
+}
+
+// NATS server with JetStream enabled, for docker-compose.yml
+var natsComposeService = composeService{
+	name: "nats",
+	definition: `    image: nats:2.10-alpine
+    command: ["--jetstream", "--http_port", "8222"]
+    ports:
+      - "4222:4222"
+      - "8222:8222"
+`,
+	devEnv: []string{"NATS_URL: nats://nats:4222"},
+}
+
+// Returns the content for cmd/<name>/main.go of a NATS JetStream service
+func natsMainGoContent(projectName string) string {
+	return fmt.Sprintf(`package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/nats-io/nats.go"
+
+	"%[1]s/internal/handlers"
+	"%[1]s/internal/messaging"
+	"%[1]s/pkg/config"
+	"%[1]s/pkg/logger"
+)
+
+// Build information, injected at build time through -ldflags (see Makefile)
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Initialize logger
+	appLog, err := logger.NewLogger(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %%v", err)
+	}
+
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		appLog.Warn().Err(err).Msg("Invalid log level, keeping the default")
+	}
+
+	// Apply log level changes at runtime when config watching is enabled
+	if cfg.WatchConfig {
+		config.Subscribe(func(c *config.Config) {
+			if err := logger.SetLevel(c.LogLevel); err != nil {
+				appLog.Warn().Err(err).Msg("Invalid log level in reloaded config")
+				return
+			}
+			appLog.Info().Str("level", c.LogLevel).Msg("Log level updated")
+		})
+		config.Watch()
+	}
+
+	appLog.Info().
+		Str("version", version).
+		Str("commit", commit).
+		Str("date", date).
+		Msg("Starting the application")
+	cfg.Print(os.Stdout)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	nc, err := nats.Connect(cfg.NatsURL, nats.Name(cfg.AppName))
+	if err != nil {
+		appLog.Fatal().Err(err).Msg("Failed to connect to NATS")
+	}
+
+	// Make sure the stream and durable consumer exist, then start consuming events
+	_, consumer, err := messaging.Provision(ctx, nc, messaging.StreamConfig{
+		Stream:   cfg.NatsStream,
+		Subjects: []string{cfg.NatsSubject},
+		Consumer: cfg.NatsConsumer,
+	})
+	if err != nil {
+		appLog.Fatal().Err(err).Msg("Failed to provision JetStream")
+	}
+	consumption, err := consumer.Consume(handlers.HandleEvent(appLog))
+	if err != nil {
+		appLog.Fatal().Err(err).Msg("Failed to consume events")
+	}
+
+	// Serve request-reply endpoints
+	svc, err := handlers.NewService(nc, cfg.AppName)
+	if err != nil {
+		appLog.Fatal().Err(err).Msg("Failed to start NATS service")
+	}
+	appLog.Info().Str("url", cfg.NatsURL).Str("stream", cfg.NatsStream).Msg("NATS service started")
+
+	// Wait for an interrupt, then stop taking new work and let in-flight messages finish
+	<-ctx.Done()
+	consumption.Stop()
+	if err := svc.Stop(); err != nil {
+		appLog.Error().Err(err).Msg("Failed to stop NATS service")
+	}
+	if err := nc.Drain(); err != nil {
+		appLog.Error().Err(err).Msg("Failed to drain NATS connection")
+	}
+	appLog.Info().Msg("Service stopped")
+}
+`, projectName)
+}
+
+// Returns the content for internal/messaging/jetstream.go
+func jetStreamGoContent() string {
+	return `package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// StreamConfig describes the stream and the durable consumer owned by this service
+type StreamConfig struct {
+	Stream   string
+	Subjects []string
+	Consumer string
+}
+
+// Provision creates or updates the stream and its durable consumer, so every
+// deployment converges on the configuration in code
+func Provision(ctx context.Context, nc *nats.Conn, cfg StreamConfig) (jetstream.JetStream, jetstream.Consumer, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating JetStream context: %w", err)
+	}
+
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.Stream,
+		Subjects: cfg.Subjects,
+		Storage:  jetstream.FileStorage,
+		MaxAge:   7 * 24 * time.Hour,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("provisioning stream %s: %w", cfg.Stream, err)
+	}
+
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:    cfg.Consumer,
+		AckPolicy:  jetstream.AckExplicitPolicy,
+		AckWait:    30 * time.Second,
+		MaxDeliver: 5,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("provisioning consumer %s: %w", cfg.Consumer, err)
+	}
+	return js, consumer, nil
+}
+
+// Publish sends v as a JSON event and waits for the stream to acknowledge it
+func Publish(ctx context.Context, js jetstream.JetStream, subject string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = js.Publish(ctx, subject, data)
+	return err
+}
+`
+}
+
+// Returns the content for internal/handlers/service.go
+func natsServiceGoContent() string {
+	return `package handlers
+
+import (
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+)
+
+// ServiceVersion is the semantic version advertised through the NATS services API
+const ServiceVersion = "0.1.0"
+
+// NewService registers the request-reply endpoints as a NATS service, discoverable
+// with "nats micro ls". Endpoints are served on <name>.<endpoint>.
+func NewService(nc *nats.Conn, name string) (micro.Service, error) {
+	svc, err := micro.AddService(nc, micro.Config{
+		Name:        name,
+		Version:     ServiceVersion,
+		Description: "Request-reply endpoints of " + name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	group := svc.AddGroup(name)
+	if err := group.AddEndpoint("ping", micro.HandlerFunc(Ping)); err != nil {
+		return nil, err
+	}
+	if err := group.AddEndpoint("echo", micro.HandlerFunc(Echo)); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// Ping reports that the service is up
+func Ping(req micro.Request) {
+	req.RespondJSON(map[string]string{"status": "ok"})
+}
+
+// Echo replies with the request payload
+func Echo(req micro.Request) {
+	if len(req.Data()) == 0 {
+		req.Error("400", "empty request", nil)
+		return
+	}
+	req.Respond(req.Data())
+}
+`
+}
+
+// Returns the content for internal/handlers/events.go
+func natsEventsGoContent() string {
+	return `package handlers
+
+import (
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+)
+
+// HandleEvent processes messages delivered by the durable consumer. Returning without
+// acknowledging lets JetStream redeliver the message after the ack wait.
+func HandleEvent(logger *zerolog.Logger) jetstream.MessageHandler {
+	return func(msg jetstream.Msg) {
+		logger.Info().
+			Str("subject", msg.Subject()).
+			Int("size", len(msg.Data())).
+			Msg("event received")
+
+		if err := msg.Ack(); err != nil {
+			logger.Error().Err(err).Str("subject", msg.Subject()).Msg("Failed to acknowledge event")
+		}
+	}
+}
+`
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// nextStep is an instruction printed after generation and written to the
+// generated README
+type nextStep struct {
+	text    string
+	command string
+}
+
+// Returns the steps to run the project opts describes, whose docker-compose.yml
+// has the given services besides postgres
+func nextSteps(opts options, services []composeService) []nextStep {
+	compose := []string{"postgres"}
+	for _, s := range services {
+		compose = append(compose, s.name)
+	}
+	steps := []nextStep{
+		{"Fetch the dependencies at the versions go.mod requires", "go mod tidy"},
+		{"Start the local dependencies", "docker compose up -d " + strings.Join(compose, " ")},
+	}
+
+	switch opts.archetype {
+	case "batch":
+		steps = append(steps,
+			nextStep{"Try the job on the sample input without writing anything", "make job-dry-run"},
+			nextStep{"Run it, with the flags in ARGS overriding the settings", `make job ARGS="--workers=4"`},
+		)
+	case "nats":
+		steps = append(steps, nextStep{"Run the service, which provisions its stream and consumer", "make run"})
+	default:
+		steps = append(steps,
+			nextStep{"Run the API", "make run"},
+			nextStep{"Check that it answers", "curl localhost:8080/healthz"},
+			nextStep{"Check that it is ready, once its dependencies are up", "curl localhost:8080/readyz"},
+		)
+		if opts.layout == "modular-monolith" {
+			steps = append(steps,
+				nextStep{"List the items of the example module", "curl localhost:8080/" + exampleModule + "/items"},
+				nextStep{"Add a module", "gogo add module billing"},
+			)
+		} else if opts.example != "" {
+			table := tableName(opts.example)
+			steps = append(steps,
+				nextStep{"Create the " + table + " table of the example and insert its seed rows", "make migrate seed"},
+				nextStep{"List them", "curl localhost:8080/" + table},
+			)
+		} else {
+			steps = append(steps, nextStep{"Add a resource, then create its table", "gogo add resource product name:string price:float && make migrate"})
+		}
+	}
+	if opts.errors == "sentry" {
+		steps = append(steps, nextStep{"Report the errors and panics to Sentry by giving the DSN of your Sentry project", "SENTRY_DSN=https://<key>@<host>/<project> make run"})
+	}
+	if opts.pprof {
+		steps = append(steps, nextStep{"Profile the running API (PROFILE=heap for its memory)", "make pprof"})
+	}
+	switch opts.profiling {
+	case "pyroscope":
+		steps = append(steps, nextStep{"Browse its continuous profiles in Pyroscope", "open http://localhost:4040"})
+	case "grafana-agent":
+		steps = append(steps, nextStep{"Browse in Pyroscope the profiles Grafana Agent scrapes from the API running in Docker (make up)", "open http://localhost:4040"})
+	}
+	if opts.workflow == "temporal" {
+		steps = append(steps,
+			nextStep{"Run the Temporal worker", "make worker"},
+			nextStep{"Start the sample workflow from another terminal", "make start-workflow NAME=Temporal"},
+		)
+	}
+	steps = append(steps,
+		nextStep{"Run the tests", "make test"},
+		nextStep{"Or run everything in Docker (APP_ENV=staging or prod for the other profiles)", "make up"},
+	)
+
+	if opts.contract == "pact" {
+		steps = append(steps, nextStep{"Verify the provider contracts", "make pact-install && make contract-test"})
+	}
+	if opts.target == "lambda" {
+		steps = append(steps, nextStep{"Run the Lambda functions locally with SAM", "make sam-local"})
+	}
+	switch {
+	case opts.iac == "terraform":
+		steps = append(steps, nextStep{"Plan the infrastructure of the dev environment", "make tf-init && make tf-plan IMAGE=<image>"})
+	case opts.deploy == "cloudrun":
+		steps = append(steps, nextStep{"Deploy to Cloud Run", "make deploy GCP_PROJECT=<project> GCP_REGION=<region>"})
+	}
+	if opts.release == "goreleaser" {
+		steps = append(steps, nextStep{"Release a version by pushing a tag", "git tag v0.1.0 && git push origin v0.1.0"})
+	}
+	if opts.changelog != "" {
+		steps = append(steps, nextStep{"Commit with conventional commit messages, then write the changelog", "make changelog"})
+	}
+	switch {
+	case opts.noGit && opts.changelog != "":
+		steps = append(steps, nextStep{"Create the Git repository and enable the commit hooks", "git init && make hooks"})
+	case opts.noGit:
+		steps = append(steps, nextStep{"Create the Git repository", "git init"})
+	case opts.noCommit:
+		steps = append(steps, nextStep{"Review the generated files, then commit them", "git add -A && git commit -m \"" + initialCommitMessage + "\""})
+	}
+	return steps
+}
+
+// Prints the steps after generating the project, starting with entering it
+func printNextSteps(projectDir string, steps []nextStep) {
+	fmt.Println("\n" + colorize(os.Stdout, styleBold, tr("Next steps:")))
+	fmt.Printf("  1. %s\n", colorize(os.Stdout, styleCyan, "cd "+projectDir))
+	for i, step := range steps {
+		fmt.Printf("  %d. %s:\n       %s\n", i+2, tr(step.text), colorize(os.Stdout, styleCyan, step.command))
+	}
+	fmt.Println("\n" + tr("These steps are also in README.md."))
+}
+
+// Returns the section of the README recording the command generating the
+// project again, to reproduce it or compare it with other options
+func generatedByContent(opts options) string {
+	by := "gogo"
+	if v := gogoVersion(); v != "" {
+		by += " " + v
+	}
+	return fmt.Sprintf("\n## Scaffold\n\nGenerated by %s with the command below, also recorded in `%s`. Run it again, changing the options, to reproduce the scaffold or compare it with another one.\n\n```sh\n%s\n```\n", by, manifestFileName, reproducibleCommand(opts))
+}
+
+// Returns the content for the README of a generated project
+func readmeContent(opts options, steps []nextStep) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", opts.projectName)
+	switch opts.archetype {
+	case "batch":
+		b.WriteString("A batch job reading records from a source, transforming them and writing them to a sink, resuming from checkpoints.\n")
+	case "nats":
+		b.WriteString("A NATS JetStream service consuming events and answering requests.\n")
+	default:
+		b.WriteString("An HTTP API.\n")
+	}
+	b.WriteString("\n## Getting started\n\n")
+	for i, step := range steps {
+		fmt.Fprintf(&b, "%d. %s:\n\n   ```sh\n   %s\n   ```\n\n", i+1, step.text, step.command)
+	}
+	fmt.Fprintf(&b, "Settings are read from `%s` (`APP_ENV` defaults to `dev`) and can be overridden with environment variables, themselves overridden by the command line flags that `--help` lists.\n", filepath.ToSlash(configFileName("<APP_ENV>", opts.configFormat)))
+	if opts.goprivate != "" {
+		b.WriteString(privateModulesReadmeContent(opts.goprivate))
+	}
+	b.WriteString(generatedByContent(opts))
+	return b.String()
+}
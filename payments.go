@@ -0,0 +1,506 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+)
+
+// Adds payments: checkout sessions, webhook handling with signature verification
+// and idempotent event processing
+// Usage: gogo add payments [--provider=stripe]
+func addPayments(p project, args []string) ([]generatedFile, []string) {
+	fs := flag.NewFlagSet("gogo add payments", flag.ExitOnError)
+	provider := fs.String("provider", "stripe", "payment provider (stripe)")
+	parseFlags(fs, args)
+
+	switch *provider {
+	case "stripe":
+	default:
+		fatalf(exitUsage, "Unsupported --provider value %q (supported: stripe)", *provider)
+	}
+
+	files := p.migration("create_payments", paymentsMigrationUpContent(), paymentsMigrationDownContent())
+	files = append(files,
+		generatedFile{path: filepath.Join("internal", "payments", "config.go"), content: paymentsConfigGoContent()},
+		generatedFile{path: filepath.Join("internal", "repository", "errors.go"), content: repositoryErrorsGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "repository", "payment_repository.go"), content: paymentRepositoryGoContent()},
+		generatedFile{path: filepath.Join("internal", "services", "payment_service.go"), content: paymentServiceGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "handlers", "respond.go"), content: respondGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "handlers", "payment_handler.go"), content: paymentHandlerGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "handlers", "payment_handler_test.go"), content: paymentHandlerTestGoContent(p.modulePath)},
+		generatedFile{path: "Makefile", marker: markerEnd, content: stripeMakefileContent(),
+			applied: "Forward webhooks locally: make stripe-listen", step: "Forward webhooks locally with the Stripe CLI: stripe listen --forward-to localhost:8080/webhooks/stripe"},
+	)
+
+	return files, []string{
+		"Fetch the Stripe SDK: go get " + goGetArgs("github.com/stripe/stripe-go/v76") + " && go mod tidy",
+		"Run the migration: make migrate DB_URL=postgres://...",
+		"Set STRIPE_SECRET_KEY, STRIPE_WEBHOOK_SECRET, PAYMENTS_SUCCESS_URL and PAYMENTS_CANCEL_URL in the environment (keep the keys out of the config files)",
+		"Create the service: paymentsCfg, err := payments.ConfigFromEnv() then paymentSvc := services.NewPaymentService(paymentsCfg, repository.NewPaymentRepository(db))",
+		"Mount the endpoints in internal/handlers/router.go: handlers.NewPaymentHandler(paymentSvc, paymentsCfg.WebhookSecret).Register(mux)",
+		"Fill in the TODOs in internal/services/payment_service.go (fulfilment, failed payments)",
+	}
+}
+
+// Returns the Makefile targets of the payments component
+func stripeMakefileContent() string {
+	return `# Forward Stripe webhooks to the local server (requires the Stripe CLI)
+stripe-listen:
+	stripe listen --forward-to localhost:8080/webhooks/stripe
+.PHONY: stripe-listen
+`
+}
+
+// Returns the content for the payments up migration
+func paymentsMigrationUpContent() string {
+	return `CREATE TABLE IF NOT EXISTS payments (
+    id                  BIGSERIAL PRIMARY KEY,
+    provider            TEXT NOT NULL,
+    checkout_session_id TEXT NOT NULL UNIQUE,
+    client_reference_id TEXT NOT NULL DEFAULT '',
+    status              TEXT NOT NULL,
+    amount_total        BIGINT,
+    currency            TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Webhook events already handled. Providers deliver events at least once, so each
+-- event ID is recorded in the same transaction as its effects.
+CREATE TABLE IF NOT EXISTS processed_webhook_events (
+    provider     TEXT NOT NULL,
+    event_id     TEXT NOT NULL,
+    event_type   TEXT NOT NULL,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (provider, event_id)
+);
+`
+}
+
+// Returns the content for the payments down migration
+func paymentsMigrationDownContent() string {
+	return `DROP TABLE IF EXISTS processed_webhook_events;
+DROP TABLE IF EXISTS payments;
+`
+}
+
+// Returns the content for internal/payments/config.go
+func paymentsConfigGoContent() string {
+	return `package payments
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Config holds the payment provider settings. They are read from the environment
+// rather than the config files so the keys never end up in the repository.
+type Config struct {
+	// SecretKey is the Stripe API key (sk_test_... or sk_live_...)
+	SecretKey string
+	// WebhookSecret verifies the signature of webhook deliveries (whsec_...)
+	WebhookSecret string
+	// SuccessURL and CancelURL are where Checkout sends the customer back to
+	SuccessURL string
+	CancelURL  string
+}
+
+// ConfigFromEnv reads the Config from STRIPE_SECRET_KEY, STRIPE_WEBHOOK_SECRET,
+// PAYMENTS_SUCCESS_URL and PAYMENTS_CANCEL_URL
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		SecretKey:     os.Getenv("STRIPE_SECRET_KEY"),
+		WebhookSecret: os.Getenv("STRIPE_WEBHOOK_SECRET"),
+		SuccessURL:    os.Getenv("PAYMENTS_SUCCESS_URL"),
+		CancelURL:     os.Getenv("PAYMENTS_CANCEL_URL"),
+	}
+
+	var errs []error
+	required := []struct{ key, value string }{
+		{"STRIPE_SECRET_KEY", cfg.SecretKey},
+		{"STRIPE_WEBHOOK_SECRET", cfg.WebhookSecret},
+		{"PAYMENTS_SUCCESS_URL", cfg.SuccessURL},
+		{"PAYMENTS_CANCEL_URL", cfg.CancelURL},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", r.key))
+		}
+	}
+	return cfg, errors.Join(errs...)
+}
+`
+}
+
+// Returns the content for internal/repository/payment_repository.go
+func paymentRepositoryGoContent() string {
+	return `package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Payment statuses
+const (
+	PaymentPending = "pending"
+	PaymentPaid    = "paid"
+	PaymentExpired = "expired"
+	PaymentFailed  = "failed"
+)
+
+// PaymentRepository stores payments and the webhook events already processed
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+// NewPaymentRepository creates a new PaymentRepository
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+// CreatePending records a checkout session that was just opened
+func (r *PaymentRepository) CreatePending(ctx context.Context, provider, sessionID, clientReferenceID string) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO payments (provider, checkout_session_id, client_reference_id, status) VALUES ($1, $2, $3, $4)",
+		provider, sessionID, clientReferenceID, PaymentPending)
+	return err
+}
+
+// UpdateStatus sets the status and amount of the payment of a checkout session, as
+// part of the transaction processing a webhook event
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, sessionID, status string, amountTotal int64, currency string) error {
+	res, err := tx.ExecContext(ctx,
+		"UPDATE payments SET status = $2, amount_total = $3, currency = $4, updated_at = now() WHERE checkout_session_id = $1",
+		sessionID, status, amountTotal, currency)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ProcessEventOnce runs fn in a transaction that also records the event, so the
+// effects of an event are applied exactly once even when it is delivered again.
+// It returns false without calling fn when the event was already processed.
+func (r *PaymentRepository) ProcessEventOnce(ctx context.Context, provider, eventID, eventType string, fn func(tx *sql.Tx) error) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO processed_webhook_events (provider, event_id, event_type) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		provider, eventID, eventType)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if err := fn(tx); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+`
+}
+
+// Returns the content for internal/services/payment_service.go
+func paymentServiceGoContent(modulePath string) string {
+	return fmt.Sprintf(`package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/checkout/session"
+
+	"%[1]s/internal/payments"
+	"%[1]s/internal/repository"
+)
+
+const paymentProvider = "stripe"
+
+// ErrInvalidCheckout is returned for checkout requests missing a price or quantity
+var ErrInvalidCheckout = errors.New("price_id and a positive quantity are required")
+
+// CheckoutRequest describes what the customer is buying
+type CheckoutRequest struct {
+	PriceID  string
+	Quantity int64
+	// ClientReferenceID links the payment to your own records, e.g. an order or account ID
+	ClientReferenceID string
+	// IdempotencyKey makes retries of the same request open a single session
+	IdempotencyKey string
+}
+
+// PaymentService opens Stripe Checkout sessions and processes Stripe webhook events
+type PaymentService struct {
+	cfg      payments.Config
+	repo     *repository.PaymentRepository
+	sessions session.Client
+}
+
+// NewPaymentService creates a new PaymentService
+func NewPaymentService(cfg payments.Config, repo *repository.PaymentRepository) *PaymentService {
+	return &PaymentService{
+		cfg:      cfg,
+		repo:     repo,
+		sessions: session.Client{B: stripe.GetBackend(stripe.APIBackend), Key: cfg.SecretKey},
+	}
+}
+
+// CreateCheckoutSession opens a hosted Checkout page and returns its URL
+func (s *PaymentService) CreateCheckoutSession(ctx context.Context, req CheckoutRequest) (string, error) {
+	if req.PriceID == "" || req.Quantity <= 0 {
+		return "", ErrInvalidCheckout
+	}
+
+	params := &stripe.CheckoutSessionParams{
+		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
+		SuccessURL: stripe.String(s.cfg.SuccessURL),
+		CancelURL:  stripe.String(s.cfg.CancelURL),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{Price: stripe.String(req.PriceID), Quantity: stripe.Int64(req.Quantity)},
+		},
+	}
+	params.Context = ctx
+	if req.ClientReferenceID != "" {
+		params.ClientReferenceID = stripe.String(req.ClientReferenceID)
+	}
+	if req.IdempotencyKey != "" {
+		params.SetIdempotencyKey(req.IdempotencyKey)
+	}
+	// TODO: set Customer/CustomerEmail, Metadata or switch Mode to subscription as your product requires
+
+	sess, err := s.sessions.New(params)
+	if err != nil {
+		return "", fmt.Errorf("creating checkout session: %%w", err)
+	}
+	if err := s.repo.CreatePending(ctx, paymentProvider, sess.ID, req.ClientReferenceID); err != nil {
+		return "", err
+	}
+	return sess.URL, nil
+}
+
+// HandleEvent applies a verified webhook event. Events are processed at most once:
+// redeliveries of an event already handled are acknowledged without effect.
+func (s *PaymentService) HandleEvent(ctx context.Context, event stripe.Event) error {
+	var apply func(tx *sql.Tx) error
+
+	switch event.Type {
+	case stripe.EventTypeCheckoutSessionCompleted, stripe.EventTypeCheckoutSessionAsyncPaymentSucceeded:
+		sess, err := checkoutSession(event)
+		if err != nil {
+			return err
+		}
+		if sess.PaymentStatus != stripe.CheckoutSessionPaymentStatusPaid && sess.PaymentStatus != stripe.CheckoutSessionPaymentStatusNoPaymentRequired {
+			// Delayed payment methods: wait for async_payment_succeeded or _failed
+			return nil
+		}
+		apply = func(tx *sql.Tx) error {
+			if err := s.repo.UpdateStatus(ctx, tx, sess.ID, repository.PaymentPaid, sess.AmountTotal, string(sess.Currency)); err != nil {
+				return err
+			}
+			// TODO: fulfil the order for sess.ClientReferenceID (grant access, ship goods, send a receipt).
+			// Use tx for database changes so they commit together with the event record.
+			return nil
+		}
+
+	case stripe.EventTypeCheckoutSessionAsyncPaymentFailed:
+		sess, err := checkoutSession(event)
+		if err != nil {
+			return err
+		}
+		apply = func(tx *sql.Tx) error {
+			// TODO: notify the customer that the payment failed
+			return s.repo.UpdateStatus(ctx, tx, sess.ID, repository.PaymentFailed, sess.AmountTotal, string(sess.Currency))
+		}
+
+	case stripe.EventTypeCheckoutSessionExpired:
+		sess, err := checkoutSession(event)
+		if err != nil {
+			return err
+		}
+		apply = func(tx *sql.Tx) error {
+			return s.repo.UpdateStatus(ctx, tx, sess.ID, repository.PaymentExpired, sess.AmountTotal, string(sess.Currency))
+		}
+
+	default:
+		// TODO: handle the other events enabled on the webhook endpoint (refunds, disputes, ...)
+		return nil
+	}
+
+	_, err := s.repo.ProcessEventOnce(ctx, paymentProvider, event.ID, string(event.Type), apply)
+	return err
+}
+
+func checkoutSession(event stripe.Event) (*stripe.CheckoutSession, error) {
+	var sess stripe.CheckoutSession
+	if err := json.Unmarshal(event.Data.Raw, &sess); err != nil {
+		return nil, fmt.Errorf("decoding %%s event: %%w", event.Type, err)
+	}
+	return &sess, nil
+}
+`, modulePath)
+}
+
+// Returns the content for internal/handlers/payment_handler.go
+func paymentHandlerGoContent(modulePath string) string {
+	return fmt.Sprintf(`package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/stripe/stripe-go/v76/webhook"
+
+	"%s/internal/services"
+)
+
+// Stripe webhook payloads are well below this size
+const maxWebhookBodyBytes = 64 << 10
+
+// PaymentHandler exposes checkout and the Stripe webhook endpoint
+type PaymentHandler struct {
+	svc           *services.PaymentService
+	webhookSecret string
+}
+
+// NewPaymentHandler creates a new PaymentHandler
+func NewPaymentHandler(svc *services.PaymentService, webhookSecret string) *PaymentHandler {
+	return &PaymentHandler{svc: svc, webhookSecret: webhookSecret}
+}
+
+// Register mounts the payment routes on mux
+func (h *PaymentHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /payments/checkout", h.checkout)
+	mux.HandleFunc("POST /webhooks/stripe", h.webhook)
+}
+
+type checkoutRequest struct {
+	PriceID           string `+"`"+`json:"price_id"`+"`"+`
+	Quantity          int64  `+"`"+`json:"quantity"`+"`"+`
+	ClientReferenceID string `+"`"+`json:"client_reference_id"`+"`"+`
+}
+
+type checkoutResponse struct {
+	URL string `+"`"+`json:"url"`+"`"+`
+}
+
+func (h *PaymentHandler) checkout(w http.ResponseWriter, r *http.Request) {
+	var req checkoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	url, err := h.svc.CreateCheckoutSession(r.Context(), services.CheckoutRequest{
+		PriceID:           req.PriceID,
+		Quantity:          req.Quantity,
+		ClientReferenceID: req.ClientReferenceID,
+		IdempotencyKey:    r.Header.Get("Idempotency-Key"),
+	})
+	if errors.Is(err, services.ErrInvalidCheckout) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "failed to create checkout session")
+		return
+	}
+	writeJSON(w, http.StatusCreated, checkoutResponse{URL: url})
+}
+
+// webhook verifies the Stripe-Signature header before trusting the payload. A
+// non-2xx response makes Stripe retry the delivery later.
+func (h *PaymentHandler) webhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "payload too large")
+		return
+	}
+
+	event, err := webhook.ConstructEvent(payload, r.Header.Get("Stripe-Signature"), h.webhookSecret)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid signature")
+		return
+	}
+
+	if err := h.svc.HandleEvent(r.Context(), event); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to process event")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+`, modulePath)
+}
+
+// Returns the content for internal/handlers/payment_handler_test.go
+func paymentHandlerTestGoContent(modulePath string) string {
+	return fmt.Sprintf(`package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhook"
+
+	"%s/internal/services"
+)
+
+const testWebhookSecret = "whsec_test"
+
+func TestStripeWebhookSignature(t *testing.T) {
+	// Events the service ignores need no database
+	h := NewPaymentHandler(&services.PaymentService{}, testWebhookSecret)
+	payload := fmt.Sprintf(`+"`"+`{"id":"evt_1","object":"event","api_version":%%q,"type":"customer.created","data":{"object":{}}}`+"`"+`, stripe.APIVersion)
+
+	tests := []struct {
+		name      string
+		signature string
+		want      int
+	}{
+		{"valid", sign(payload, testWebhookSecret, time.Now()), http.StatusOK},
+		{"wrong secret", sign(payload, "whsec_other", time.Now()), http.StatusBadRequest},
+		{"expired", sign(payload, testWebhookSecret, time.Now().Add(-time.Hour)), http.StatusBadRequest},
+		{"missing", "", http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", strings.NewReader(payload))
+			req.Header.Set("Stripe-Signature", tc.signature)
+			rec := httptest.NewRecorder()
+			h.webhook(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("status = %%d, want %%d (body %%s)", rec.Code, tc.want, rec.Body)
+			}
+		})
+	}
+}
+
+func sign(payload, secret string, at time.Time) string {
+	sig := webhook.ComputeSignature(at, []byte(payload), secret)
+	return fmt.Sprintf("t=%%d,v1=%%x", at.Unix(), sig)
+}
+`, modulePath)
+}
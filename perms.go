@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Mode of generated directories, set with --perm or the perm setting of the gogo
+// config. Generated files derive their mode from it (see fileMode); like any
+// created file, both are then restricted by the umask.
+var dirMode os.FileMode = 0755
+
+// Kinds of generated files
+const (
+	fileRegular    = iota
+	fileExecutable // mode of the directories, e.g. 0755
+	fileSecret     // readable by the owner only (0600), whatever the directories' mode
+)
+
+// Kinds of generated files, by glob matched against the end of their path
+var fileModes = []struct {
+	pattern string
+	kind    int
+}{
+	{"*.sh", fileExecutable},        // scripts, e.g. scripts/seed.sh
+	{".githooks/*", fileExecutable}, // Git only runs executable hooks
+	{"configs/*", fileSecret},       // config files hold the database password
+	{".env", fileSecret},
+	{".env.*", fileSecret},
+}
+
+// Returns the mode to create the file at filePath with, after the kind of the
+// first rule of fileModes matching it: the mode of the directories without the
+// execute bits for regular files (0644 for 0755)
+func fileMode(filePath string) os.FileMode {
+	parts := strings.Split(filepath.ToSlash(filePath), "/")
+	for _, rule := range fileModes {
+		n := strings.Count(rule.pattern, "/") + 1
+		if len(parts) < n {
+			continue
+		}
+		if ok, _ := path.Match(rule.pattern, strings.Join(parts[len(parts)-n:], "/")); ok {
+			return kindMode(rule.kind)
+		}
+	}
+	return kindMode(fileRegular)
+}
+
+// Returns the mode of a kind of file
+func kindMode(kind int) os.FileMode {
+	switch kind {
+	case fileExecutable:
+		return dirMode
+	case fileSecret:
+		return 0600
+	default:
+		return dirMode &^ 0111
+	}
+}
+
+// Returns the mode to copy the file at filePath with from a source of mode src:
+// that of fileMode, executable when the source is, so that a template's bin/tool
+// stays runnable
+func copiedFileMode(filePath string, src fs.FileMode) os.FileMode {
+	mode := fileMode(filePath)
+	if mode == kindMode(fileRegular) && src&0111 != 0 {
+		return kindMode(fileExecutable)
+	}
+	return mode
+}
+
+// templateFileMode sets the mode of paths of a template's files directory, e.g.
+// {"paths": ["scripts/*.sh", "bin/**"], "mode": "0755"}
+type templateFileMode struct {
+	Paths []string `json:"paths"` // globs, ** as the last element matching a whole tree
+	Mode  string   `json:"mode"`  // octal
+}
+
+// Parses the mode of m
+func (m templateFileMode) parse() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(m.Mode, 8, 32)
+	if err != nil || mode > 0777 || mode&0400 == 0 {
+		return 0, fmt.Errorf("mode %q must be an octal file mode the owner can read, e.g. 0755 or 0600", m.Mode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// Checks the declaration of m in a template manifest
+func (m templateFileMode) validate() error {
+	if _, err := m.parse(); err != nil {
+		return err
+	}
+	if len(m.Paths) == 0 {
+		return fmt.Errorf("mode %s has no paths", m.Mode)
+	}
+	for _, pattern := range m.Paths {
+		elems := strings.Split(pattern, "/")
+		for i, elem := range elems {
+			if elem == "**" && i < len(elems)-1 {
+				return fmt.Errorf("path %s of mode %s: ** is only supported last", pattern, m.Mode)
+			}
+			if _, err := path.Match(elem, ""); err != nil {
+				return fmt.Errorf("path %s of mode %s: %v", pattern, m.Mode, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Sets the modes a template declares on the files it copied into dir, created
+// being their slash-separated paths. The first declaration matching a file wins.
+func applyTemplateModes(modes []templateFileMode, dir string, created []string) error {
+	for _, rel := range created {
+		for _, m := range modes {
+			matched := slices.ContainsFunc(m.Paths, func(pattern string) bool {
+				return matchTreePattern(pattern, rel) || matchTreePattern(strings.TrimSuffix(pattern, templateSuffix), rel)
+			})
+			if !matched {
+				continue
+			}
+			mode, err := m.parse()
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(dir, filepath.FromSlash(rel))
+			debugf("chmod %s %v", target, mode)
+			if err := os.Chmod(target, mode); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// Sets the mode of generated directories from a --perm value such as 0750,
+// which must give the owner full access
+func setPerm(value string) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 || mode&0700 != 0700 {
+		fatalf(exitUsage, "Unsupported --perm value %q (an octal directory mode giving the owner full access, e.g. 0755 or 0700)", value)
+	}
+	dirMode = os.FileMode(mode)
+}
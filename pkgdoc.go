@@ -0,0 +1,184 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// packageDoc is the package documentation written to the doc.go of the
+// generated packages whose directory matches pattern
+type packageDoc struct {
+	pattern string // path.Match pattern of the slash directory relative to the project
+	file    string // file the package must have for doc to apply to it, "" for any
+	doc     string // NAME stands for the last element of the directory, PARENT for the one before
+}
+
+// Documentation of the generated packages, the first matching pattern applying
+// to a package
+var packageDocs = []packageDoc{
+	// Commands
+	{"cmd/worker", "", "Command worker runs the Temporal worker of the service: it registers the workflows and activities of internal/workflows and executes them as the Temporal server schedules them."},
+	{"cmd/starter", "", "Command starter starts a workflow on the Temporal server, to try the worker out."},
+	{"cmd/lambda-api", "", "Command lambda-api serves the HTTP API of the service on AWS Lambda, behind API Gateway."},
+	{"cmd/lambda-sqs", "", "Command lambda-sqs processes the SQS messages of the service on AWS Lambda."},
+	{"cmd/*", "", "Command NAME is the entry point of the service. It loads the configuration, creates the logger, connects to the dependencies and wires them into the packages of internal/, then runs until it is interrupted."},
+
+	// Application code
+	{"internal/handlers", "", "Package handlers is the transport layer of the service: its handlers decode the incoming requests, call the business code and encode the responses."},
+	{"internal/middlewares", "", "Package middlewares holds the HTTP middlewares wrapped around every route, such as request IDs and access logs. A middleware is a func(http.Handler) http.Handler, applied in order by Chain."},
+	{"internal/services", "", "Package services holds the business rules of the application. Services are called by the handlers and call the repository or external APIs, and know nothing about HTTP."},
+	{"internal/repository", "", "Package repository is the data layer of the application: it runs the SQL queries against the database opened by Open and maps their rows to the models of internal/models/db."},
+	{"internal/models/api", "", "Package api holds the request and response bodies of the HTTP API."},
+	{"internal/models/db", "", "Package db holds the rows of the database tables, as read and written by internal/repository."},
+	{"internal/modules", "", "Package modules wires the modules of the application. Each module is a vertical slice under internal/modules/<module> with its own handler, service and repo packages; modules use each other only through their root package."},
+	{"internal/modules/*/handler", "", "Package handler exposes the PARENT module over HTTP."},
+	{"internal/modules/*/service", "", "Package service holds the business rules of the PARENT module."},
+	{"internal/modules/*/repo", "", "Package repo stores the data of the PARENT module."},
+	{"internal/modules/*", "", "Package NAME is the NAME module. Its handler, service and repo packages are its own: other modules only use what this package exports."},
+	{"internal/domain", "", "Package domain holds the domain model of the application: entities enforcing their own invariants, free of storage and transport concerns."},
+	{"internal/domain/*", "", "Package NAME is the NAME aggregate. Its state changes only through the events it records, from which Load rebuilds it."},
+	{"internal/cqrs", "", "Package cqrs dispatches commands and queries to the handlers registered for their type, keeping the code changing state apart from the code reading it."},
+	{"internal/commands", "", "Package commands holds the commands of the application, which change its state, and their handlers."},
+	{"internal/queries", "", "Package queries holds the queries of the application, which read its state without changing it, and their handlers."},
+	{"internal/auth", "", "Package auth hashes and verifies the passwords of the accounts."},
+	{"internal/imports", "", "Package imports reads CSV files row by row, collecting the errors of each row into a report instead of stopping at the first one."},
+	{"internal/search", "meilisearch.go", "Package search indexes documents in Meilisearch and searches them."},
+	{"internal/search", "", "Package search indexes documents in Elasticsearch, or OpenSearch through the same API, and searches them."},
+	{"internal/payments", "", "Package payments takes payments through the payment provider of the application."},
+	{"internal/workflows", "", "Package workflows holds the Temporal workflows of the application and the activities they run. Workflows must stay deterministic; activities do the I/O and are retried when they fail."},
+	{"internal/etl", "", "Package etl runs extract-transform-load pipelines: a Source reads records, workers transform them concurrently and a Sink loads them, with checkpoints to resume interrupted runs."},
+	{"internal/jobs", "", "Package jobs holds the transform of the job, applied by the pipeline of internal/etl to every record."},
+	{"internal/messaging", "", "Package messaging connects to NATS and provisions the JetStream stream and consumer the service reads its events from."},
+	{"internal/consumers", "", "Package consumers processes the messages the service receives from SQS."},
+	{"internal/lambdahttp", "", "Package lambdahttp serves a net/http handler on AWS Lambda, converting API Gateway events to requests and the responses back."},
+
+	// Shared packages
+	{"pkg/config", "", "Package config reads the settings of the application: LoadConfig resolves each of them from its sources into a Config."},
+	{"pkg/logger", "", "Package logger creates the structured logger of the application, and carries the loggers scoped to requests in their context (FromContext)."},
+	{"pkg/httpclient", "", "Package httpclient is the client for calling other HTTP APIs, with timeouts, retries and a circuit breaker."},
+	{"pkg/health", "", "Package health checks the dependencies of the application for /healthz."},
+	{"pkg/bootstrap", "", "Package bootstrap starts the dependencies of the application in order and gates its readiness on them."},
+	{"pkg/cache", "", "Package cache connects to the Redis server of the application."},
+	{"pkg/ratelimit", "", "Package ratelimit limits the rate of requests with sliding windows kept in Redis, shared by every instance of the application."},
+	{"pkg/errorreport", "", "Package errorreport reports the errors and panics of the application to Sentry."},
+	{"pkg/profiling", "", "Package profiling exposes the runtime profiles of the application."},
+	{"pkg/mailer", "", "Package mailer sends the emails of the application, through SMTP or to the logs in development."},
+	{"pkg/reports", "", "Package reports renders tabular reports in the formats the application serves them in."},
+	{"web", "", "Package web embeds the static files served by the application, such as favicon.ico and robots.txt."},
+}
+
+// Returns the content for the doc.go of the package named pkg in dir, a slash
+// path relative to the project, whose files are named files, or false when
+// packageDocs has no documentation for it
+func docGoContent(dir, pkg string, files []string) (string, bool) {
+	for _, d := range packageDocs {
+		if ok, _ := path.Match(d.pattern, dir); !ok || d.file != "" && !slices.Contains(files, d.file) {
+			continue
+		}
+		doc := strings.NewReplacer("NAME", path.Base(dir), "PARENT", path.Base(path.Dir(dir))).Replace(d.doc)
+		var b strings.Builder
+		for _, line := range wrapWords(doc, 77) {
+			b.WriteString("// " + line + "\n")
+		}
+		b.WriteString("package " + pkg + "\n")
+		return b.String(), true
+	}
+	return "", false
+}
+
+// Writes the doc.go of every package of the project in dir that packageDocs
+// documents
+func writePackageDocs(dir string) {
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		pkg, ok := packageName(p)
+		if !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if content, ok := docGoContent(filepath.ToSlash(rel), pkg, fileNames(p)); ok {
+			createFile(filepath.Join(p, "doc.go"), content)
+		}
+		return nil
+	})
+	if err != nil {
+		fatalf(exitFailure, "Failed to document the generated packages: %v", err)
+	}
+}
+
+// Returns the names of the files in dir
+func fileNames(dir string) []string {
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// Returns the name of the package whose files are in dir, or false when it has
+// no Go files besides tests
+func packageName(dir string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, e.Name()), nil, parser.PackageClauseOnly)
+		if err == nil {
+			return f.Name.Name, true
+		}
+	}
+	return "", false
+}
+
+// Returns the doc.go files of the packages among files, the ones of a
+// component. The doc.go of a package that has one already is returned as a
+// shared file, so the component is recorded as using it.
+func packageDocFiles(p project, files []generatedFile) []generatedFile {
+	var dirs []string
+	names := map[string][]string{} // of the files of each directory, on disk and new
+	pkgs := map[string]string{}
+	for _, f := range files {
+		if f.marker != "" || !strings.HasSuffix(f.path, ".go") || strings.HasSuffix(f.path, "_test.go") {
+			continue
+		}
+		dir := path.Dir(filepath.ToSlash(f.path))
+		if _, ok := names[dir]; !ok {
+			dirs = append(dirs, dir)
+			names[dir] = fileNames(filepath.Join(p.dir, filepath.FromSlash(dir)))
+		}
+		names[dir] = append(names[dir], filepath.Base(f.path))
+		if pkg, err := parser.ParseFile(token.NewFileSet(), f.path, f.content, parser.PackageClauseOnly); err == nil && pkgs[dir] == "" {
+			pkgs[dir] = pkg.Name.Name
+		}
+	}
+
+	var docs []generatedFile
+	for _, dir := range dirs {
+		if pkgs[dir] == "" {
+			continue
+		}
+		if content, ok := docGoContent(dir, pkgs[dir], names[dir]); ok {
+			docs = append(docs, generatedFile{path: filepath.Join(filepath.FromSlash(dir), "doc.go"), content: content, shared: true})
+		}
+	}
+	return docs
+}
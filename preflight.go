@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Checks the tools the next steps rely on before anything is created, warning
+// about the missing ones rather than failing halfway. gogo creates the Git
+// repository itself (see gitrepo.go), so git is only needed to work on it.
+func preflight(opts options) {
+	if _, err := exec.LookPath("go"); err != nil {
+		fmt.Println(colorize(os.Stdout, styleYellow, tr("Warning: go is not in PATH, install Go (https://go.dev/dl/) to build the project")))
+	}
+	if _, err := exec.LookPath("git"); err != nil && !opts.noGit {
+		fmt.Println(colorize(os.Stdout, styleYellow, tr("Warning: git is not in PATH, gogo creates the project's repository without it; install git to work on it")))
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Runs `gogo preview [flags] <file>`: generates the project the flags describe
+// into a temporary directory and prints one of its files, named by its path or
+// the end of it (e.g. Dockerfile, logger.go or handlers/router.go)
+func runPreview(args []string) {
+	flags := flag.NewFlagSet("gogo preview", flag.ExitOnError)
+	name := flags.String("name", "myapp", "project name the file is rendered for")
+	opts, positional := parseGenerationFlags(flags, args)
+	if len(positional) != 1 {
+		fatalf(exitUsage, "Please provide the file to preview, e.g. gogo preview --archetype=nats Dockerfile")
+	}
+	opts.projectName = *name
+	opts.modulePath = resolveModulePath(opts.modulePath, opts.projectName)
+	generatedModule, generatedProjectName = opts.modulePath, opts.projectName
+
+	dir, err := os.MkdirTemp("", "gogo-preview-")
+	if err != nil {
+		fatalf(exitFailure, "Failed to create a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	generateProject(opts, dir)
+
+	files := generatedFiles(dir)
+	want := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(positional[0])), "./")
+	var matches []string
+	for _, f := range files {
+		if f == want {
+			matches = []string{f}
+			break
+		}
+		if strings.HasSuffix(f, "/"+want) {
+			matches = append(matches, f)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		os.RemoveAll(dir)
+		fatalf(exitUsage, "No generated file matches %s. Generated files:\n  %s", want, strings.Join(files, "\n  "))
+	case 1:
+	default:
+		os.RemoveAll(dir)
+		fatalf(exitUsage, "Several generated files match %s, please give more of the path:\n  %s", want, strings.Join(matches, "\n  "))
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(matches[0])))
+	if err != nil {
+		os.RemoveAll(dir)
+		fatalf(exitFailure, "Failed to read %s: %v", matches[0], err)
+	}
+	fmt.Print(string(content))
+}
+
+// Returns the files under dir, as sorted slash-separated paths relative to it
+func generatedFiles(dir string) []string {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		fatalf(exitFailure, "Failed to list the generated files: %v", err)
+	}
+	slices.Sort(files)
+	return files
+}
@@ -0,0 +1,209 @@
+package main
+
+import "fmt"
+
+// Settings added to the config by --pprof
+var pprofConfigFields = []configField{
+	{goName: "PprofAddr", goType: "string", key: "PPROF_ADDR", value: "localhost:6060", comment: "Address of the net/http/pprof listener, kept off the public port: never expose it outside the network. Empty disables it"},
+}
+
+// Settings added to the config by --profiling=pyroscope
+var pyroscopeConfigFields = []configField{
+	{goName: "PyroscopeServerAddress", goType: "string", key: "PYROSCOPE_SERVER_ADDRESS", value: "http://localhost:4040", comment: "Pyroscope server the profiles are pushed to continuously. Empty disables it"},
+	{goName: "PyroscopeAuthToken", goType: "string", key: "PYROSCOPE_AUTH_TOKEN", secret: true},
+}
+
+// Pyroscope server storing the profiles, for docker-compose.yml. With
+// pyroscope, the application pushes its profiles to it.
+func pyroscopeComposeService(push bool) composeService {
+	svc := composeService{
+		name: "pyroscope",
+		definition: `    image: grafana/pyroscope:latest
+    ports:
+      - "4040:4040"
+`,
+	}
+	if push {
+		svc.devEnv = []string{"PYROSCOPE_SERVER_ADDRESS: http://pyroscope:4040"}
+	}
+	return svc
+}
+
+// Grafana Agent scraping the pprof listener of app-dev into Pyroscope, for
+// docker-compose.yml. The listener binds every interface of the container,
+// whose port is not published.
+var grafanaAgentComposeService = composeService{
+	name: "grafana-agent",
+	definition: `    image: grafana/agent:latest
+    environment:
+      AGENT_MODE: flow
+    command: ["run", "/etc/agent/config.river"]
+    volumes:
+      - ./deploy/profiling/agent.river:/etc/agent/config.river:ro
+    depends_on:
+      - pyroscope
+`,
+	devEnv: []string{`PPROF_ADDR: ":6060"`},
+}
+
+// Returns the imports and setup code of main.go serving pprof and, depending on
+// profiling, pushing profiles to Pyroscope
+func profilingSetup(projectName, profiling string) (imports, setup string) {
+	imports = fmt.Sprintf("\t\"%s/pkg/profiling\"\n", projectName)
+	setup = `	// Serve the runtime profiles on their own listener, off the public port
+	if cfg.PprofAddr != "" {
+		profiling.ServePprof(cfg.PprofAddr, appLog)
+	}
+
+`
+	if profiling == "pyroscope" {
+		setup += `	// Push profiles to Pyroscope continuously
+	if cfg.PyroscopeServerAddress != "" {
+		profiler, err := profiling.StartPyroscope(cfg)
+		if err != nil {
+			appLog.Error().Err(err).Msg("Failed to start continuous profiling")
+		} else {
+			defer profiler.Stop()
+		}
+	}
+
+`
+	}
+	return imports, setup
+}
+
+// Returns the content for pkg/profiling/pprof.go
+func pprofGoContent() string {
+	return `package profiling
+
+import (
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// ServePprof serves the net/http/pprof handlers under /debug/pprof/ on addr, in
+// the background. addr must only be reachable from inside the network (e.g.
+// localhost:6060): profiles reveal the internals of the application.
+//
+// Fetch a 30 seconds CPU profile with:
+//
+//	go tool pprof http://localhost:6060/debug/pprof/profile?seconds=30
+func ServePprof(addr string, log *zerolog.Logger) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Info().Str("addr", addr).Msg("pprof listening")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("pprof server failed")
+		}
+	}()
+}
+`
+}
+
+// Returns the content for pkg/profiling/pyroscope.go
+func pyroscopeGoContent(projectName string) string {
+	return fmt.Sprintf(`package profiling
+
+import (
+	"runtime"
+
+	"github.com/grafana/pyroscope-go"
+
+	"%s/pkg/config"
+)
+
+// StartPyroscope starts pushing the CPU, memory, goroutine, mutex and block
+// profiles of the application to the Pyroscope server of cfg, tagged with its
+// environment. Stop the returned profiler to flush the last profiles.
+func StartPyroscope(cfg *config.Config) (*pyroscope.Profiler, error) {
+	// Sample a fraction of the mutex and block events, which are not profiled by default
+	runtime.SetMutexProfileFraction(5)
+	runtime.SetBlockProfileRate(5)
+
+	return pyroscope.Start(pyroscope.Config{
+		ApplicationName: cfg.AppName,
+		ServerAddress:   cfg.PyroscopeServerAddress,
+		AuthToken:       cfg.PyroscopeAuthToken,
+		Tags:            map[string]string{"env": cfg.AppEnv},
+		ProfileTypes: []pyroscope.ProfileType{
+			pyroscope.ProfileCPU,
+			pyroscope.ProfileAllocObjects,
+			pyroscope.ProfileAllocSpace,
+			pyroscope.ProfileInuseObjects,
+			pyroscope.ProfileInuseSpace,
+			pyroscope.ProfileGoroutines,
+			pyroscope.ProfileMutexCount,
+			pyroscope.ProfileMutexDuration,
+			pyroscope.ProfileBlockCount,
+			pyroscope.ProfileBlockDuration,
+		},
+	})
+}
+`, projectName)
+}
+
+// Returns the content for deploy/profiling/agent.river, the Grafana Agent (flow
+// mode) configuration scraping the pprof listener into Pyroscope
+func grafanaAgentConfigContent(appName string) string {
+	return fmt.Sprintf(`// Grafana Agent in flow mode: scrapes the pprof listener of the application
+// (PPROF_ADDR) every 15 seconds and writes the profiles to Pyroscope.
+// In production, point the targets at the pods or tasks of the service, e.g.
+// with discovery.kubernetes, and the endpoint at your Pyroscope or Grafana Cloud.
+
+pyroscope.scrape "app" {
+  targets = [
+    {"__address__" = "app-dev:6060", "service_name" = %[1]q},
+  ]
+  forward_to      = [pyroscope.write.local.receiver]
+  scrape_interval = "15s"
+
+  profiling_config {
+    profile.goroutine {
+      enabled = true
+    }
+    profile.block {
+      enabled = false
+    }
+    profile.mutex {
+      enabled = false
+    }
+  }
+}
+
+pyroscope.write "local" {
+  endpoint {
+    url = "http://pyroscope:4040"
+  }
+}
+`, appName)
+}
+
+// Returns the Makefile targets fetching profiles from the pprof listener
+func pprofMakefileContent() string {
+	return `
+PPROF_ADDR ?= localhost:6060
+PROFILE ?= profile?seconds=30
+
+.PHONY: pprof
+
+# Open a 30 seconds CPU profile of the running application in the browser, e.g.
+# make pprof PROFILE=heap (or goroutine, allocs, block, mutex)
+pprof:
+	go tool pprof -http=localhost:8081 "http://$(PPROF_ADDR)/debug/pprof/$(PROFILE)"
+`
+}
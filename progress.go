@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Frames of the spinner, and how often it turns
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+// Width of the progress bar, in characters
+const progressBarWidth = 24
+
+// progress shows a long-running step on stderr, keeping stdout to the output of
+// the command: a spinner, with a bar when the step has a number of units, redrawn
+// on one line in an interactive terminal, and a line when it starts and one when
+// it ends otherwise, as in CI logs
+type progress struct {
+	label       string
+	start       time.Time
+	total, done int
+	interactive bool
+
+	mu     sync.Mutex
+	frame  int
+	stop   chan struct{}
+	exited chan struct{}
+}
+
+// Reports whether stderr is a terminal a spinner can be redrawn on
+func progressInteractive() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Starts showing the step described by the message format, translated like
+// printf. total is its number of units, counted with step, 0 when unknown.
+func startProgress(total int, format string, args ...any) *progress {
+	p := &progress{
+		label:       fmt.Sprintf(tr(format), args...),
+		start:       time.Now(),
+		total:       total,
+		interactive: progressInteractive(),
+	}
+	if !p.interactive {
+		fmt.Fprintf(os.Stderr, "%s...\n", p.label)
+		return p
+	}
+	p.stop, p.exited = make(chan struct{}), make(chan struct{})
+	p.draw()
+	go func() {
+		defer close(p.exited)
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				p.frame++
+				p.draw()
+				p.mu.Unlock()
+			}
+		}
+	}()
+	return p
+}
+
+// Redraws the line of the step, with p.mu held
+func (p *progress) draw() {
+	line := spinnerFrames[p.frame%len(spinnerFrames)] + " " + p.label
+	if p.total > 0 {
+		filled := progressBarWidth * p.done / p.total
+		line += fmt.Sprintf(" [%s%s] %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), p.done, p.total)
+	}
+	fmt.Fprint(os.Stderr, "\r\x1b[K"+line)
+}
+
+// Counts one more unit of the step done
+func (p *progress) step() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if p.interactive {
+		p.draw()
+	}
+}
+
+// Runs write, which prints lines of output, above the line of the step
+func (p *progress) above(write func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.interactive {
+		write()
+		return
+	}
+	fmt.Fprint(os.Stderr, "\r\x1b[K")
+	write()
+	p.draw()
+}
+
+// Stops showing the step, reporting whether it succeeded and how long it took
+func (p *progress) finish(ok bool) {
+	elapsed := time.Since(p.start).Round(100 * time.Millisecond)
+	if !p.interactive {
+		status := tr("done")
+		if !ok {
+			status = tr("failed")
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s (%s)\n", p.label, status, elapsed)
+		return
+	}
+	close(p.stop)
+	<-p.exited
+
+	mark := colorize(os.Stderr, styleGreen, "✓")
+	if !ok {
+		mark = colorize(os.Stderr, styleRed, "✗")
+	}
+	fmt.Fprintf(os.Stderr, "\r\x1b[K%s %s (%s)\n", mark, p.label, elapsed)
+}
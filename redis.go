@@ -0,0 +1,215 @@
+package main
+
+import "fmt"
+
+// Settings added to the config when Redis is part of the stack
+var redisConfigFields = []configField{
+	{goName: "RedisAddr", goType: "string", key: "REDIS_ADDR", value: "localhost:6379", comment: "Redis connection, shared by the instances for caching and rate limiting"},
+	{goName: "RedisPassword", goType: "string", key: "REDIS_PASSWORD", secret: true},
+	{goName: "RedisDB", goType: "int", key: "REDIS_DB", value: "0"},
+	{goName: "RateLimitRequests", goType: "int", key: "RATE_LIMIT_REQUESTS", value: "100", comment: "Default rate limit: RateLimitRequests per RateLimitWindow for each API key or client IP"},
+	{goName: "RateLimitWindow", goType: "time.Duration", key: "RATE_LIMIT_WINDOW", value: "1m"},
+}
+
+// Redis server, for docker-compose.yml
+var redisComposeService = composeService{
+	name: "redis",
+	definition: `    image: redis:7-alpine
+    ports:
+      - "6379:6379"
+`,
+	devEnv: []string{"REDIS_ADDR: redis:6379"},
+}
+
+// Returns the content for pkg/cache/redis.go
+func redisClientGoContent(projectName string) string {
+	return fmt.Sprintf(`package cache
+
+import (
+	"github.com/redis/go-redis/v9"
+
+	"%s/pkg/config"
+)
+
+// NewRedisClient creates a client for the Redis server configured in cfg
+func NewRedisClient(cfg *config.Config) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+}
+`, projectName)
+}
+
+// Returns the content for pkg/ratelimit/ratelimit.go
+func rateLimitGoContent() string {
+	return `package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit allows Requests per sliding Window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Result is the outcome of a single Allow call
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// slidingWindow keeps one sorted set member per request, scored by its time in
+// milliseconds, and atomically drops the ones that left the window before counting
+var slidingWindow = redis.NewScript(` + "`" + `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", key, 0, now - window)
+local count = redis.call("ZCARD", key)
+if count < limit then
+	redis.call("ZADD", key, now, ARGV[4])
+	redis.call("PEXPIRE", key, window)
+	return {1, limit - count - 1, 0}
+end
+
+local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+return {0, 0, tonumber(oldest[2]) + window - now}
+` + "`" + `)
+
+// Limiter is a sliding window rate limiter backed by Redis, so the limits hold
+// across every instance of the service
+type Limiter struct {
+	client redis.Scripter
+	prefix string
+}
+
+// New creates a Limiter storing its counters under keys starting with prefix
+func New(client redis.Scripter, prefix string) *Limiter {
+	return &Limiter{client: client, prefix: prefix}
+}
+
+// Allow records a request for key and reports whether it is within limit
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now().UnixMilli()
+	res, err := slidingWindow.Run(ctx, l.client, []string{l.prefix + ":ratelimit:" + key},
+		now, limit.Window.Milliseconds(), limit.Requests, requestID(now)).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
+
+// requestID returns a sorted set member unique to this request
+func requestID(now int64) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return strconv.FormatInt(now, 10) + "-" + hex.EncodeToString(b)
+}
+
+// Config selects the limit applied to each request
+type Config struct {
+	// Default applies when neither the API key nor the route has its own limit
+	Default Limit
+	// Routes overrides the limit for paths starting with the given prefix; the longest prefix wins
+	Routes map[string]Limit
+	// APIKeys overrides the limit for individual API keys, e.g. per pricing plan,
+	// by the ID APIKey returns for them
+	APIKeys map[string]Limit
+	// APIKey returns the ID of the API key that authenticated the request, e.g.
+	// the prefix of the one the apikeys middleware stored in its context. Requests
+	// are limited per authenticated key, and per client IP when APIKey is nil or
+	// reports false: a key taken unchecked from a header would let clients pick a
+	// new bucket for every request.
+	APIKey func(r *http.Request) (string, bool)
+	// OnError is called when Redis fails. Requests are let through so an outage does not take the API down.
+	OnError func(error)
+}
+
+// Middleware rejects requests over their limit with 429 Too Many Requests
+func Middleware(l *Limiter, cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, limit := cfg.limitFor(r.URL.Path)
+			client := "ip:" + clientIP(r)
+			if id, ok := cfg.apiKey(r); ok {
+				// Hashed, so the Redis key names do not reveal the API keys
+				sum := sha256.Sum256([]byte(id))
+				client = "key:" + hex.EncodeToString(sum[:])
+				if keyLimit, ok := cfg.APIKeys[id]; ok {
+					limit = keyLimit
+				}
+			}
+
+			res, err := l.Allow(r.Context(), route+":"+client, limit)
+			if err != nil {
+				if cfg.OnError != nil {
+					cfg.OnError(err)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(res.RetryAfter.Seconds())+1))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]string{"error": "rate limit exceeded"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// apiKey returns the ID of the API key that authenticated r, if any
+func (cfg Config) apiKey(r *http.Request) (string, bool) {
+	if cfg.APIKey == nil {
+		return "", false
+	}
+	return cfg.APIKey(r)
+}
+
+// limitFor returns the route key and limit for path, using the longest matching route prefix
+func (cfg Config) limitFor(path string) (string, Limit) {
+	route, limit := "*", cfg.Default
+	for prefix, l := range cfg.Routes {
+		if strings.HasPrefix(path, prefix) && (route == "*" || len(prefix) > len(route)) {
+			route, limit = prefix, l
+		}
+	}
+	return route, limit
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+`
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Templates are Git repositories installed under templatesDir: their files/
+// directory is copied over the generated project like the embedded assets (see
+// assets.go), with the template variables available as {{.Vars.<name>}}.
+// templateManifestName describes them.
+const templateManifestName = "gogo-template.json"
+
+// templateManifest is the gogo-template.json of a template
+type templateManifest struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	MinVersion  string              `json:"min_gogo_version,omitempty"` // e.g. v1.4.0
+	Extends     []string            `json:"extends,omitempty"`          // templates laid first (see templateextends.go)
+	Variables   []templateVariable  `json:"variables,omitempty"`
+	Conditions  []templateCondition `json:"conditions,omitempty"`
+	Modes       []templateFileMode  `json:"modes,omitempty"` // see perms.go
+}
+
+// templateVariable is a value given with --var name=value when generating a
+// project, or prompted for (see templatevars.go)
+type templateVariable struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        string   `json:"type,omitempty"` // string (default), int, bool or select
+	Default     string   `json:"default,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"` // regular expression the whole value must match
+	Options     []string `json:"options,omitempty"` // values of a select
+}
+
+// Template names, also used as directory names
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Runs `gogo template init|install|list|update|validate|bundle`
+func runTemplate(args []string) {
+	if len(args) == 0 {
+		fatalf(exitUsage, "Please provide a template command: init <dir>, install <git-url> [--name=<name>], list, update [name ...], validate [dir] or bundle export|import")
+	}
+	switch args[0] {
+	case "init":
+		initTemplate(args[1:])
+	case "install":
+		installTemplate(args[1:])
+	case "list":
+		listTemplates()
+	case "update":
+		updateTemplates(args[1:])
+	case "validate":
+		validateTemplate(args[1:])
+	case "bundle":
+		runTemplateBundle(args[1:])
+	default:
+		fatalf(exitUsage, "Unknown template command %q (supported: init, install, list, update, validate, bundle)", args[0])
+	}
+}
+
+// Returns the directory templates are installed in: $GOGO_HOME/templates,
+// ~/.gogo/templates by default
+func templatesDir() string {
+	return filepath.Join(gogoHome(), "templates")
+}
+
+// Clones a template repository into templatesDir, under the name of its manifest
+// unless --name is given
+func installTemplate(args []string) {
+	flags := flag.NewFlagSet("gogo template install", flag.ExitOnError)
+	name := flags.String("name", "", "name to install the template under (default: the name in its manifest)")
+	positional := parseFlags(flags, args)
+	if len(positional) != 1 {
+		fatalf(exitUsage, "Please provide the Git URL of the template, e.g. gogo template install https://github.com/acme/gogo-api-template")
+	}
+
+	root := templatesDir()
+	if err := os.MkdirAll(root, 0755); err != nil {
+		fatalf(exitFailure, "Failed to create %s: %v", root, err)
+	}
+	tmp, err := os.MkdirTemp(root, ".install-")
+	if err != nil {
+		fatalf(exitFailure, "Failed to create a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	p := startProgress(0, "Cloning %s", positional[0])
+	out, err := runCombined(exec.Command("git", "clone", "--quiet", "--depth=1", positional[0], tmp))
+	p.finish(err == nil)
+	if err != nil {
+		os.RemoveAll(tmp)
+		fatalf(commandExitCode(err), "Failed to clone %s: %v\n%s", positional[0], err, out)
+	}
+
+	m, err := readTemplateManifest(tmp)
+	if err == nil && *name == "" {
+		*name = m.Name
+	}
+	if err == nil {
+		_, _, err = templateChain(*name, tmp, false)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		fatalf(exitTemplate, "Invalid template %s: %v", positional[0], err)
+	}
+	dir := filepath.Join(root, *name)
+	if _, err := os.Stat(dir); err == nil {
+		os.RemoveAll(tmp)
+		fatalf(exitExists, "Template %s is already installed, run gogo template update %s", *name, *name)
+	}
+	debugf("install %s from %s in %s", *name, positional[0], dir)
+	if err := os.Rename(tmp, dir); err != nil {
+		os.RemoveAll(tmp)
+		fatalf(exitFailure, "Failed to install the template: %v", err)
+	}
+	printf("Template %s has been installed in %s. Use it with gogo --template=%s <project-name>\n", *name, dir, *name)
+}
+
+// Prints the installed templates with their description, source and variables
+func listTemplates() {
+	names := installedTemplates()
+	if len(names) == 0 {
+		printf("No template installed. Install one with gogo template install <git-url>\n")
+		return
+	}
+	for _, name := range names {
+		dir := filepath.Join(templatesDir(), name)
+		m, err := readTemplateManifest(dir)
+		if err != nil {
+			printf("%s (invalid: %v)\n", name, err)
+			continue
+		}
+		fmt.Printf("%s - %s\n", name, m.Description)
+		source, revision, imported := templateSource(dir)
+		if source != "" {
+			printf("  source: %s\n", source)
+		}
+		if imported {
+			printf("  imported from a bundle, at revision %s\n", revision)
+		}
+		if len(m.Extends) > 0 {
+			printf("  extends: %s\n", strings.Join(m.Extends, ", "))
+		}
+		for _, v := range m.Variables {
+			detail := v.Description
+			if len(v.Options) > 0 {
+				detail += " (" + strings.Join(v.Options, ", ") + ")"
+			}
+			switch {
+			case v.Required:
+				detail += tr(" (required)")
+			case v.Default != "":
+				detail += fmt.Sprintf(tr(" (default %q)"), v.Default)
+			}
+			fmt.Printf("  --var %s=<%s>  %s\n", v.Name, v.typeName(), detail)
+		}
+	}
+}
+
+// Pulls the latest version of the named templates, or of all installed ones
+func updateTemplates(names []string) {
+	if len(names) == 0 {
+		names = installedTemplates()
+	}
+	failed := false
+	for _, name := range names {
+		dir := filepath.Join(templatesDir(), name)
+		if _, err := os.Stat(filepath.Join(dir, templateManifestName)); err != nil {
+			fatalf(exitUsage, "Template %s is not installed (installed: %s)", name, strings.Join(installedTemplates(), ", "))
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+			printStyled(styleYellow, "Skipping %s: it was imported from a bundle, not cloned with Git. Import a newer bundle with gogo template bundle import --force\n", name)
+			continue
+		}
+		before := templateRevision(dir)
+		p := startProgress(0, "Updating %s", name)
+		out, err := runCombined(exec.Command("git", "-C", dir, "pull", "--quiet", "--ff-only"))
+		p.finish(err == nil)
+		if err != nil {
+			printf("Failed to update %s: %v\n%s", name, err, out)
+			failed = true
+			continue
+		}
+		if _, _, err := templateChain(name, dir, false); err != nil {
+			printStyled(styleYellow, "Warning: %s is now invalid: %v\n", name, err)
+		}
+		if after := templateRevision(dir); after != before {
+			printf("Updated %s from %s to %s\n", name, before, after)
+		} else {
+			printf("%s is up to date\n", name)
+		}
+	}
+	if failed {
+		debugExit(exitSubprocess)
+		os.Exit(exitSubprocess)
+	}
+}
+
+// Returns the names of the installed templates, sorted
+func installedTemplates() []string {
+	entries, err := os.ReadDir(templatesDir())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fatalf(exitFailure, "Failed to read %s: %v", templatesDir(), err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Returns the Git URL and revision the template in dir was installed from, read
+// from its Git metadata or, when it was imported from a bundle (reported by
+// imported), from its templateOriginName file
+func templateSource(dir string) (source, revision string, imported bool) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		if out, err := runOutput(exec.Command("git", "-C", dir, "remote", "get-url", "origin")); err == nil {
+			source = strings.TrimSpace(string(out))
+		}
+		return source, templateRevision(dir), false
+	}
+	var origin templateOrigin
+	data, err := os.ReadFile(filepath.Join(dir, templateOriginName))
+	if err != nil || json.Unmarshal(data, &origin) != nil {
+		return "", "unknown", false
+	}
+	if origin.Revision == "" {
+		origin.Revision = "unknown"
+	}
+	return origin.Source, origin.Revision, true
+}
+
+// Returns the abbreviated commit a template is at
+func templateRevision(dir string) string {
+	out, err := runOutput(exec.Command("git", "-C", dir, "rev-parse", "--short", "HEAD"))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Reads the manifest of the template in dir
+func readTemplateManifest(dir string) (templateManifest, error) {
+	var m templateManifest
+	data, err := os.ReadFile(filepath.Join(dir, templateManifestName))
+	if err != nil {
+		return m, fmt.Errorf("no %s: %w", templateManifestName, err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", templateManifestName, err)
+	}
+	return m, nil
+}
+
+// Checks a template can be installed under name and used by this gogo binary
+func checkTemplate(name string, m templateManifest) error {
+	if !templateNamePattern.MatchString(name) {
+		return fmt.Errorf("name %q must be lower case letters, digits, dots, dashes and underscores", name)
+	}
+	for _, v := range m.Variables {
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+	for _, c := range m.Conditions {
+		if err := c.validate(m.Variables); err != nil {
+			return err
+		}
+	}
+	for _, mode := range m.Modes {
+		if err := mode.validate(); err != nil {
+			return err
+		}
+	}
+	if m.MinVersion != "" && !versionAtLeast(gogoVersion(), m.MinVersion) {
+		return fmt.Errorf("requires gogo %s or later (this is %s), update gogo with go install github.com/parth-javiya/gogo@latest", m.MinVersion, gogoVersion())
+	}
+	return nil
+}
+
+// Loads the template name for generating a project with the templates it
+// extends (see templateextends.go), resolving their variables from the --var
+// values and the defaults of the manifests, and returns its layers with the
+// paths their conditions leave out. name is an installed template, or a path
+// to a template being written (e.g. ./acme-api). Variables are prompted for
+// when interactive (see resolveTemplateVars).
+func loadTemplate(name string, given map[string]string, interactive bool) (layers []templateLayer, vars map[string]string) {
+	dir, local := templateRefDir(name, "")
+	if _, err := os.Stat(filepath.Join(dir, templateManifestName)); errors.Is(err, fs.ErrNotExist) && !local {
+		fatalf(exitUsage, "Unsupported --template value %q (installed: %s)", name, strings.Join(installedTemplates(), ", "))
+	}
+	layers, variables, err := templateChain(name, dir, local)
+	if err != nil {
+		fatalf(exitTemplate, "Invalid template %s: %v", name, err)
+	}
+
+	vars = resolveTemplateVars(name, variables, given, interactive)
+	scanTemplateVars(name, vars)
+	for i := range layers {
+		layers[i].excluded = excludedPaths(layers[i].manifest.Conditions, vars)
+	}
+	return layers, vars
+}
+
+// Returns the version of this gogo binary, "" for development builds
+func gogoVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "(devel)" {
+		return ""
+	}
+	return info.Main.Version
+}
+
+// Reports whether version is min or later. Development and pre-release builds
+// satisfy any minimum.
+func versionAtLeast(version, min string) bool {
+	if version == "" || strings.ContainsAny(version, "-+") {
+		return true
+	}
+	have, want := versionNumbers(version), versionNumbers(min)
+	for i := range want {
+		if have[i] != want[i] {
+			return have[i] > want[i]
+		}
+	}
+	return true
+}
+
+// Returns the major, minor and patch numbers of a version such as v1.4 or 1.4.2
+func versionNumbers(version string) [3]int {
+	var numbers [3]int
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	for i, part := range parts {
+		numbers[i], _ = strconv.Atoi(part)
+	}
+	return numbers
+}
+
+// Copies the files of the layers of a template over the project generated in
+// dir one after the other, except the paths their conditions leave out. Nothing
+// is written when the rendered files contain what looks like credentials.
+func applyTemplate(name string, layers []templateLayer, dir string, data assetData) {
+	// Patterns may leave out the .tmpl suffix, naming files as they are generated
+	skip := func(excluded []string) func(string) bool {
+		return func(rel string) bool {
+			return slices.ContainsFunc(excluded, func(pattern string) bool {
+				return matchTreePattern(pattern, rel) || matchTreePattern(pattern, strings.TrimSuffix(rel, templateSuffix))
+			})
+		}
+	}
+	var present []templateLayer
+	for _, l := range layers {
+		if _, err := os.Stat(filepath.Join(l.dir, "files")); err == nil {
+			present = append(present, l)
+		}
+	}
+	for _, l := range present {
+		scanTemplateFiles(l.name, filepath.Join(l.dir, "files"), data, skip(l.excluded))
+	}
+	for _, l := range present {
+		created, err := copyTree(os.DirFS(filepath.Join(l.dir, "files")), ".", dir, data, skip(l.excluded))
+		if err == nil {
+			err = applyTemplateModes(l.manifest.Modes, dir, created)
+		}
+		if err != nil {
+			fatalf(exitTemplate, "Failed to apply template %s: %v", l.name, err)
+		}
+	}
+}
+
+// Parses repeated --var name=value flags
+type templateVars map[string]string
+
+func (v templateVars) String() string { return "" }
+
+func (v templateVars) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected name=value, got %q", s)
+	}
+	v[key] = value
+	return nil
+}
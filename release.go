@@ -0,0 +1,140 @@
+package main
+
+import "fmt"
+
+// Returns the content for .goreleaser.yaml, building binary and publishing image
+// to the GitHub Container Registry
+func goreleaserConfigContent(projectName, binary, image string, static bool) string {
+	tags := ""
+	if static {
+		tags = "    tags:\n      - netgo\n      - osusergo\n"
+	}
+	return fmt.Sprintf(`version: 2
+
+project_name: %[1]s
+
+before:
+  hooks:
+    - go mod tidy
+
+builds:
+  - id: %[2]s
+    main: ./cmd/%[2]s
+    binary: %[2]s
+    env:
+      - CGO_ENABLED=0
+%[4]s    goos:
+      - linux
+      - darwin
+      - windows
+    goarch:
+      - amd64
+      - arm64
+    ldflags:
+      - -s -w -X main.version={{ .Version }} -X main.commit={{ .ShortCommit }} -X main.date={{ .Date }}
+
+archives:
+  - formats: [tar.gz]
+    name_template: "{{ .ProjectName }}_{{ .Version }}_{{ .Os }}_{{ .Arch }}"
+    format_overrides:
+      - goos: windows
+        formats: [zip]
+
+checksum:
+  name_template: checksums.txt
+
+dockers:
+  - image_templates:
+      - "ghcr.io/{{ .Env.GITHUB_REPOSITORY_OWNER }}/%[3]s:{{ .Version }}"
+      - "ghcr.io/{{ .Env.GITHUB_REPOSITORY_OWNER }}/%[3]s:latest"
+    dockerfile: goreleaser.Dockerfile
+    extra_files:
+      - configs
+    build_flag_templates:
+      - "--label=org.opencontainers.image.title={{ .ProjectName }}"
+      - "--label=org.opencontainers.image.version={{ .Version }}"
+      - "--label=org.opencontainers.image.revision={{ .FullCommit }}"
+      - "--label=org.opencontainers.image.created={{ .Date }}"
+
+brews:
+  - name: %[2]s
+    repository:
+      owner: "{{ .Env.GITHUB_REPOSITORY_OWNER }}"
+      name: homebrew-tap
+      token: "{{ .Env.HOMEBREW_TAP_GITHUB_TOKEN }}"
+    directory: Formula
+    homepage: "https://github.com/{{ .Env.GITHUB_REPOSITORY_OWNER }}/%[1]s"
+    description: "%[1]s API service"
+    install: |
+      bin.install "%[2]s"
+
+changelog:
+  sort: asc
+  filters:
+    exclude:
+      - "^docs:"
+      - "^test:"
+`, projectName, binary, image, tags)
+}
+
+// Returns the content for goreleaser.Dockerfile, which packages the prebuilt binary
+func goreleaserDockerfileContent(binary string) string {
+	return fmt.Sprintf(`FROM gcr.io/distroless/static-debian12:nonroot
+WORKDIR /app
+COPY configs ./configs
+COPY %[1]s /usr/local/bin/%[1]s
+ENTRYPOINT ["/usr/local/bin/%[1]s"]
+`, binary)
+}
+
+// Returns the release target of the Makefile, publishing with GoReleaser
+func releaseMakefileContent() string {
+	return `
+.PHONY: release
+
+release:
+	goreleaser release --clean
+`
+}
+
+// Returns the content for .github/workflows/release.yml
+func releaseWorkflowContent() string {
+	return `name: release
+
+on:
+  push:
+    tags:
+      - "v*"
+
+permissions:
+  contents: write
+  packages: write
+
+jobs:
+  goreleaser:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+
+      - uses: docker/login-action@v3
+        with:
+          registry: ghcr.io
+          username: ${{ github.actor }}
+          password: ${{ secrets.GITHUB_TOKEN }}
+
+      - uses: goreleaser/goreleaser-action@v6
+        with:
+          distribution: goreleaser
+          version: "~> v2"
+          args: release --clean
+        env:
+          GITHUB_TOKEN: ${{ secrets.GITHUB_TOKEN }}
+          HOMEBREW_TAP_GITHUB_TOKEN: ${{ secrets.HOMEBREW_TAP_GITHUB_TOKEN }}
+`
+}
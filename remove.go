@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Runs `gogo remove <component> [name]` in the current directory: deletes the
+// files recorded for the component in .gogo.json and the marked blocks it
+// inserted into other files
+func runRemove(args []string) {
+	flags := flag.NewFlagSet("remove", flag.ExitOnError)
+	force := flags.Bool("force", false, "also delete files edited since they were generated, and components others were built on")
+	positional := parseFlags(flags, args)
+	if len(positional) < 1 {
+		fatalf(exitUsage, "Please provide the component to remove, e.g. gogo remove resource product")
+	}
+	component := positional[0]
+	target := ""
+	if len(positional) > 1 {
+		target = positional[1]
+	}
+
+	p := loadProject(".")
+	m := p.loadManifest()
+
+	// Components added later for the same name are built on this one (e.g. gogo add import product)
+	var removed, kept []manifestEntry
+	for _, e := range m.Components {
+		switch {
+		case e.Component == component && (target == "" || sameName(e.target(), target)):
+			removed = append(removed, e)
+		case len(removed) > 0 && e.target() != "" && sameName(e.target(), removed[0].target()):
+			if !*force {
+				fatalf(exitUsage, "%s is built on %s: remove it first, or use --force", e.id(), removed[0].id())
+			}
+			printStyled(styleYellow, "Warning: %s is built on %s and may not compile anymore\n", e.id(), removed[0].id())
+			kept = append(kept, e)
+		default:
+			kept = append(kept, e)
+		}
+	}
+	if len(removed) == 0 {
+		fatalf(exitUsage, "No %s recorded in %s (only components added with gogo add are tracked)", strings.TrimSpace(component+" "+target), manifestFileName)
+	}
+
+	// Shared files and insertions stay while another component uses them
+	inUse := map[string]bool{}
+	for _, e := range kept {
+		for _, f := range e.Files {
+			inUse[f.Path] = true
+		}
+		for _, path := range e.Uses {
+			inUse[path] = true
+		}
+		for _, edit := range e.Edits {
+			inUse[edit.ID] = true
+		}
+	}
+
+	var deleted, edited []string
+	var migrations bool
+	for _, e := range removed {
+		for _, f := range e.Files {
+			if inUse[f.Path] {
+				continue
+			}
+			path := filepath.Join(p.dir, filepath.FromSlash(f.Path))
+			content, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				fatalf(exitFailure, "Failed to read %s: %v", f.Path, err)
+			}
+			if contentHash(content) != f.SHA256 && !*force {
+				edited = append(edited, f.Path)
+				continue
+			}
+			debugf("delete %s", path)
+			if err := os.Remove(path); err != nil {
+				fatalf(exitFailure, "Failed to delete %s: %v", f.Path, err)
+			}
+			removeEmptyDirs(p.dir, filepath.Dir(path))
+			deleted = append(deleted, f.Path)
+			migrations = migrations || strings.HasPrefix(f.Path, "migrations/")
+		}
+	}
+
+	ids := map[string]bool{}
+	for _, e := range removed {
+		ids[e.id()] = true
+		for _, edit := range e.Edits {
+			if !inUse[edit.ID] {
+				ids[edit.ID] = true
+			}
+		}
+	}
+	var unwired []string
+	for id := range ids {
+		for _, path := range p.removeMarkedBlocks(id) {
+			if !slices.Contains(unwired, path) {
+				unwired = append(unwired, path)
+			}
+		}
+	}
+	sort.Strings(unwired)
+	for _, path := range unwired {
+		if strings.HasSuffix(path, ".go") {
+			p.pruneImports(path)
+		}
+	}
+
+	m.Components = kept
+	p.saveManifest(m)
+
+	printStyled(styleGreen, "Component %s has been removed.\n", removed[0].id())
+	for _, path := range deleted {
+		printf("  deleted %s\n", path)
+	}
+	for _, path := range unwired {
+		printf("  unwired %s\n", path)
+	}
+	if len(edited) > 0 {
+		printf("\nKept files edited since they were generated (delete them by hand, or rerun with --force):\n")
+		for _, path := range edited {
+			printf("  %s\n", path)
+		}
+	}
+	if migrations {
+		printf("\nIts migrations were deleted: if they were applied, roll them back with the versions from Git.\n")
+	}
+	printf("\nRun go build ./... to find references left in hand-written code.\n")
+}
+
+// Removes the blocks between `gogo:begin <id>` and `gogo:end <id>` comment lines
+// from the text files of the project, returning the files changed
+func (p project) removeMarkedBlocks(id string) []string {
+	begin, end := "gogo:begin "+id, "gogo:end "+id
+	var changed []string
+	err := filepath.WalkDir(p.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", "bin", "dist", "vendor", "node_modules":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(content), begin) {
+			return err
+		}
+
+		var out strings.Builder
+		skipping := false
+		scanner := bufio.NewScanner(strings.NewReader(string(content)))
+		scanner.Buffer(nil, len(content)+1)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case markerIs(line, begin):
+				skipping = true
+			case skipping && markerIs(line, end):
+				skipping = false
+			case !skipping:
+				out.WriteString(line + "\n")
+			}
+		}
+		if skipping {
+			fatalf(exitFailure, "%s has no %q line closing %q", path, end, begin)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		debugf("edit %s (%d bytes)", path, out.Len())
+		if err := os.WriteFile(path, []byte(out.String()), info.Mode().Perm()); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(p.dir, path)
+		changed = append(changed, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		fatalf(exitFailure, "Failed to remove the edits of %s: %v", id, err)
+	}
+	return changed
+}
+
+// Drops the imports of the module's packages left unused in the Go file at path
+func (p project) pruneImports(path string) {
+	full := filepath.Join(p.dir, filepath.FromSlash(path))
+	content, err := os.ReadFile(full)
+	if err != nil {
+		fatalf(exitFailure, "Failed to read %s: %v", path, err)
+	}
+	pruned, err := pruneModuleImports(string(content), p.modulePath)
+	if err != nil {
+		printStyled(styleYellow, "Warning: could not clean up the imports of %s: %v\n", path, err)
+		return
+	}
+	if pruned != string(content) {
+		writeEditedFile(full, pruned)
+	}
+}
+
+// Removes dir and its parents up to root while they are empty
+func removeEmptyDirs(root, dir string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import "fmt"
+
+// Settings added to the config when the database has a read replica
+var replicaConfigFields = []configField{
+	{goName: "DBReplicaHost", goType: "string", key: "DB_REPLICA_HOST", value: "localhost", comment: "Read replica of the database, streaming from the primary. It shares the primary's user, password and database"},
+	{goName: "DBReplicaPort", goType: "int", key: "DB_REPLICA_PORT", value: "5433"},
+}
+
+// Read replica of the postgres service, for docker-compose.yml. It clones the
+// primary with pg_basebackup on first start, then streams its changes.
+var replicaComposeService = composeService{
+	name: "postgres-replica",
+	definition: `    image: postgres:16-alpine
+    user: postgres
+    entrypoint: ["/bin/sh", "/usr/local/bin/replica.sh"]
+    environment:
+      POSTGRES_USER: root
+      PGPASSWORD: ${DB_PASSWORD:?DB_PASSWORD must be set, see .env.example}
+      PGDATA: /var/lib/postgresql/data/pgdata
+    ports:
+      - "5433:5432"
+    volumes:
+      - ./deploy/postgres/replica.sh:/usr/local/bin/replica.sh:ro
+    depends_on:
+      - postgres
+`,
+	devEnv: []string{"DB_REPLICA_HOST: postgres-replica", `DB_REPLICA_PORT: "5432"`},
+}
+
+// Returns the content for deploy/postgres/replication.sh, run by the primary
+// when its data directory is initialized to accept the replica's connections
+func primaryReplicationContent() string {
+	return `#!/bin/sh
+# Lets the read replica stream from this primary, with the primary's user and
+# password. Run once, when the data directory is initialized.
+set -e
+
+echo "host replication all all scram-sha-256" >> "$PGDATA/pg_hba.conf"
+`
+}
+
+// Returns the content for deploy/postgres/replica.sh, the entrypoint of the
+// postgres-replica service
+func replicaEntrypointContent() string {
+	return `#!/bin/sh
+# Clones the primary on first start, then runs as its hot standby: -R writes the
+# primary_conninfo and standby.signal that keep it streaming and read-only.
+set -e
+
+if [ ! -s "$PGDATA/PG_VERSION" ]; then
+  until pg_basebackup -h postgres -U "$POSTGRES_USER" -D "$PGDATA" -R -X stream; do
+    echo "Waiting for the primary..."
+    rm -rf "$PGDATA"
+    sleep 2
+  done
+  chmod 700 "$PGDATA"
+fi
+
+exec postgres
+`
+}
+
+// Returns the content for internal/repository/replica.go
+func repositoryReplicaGoContent(modulePath string) string {
+	return fmt.Sprintf(`package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"%s/pkg/config"
+)
+
+// OpenReplica returns the connection pool of the read replica configured in cfg,
+// with the primary's credentials. Connections are opened on first use.
+func OpenReplica(cfg *config.Config) (*sql.DB, error) {
+	dsn := fmt.Sprintf("host=%%s port=%%d user=%%s password=%%s dbname=%%s",
+		quoteDSN(cfg.DBReplicaHost), cfg.DBReplicaPort, quoteDSN(cfg.DBUser), quoteDSN(cfg.DBPassword), quoteDSN(cfg.DBName))
+	return sql.Open("pgx", dsn)
+}
+
+// Statements the replica can run: SELECTs that do not lock rows
+var (
+	readPattern    = regexp.MustCompile(`+"`(?is)^\\s*select\\b`"+`)
+	lockingPattern = regexp.MustCompile(`+"`(?i)\\bfor\\s+(update|no\\s+key\\s+update|share|key\\s+share)\\b`"+`)
+)
+
+type readPrimaryKey struct{}
+
+// ReadPrimary returns a context whose reads go to the primary, for a request
+// that must see its own writes: the replica lags behind the primary.
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readPrimaryKey{}, true)
+}
+
+// Router sends the writes and transactions of a repository to the primary and
+// its reads to the replica. Its methods are those of *sql.DB that repositories
+// use, so a repository switches to it by changing the type of its db field.
+type Router struct {
+	primary *sql.DB
+	replica *sql.DB
+}
+
+// NewRouter creates a Router over the primary and replica pools
+func NewRouter(primary, replica *sql.DB) *Router {
+	return &Router{primary: primary, replica: replica}
+}
+
+// Primary returns the pool of the primary
+func (r *Router) Primary() *sql.DB {
+	return r.primary
+}
+
+// ExecContext runs query on the primary
+func (r *Router) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// QueryContext runs query on the replica when it only reads, else on the primary
+func (r *Router) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.pool(ctx, query).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs query on the replica when it only reads, else on the
+// primary, e.g. an INSERT ... RETURNING
+func (r *Router) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return r.pool(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx starts a transaction on the primary, where its reads see its writes
+func (r *Router) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return r.primary.BeginTx(ctx, opts)
+}
+
+// Returns the pool to run query on
+func (r *Router) pool(ctx context.Context, query string) *sql.DB {
+	if primary, _ := ctx.Value(readPrimaryKey{}).(bool); primary {
+		return r.primary
+	}
+	if !readPattern.MatchString(query) || lockingPattern.MatchString(query) {
+		return r.primary
+	}
+	return r.replica
+}
+`, modulePath)
+}
+
+// Returns the content for internal/repository/replica_test.go
+func repositoryReplicaTestGoContent() string {
+	return `package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+)
+
+// recordingConnector is a database whose statements are recorded under its name
+type recordingConnector struct {
+	name string
+	ran  *[]string
+}
+
+func (c recordingConnector) Connect(context.Context) (driver.Conn, error) { return recordingConn(c), nil }
+func (c recordingConnector) Driver() driver.Driver                        { return nil }
+
+type recordingConn recordingConnector
+
+func (c recordingConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c recordingConn) Close() error                        { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) {
+	*c.ran = append(*c.ran, c.name)
+	return recordingTx{}, nil
+}
+
+func (c recordingConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	*c.ran = append(*c.ran, c.name)
+	return driver.RowsAffected(1), nil
+}
+
+func (c recordingConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	*c.ran = append(*c.ran, c.name)
+	return emptyRows{}, nil
+}
+
+type recordingTx struct{}
+
+func (recordingTx) Commit() error   { return nil }
+func (recordingTx) Rollback() error { return nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return []string{"id"} }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+func TestRouter(t *testing.T) {
+	var ran []string
+	primary := sql.OpenDB(recordingConnector{"primary", &ran})
+	replica := sql.OpenDB(recordingConnector{"replica", &ran})
+	defer primary.Close()
+	defer replica.Close()
+	r := NewRouter(primary, replica)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func() error
+		want string
+	}{
+		{"select", func() error {
+			rows, err := r.QueryContext(ctx, "SELECT id FROM tasks ORDER BY id")
+			if err == nil {
+				rows.Close()
+			}
+			return err
+		}, "replica"},
+		{"select one row", func() error {
+			var id int
+			if err := r.QueryRowContext(ctx, "\n  select id from tasks where id = $1", 1).Scan(&id); err != sql.ErrNoRows {
+				return err
+			}
+			return nil
+		}, "replica"},
+		{"select for update", func() error {
+			var id int
+			if err := r.QueryRowContext(ctx, "SELECT id FROM tasks WHERE id = $1 FOR UPDATE", 1).Scan(&id); err != sql.ErrNoRows {
+				return err
+			}
+			return nil
+		}, "primary"},
+		{"insert returning", func() error {
+			var id int
+			if err := r.QueryRowContext(ctx, "INSERT INTO tasks (title) VALUES ($1) RETURNING id", "a").Scan(&id); err != sql.ErrNoRows {
+				return err
+			}
+			return nil
+		}, "primary"},
+		{"exec", func() error {
+			_, err := r.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", 1)
+			return err
+		}, "primary"},
+		{"read primary", func() error {
+			rows, err := r.QueryContext(ReadPrimary(ctx), "SELECT id FROM tasks")
+			if err == nil {
+				rows.Close()
+			}
+			return err
+		}, "primary"},
+		{"transaction", func() error {
+			tx, err := r.BeginTx(ctx, nil)
+			if err == nil {
+				err = tx.Rollback()
+			}
+			return err
+		}, "primary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran = nil
+			if err := tt.run(); err != nil {
+				t.Fatal(err)
+			}
+			if len(ran) != 1 || ran[0] != tt.want {
+				t.Errorf("ran on %v, want %s", ran, tt.want)
+			}
+		})
+	}
+}
+`
+}
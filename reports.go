@@ -0,0 +1,859 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Report formats supported by `gogo add reports`, with the library each one needs
+var reportFormats = []struct {
+	name       string
+	typeName   string
+	dependency string
+}{
+	{name: "pdf", typeName: "PDF", dependency: "github.com/jung-kurt/gofpdf"},
+	{name: "xlsx", typeName: "Excel", dependency: "github.com/xuri/excelize/v2"},
+	{name: "csv", typeName: "CSV"},
+}
+
+// Adds reports: a template-driven report builder in pkg/reports with PDF, Excel
+// and CSV renderers, and an endpoint that streams the rendered file
+// Usage: gogo add reports [--formats=pdf,xlsx,csv]
+func addReports(p project, args []string) ([]generatedFile, []string) {
+	fs := flag.NewFlagSet("gogo add reports", flag.ExitOnError)
+	formatsFlag := fs.String("formats", "pdf,xlsx,csv", "comma-separated report formats (pdf, xlsx, csv)")
+	parseFlags(fs, args)
+
+	selected := map[string]bool{}
+	for _, f := range strings.Split(*formatsFlag, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		supported := false
+		for _, rf := range reportFormats {
+			supported = supported || rf.name == f
+		}
+		if !supported {
+			fatalf(exitUsage, "Unsupported --formats value %q (supported: pdf, xlsx, csv)", f)
+		}
+		selected[f] = true
+	}
+	if len(selected) == 0 {
+		fatalf(exitUsage, "Please provide at least one report format in --formats (supported: pdf, xlsx, csv)")
+	}
+
+	files := []generatedFile{
+		{path: filepath.Join("pkg", "reports", "report.go"), content: reportsGoContent(selected)},
+		{path: filepath.Join("pkg", "reports", "report_test.go"), content: reportsTestGoContent(selected)},
+	}
+	var deps []string
+	for _, rf := range reportFormats {
+		if !selected[rf.name] {
+			continue
+		}
+		files = append(files, generatedFile{path: filepath.Join("pkg", "reports", rf.name+".go"), content: reportRendererContent(rf.name)})
+		if rf.dependency != "" {
+			deps = append(deps, rf.dependency)
+		}
+	}
+	files = append(files,
+		generatedFile{path: filepath.Join("internal", "services", "report_definitions.go"), content: reportDefinitionsGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "handlers", "respond.go"), content: respondGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "handlers", "report_handler.go"), content: reportHandlerGoContent(p.modulePath)},
+	)
+	ignored := "# Example reports downloaded from the endpoint\n"
+	for _, rf := range reportFormats {
+		if selected[rf.name] {
+			ignored += "/example-*." + rf.name + "\n"
+		}
+	}
+	files = append(files, generatedFile{path: ".gitignore", marker: markerEnd, content: ignored})
+	routes := "NewReportHandler(services.ReportDefinitions()).Register(mux)"
+	files = append(files, p.routerWiring([]string{"internal/services"}, false, routes, "Mount the endpoint in internal/handlers/router.go: "+routes)...)
+
+	var steps []string
+	if len(deps) > 0 {
+		steps = append(steps, "Fetch the rendering libraries: go get "+goGetArgs(deps...)+" && go mod tidy")
+	}
+	return files, append(steps,
+		"Download the example report: curl -OJ 'localhost:8080/reports/example?format="+firstReportFormat(selected)+"'",
+		"Replace the example in internal/services/report_definitions.go with your own reports, loading their rows from the repositories",
+	)
+}
+
+// Returns the first selected format, in the order of reportFormats
+func firstReportFormat(selected map[string]bool) string {
+	for _, rf := range reportFormats {
+		if selected[rf.name] {
+			return rf.name
+		}
+	}
+	return ""
+}
+
+// Returns the content for pkg/reports/report.go
+func reportsGoContent(selected map[string]bool) string {
+	var registry strings.Builder
+	for _, rf := range reportFormats {
+		if selected[rf.name] {
+			fmt.Fprintf(&registry, "\t%q: %s{},\n", rf.name, rf.typeName)
+		}
+	}
+
+	return formatGo(`package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// Align is the horizontal alignment of a column
+type Align int
+
+// Alignments of a column
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Column describes one column of a report
+type Column struct {
+	// Header is the column title
+	Header string
+	// Field is the key of the column value in each Row
+	Field string
+	// Width is the column width in characters. PDF scales the widths to fit the page.
+	Width float64
+	Align Align
+	// Format is the fmt verb used to print the value in PDF and CSV (e.g. "%.2f").
+	// Excel keeps the raw value so numbers and dates stay usable in formulas.
+	Format string
+}
+
+// Text returns the value as printed in the column
+func (c Column) Text(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if c.Format != "" {
+			return v.Format(c.Format)
+		}
+		return v.Format(time.DateTime)
+	}
+	if c.Format != "" {
+		return fmt.Sprintf(c.Format, v)
+	}
+	return fmt.Sprint(v)
+}
+
+// Row is one line of a report, keyed by Column.Field
+type Row map[string]any
+
+// Params are the parameters a report is requested with (e.g. a date range)
+type Params map[string]string
+
+// Source streams the rows of a report for the given parameters by calling emit
+// once per row, so large reports never have to be held in memory
+type Source func(ctx context.Context, params Params, emit func(Row) error) error
+
+// Template describes a report. Title, Subtitle and Footer are text/template
+// strings executed with the report's TemplateData, e.g.
+// "Sales from {{.Params.from}} to {{.Params.to}}".
+type Template struct {
+	Title     string
+	Subtitle  string
+	Footer    string
+	Columns   []Column
+	Landscape bool
+}
+
+// TemplateData is what the Template texts are executed with
+type TemplateData struct {
+	Params      Params
+	GeneratedAt time.Time
+}
+
+// Definition is a named report: how it looks and where its rows come from
+type Definition struct {
+	Template Template
+	Source   Source
+}
+
+// Report is a Template built for a set of parameters, ready to be rendered
+type Report struct {
+	Title       string
+	Subtitle    string
+	Footer      string
+	Columns     []Column
+	Landscape   bool
+	GeneratedAt time.Time
+
+	ctx    context.Context
+	params Params
+	source Source
+}
+
+// Build executes the texts of the template with params and returns the report.
+// The rows are only read from source when the report is rendered.
+func (t Template) Build(ctx context.Context, params Params, source Source) (*Report, error) {
+	data := TemplateData{Params: params, GeneratedAt: time.Now().UTC()}
+	r := &Report{
+		Columns:     t.Columns,
+		Landscape:   t.Landscape,
+		GeneratedAt: data.GeneratedAt,
+		ctx:         ctx,
+		params:      params,
+		source:      source,
+	}
+
+	texts := []struct {
+		name string
+		text string
+		dst  *string
+	}{
+		{"title", t.Title, &r.Title},
+		{"subtitle", t.Subtitle, &r.Subtitle},
+		{"footer", t.Footer, &r.Footer},
+	}
+	for _, tt := range texts {
+		out, err := execute(tt.name, tt.text, data)
+		if err != nil {
+			return nil, err
+		}
+		*tt.dst = out
+	}
+	return r, nil
+}
+
+// Each calls fn with the values of every row, in column order
+func (r *Report) Each(fn func(values []any) error) error {
+	values := make([]any, len(r.Columns))
+	return r.source(r.ctx, r.params, func(row Row) error {
+		for i, c := range r.Columns {
+			values[i] = row[c.Field]
+		}
+		return fn(values)
+	})
+}
+
+// Renderer writes a report in one file format
+type Renderer interface {
+	ContentType() string
+	Extension() string
+	Render(w io.Writer, r *Report) error
+}
+
+var renderers = map[string]Renderer{
+` + registry.String() + `}
+
+// RendererFor returns the renderer of a format (e.g. "pdf")
+func RendererFor(format string) (Renderer, bool) {
+	rd, ok := renderers[format]
+	return rd, ok
+}
+
+// Formats returns the supported formats, sorted
+func Formats() []string {
+	formats := make([]string, 0, len(renderers))
+	for f := range renderers {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func execute(name, text string, data TemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse report %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute report %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+`)
+}
+
+// Returns the content for the renderer of a report format in pkg/reports
+func reportRendererContent(format string) string {
+	switch format {
+	case "pdf":
+		return reportPDFGoContent()
+	case "xlsx":
+		return reportExcelGoContent()
+	default:
+		return reportCSVGoContent()
+	}
+}
+
+// Returns the content for pkg/reports/pdf.go
+func reportPDFGoContent() string {
+	return `package reports
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	pdfRowHeight = 7.0
+	pdfMargin    = 12.0
+)
+
+// PDF renders reports as a paginated table, repeating the column headers on every page
+type PDF struct{}
+
+// ContentType returns the media type of PDF files
+func (PDF) ContentType() string { return "application/pdf" }
+
+// Extension returns the file extension of PDF files
+func (PDF) Extension() string { return "pdf" }
+
+// Render writes r to w as a PDF
+func (PDF) Render(w io.Writer, r *Report) error {
+	orientation := "P"
+	if r.Landscape {
+		orientation = "L"
+	}
+	pdf := gofpdf.New(orientation, "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.SetTitle(r.Title, true)
+	pdf.SetCreator("reports", true)
+	pdf.AliasNbPages("")
+	// The core fonts only cover cp1252; this maps UTF-8 text onto it
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	widths := pdfColumnWidths(r.Columns, pageWidth-2*pdfMargin)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(110, 110, 110)
+		half := (pageWidth - 2*pdfMargin) / 2
+		pdf.CellFormat(half, 5, tr(r.Footer), "", 0, "L", false, 0, "")
+		pdf.CellFormat(half, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.SetTextColor(0, 0, 0)
+		for i, c := range r.Columns {
+			pdf.CellFormat(widths[i], pdfRowHeight, tr(fit(pdf, c.Header, widths[i])), "1", 0, pdfAlign(c.Align), true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr(r.Title), "", 1, "L", false, 0, "")
+	if r.Subtitle != "" {
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 7, tr(r.Subtitle), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+	header()
+
+	err := r.Each(func(values []any) error {
+		if pdf.GetY()+pdfRowHeight > pageHeight-2*pdfMargin {
+			pdf.AddPage()
+			header()
+		}
+		for i, c := range r.Columns {
+			pdf.CellFormat(widths[i], pdfRowHeight, tr(fit(pdf, c.Text(values[i]), widths[i])), "1", 0, pdfAlign(c.Align), false, 0, "")
+		}
+		pdf.Ln(-1)
+		return pdf.Error()
+	})
+	if err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// Scales the column widths to fill the printable width of the page
+func pdfColumnWidths(columns []Column, total float64) []float64 {
+	var sum float64
+	for _, c := range columns {
+		sum += columnWidth(c)
+	}
+	widths := make([]float64, len(columns))
+	for i, c := range columns {
+		widths[i] = columnWidth(c) / sum * total
+	}
+	return widths
+}
+
+func columnWidth(c Column) float64 {
+	if c.Width > 0 {
+		return c.Width
+	}
+	return 15
+}
+
+func pdfAlign(a Align) string {
+	switch a {
+	case AlignCenter:
+		return "CM"
+	case AlignRight:
+		return "RM"
+	default:
+		return "LM"
+	}
+}
+
+// Shortens text with an ellipsis so it fits a cell of the given width
+func fit(pdf *gofpdf.Fpdf, text string, width float64) string {
+	const padding = 2
+	if pdf.GetStringWidth(text)+padding <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...")+padding > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+`
+}
+
+// Returns the content for pkg/reports/xlsx.go
+func reportExcelGoContent() string {
+	return `package reports
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Excel renders reports as an .xlsx workbook with a single sheet. Rows are
+// written through a stream writer so large reports stay out of memory.
+type Excel struct{}
+
+// ContentType returns the media type of Excel workbooks
+func (Excel) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// Extension returns the file extension of Excel workbooks
+func (Excel) Extension() string { return "xlsx" }
+
+// Render writes r to w as an Excel workbook
+func (Excel) Render(w io.Writer, r *Report) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetDocProps(&excelize.DocProperties{Title: r.Title, Creator: "reports"})
+
+	sheet := f.GetSheetName(0)
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	titleStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	if err != nil {
+		return err
+	}
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"E6E6E6"}},
+	})
+	if err != nil {
+		return err
+	}
+	dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 22})
+	if err != nil {
+		return err
+	}
+
+	// Column widths and panes must be set before the first row
+	for i, c := range r.Columns {
+		if err := sw.SetColWidth(i+1, i+1, columnWidth(c)); err != nil {
+			return err
+		}
+	}
+	// Keep the title and column headers visible while scrolling
+	headerRow := 3
+	if r.Subtitle != "" {
+		headerRow++
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: headerRow, TopLeftCell: fmt.Sprintf("A%d", headerRow+1), ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	row := 1
+	writeRow := func(values []any) error {
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		row++
+		return sw.SetRow(cell, values)
+	}
+
+	if err := writeRow([]any{excelize.Cell{StyleID: titleStyle, Value: r.Title}}); err != nil {
+		return err
+	}
+	if r.Subtitle != "" {
+		if err := writeRow([]any{r.Subtitle}); err != nil {
+			return err
+		}
+	}
+	row++
+
+	headers := make([]any, len(r.Columns))
+	for i, c := range r.Columns {
+		headers[i] = excelize.Cell{StyleID: headerStyle, Value: c.Header}
+	}
+	if err := writeRow(headers); err != nil {
+		return err
+	}
+
+	cells := make([]any, len(r.Columns))
+	err = r.Each(func(values []any) error {
+		for i, v := range values {
+			cells[i] = v
+			if _, ok := v.(time.Time); ok {
+				cells[i] = excelize.Cell{StyleID: dateStyle, Value: v}
+			}
+		}
+		return writeRow(cells)
+	})
+	if err != nil {
+		return err
+	}
+
+	if r.Footer != "" {
+		row++
+		if err := writeRow([]any{r.Footer}); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+`
+}
+
+// Returns the content for pkg/reports/csv.go
+func reportCSVGoContent() string {
+	return `package reports
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// CSV renders reports as a header line followed by one line per row. Unlike
+// the other formats it is written as the rows are read.
+type CSV struct{}
+
+// ContentType returns the media type of CSV files
+func (CSV) ContentType() string { return "text/csv; charset=utf-8" }
+
+// Extension returns the file extension of CSV files
+func (CSV) Extension() string { return "csv" }
+
+// Render writes r to w as CSV
+func (CSV) Render(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(r.Columns))
+	for i, c := range r.Columns {
+		record[i] = c.Header
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	err := r.Each(func(values []any) error {
+		for i, c := range r.Columns {
+			record[i] = c.Text(values[i])
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+`
+}
+
+// Returns the content for pkg/reports/report_test.go
+func reportsTestGoContent(selected map[string]bool) string {
+	var signatures strings.Builder
+	for _, rf := range reportFormats {
+		if !selected[rf.name] {
+			continue
+		}
+		switch rf.name {
+		case "pdf":
+			signatures.WriteString("\t\t\"pdf\":  \"%PDF-\",\n")
+		case "xlsx":
+			signatures.WriteString("\t\t\"xlsx\": \"PK\",\n")
+		case "csv":
+			signatures.WriteString("\t\t\"csv\":  \"Product,Price\",\n")
+		}
+	}
+
+	return formatGo(`package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var testTemplate = Template{
+	Title:  "Sales for {{.Params.month}}",
+	Footer: "Generated at {{.GeneratedAt.Format \"2006-01-02\"}}",
+	Columns: []Column{
+		{Header: "Product", Field: "product", Width: 30},
+		{Header: "Price", Field: "price", Width: 10, Align: AlignRight, Format: "%.2f"},
+	},
+}
+
+func testSource(n int) Source {
+	return func(ctx context.Context, params Params, emit func(Row) error) error {
+		for i := 0; i < n; i++ {
+			if err := emit(Row{"product": fmt.Sprintf("Product %d", i), "price": float64(i) * 1.5}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestBuildExecutesTemplates(t *testing.T) {
+	r, err := testTemplate.Build(context.Background(), Params{"month": "March"}, testSource(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Title != "Sales for March" {
+		t.Errorf("Title = %q", r.Title)
+	}
+	if !strings.HasPrefix(r.Footer, "Generated at 20") {
+		t.Errorf("Footer = %q", r.Footer)
+	}
+}
+
+func TestBuildRejectsInvalidTemplate(t *testing.T) {
+	tmpl := Template{Title: "{{.Params.month"}
+	if _, err := tmpl.Build(context.Background(), nil, testSource(0)); err == nil {
+		t.Fatal("expected an error for an unterminated action")
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	signatures := map[string]string{
+` + signatures.String() + `	}
+	for format, signature := range signatures {
+		t.Run(format, func(t *testing.T) {
+			rd, ok := RendererFor(format)
+			if !ok {
+				t.Fatalf("no renderer for %s", format)
+			}
+			r, err := testTemplate.Build(context.Background(), Params{"month": "March"}, testSource(200))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := rd.Render(&buf, r); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(buf.String(), signature) {
+				t.Errorf("output starts with %q, want %q", buf.String()[:min(buf.Len(), 16)], signature)
+			}
+		})
+	}
+}
+
+func TestRenderReturnsSourceErrors(t *testing.T) {
+	boom := errors.New("boom")
+	failing := func(ctx context.Context, params Params, emit func(Row) error) error { return boom }
+	for _, format := range Formats() {
+		rd, _ := RendererFor(format)
+		r, err := testTemplate.Build(context.Background(), nil, failing)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rd.Render(&bytes.Buffer{}, r); !errors.Is(err, boom) {
+			t.Errorf("%s: err = %v, want %v", format, err, boom)
+		}
+	}
+}
+`)
+}
+
+// Returns the content for internal/services/report_definitions.go
+func reportDefinitionsGoContent(modulePath string) string {
+	return fmt.Sprintf(`package services
+
+import (
+	"context"
+	"time"
+
+	"%[1]s/pkg/reports"
+)
+
+// ReportDefinitions returns the reports served by the report handler, by name
+func ReportDefinitions() map[string]reports.Definition {
+	return map[string]reports.Definition{
+		"example": {
+			Template: reports.Template{
+				Title:    "Example report",
+				Subtitle: "{{with .Params.note}}{{.}}{{else}}Rows generated in memory{{end}}",
+				Footer:   "Generated on {{.GeneratedAt.Format \"2006-01-02 15:04 MST\"}}",
+				Columns: []reports.Column{
+					{Header: "#", Field: "n", Width: 6, Align: reports.AlignRight},
+					{Header: "Item", Field: "item", Width: 30},
+					{Header: "Amount", Field: "amount", Width: 12, Align: reports.AlignRight, Format: "%%.2f"},
+					{Header: "Date", Field: "date", Width: 14, Format: time.DateOnly},
+				},
+			},
+			Source: exampleReportRows,
+		},
+	}
+}
+
+// TODO: replace with a report backed by a repository, e.g. iterate over
+// ListX pages (or a dedicated query) and emit one Row per record
+func exampleReportRows(ctx context.Context, params reports.Params, emit func(reports.Row) error) error {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 100; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row := reports.Row{
+			"n":      i,
+			"item":   "Item " + string(rune('A'+(i-1)%%26)),
+			"amount": float64(i) * 12.5,
+			"date":   start.AddDate(0, 0, i),
+		}
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+`, modulePath)
+}
+
+// Returns the content for internal/handlers/report_handler.go
+func reportHandlerGoContent(modulePath string) string {
+	return fmt.Sprintf(`package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"%[1]s/pkg/reports"
+)
+
+// ReportHandler renders reports on demand and streams them to the client
+type ReportHandler struct {
+	definitions map[string]reports.Definition
+}
+
+// NewReportHandler creates a new ReportHandler serving the given reports
+func NewReportHandler(definitions map[string]reports.Definition) *ReportHandler {
+	return &ReportHandler{definitions: definitions}
+}
+
+// Register mounts the export route on mux: GET /reports/{name}?format=pdf&<params>.
+// format defaults to the first of reports.Formats(); every other query
+// parameter is passed to the report.
+func (h *ReportHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /reports/{name}", h.export)
+}
+
+func (h *ReportHandler) export(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	def, ok := h.definitions[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "report not found")
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = reports.Formats()[0]
+	}
+	renderer, ok := reports.RendererFor(format)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported format %%q (supported: %%s)", format, strings.Join(reports.Formats(), ", ")))
+		return
+	}
+
+	params := reports.Params{}
+	for key := range query {
+		if key != "format" {
+			params[key] = query.Get(key)
+		}
+	}
+	report, err := def.Template.Build(r.Context(), params, def.Source)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not build the report")
+		return
+	}
+
+	w.Header().Set("Content-Type", renderer.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%%q", fmt.Sprintf("%%s-%%s.%%s", name, report.GeneratedAt.Format("20060102-150405"), renderer.Extension())))
+	w.Header().Set("Cache-Control", "no-store")
+
+	sw := &startedWriter{ResponseWriter: w}
+	if err := renderer.Render(sw, report); err != nil {
+		if !sw.started {
+			w.Header().Del("Content-Disposition")
+			writeError(w, http.StatusInternalServerError, "could not render the report")
+			return
+		}
+		// Part of the file is already on the wire: abort the connection so the
+		// client sees a failed download instead of a truncated file
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// startedWriter records whether anything has been written to the response
+type startedWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *startedWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+`, modulePath)
+}
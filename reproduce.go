@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Generation flags left out of the reproducible command: they change what gogo
+// does once the project is generated, not the project. --var and --dep are
+// written from their resolved values instead.
+var unreproducedFlags = map[string]bool{"open": true, "lint": true, "non-interactive": true, "var": true, "dep": true}
+
+// Returns the generation flags of fs that differ from their defaults, with the
+// values resolved in opts: the add-ons required, the settings of gogo's config,
+// the module versions pinned and every template variable, prompted or not.
+// Running gogo with them generates the same project without asking anything.
+func reproducibleFlags(fs *flag.FlagSet, opts options) []string {
+	var flags []string
+	fs.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if unreproducedFlags[f.Name] || value == f.DefValue {
+			return
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() && value == "true" {
+			flags = append(flags, "--"+f.Name)
+		} else {
+			flags = append(flags, "--"+f.Name+"="+value)
+		}
+	})
+
+	var modules []string
+	for module, version := range opts.dependencies {
+		if version != dependencyVersions[module] {
+			modules = append(modules, module)
+		}
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		flags = append(flags, "--dep="+module+"@"+opts.dependencies[module])
+	}
+
+	if opts.template != "" {
+		names := make([]string, 0, len(opts.vars))
+		for name := range opts.vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			flags = append(flags, "--var="+name+"="+opts.vars[name])
+		}
+		flags = append(flags, "--non-interactive")
+	}
+	return flags
+}
+
+// Returns the command line generating the project of opts again, quoted for a
+// POSIX shell
+func reproducibleCommand(opts options) string {
+	dir := opts.projectDir
+	if dir == "" {
+		dir = opts.projectName
+	}
+	args := []string{"gogo"}
+	for _, f := range opts.flags {
+		args = append(args, shellQuote(f))
+	}
+	return strings.Join(append(args, shellQuote(dir)), " ")
+}
+
+// Characters a shell word can have without quoting
+var shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Returns s quoted for a POSIX shell, unless it does not need to be
+func shellQuote(s string) string {
+	if shellSafePattern.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Records in the manifest of the project generated in dir how it was generated
+func recordGeneration(dir string, opts options) {
+	p := project{dir: dir}
+	m := p.loadManifest()
+	m.Generated = &manifestGeneration{
+		Command: reproducibleCommand(opts),
+		Version: gogoVersion(),
+		At:      time.Now().UTC().Truncate(time.Second),
+	}
+	p.saveManifest(m)
+}
@@ -0,0 +1,1075 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resourceField is a user-defined column of a generated resource
+type resourceField struct {
+	column  string // snake_case column and JSON name
+	goName  string // exported Go field name
+	goType  string
+	sqlType string
+}
+
+// resource holds everything the resource generator needs to render its files
+type resource struct {
+	name       string // snake_case singular, used for file names
+	typeName   string // exported Go type name
+	table      string // snake_case plural table name
+	path       string // URL path segment
+	fields     []resourceField
+	timestamps bool
+	softDelete bool
+	id         resourceIDType
+}
+
+// resourceIDType describes how primary keys of a given --id-type are stored and generated
+type resourceIDType struct {
+	name       string
+	goType     string
+	sqlType    string
+	importPath string // package providing goType and newID, if any
+	newID      string // expression generating a new ID in the repository (empty: generated by the database)
+	parse      string // expression parsing the string s into (id, err) in the handler
+	jsonOpts   string // extra JSON tag options for the ID field
+	sampleID   string // a well-formed ID used in generated tests
+}
+
+// Primary key types accepted by --id-type
+var resourceIDTypes = map[string]resourceIDType{
+	"serial": {
+		goType:   "int64",
+		sqlType:  "BIGSERIAL",
+		parse:    "strconv.ParseInt(s, 10, 64)",
+		sampleID: "1",
+	},
+	"snowflake": {
+		goType:   "int64",
+		sqlType:  "BIGINT",
+		newID:    "newSnowflakeID()",
+		parse:    "strconv.ParseInt(s, 10, 64)",
+		jsonOpts: ",string",
+		sampleID: "1",
+	},
+	"uuid": {
+		goType:     "uuid.UUID",
+		sqlType:    "UUID",
+		importPath: "github.com/google/uuid",
+		newID:      "uuid.Must(uuid.NewV7())",
+		parse:      "uuid.Parse(s)",
+		sampleID:   "00000000-0000-0000-0000-000000000000",
+	},
+	"ulid": {
+		goType:     "ulid.ULID",
+		sqlType:    "CHAR(26)",
+		importPath: "github.com/oklog/ulid/v2",
+		newID:      "ulid.Make()",
+		parse:      "ulid.Parse(s)",
+		sampleID:   "00000000000000000000000000",
+	},
+}
+
+// Go and SQL types for the field types accepted on the command line
+var resourceFieldTypes = map[string][2]string{
+	"string": {"string", "TEXT"},
+	"int":    {"int64", "BIGINT"},
+	"float":  {"float64", "DOUBLE PRECISION"},
+	"bool":   {"bool", "BOOLEAN"},
+	"time":   {"time.Time", "TIMESTAMPTZ"},
+}
+
+// Adds a CRUD resource: migration, db model, repository, service, API models and handler.
+// Usage: gogo add resource <name> [field:type ...] [--model-conventions=timestamps,soft-delete] [--id-type=serial]
+func addResource(p project, args []string) ([]generatedFile, []string) {
+	fs := flag.NewFlagSet("gogo add resource", flag.ExitOnError)
+	conventions := fs.String("model-conventions", "", "comma-separated model conventions (timestamps, soft-delete)")
+	idType := fs.String("id-type", "serial", "primary key type (serial, uuid, ulid, snowflake)")
+	positional := parseFlags(fs, args)
+	if len(positional) < 1 {
+		fatalf(exitUsage, "Please provide a resource name, e.g. gogo add resource product name:string price:int")
+	}
+
+	r := newResource(positional[0], positional[1:], *conventions, *idType)
+
+	loggerContext := loggerContextGoContent()
+	if p.slog {
+		loggerContext = slogLoggerContextGoContent()
+	}
+	files := p.migration("create_"+r.table, resourceMigrationUpContent(r), resourceMigrationDownContent(r))
+	files = append(files,
+		generatedFile{path: filepath.Join("internal", "models", "db", r.name+".go"), content: resourceModelGoContent(r)},
+		generatedFile{path: filepath.Join("internal", "models", "api", r.name+".go"), content: resourceAPIModelGoContent(r)},
+		generatedFile{path: filepath.Join("internal", "repository", "errors.go"), content: repositoryErrorsGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "repository", r.name+"_repository.go"), content: resourceRepositoryGoContent(p.modulePath, r)},
+		generatedFile{path: filepath.Join("internal", "repository", "main_integration_test.go"), content: repositoryTestMainGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "repository", r.name+"_repository_integration_test.go"), content: resourceRepositoryTestGoContent(p.modulePath, r)},
+		generatedFile{path: filepath.Join("internal", "services", r.name+"_service.go"), content: resourceServiceGoContent(p.modulePath, r)},
+		generatedFile{path: filepath.Join("internal", "handlers", "respond.go"), content: respondGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "handlers", r.name+"_handler.go"), content: resourceHandlerGoContent(p.modulePath, r)},
+		generatedFile{path: filepath.Join("internal", "handlers", "golden_test.go"), content: goldenTestGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "handlers", "unavailable_db_test.go"), content: unavailableDBTestGoContent(), shared: true},
+		generatedFile{path: filepath.Join("internal", "handlers", r.name+"_handler_test.go"), content: resourceHandlerTestGoContent(p.modulePath, r)},
+	)
+	// Minimal projects generated before context.go was split out of their
+	// logger.go declare FromContext there
+	if logger, err := os.ReadFile(filepath.Join(p.dir, "pkg", "logger", "logger.go")); err != nil || !strings.Contains(string(logger), "func FromContext(") {
+		files = append(files, generatedFile{path: filepath.Join("pkg", "logger", "context.go"), content: loggerContext, shared: true})
+	}
+	for _, g := range resourceHandlerGoldens(r) {
+		files = append(files, generatedFile{path: filepath.Join("tests", "golden", g[0]+".json"), content: g[1]})
+	}
+
+	deps := []string{"github.com/jackc/pgx/v5", "github.com/testcontainers/testcontainers-go", "github.com/testcontainers/testcontainers-go/modules/postgres"}
+	switch {
+	case r.id.importPath != "":
+		deps = append(deps, r.id.importPath)
+	case r.id.name == "snowflake":
+		deps = append(deps, "github.com/bwmarrin/snowflake")
+	}
+	steps := []string{
+		"Fetch the Postgres driver and test dependencies: go get " + goGetArgs(deps...) + " && go mod tidy",
+		"Run the migration: make migrate DB_URL=postgres://...",
+		"Run the repository integration tests (requires Docker): make test-integration",
+	}
+	if r.id.name == "snowflake" {
+		files = append(files, generatedFile{path: filepath.Join("internal", "repository", "snowflake.go"), content: snowflakeGoContent(), shared: true})
+		steps = append(steps, "Give every running instance a distinct SNOWFLAKE_NODE (0-1023) environment variable")
+	}
+
+	routes := fmt.Sprintf("New%[1]sHandler(services.New%[1]sService(repository.New%[1]sRepository(db))).Register(mux)", r.typeName)
+	files = append(files, p.routerWiring([]string{"internal/services"}, true, routes, "Register the routes in internal/handlers/router.go: "+routes)...)
+	return files, steps
+}
+
+// Builds a resource from its name, field specs (name:type), conventions and ID type
+func newResource(name string, fieldSpecs []string, conventions, idType string) resource {
+	id, ok := resourceIDTypes[idType]
+	if !ok {
+		fatalf(exitUsage, "Unsupported --id-type %q (supported: serial, uuid, ulid, snowflake)", idType)
+	}
+	id.name = idType
+
+	name = singularize(snakeCase(name))
+	r := resource{
+		name:     name,
+		typeName: exportedName(name),
+		table:    tableName(name),
+		path:     kebabCase(tableName(name)),
+		id:       id,
+	}
+
+	if len(fieldSpecs) == 0 {
+		fieldSpecs = []string{"name:string"}
+	}
+	for _, spec := range fieldSpecs {
+		fieldName, fieldType, ok := strings.Cut(spec, ":")
+		if !ok {
+			fieldType = "string"
+		}
+		types, ok := resourceFieldTypes[fieldType]
+		if !ok {
+			fatalf(exitUsage, "Unsupported type %q for field %s (supported: string, int, float, bool, time)", fieldType, fieldName)
+		}
+		column := snakeCase(fieldName)
+		switch column {
+		case "id", "created_at", "updated_at", "deleted_at":
+			fatalf(exitUsage, "Field %s is reserved", column)
+		}
+		r.fields = append(r.fields, resourceField{
+			column:  column,
+			goName:  exportedName(column),
+			goType:  types[0],
+			sqlType: types[1],
+		})
+	}
+
+	for _, c := range strings.Split(conventions, ",") {
+		switch strings.TrimSpace(c) {
+		case "":
+		case "timestamps":
+			r.timestamps = true
+		case "soft-delete":
+			r.softDelete = true
+		default:
+			fatalf(exitUsage, "Unsupported model convention %q (supported: timestamps, soft-delete)", c)
+		}
+	}
+
+	return r
+}
+
+// Returns true when the db model needs the time package
+func (r resource) usesTime() bool {
+	return r.softDelete || r.apiUsesTime()
+}
+
+// Returns true when the API models need the time package
+func (r resource) apiUsesTime() bool {
+	if r.timestamps {
+		return true
+	}
+	for _, f := range r.fields {
+		if f.goType == "time.Time" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the columns read back by the repository, in scan order
+func (r resource) selectColumns() []string {
+	cols := []string{"id"}
+	for _, f := range r.fields {
+		cols = append(cols, f.column)
+	}
+	if r.timestamps {
+		cols = append(cols, "created_at", "updated_at")
+	}
+	return cols
+}
+
+// Returns the SQL condition excluding soft-deleted rows, prefixed with op, or an empty string
+func (r resource) aliveCondition(op string) string {
+	if r.softDelete {
+		return " " + op + " deleted_at IS NULL"
+	}
+	return ""
+}
+
+// Returns the content for the create table up migration
+func resourceMigrationUpContent(r resource) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", r.table)
+	fmt.Fprintf(&b, "    id %s PRIMARY KEY", r.id.sqlType)
+	for _, f := range r.fields {
+		fmt.Fprintf(&b, ",\n    %s %s NOT NULL", f.column, f.sqlType)
+	}
+	if r.timestamps {
+		b.WriteString(",\n    created_at TIMESTAMPTZ NOT NULL DEFAULT now()")
+		b.WriteString(",\n    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()")
+	}
+	if r.softDelete {
+		b.WriteString(",\n    deleted_at TIMESTAMPTZ")
+	}
+	b.WriteString("\n);\n")
+	if r.softDelete {
+		fmt.Fprintf(&b, "\n-- Speeds up the deleted_at IS NULL filter used by every query\n")
+		fmt.Fprintf(&b, "CREATE INDEX IF NOT EXISTS %s_alive_idx ON %s (id) WHERE deleted_at IS NULL;\n", r.table, r.table)
+	}
+	return b.String()
+}
+
+// Returns the content for the create table down migration
+func resourceMigrationDownContent(r resource) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", r.table)
+}
+
+// Returns the content for internal/models/db/<name>.go
+func resourceModelGoContent(r resource) string {
+	var b strings.Builder
+	b.WriteString("package db\n\n")
+	writeImports(&b, r.usesTime(), r.id.importPath)
+	fmt.Fprintf(&b, "// %s is a row of the %s table\n", r.typeName, r.table)
+	fmt.Fprintf(&b, "type %s struct {\n", r.typeName)
+	fmt.Fprintf(&b, "\tID %s\n", r.id.goType)
+	for _, f := range r.fields {
+		fmt.Fprintf(&b, "\t%s %s\n", f.goName, f.goType)
+	}
+	if r.timestamps {
+		b.WriteString("\tCreatedAt time.Time\n\tUpdatedAt time.Time\n")
+	}
+	if r.softDelete {
+		b.WriteString("\tDeletedAt *time.Time\n")
+	}
+	b.WriteString("}\n")
+	return formatGo(b.String())
+}
+
+// Returns the content for internal/models/api/<name>.go
+func resourceAPIModelGoContent(r resource) string {
+	var b strings.Builder
+	b.WriteString("package api\n\n")
+	writeImports(&b, r.apiUsesTime(), r.id.importPath)
+	fmt.Fprintf(&b, "// %sRequest is the body accepted when creating or updating %s\n", r.typeName, withArticle(humanize(r.name)))
+	fmt.Fprintf(&b, "type %sRequest struct {\n", r.typeName)
+	for _, f := range r.fields {
+		fmt.Fprintf(&b, "\t%s %s `json:\"%s\"`\n", f.goName, f.goType, f.column)
+	}
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "// %sResponse is the representation of %s returned by the API\n", r.typeName, withArticle(humanize(r.name)))
+	fmt.Fprintf(&b, "type %sResponse struct {\n", r.typeName)
+	fmt.Fprintf(&b, "\tID %s `json:\"id%s\"`\n", r.id.goType, r.id.jsonOpts)
+	for _, f := range r.fields {
+		fmt.Fprintf(&b, "\t%s %s `json:\"%s\"`\n", f.goName, f.goType, f.column)
+	}
+	if r.timestamps {
+		b.WriteString("\tCreatedAt time.Time `json:\"created_at\"`\n\tUpdatedAt time.Time `json:\"updated_at\"`\n")
+	}
+	b.WriteString("}\n")
+	return formatGo(b.String())
+}
+
+// Writes the import block for a generated model file
+func writeImports(b *strings.Builder, usesTime bool, importPath string) {
+	var imports []string
+	if usesTime {
+		imports = append(imports, `"time"`)
+	}
+	if importPath != "" {
+		imports = append(imports, strconv.Quote(importPath))
+	}
+	switch len(imports) {
+	case 0:
+	case 1:
+		fmt.Fprintf(b, "import %s\n\n", imports[0])
+	default:
+		fmt.Fprintf(b, "import (\n\t%s\n)\n\n", strings.Join(imports, "\n\n\t"))
+	}
+}
+
+// Returns an import group to place after the standard library imports, or an empty string
+func extraImport(importPath string) string {
+	if importPath == "" {
+		return ""
+	}
+	return "\n\t" + strconv.Quote(importPath) + "\n"
+}
+
+// Returns the content for internal/repository/errors.go
+func repositoryErrorsGoContent() string {
+	return `package repository
+
+import "errors"
+
+// ErrNotFound is returned when a row does not exist (or has been soft-deleted)
+var ErrNotFound = errors.New("not found")
+`
+}
+
+// Returns the content for internal/repository/<name>_repository.go
+func resourceRepositoryGoContent(modulePath string, r resource) string {
+	var insertCols, insertArgs, placeholders, updateSets, scanArgs []string
+	for i, f := range r.fields {
+		insertCols = append(insertCols, f.column)
+		insertArgs = append(insertArgs, "m."+f.goName)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+		updateSets = append(updateSets, fmt.Sprintf("%s = $%d", f.column, i+1))
+	}
+	scanArgs = append(scanArgs, "&m.ID")
+	for _, f := range r.fields {
+		scanArgs = append(scanArgs, "&m."+f.goName)
+	}
+
+	var returning, returningScan []string
+	if r.id.newID == "" {
+		returning, returningScan = append(returning, "id"), append(returningScan, "&m.ID")
+	}
+	updateReturning, updateScan := "id", "&m.ID"
+	if r.timestamps {
+		scanArgs = append(scanArgs, "&m.CreatedAt", "&m.UpdatedAt")
+		returning = append(returning, "created_at", "updated_at")
+		returningScan = append(returningScan, "&m.CreatedAt", "&m.UpdatedAt")
+		updateSets = append(updateSets, "updated_at = now()")
+		updateReturning, updateScan = "updated_at", "&m.UpdatedAt"
+	}
+
+	// IDs not generated by the database are set by Create before inserting
+	createBody := ""
+	if r.id.newID != "" {
+		createBody = "\tm.ID = " + r.id.newID + "\n"
+		insertCols = append([]string{"id"}, insertCols...)
+		insertArgs = append([]string{"m.ID"}, insertArgs...)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(placeholders)+1))
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", r.table, strings.Join(insertCols, ", "), strings.Join(placeholders, ", "))
+	if len(returning) > 0 {
+		createBody += fmt.Sprintf("\treturn r.db.QueryRowContext(ctx,\n\t\t%q,\n\t\t%s,\n\t).Scan(%s)\n",
+			insert+" RETURNING "+strings.Join(returning, ", "), strings.Join(insertArgs, ", "), strings.Join(returningScan, ", "))
+	} else {
+		createBody += fmt.Sprintf("\t_, err := r.db.ExecContext(ctx,\n\t\t%q,\n\t\t%s,\n\t)\n\treturn err\n",
+			insert, strings.Join(insertArgs, ", "))
+	}
+	updateArgs := insertArgs
+	if r.id.newID != "" {
+		updateArgs = insertArgs[1:]
+	}
+
+	imports := extraImport(r.id.importPath)
+
+	idParam := len(r.fields) + 1
+	cols := strings.Join(r.selectColumns(), ", ")
+	alive := r.aliveCondition("AND")
+
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE id = $1 RETURNING id", r.table)
+	deleteDoc := fmt.Sprintf("// Delete removes the %s with the given ID", humanize(r.name))
+	if r.softDelete {
+		deleteQuery = fmt.Sprintf("UPDATE %s SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id", r.table)
+		deleteDoc = fmt.Sprintf("// Delete soft-deletes the %s with the given ID by setting deleted_at", humanize(r.name))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+%[19]s
+	dbmodels "%[1]s/internal/models/db"
+	"%[1]s/pkg/logger"
+)
+
+// %[2]sRepository stores %[3]s in the %[4]s table
+type %[2]sRepository struct {
+	db *sql.DB
+}
+
+// New%[2]sRepository creates a new %[2]sRepository
+func New%[2]sRepository(db *sql.DB) *%[2]sRepository {
+	return &%[2]sRepository{db: db}
+}
+
+// Create inserts m and sets its generated columns
+func (r *%[2]sRepository) Create(ctx context.Context, m *dbmodels.%[2]s) error {
+%[5]s}
+
+// Get returns the %[8]s with the given ID, or ErrNotFound
+func (r *%[2]sRepository) Get(ctx context.Context, id %[6]s) (*dbmodels.%[2]s, error) {
+	row := r.db.QueryRowContext(ctx,
+		"SELECT %[9]s FROM %[4]s WHERE id = $1%[10]s",
+		id,
+	)
+	return scan%[2]s(row)
+}
+
+// List returns a page of %[3]s ordered by ID
+func (r *%[2]sRepository) List(ctx context.Context, limit, offset int) ([]dbmodels.%[2]s, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT %[9]s FROM %[4]s%[18]s ORDER BY id LIMIT $1 OFFSET $2",
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []dbmodels.%[2]s
+	for rows.Next() {
+		m, err := scan%[2]s(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Debug().Int("count", len(items)).Int("offset", offset).Msg("Listed %[3]s")
+	return items, nil
+}
+
+// Update saves the changes to m, or returns ErrNotFound
+func (r *%[2]sRepository) Update(ctx context.Context, m *dbmodels.%[2]s) error {
+	err := r.db.QueryRowContext(ctx,
+		"UPDATE %[4]s SET %[11]s WHERE id = $%[12]d%[10]s RETURNING %[13]s",
+		%[7]s, m.ID,
+	).Scan(%[14]s)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+%[15]s, or returns ErrNotFound
+func (r *%[2]sRepository) Delete(ctx context.Context, id %[6]s) error {
+	err := r.db.QueryRowContext(ctx, "%[16]s", id).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func scan%[2]s(row interface{ Scan(...any) error }) (*dbmodels.%[2]s, error) {
+	var m dbmodels.%[2]s
+	if err := row.Scan(%[17]s); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+`,
+		modulePath, r.typeName, humanize(r.table), r.table,
+		createBody, r.id.goType, strings.Join(updateArgs, ", "),
+		humanize(r.name), cols, alive,
+		strings.Join(updateSets, ", "), idParam, updateReturning, updateScan,
+		deleteDoc, deleteQuery,
+		strings.Join(scanArgs, ", "), r.aliveCondition("WHERE"), imports,
+	)
+	return formatGo(b.String())
+}
+
+// Returns the content for internal/repository/main_integration_test.go, which starts
+// a Postgres container shared by all repository integration tests
+func repositoryTestMainGoContent() string {
+	return `//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+// testDB is connected to a throwaway Postgres container with all migrations applied
+var testDB *sql.DB
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	container, err := postgres.Run(ctx, "postgres:16-alpine",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		log.Fatalf("Failed to start postgres container: %v", err)
+	}
+
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		log.Fatalf("Failed to get connection string: %v", err)
+	}
+	testDB, err = sql.Open("pgx", dsn)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	if err := applyMigrations(ctx, testDB, filepath.Join("..", "..", "migrations")); err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
+
+	code := m.Run()
+
+	testDB.Close()
+	if err := testcontainers.TerminateContainer(container); err != nil {
+		log.Printf("Failed to terminate postgres container: %v", err)
+	}
+	os.Exit(code)
+}
+
+// applyMigrations runs every up migration in version order
+func applyMigrations(ctx context.Context, db *sql.DB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		query, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, string(query)); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+`
+}
+
+// Sample values used by generated tests for each field type: initial and updated
+var resourceFieldSamples = map[string][2]string{
+	"string":    {`"sample"`, `"updated"`},
+	"int64":     {"42", "43"},
+	"float64":   {"4.2", "8.4"},
+	"bool":      {"true", "false"},
+	"time.Time": {"time.Now().UTC().Truncate(time.Microsecond)", "time.Now().UTC().Add(time.Hour).Truncate(time.Microsecond)"},
+}
+
+// Returns the content for internal/repository/<name>_repository_integration_test.go
+func resourceRepositoryTestGoContent(modulePath string, r resource) string {
+	var initial, update, compare strings.Builder
+	usesTime := false
+	for _, f := range r.fields {
+		samples := resourceFieldSamples[f.goType]
+		fmt.Fprintf(&initial, "\t\t%s: %s,\n", f.goName, samples[0])
+		fmt.Fprintf(&update, "\tm.%s = %s\n", f.goName, samples[1])
+		if f.goType == "time.Time" {
+			usesTime = true
+			fmt.Fprintf(&compare, "\tif !got.%[1]s.Equal(want.%[1]s) {\n\t\tt.Errorf(\"%[1]s = %%v, want %%v\", got.%[1]s, want.%[1]s)\n\t}\n", f.goName)
+		} else {
+			fmt.Fprintf(&compare, "\tif got.%[1]s != want.%[1]s {\n\t\tt.Errorf(\"%[1]s = %%v, want %%v\", got.%[1]s, want.%[1]s)\n\t}\n", f.goName)
+		}
+	}
+
+	imports := ""
+	if usesTime {
+		imports = "\n\t\"time\""
+	}
+
+	return formatGo(fmt.Sprintf(`//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"%[6]s
+
+	dbmodels "%[1]s/internal/models/db"
+	"%[1]s/internal/repository"
+)
+
+func Test%[2]sRepositoryCRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.New%[2]sRepository(testDB)
+
+	m := &dbmodels.%[2]s{
+%[3]s	}
+	if err := repo.Create(ctx, m); err != nil {
+		t.Fatalf("Create: %%v", err)
+	}
+
+	got, err := repo.Get(ctx, m.ID)
+	if err != nil {
+		t.Fatalf("Get: %%v", err)
+	}
+	assert%[2]sEqual(t, got, m)
+
+%[4]s	if err := repo.Update(ctx, m); err != nil {
+		t.Fatalf("Update: %%v", err)
+	}
+	got, err = repo.Get(ctx, m.ID)
+	if err != nil {
+		t.Fatalf("Get after update: %%v", err)
+	}
+	assert%[2]sEqual(t, got, m)
+
+	items, err := repo.List(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("List: %%v", err)
+	}
+	if len(items) == 0 {
+		t.Fatal("List returned no items")
+	}
+
+	if err := repo.Delete(ctx, m.ID); err != nil {
+		t.Fatalf("Delete: %%v", err)
+	}
+	if _, err := repo.Get(ctx, m.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("Get after delete: got %%v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, m.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("Delete twice: got %%v, want ErrNotFound", err)
+	}
+}
+
+func assert%[2]sEqual(t *testing.T, got, want *dbmodels.%[2]s) {
+	t.Helper()
+%[5]s}
+`, modulePath, r.typeName, initial.String(), update.String(), compare.String(), imports))
+}
+
+// Returns the content for internal/services/<name>_service.go
+func resourceServiceGoContent(modulePath string, r resource) string {
+	content := fmt.Sprintf(`package services
+
+import (
+	"context"
+%[6]s
+
+	dbmodels "%[1]s/internal/models/db"
+	"%[1]s/internal/repository"
+	"%[1]s/pkg/logger"
+)
+
+// %[2]sService holds the business logic for %[3]s
+type %[2]sService struct {
+	repo *repository.%[2]sRepository
+}
+
+// New%[2]sService creates a new %[2]sService
+func New%[2]sService(repo *repository.%[2]sRepository) *%[2]sService {
+	return &%[2]sService{repo: repo}
+}
+
+// Create stores a new %[4]s
+func (s *%[2]sService) Create(ctx context.Context, m *dbmodels.%[2]s) error {
+	if err := s.repo.Create(ctx, m); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info().Interface("id", m.ID).Msg("Created %[4]s")
+	return nil
+}
+
+// Get returns a single %[4]s
+func (s *%[2]sService) Get(ctx context.Context, id %[5]s) (*dbmodels.%[2]s, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// List returns a page of %[3]s
+func (s *%[2]sService) List(ctx context.Context, limit, offset int) ([]dbmodels.%[2]s, error) {
+	return s.repo.List(ctx, limit, offset)
+}
+
+// Update saves the changes to an existing %[4]s
+func (s *%[2]sService) Update(ctx context.Context, m *dbmodels.%[2]s) error {
+	return s.repo.Update(ctx, m)
+}
+
+// Delete removes the %[4]s with the given ID
+func (s *%[2]sService) Delete(ctx context.Context, id %[5]s) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info().Interface("id", id).Msg("Deleted %[4]s")
+	return nil
+}
+`, modulePath, r.typeName, humanize(r.table), humanize(r.name), r.id.goType, extraImport(r.id.importPath))
+	return formatGo(content)
+}
+
+// Returns the content for internal/handlers/respond.go
+func respondGoContent() string {
+	return `package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// pageParams reads the limit and offset query parameters, with defaults and bounds
+func pageParams(r *http.Request) (limit, offset int) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, err = strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+`
+}
+
+// Returns the content for internal/handlers/<name>_handler.go
+func resourceHandlerGoContent(modulePath string, r resource) string {
+	var toModel, toResponse strings.Builder
+	for _, f := range r.fields {
+		fmt.Fprintf(&toModel, "\t\t%s: req.%s,\n", f.goName, f.goName)
+		fmt.Fprintf(&toResponse, "\t\t%s: m.%s,\n", f.goName, f.goName)
+	}
+	if r.timestamps {
+		toResponse.WriteString("\t\tCreatedAt: m.CreatedAt,\n\t\tUpdatedAt: m.UpdatedAt,\n")
+	}
+
+	parseImport := `"strconv"`
+	if r.id.importPath != "" {
+		parseImport = strconv.Quote(r.id.importPath)
+	}
+
+	content := fmt.Sprintf(`package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	%[11]s
+
+	"%[1]s/internal/models/api"
+	dbmodels "%[1]s/internal/models/db"
+	"%[1]s/internal/repository"
+	"%[1]s/internal/services"
+	"%[1]s/pkg/logger"
+)
+
+// %[2]sHandler exposes %[3]s over HTTP
+type %[2]sHandler struct {
+	svc *services.%[2]sService
+}
+
+// New%[2]sHandler creates a new %[2]sHandler
+func New%[2]sHandler(svc *services.%[2]sService) *%[2]sHandler {
+	return &%[2]sHandler{svc: svc}
+}
+
+// Register mounts the %[4]s routes on mux
+func (h *%[2]sHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /%[5]s", h.list)
+	mux.HandleFunc("POST /%[5]s", h.create)
+	mux.HandleFunc("GET /%[5]s/{id}", h.get)
+	mux.HandleFunc("PUT /%[5]s/{id}", h.update)
+	mux.HandleFunc("DELETE /%[5]s/{id}", h.delete)
+}
+
+func (h *%[2]sHandler) list(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pageParams(r)
+	items, err := h.svc.List(r.Context(), limit, offset)
+	if err != nil {
+		logger.FromContext(r.Context()).Error().Err(err).Msg("Failed to list %[3]s")
+		writeError(w, http.StatusInternalServerError, "failed to list %[3]s")
+		return
+	}
+
+	resp := make([]api.%[2]sResponse, 0, len(items))
+	for i := range items {
+		resp = append(resp, to%[2]sResponse(&items[i]))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *%[2]sHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req api.%[2]sRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	m := to%[2]sModel(req)
+	if err := h.svc.Create(r.Context(), m); err != nil {
+		logger.FromContext(r.Context()).Error().Err(err).Msg("Failed to create %[6]s")
+		writeError(w, http.StatusInternalServerError, "failed to create %[6]s")
+		return
+	}
+	writeJSON(w, http.StatusCreated, to%[2]sResponse(m))
+}
+
+func (h *%[2]sHandler) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := parse%[2]sID(w, r)
+	if !ok {
+		return
+	}
+
+	m, err := h.svc.Get(r.Context(), id)
+	if err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, to%[2]sResponse(m))
+}
+
+func (h *%[2]sHandler) update(w http.ResponseWriter, r *http.Request) {
+	id, ok := parse%[2]sID(w, r)
+	if !ok {
+		return
+	}
+
+	var req api.%[2]sRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	m := to%[2]sModel(req)
+	m.ID = id
+	if err := h.svc.Update(r.Context(), m); err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, to%[2]sResponse(m))
+}
+
+func (h *%[2]sHandler) delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := parse%[2]sID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *%[2]sHandler) writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "%[6]s not found")
+		return
+	}
+	logger.FromContext(r.Context()).Error().Err(err).Msg("%[6]s service failed")
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+func parse%[2]sID(w http.ResponseWriter, r *http.Request) (%[9]s, bool) {
+	s := r.PathValue("id")
+	id, err := %[10]s
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return id, false
+	}
+	return id, true
+}
+
+func to%[2]sModel(req api.%[2]sRequest) *dbmodels.%[2]s {
+	return &dbmodels.%[2]s{
+%[7]s	}
+}
+
+func to%[2]sResponse(m *dbmodels.%[2]s) api.%[2]sResponse {
+	return api.%[2]sResponse{
+		ID: m.ID,
+%[8]s	}
+}
+`, modulePath, r.typeName, humanize(r.table), humanize(r.name), r.path, humanize(r.name), toModel.String(), toResponse.String(),
+		r.id.goType, r.id.parse, parseImport)
+	return formatGo(content)
+}
+
+// Returns the content for internal/handlers/unavailable_db_test.go
+func unavailableDBTestGoContent() string {
+	return `package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// unavailableDB returns a *sql.DB whose connections always fail, to exercise
+// the error paths of handlers without a running database
+func unavailableDB() *sql.DB {
+	return sql.OpenDB(failingConnector{})
+}
+
+type failingConnector struct{}
+
+func (failingConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("database unavailable")
+}
+
+func (failingConnector) Driver() driver.Driver {
+	return nil
+}
+`
+}
+
+// Returns the content for internal/handlers/<name>_handler_test.go
+func resourceHandlerTestGoContent(modulePath string, r resource) string {
+	return formatGo(fmt.Sprintf(`package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"%[1]s/internal/repository"
+	"%[1]s/internal/services"
+)
+
+func Test%[2]sHandlerErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	New%[2]sHandler(services.New%[2]sService(repository.New%[2]sRepository(unavailableDB()))).Register(mux)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"%[3]s_invalid_id", http.MethodGet, "/%[4]s/not-an-id", "", http.StatusBadRequest},
+		{"%[3]s_invalid_body", http.MethodPost, "/%[4]s", "{", http.StatusBadRequest},
+		{"%[3]s_list_error", http.MethodGet, "/%[4]s", "", http.StatusInternalServerError},
+		{"%[3]s_get_error", http.MethodGet, "/%[4]s/%[5]s", "", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(mux, tt.method, tt.target, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %%d, want %%d", rec.Code, tt.status)
+			}
+			assertGolden(t, tt.name, rec.Body.Bytes())
+		})
+	}
+}
+`, modulePath, r.typeName, r.name, r.path, r.id.sampleID))
+}
+
+// Returns the name and content of the golden files checked by the generated handler test
+func resourceHandlerGoldens(r resource) [][2]string {
+	golden := func(msg string) string {
+		return fmt.Sprintf("{\n  \"error\": %s\n}\n", strconv.Quote(msg))
+	}
+	return [][2]string{
+		{r.name + "_invalid_id", golden("invalid id")},
+		{r.name + "_invalid_body", golden("invalid request body")},
+		{r.name + "_list_error", golden("failed to list " + humanize(r.table))},
+		{r.name + "_get_error", golden("internal error")},
+	}
+}
+
+// Returns the content for internal/repository/snowflake.go
+func snowflakeGoContent() string {
+	return `package repository
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// snowflakeNode generates unique, time-ordered int64 IDs. Every running instance
+// needs a distinct SNOWFLAKE_NODE (0-1023), otherwise IDs may collide.
+var snowflakeNode = newSnowflakeNode()
+
+func newSnowflakeNode() *snowflake.Node {
+	id, _ := strconv.ParseInt(os.Getenv("SNOWFLAKE_NODE"), 10, 64)
+	node, err := snowflake.NewNode(id)
+	if err != nil {
+		panic(fmt.Sprintf("invalid SNOWFLAKE_NODE: %v", err))
+	}
+	return node
+}
+
+func newSnowflakeID() int64 {
+	return snowflakeNode.Generate().Int64()
+}
+`
+}
+
+// Aligns Go code assembled from fragments the way gofmt would
+func formatGo(src string) string {
+	out, err := format.Source([]byte(src))
+	if err != nil {
+		fatalf(exitTemplate, "Generated invalid Go code: %v", err)
+	}
+	return string(out)
+}
@@ -0,0 +1,718 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Adds full-text search for an existing resource: an engine client, the document
+// mapping, a service indexing every change and a paginated search endpoint
+// Usage: gogo add search <resource> [--engine=elasticsearch|opensearch|meilisearch]
+func addSearch(p project, args []string) ([]generatedFile, []string) {
+	fs := flag.NewFlagSet("gogo add search", flag.ExitOnError)
+	engine := fs.String("engine", "elasticsearch", "search engine (elasticsearch, opensearch, meilisearch)")
+	positional := parseFlags(fs, args)
+	if len(positional) < 1 {
+		fatalf(exitUsage, "Please provide the resource to index, e.g. gogo add search product --engine=meilisearch")
+	}
+
+	var engineFile, engineContent, constructor, dockerRun string
+	switch *engine {
+	case "elasticsearch", "opensearch":
+		engineFile, engineContent = "elasticsearch.go", elasticsearchGoContent()
+		constructor = `search.NewElasticsearch("http://localhost:9200", username, password)`
+		dockerRun = "docker run -p 9200:9200 -e discovery.type=single-node -e xpack.security.enabled=false elasticsearch:8.15.0"
+		if *engine == "opensearch" {
+			dockerRun = "docker run -p 9200:9200 -e discovery.type=single-node -e DISABLE_SECURITY_PLUGIN=true opensearchproject/opensearch:2"
+		}
+	case "meilisearch":
+		engineFile, engineContent = "meilisearch.go", meilisearchGoContent()
+		constructor = `search.NewMeilisearch("http://localhost:7700", apiKey)`
+		dockerRun = "docker run -p 7700:7700 --env-file .env getmeili/meilisearch:v1.10"
+	default:
+		fatalf(exitUsage, "Unsupported --engine value %q (supported: elasticsearch, opensearch, meilisearch)", *engine)
+	}
+
+	var keyStep []string
+	if *engine == "meilisearch" && !dotEnvHas(p.dir, "MEILI_MASTER_KEY") {
+		keyStep = append(keyStep, fmt.Sprintf("Set the master key of the engine: echo MEILI_MASTER_KEY=%s >> .env && echo MEILI_MASTER_KEY=%s >> .env.example", randomSecret(), secretPlaceholder))
+	}
+
+	m := p.model(positional[0])
+	path := strings.ReplaceAll(m.table, "_", "-")
+	service, volume := searchComposeService(*engine)
+	compose := "docker-compose.yml"
+
+	return []generatedFile{
+		{path: compose, marker: markerServices, shared: true, id: "compose " + *engine, content: service,
+			applied: "Start the engine locally: docker compose up -d " + *engine, step: "Start the engine locally: " + dockerRun},
+		{path: compose, marker: markerVolumes, shared: true, id: "compose " + *engine + " volume", content: volume + ":"},
+		{path: filepath.Join("internal", "search", "search.go"), content: searchGoContent(), shared: true},
+		{path: filepath.Join("internal", "search", engineFile), content: engineContent, shared: true},
+		{path: filepath.Join("internal", "search", m.name+"_document.go"), content: searchDocumentGoContent(p.modulePath, m)},
+		{path: filepath.Join("internal", "services", m.name+"_search.go"), content: searchServiceGoContent(p.modulePath, m)},
+		{path: filepath.Join("internal", "handlers", "respond.go"), content: respondGoContent(), shared: true},
+		{path: filepath.Join("internal", "handlers", m.name+"_search_handler.go"), content: searchHandlerGoContent(p.modulePath, m, path)},
+	}, append(keyStep,
+		"Create the client: engine := "+constructor,
+		fmt.Sprintf("Wrap the service: svc := services.NewSearchable%[1]sService(services.New%[1]sService(repo), engine, func(err error) { logger.Warn().Err(err).Msg(\"Indexing failed\") })", m.typeName),
+		fmt.Sprintf("Create the index at startup: svc.EnsureIndex(ctx); svc.Reindex(ctx) indexes the existing %s", humanize(m.table)),
+		fmt.Sprintf("Index every change: in internal/handlers/%[1]s_handler.go, change the service type from *services.%[2]sService to *services.Searchable%[2]sService, and wrap the service in %[1]s_handler_test.go with services.NewSearchable%[2]sService(svc, nil, nil)", m.name, m.typeName),
+		fmt.Sprintf("Register the search route in internal/handlers/router.go: New%sSearchHandler(svc).Register(mux)", m.typeName),
+	)
+}
+
+// Returns the docker-compose service running the search engine locally, and the
+// volume holding its data
+func searchComposeService(engine string) (string, string) {
+	switch engine {
+	case "opensearch":
+		return `opensearch:
+  image: opensearchproject/opensearch:2
+  environment:
+    discovery.type: single-node
+    DISABLE_SECURITY_PLUGIN: "true"
+    DISABLE_INSTALL_DEMO_CONFIG: "true"
+    OPENSEARCH_JAVA_OPTS: -Xms512m -Xmx512m
+  ports:
+    - "9200:9200"
+  volumes:
+    - opensearch-data:/usr/share/opensearch/data
+`, "opensearch-data"
+	case "meilisearch":
+		return `meilisearch:
+  image: getmeili/meilisearch:v1.10
+  environment:
+    MEILI_MASTER_KEY: ${MEILI_MASTER_KEY:?MEILI_MASTER_KEY must be set, see .env.example}
+  ports:
+    - "7700:7700"
+  volumes:
+    - meilisearch-data:/meili_data
+`, "meilisearch-data"
+	default:
+		return `elasticsearch:
+  image: elasticsearch:8.15.0
+  environment:
+    discovery.type: single-node
+    xpack.security.enabled: "false"
+    ES_JAVA_OPTS: -Xms512m -Xmx512m
+  ports:
+    - "9200:9200"
+  volumes:
+    - elasticsearch-data:/usr/share/elasticsearch/data
+`, "elasticsearch-data"
+	}
+}
+
+// Returns the engine-neutral search field type of a db model field, and the
+// expression converting the model value to the document value
+func searchFieldType(f modelField) (fieldType, docType, conv string) {
+	switch strings.TrimPrefix(f.goType, "*") {
+	case "string":
+		return "Text", f.goType, "m." + f.goName
+	case "int", "int32", "int64":
+		return "Integer", f.goType, "m." + f.goName
+	case "float32", "float64":
+		return "Float", f.goType, "m." + f.goName
+	case "bool":
+		return "Boolean", f.goType, "m." + f.goName
+	case "time.Time":
+		return "Date", f.goType, "m." + f.goName
+	default:
+		return "Keyword", "string", "fmt.Sprint(m." + f.goName + ")"
+	}
+}
+
+// Returns the document fields of a model: soft-deleted rows are removed from the
+// index, so deleted_at is not part of the document
+func searchFields(m model) []modelField {
+	var fields []modelField
+	for _, f := range m.fields {
+		if f.column != "deleted_at" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Returns the content for internal/search/search.go
+func searchGoContent() string {
+	return `package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// FieldType is the engine-neutral type of an indexed field
+type FieldType int
+
+const (
+	// Text fields are analyzed for full-text search
+	Text FieldType = iota
+	// Keyword fields are matched exactly and can be filtered on
+	Keyword
+	// Integer, Float, Boolean and Date fields hold values of that type, which
+	// can be filtered on and sorted by
+	Integer
+	Float
+	Boolean
+	Date
+)
+
+// Field describes one field of the indexed documents
+type Field struct {
+	Name string
+	Type FieldType
+}
+
+// Mapping describes the documents of an index
+type Mapping struct {
+	PrimaryKey string
+	Fields     []Field
+}
+
+// TextFields returns the names of the full-text searchable fields
+func (m Mapping) TextFields() []string {
+	var names []string
+	for _, f := range m.Fields {
+		if f.Type == Text {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// Query is a paginated full-text query. An empty Text matches every document.
+type Query struct {
+	Text   string
+	Fields []string
+	Limit  int
+	Offset int
+}
+
+// Result is a page of matching documents, most relevant first
+type Result struct {
+	Total int
+	Hits  []json.RawMessage
+}
+
+// Engine is implemented by the search engine clients
+type Engine interface {
+	// EnsureIndex creates the index with the mapping if it does not exist yet
+	EnsureIndex(ctx context.Context, index string, mapping Mapping) error
+	// Upsert adds or replaces the document with the given ID
+	Upsert(ctx context.Context, index, id string, doc any) error
+	// Delete removes a document; deleting a missing document is not an error
+	Delete(ctx context.Context, index, id string) error
+	Search(ctx context.Context, index string, q Query) (*Result, error)
+}
+
+// send performs req and returns the status and body of the response
+func send(client *http.Client, req *http.Request) (int, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, b, err
+}
+
+// checkStatus turns error responses into errors
+func checkStatus(status int, body []byte) error {
+	if status >= 300 {
+		return fmt.Errorf("search engine returned %d: %s", status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+`
+}
+
+// Returns the content for internal/search/elasticsearch.go
+func elasticsearchGoContent() string {
+	return `package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Elasticsearch is an Engine talking to the REST API shared by Elasticsearch and OpenSearch
+type Elasticsearch struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewElasticsearch creates a client for the cluster at baseURL. Leave username
+// empty when security is disabled.
+func NewElasticsearch(baseURL, username, password string) *Elasticsearch {
+	return &Elasticsearch{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+var esFieldTypes = map[FieldType]string{
+	Text:    "text",
+	Keyword: "keyword",
+	Integer: "long",
+	Float:   "double",
+	Boolean: "boolean",
+	Date:    "date",
+}
+
+// EnsureIndex implements Engine
+func (e *Elasticsearch) EnsureIndex(ctx context.Context, index string, mapping Mapping) error {
+	properties := map[string]any{}
+	for _, f := range mapping.Fields {
+		properties[f.Name] = map[string]string{"type": esFieldTypes[f.Type]}
+	}
+	body := map[string]any{"mappings": map[string]any{"properties": properties}}
+
+	status, resp, err := e.do(ctx, http.MethodPut, "/"+url.PathEscape(index), body)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusBadRequest && bytes.Contains(resp, []byte("resource_already_exists_exception")) {
+		return nil
+	}
+	return checkStatus(status, resp)
+}
+
+// Upsert implements Engine
+func (e *Elasticsearch) Upsert(ctx context.Context, index, id string, doc any) error {
+	status, resp, err := e.do(ctx, http.MethodPut, "/"+url.PathEscape(index)+"/_doc/"+url.PathEscape(id), doc)
+	if err != nil {
+		return err
+	}
+	return checkStatus(status, resp)
+}
+
+// Delete implements Engine
+func (e *Elasticsearch) Delete(ctx context.Context, index, id string) error {
+	status, resp, err := e.do(ctx, http.MethodDelete, "/"+url.PathEscape(index)+"/_doc/"+url.PathEscape(id), nil)
+	if err != nil || status == http.StatusNotFound {
+		return err
+	}
+	return checkStatus(status, resp)
+}
+
+// Search implements Engine
+func (e *Elasticsearch) Search(ctx context.Context, index string, q Query) (*Result, error) {
+	query := map[string]any{"match_all": map[string]any{}}
+	if q.Text != "" {
+		query = map[string]any{"simple_query_string": map[string]any{
+			"query":            q.Text,
+			"fields":           q.Fields,
+			"default_operator": "and",
+		}}
+	}
+	body := map[string]any{"query": query, "from": q.Offset, "size": q.Limit, "track_total_hits": true}
+
+	status, resp, err := e.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_search", body)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatus(status, resp); err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Hits struct {
+			Total struct {
+				Value int ` + "`" + `json:"value"` + "`" + `
+			} ` + "`" + `json:"total"` + "`" + `
+			Hits []struct {
+				Source json.RawMessage ` + "`" + `json:"_source"` + "`" + `
+			} ` + "`" + `json:"hits"` + "`" + `
+		} ` + "`" + `json:"hits"` + "`" + `
+	}
+	if err := json.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("decoding search response: %w", err)
+	}
+	result := &Result{Total: parsed.Hits.Total.Value}
+	for _, h := range parsed.Hits.Hits {
+		result.Hits = append(result.Hits, h.Source)
+	}
+	return result, nil
+}
+
+func (e *Elasticsearch) do(ctx context.Context, method, path string, body any) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, e.baseURL+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.username != "" {
+		req.SetBasicAuth(e.username, e.password)
+	}
+	return send(e.client, req)
+}
+`
+}
+
+// Returns the content for internal/search/meilisearch.go
+func meilisearchGoContent() string {
+	return `package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Meilisearch is an Engine talking to the Meilisearch REST API. Writes are queued
+// as tasks by Meilisearch and become searchable shortly after they return.
+type Meilisearch struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewMeilisearch creates a client for the instance at baseURL, authenticating with apiKey
+func NewMeilisearch(baseURL, apiKey string) *Meilisearch {
+	return &Meilisearch{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// EnsureIndex implements Engine. Text fields become searchable attributes, the
+// others filterable, and numbers and dates sortable too.
+func (m *Meilisearch) EnsureIndex(ctx context.Context, index string, mapping Mapping) error {
+	status, resp, err := m.do(ctx, http.MethodPost, "/indexes", map[string]string{"uid": index, "primaryKey": mapping.PrimaryKey})
+	if err != nil {
+		return err
+	}
+	if err := checkStatus(status, resp); err != nil {
+		return err
+	}
+
+	searchable, filterable, sortable := []string{}, []string{}, []string{}
+	for _, f := range mapping.Fields {
+		switch f.Type {
+		case Text:
+			searchable = append(searchable, f.Name)
+		case Integer, Float, Date:
+			sortable = append(sortable, f.Name)
+			filterable = append(filterable, f.Name)
+		default:
+			filterable = append(filterable, f.Name)
+		}
+	}
+	settings := map[string][]string{
+		"searchableAttributes": searchable,
+		"filterableAttributes": filterable,
+		"sortableAttributes":   sortable,
+	}
+	status, resp, err = m.do(ctx, http.MethodPatch, "/indexes/"+url.PathEscape(index)+"/settings", settings)
+	if err != nil {
+		return err
+	}
+	return checkStatus(status, resp)
+}
+
+// Upsert implements Engine
+func (m *Meilisearch) Upsert(ctx context.Context, index, id string, doc any) error {
+	status, resp, err := m.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(index)+"/documents", []any{doc})
+	if err != nil {
+		return err
+	}
+	return checkStatus(status, resp)
+}
+
+// Delete implements Engine
+func (m *Meilisearch) Delete(ctx context.Context, index, id string) error {
+	status, resp, err := m.do(ctx, http.MethodDelete, "/indexes/"+url.PathEscape(index)+"/documents/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	return checkStatus(status, resp)
+}
+
+// Search implements Engine
+func (m *Meilisearch) Search(ctx context.Context, index string, q Query) (*Result, error) {
+	body := map[string]any{"q": q.Text, "limit": q.Limit, "offset": q.Offset}
+	if len(q.Fields) > 0 {
+		body["attributesToSearchOn"] = q.Fields
+	}
+	status, resp, err := m.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(index)+"/search", body)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatus(status, resp); err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Hits               []json.RawMessage ` + "`" + `json:"hits"` + "`" + `
+		EstimatedTotalHits int               ` + "`" + `json:"estimatedTotalHits"` + "`" + `
+	}
+	if err := json.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("decoding search response: %w", err)
+	}
+	return &Result{Total: parsed.EstimatedTotalHits, Hits: parsed.Hits}, nil
+}
+
+func (m *Meilisearch) do(ctx context.Context, method, path string, body any) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, m.baseURL+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	return send(m.client, req)
+}
+`
+}
+
+// Returns the content for internal/search/<name>_document.go
+func searchDocumentGoContent(modulePath string, m model) string {
+	var fields, mapping, conv strings.Builder
+	usesTime := false
+	fmt.Fprintf(&mapping, "\t\t{Name: \"id\", Type: Keyword},\n")
+	for _, f := range searchFields(m) {
+		fieldType, docType, expr := searchFieldType(f)
+		fmt.Fprintf(&fields, "\t%s %s `json:\"%s\"`\n", f.goName, docType, f.column)
+		fmt.Fprintf(&mapping, "\t\t{Name: %q, Type: %s},\n", f.column, fieldType)
+		fmt.Fprintf(&conv, "\t\t%s: %s,\n", f.goName, expr)
+		usesTime = usesTime || strings.Contains(docType, "time.")
+	}
+
+	var imports strings.Builder
+	imports.WriteString("import (\n\t\"fmt\"\n")
+	if usesTime {
+		imports.WriteString("\t\"time\"\n")
+	}
+	fmt.Fprintf(&imports, "\n\tdbmodels \"%s/internal/models/db\"\n)\n", modulePath)
+
+	return formatGo(fmt.Sprintf(`package search
+
+%[1]s
+// %[2]sIndex is the index holding the %[3]s
+const %[2]sIndex = %[4]q
+
+// %[2]sMapping describes the %[5]s documents
+var %[2]sMapping = Mapping{
+	PrimaryKey: "id",
+	Fields: []Field{
+%[6]s	},
+}
+
+// %[2]sDocument is the indexed representation of %[7]s
+type %[2]sDocument struct {
+	ID string `+"`"+`json:"id"`+"`"+`
+%[8]s}
+
+// New%[2]sDocument maps a %[5]s row to its document
+func New%[2]sDocument(m *dbmodels.%[2]s) %[2]sDocument {
+	return %[2]sDocument{
+		ID: fmt.Sprint(m.ID),
+%[9]s	}
+}
+`, imports.String(), m.typeName, humanize(m.table), m.table, humanize(m.name), mapping.String(),
+		withArticle(humanize(m.name)), fields.String(), conv.String()))
+}
+
+// Returns the content for internal/services/<name>_search.go
+func searchServiceGoContent(modulePath string, m model) string {
+	return formatGo(fmt.Sprintf(`package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+%[6]s
+	dbmodels "%[1]s/internal/models/db"
+	"%[1]s/internal/search"
+)
+
+// Searchable%[2]sService is a %[2]sService that keeps the search index up to date.
+// The database stays the source of truth: indexing failures are reported to
+// onIndexError without failing the request, and Reindex repairs the index.
+type Searchable%[2]sService struct {
+	*%[2]sService
+	engine       search.Engine
+	onIndexError func(error)
+}
+
+// NewSearchable%[2]sService wraps svc to index every change in engine
+func NewSearchable%[2]sService(svc *%[2]sService, engine search.Engine, onIndexError func(error)) *Searchable%[2]sService {
+	if onIndexError == nil {
+		onIndexError = func(error) {}
+	}
+	return &Searchable%[2]sService{%[2]sService: svc, engine: engine, onIndexError: onIndexError}
+}
+
+// EnsureIndex creates the %[3]s index if needed
+func (s *Searchable%[2]sService) EnsureIndex(ctx context.Context) error {
+	return s.engine.EnsureIndex(ctx, search.%[2]sIndex, search.%[2]sMapping)
+}
+
+// Create stores a new %[4]s and indexes it
+func (s *Searchable%[2]sService) Create(ctx context.Context, m *dbmodels.%[2]s) error {
+	if err := s.%[2]sService.Create(ctx, m); err != nil {
+		return err
+	}
+	s.index(ctx, m)
+	return nil
+}
+
+// Update saves the changes to an existing %[4]s and reindexes it
+func (s *Searchable%[2]sService) Update(ctx context.Context, m *dbmodels.%[2]s) error {
+	if err := s.%[2]sService.Update(ctx, m); err != nil {
+		return err
+	}
+	s.index(ctx, m)
+	return nil
+}
+
+// Delete removes the %[4]s with the given ID and its document
+func (s *Searchable%[2]sService) Delete(ctx context.Context, id %[5]s) error {
+	if err := s.%[2]sService.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := s.engine.Delete(ctx, search.%[2]sIndex, fmt.Sprint(id)); err != nil {
+		s.onIndexError(fmt.Errorf("removing %[4]s %%v from the index: %%w", id, err))
+	}
+	return nil
+}
+
+// Search returns a page of the %[3]s matching text, and the total number of matches
+func (s *Searchable%[2]sService) Search(ctx context.Context, text string, limit, offset int) ([]search.%[2]sDocument, int, error) {
+	res, err := s.engine.Search(ctx, search.%[2]sIndex, search.Query{
+		Text:   text,
+		Fields: search.%[2]sMapping.TextFields(),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	docs := make([]search.%[2]sDocument, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		var doc search.%[2]sDocument
+		if err := json.Unmarshal(hit, &doc); err != nil {
+			return nil, 0, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, res.Total, nil
+}
+
+// Reindex indexes every %[4]s, e.g. after creating the index or an outage of the engine
+func (s *Searchable%[2]sService) Reindex(ctx context.Context) error {
+	const pageSize = 100
+	for offset := 0; ; offset += pageSize {
+		items, err := s.List(ctx, pageSize, offset)
+		if err != nil {
+			return err
+		}
+		for i := range items {
+			if err := s.engine.Upsert(ctx, search.%[2]sIndex, fmt.Sprint(items[i].ID), search.New%[2]sDocument(&items[i])); err != nil {
+				return err
+			}
+		}
+		if len(items) < pageSize {
+			return nil
+		}
+	}
+}
+
+func (s *Searchable%[2]sService) index(ctx context.Context, m *dbmodels.%[2]s) {
+	if err := s.engine.Upsert(ctx, search.%[2]sIndex, fmt.Sprint(m.ID), search.New%[2]sDocument(m)); err != nil {
+		s.onIndexError(fmt.Errorf("indexing %[4]s %%v: %%w", m.ID, err))
+	}
+}
+`, modulePath, m.typeName, humanize(m.table), humanize(m.name), m.idType, extraImport(m.idImport)))
+}
+
+// Returns the content for internal/handlers/<name>_search_handler.go
+func searchHandlerGoContent(modulePath string, m model, path string) string {
+	return formatGo(fmt.Sprintf(`package handlers
+
+import (
+	"net/http"
+
+	"%[1]s/internal/search"
+	"%[1]s/internal/services"
+)
+
+// %[2]sSearchHandler exposes the search of %[3]s over HTTP
+type %[2]sSearchHandler struct {
+	svc *services.Searchable%[2]sService
+}
+
+// New%[2]sSearchHandler creates a new %[2]sSearchHandler
+func New%[2]sSearchHandler(svc *services.Searchable%[2]sService) *%[2]sSearchHandler {
+	return &%[2]sSearchHandler{svc: svc}
+}
+
+// Register mounts the search route on mux: GET /%[4]s/search?q=...&limit=20&offset=0
+func (h *%[2]sSearchHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /%[4]s/search", h.search)
+}
+
+type %[5]sSearchResponse struct {
+	Items  []search.%[2]sDocument `+"`"+`json:"items"`+"`"+`
+	Total  int                   `+"`"+`json:"total"`+"`"+`
+	Limit  int                   `+"`"+`json:"limit"`+"`"+`
+	Offset int                   `+"`"+`json:"offset"`+"`"+`
+}
+
+func (h *%[2]sSearchHandler) search(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pageParams(r)
+	items, total, err := h.svc.Search(r.Context(), r.URL.Query().Get("q"), limit, offset)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "search is unavailable")
+		return
+	}
+	writeJSON(w, http.StatusOK, %[5]sSearchResponse{Items: items, Total: total, Limit: limit, Offset: offset})
+}
+`, modulePath, m.typeName, humanize(m.table), path, camelCase(m.name)))
+}
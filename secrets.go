@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Placeholder of the secrets in .env.example
+const secretPlaceholder = "change-me"
+
+// Returns a random secret of 32 hex digits, which fits unquoted in a URL, a DSN
+// and every config format
+func randomSecret() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		fatalf(exitFailure, "Failed to generate a secret: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Returns the secrets of the development environment, generated for each project
+// rather than shipped as well-known values. They are only written to .env, kept
+// out of Git, where docker compose and the Makefile read them: the committed
+// files refer to them as ${DB_PASSWORD} or leave them empty. MEILI_MASTER_KEY
+// is the master key of the Meilisearch service gogo add search sets up.
+func devSecrets() map[string]string {
+	return map[string]string{
+		"DB_PASSWORD":      randomSecret(),
+		"MEILI_MASTER_KEY": randomSecret(),
+	}
+}
+
+// Reports whether the .env file of dir sets key, which projects generated before
+// key joined devSecrets lack
+func dotEnvHas(dir, key string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, ".env"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), key+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the content for .env, read by docker compose and the Makefile
+func dotEnvContent(secrets map[string]string) string {
+	var b strings.Builder
+	b.WriteString("# Secrets of the local stack, generated by gogo for this project. docker compose\n")
+	b.WriteString("# reads them from this file, which is kept out of Git.\n")
+	for _, key := range sortedKeys(secrets) {
+		fmt.Fprintf(&b, "%s=%s\n", key, secrets[key])
+	}
+	return b.String()
+}
+
+// Returns the content for .env.example, listing the variables of .env with
+// placeholders
+func dotEnvExampleContent(secrets map[string]string) string {
+	var b strings.Builder
+	b.WriteString("# Variables docker compose and the Makefile read from .env, which is not\n")
+	b.WriteString("# committed: copy this file to .env and replace the placeholders with secrets\n")
+	b.WriteString("# of your choice for the local stack.\n")
+	for _, key := range sortedKeys(secrets) {
+		fmt.Fprintf(&b, "%s=%s\n", key, secretPlaceholder)
+	}
+	return b.String()
+}
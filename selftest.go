@@ -0,0 +1,236 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// selftestCase is a project generated and compiled by gogo selftest: the flags
+// it is generated with and the components then added to it
+type selftestCase struct {
+	name  string
+	flags []string
+	add   [][]string
+}
+
+// Returns the combinations of built-in templates checked by gogo selftest: every
+// archetype and config format, each optional flag with an archetype supporting it,
+// and every component
+func selftestCases() []selftestCase {
+	cases := []selftestCase{
+		{name: "api"},
+		{name: "api-yaml", flags: []string{"--config-format=yaml"}},
+		{name: "api-toml", flags: []string{"--config-format=toml"}},
+		{name: "api-json", flags: []string{"--config-format=json"}},
+		{name: "nats", flags: []string{"--archetype=nats"}},
+		{name: "nats-yaml", flags: []string{"--archetype=nats", "--config-format=yaml"}},
+		{name: "batch", flags: []string{"--archetype=batch"}},
+		{name: "batch-context-first", flags: []string{"--archetype=batch", "--context-first"}},
+		{name: "api-redis", flags: []string{"--cache=redis"}},
+		{name: "api-temporal", flags: []string{"--workflow=temporal"}},
+		{name: "api-pact", flags: []string{"--contract-tests=pact"}},
+		{name: "api-goreleaser", flags: []string{"--release=goreleaser"}},
+		{name: "api-lambda-redis", flags: []string{"--target=lambda", "--cache=redis", "--config-format=yaml"}},
+		{name: "api-cloudrun-terraform", flags: []string{"--deploy=cloudrun", "--iac=terraform"}},
+		{name: "api-terraform", flags: []string{"--iac=terraform"}},
+		{name: "api-git-cliff", flags: []string{"--changelog=git-cliff"}},
+		{name: "api-chglog", flags: []string{"--changelog=chglog"}},
+		{name: "api-community", flags: []string{"--github-community", "--owner=@acme/backend"}},
+		{name: "api-context-first", flags: []string{"--context-first"}},
+		{name: "api-minimal", flags: []string{"--minimal"}, add: [][]string{{"resource", "product", "name:string"}}},
+		{name: "api-stdlib", flags: []string{"--stdlib=logger,config", "--cache=redis", "--example=todo"}},
+		{name: "nats-stdlib-config", flags: []string{"--archetype=nats", "--stdlib=config"}},
+		{name: "api-static", flags: []string{"--static", "--release=goreleaser"}},
+		{name: "api-pprof-pyroscope", flags: []string{"--pprof", "--profiling=pyroscope"}},
+		{name: "api-pprof-grafana-agent", flags: []string{"--pprof", "--profiling=grafana-agent", "--config-format=yaml"}},
+		{name: "api-sentry", flags: []string{"--errors=sentry", "--cache=redis"}},
+		{name: "api-with", flags: []string{"--with=" + strings.Join(addonNames(), ",")}},
+		{name: "api-module", flags: []string{"--module=github.com/acme/payments", "--goprivate=github.com/acme"}, add: [][]string{{"resource", "product", "name:string"}}},
+		{name: "api-primary-replica", flags: []string{"--db-topology=primary-replica", "--stdlib=logger"}},
+		{name: "api-annotated", flags: []string{"--annotated", "--cache=redis"}},
+		{name: "api-modular", flags: []string{"--layout=modular-monolith"}, add: [][]string{{"module", "billing"}}},
+	}
+
+	// Components taking arguments are listed; the others are added without any,
+	// but those of other layouts, checked by their own case
+	components := selftestCase{name: "api-components", add: [][]string{
+		{"resource", "product", "name:string", "price:float", "--model-conventions=timestamps,soft-delete"},
+		{"resource", "order", "total:float", "--id-type=uuid"},
+		{"import", "product"},
+		{"search", "product"},
+		{"reports", "--formats=csv"},
+		{"aggregate", "Invoice"},
+		{"example", "todo"},
+		{"adr", "Use PostgreSQL"},
+	}}
+	listed := map[string]bool{"module": true}
+	for _, add := range components.add {
+		listed[add[0]] = true
+	}
+	for _, name := range componentNames() {
+		if !listed[name] {
+			components.add = append(components.add, []string{name})
+		}
+	}
+	return append(cases, components)
+}
+
+// Runs `gogo selftest`: generates every case of selftestCases into a temporary
+// directory with this binary and compiles and vets the result
+func runSelftest(args []string) {
+	flags := flag.NewFlagSet("selftest", flag.ExitOnError)
+	run := flags.String("run", "", "only run the cases whose name contains this string")
+	keep := flags.Bool("keep", false, "keep the generated projects")
+	parallel := flags.Int("parallel", runtime.NumCPU(), "number of cases run at once")
+	parseFlags(flags, args)
+
+	exe, err := os.Executable()
+	if err != nil {
+		fatalf(exitFailure, "Failed to locate the gogo binary: %v", err)
+	}
+	root, err := os.MkdirTemp("", "gogo-selftest-")
+	if err != nil {
+		fatalf(exitFailure, "Failed to create a temporary directory: %v", err)
+	}
+	if !*keep {
+		defer os.RemoveAll(root)
+	}
+
+	var cases []selftestCase
+	for _, c := range selftestCases() {
+		if strings.Contains(c.name, *run) {
+			cases = append(cases, c)
+		}
+	}
+	if len(cases) == 0 {
+		fatalf(exitUsage, "No selftest case matches %q", *run)
+	}
+
+	var failed []string
+	p := startProgress(len(cases), "Running %d selftest cases", len(cases))
+	runCases(exe, root, cases, *parallel, func(r caseResult) {
+		defer p.step()
+		if r.Status != caseOK {
+			failed = append(failed, r.Name)
+			p.above(func() {
+				printf("FAIL %s (%s)\n%s: %s\n", r.Name, r.duration().Round(time.Second), r.Step, r.Output)
+			})
+			return
+		}
+		p.above(func() { printf("ok   %s (%s)\n", r.Name, r.duration().Round(time.Second)) })
+	})
+	p.finish(len(failed) == 0)
+
+	if *keep {
+		printf("\nProjects kept in %s\n", root)
+	}
+	if len(failed) > 0 {
+		if !*keep {
+			os.RemoveAll(root)
+		}
+		fatalf(exitSubprocess, "%d of %d selftest cases failed: %s", len(failed), len(cases), strings.Join(failed, ", "))
+	}
+	printf("\nAll %d selftest cases passed.\n", len(cases))
+}
+
+// Outcomes of a case
+const (
+	caseOK       = "ok"
+	caseFailed   = "fail"
+	caseRejected = "rejected" // gogo refused the flags
+)
+
+// caseResult is the outcome of generating and compiling a case
+type caseResult struct {
+	Name       string   `json:"name"`
+	Flags      []string `json:"flags"`
+	Status     string   `json:"status"`
+	Step       string   `json:"step,omitempty"`   // failing command
+	Output     string   `json:"output,omitempty"` // of the failing command
+	DurationMS int64    `json:"duration_ms"`
+}
+
+// Returns the time the case took
+func (r caseResult) duration() time.Duration {
+	return time.Duration(r.DurationMS) * time.Millisecond
+}
+
+// Runs the cases, at most parallel at once, each in its own directory under root,
+// calling report with each result as it completes (one call at a time)
+func runCases(exe, root string, cases []selftestCase, parallel int, report func(caseResult)) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(parallel, 1))
+	for _, c := range cases {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			r := runSelftestCase(exe, filepath.Join(root, c.name), c)
+			mu.Lock()
+			defer mu.Unlock()
+			report(r)
+		}()
+	}
+	wg.Wait()
+}
+
+// Generates the project of c in dir with the gogo binary exe, adds its
+// components, then resolves its dependencies, builds and vets it, stopping at
+// the first failing command
+func runSelftestCase(exe, dir string, c selftestCase) (result caseResult) {
+	start := time.Now()
+	result = caseResult{Name: c.name, Flags: c.flags, Status: caseOK}
+	defer func() { result.DurationMS = time.Since(start).Milliseconds() }()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		result.Status, result.Output = caseFailed, err.Error()
+		return result
+	}
+	project := filepath.Join(dir, c.name)
+	// --open=none keeps an editor set in gogo's config from opening every case
+	commands := [][]string{append(append([]string{exe, "--open=none"}, c.flags...), c.name)}
+	for _, add := range c.add {
+		commands = append(commands, append([]string{exe, "add"}, add...))
+	}
+	commands = append(commands,
+		[]string{"go", "mod", "tidy"},
+		[]string{"go", "build", "./..."},
+		[]string{"go", "vet", "./..."},
+	)
+
+	for i, command := range commands {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Dir = project
+		if i == 0 {
+			cmd.Dir = dir
+		}
+		out, err := runCombined(cmd)
+		if err == nil {
+			continue
+		}
+
+		result.Status, result.Step = caseFailed, "gogo "+strings.Join(command[1:], " ")
+		if command[0] == "go" {
+			result.Step = strings.Join(command, " ")
+		}
+		result.Output = strings.TrimSpace(string(out))
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) {
+			result.Output = err.Error()
+		} else if i == 0 && exit.ExitCode() == exitUsage {
+			result.Status = caseRejected
+		}
+		return result
+	}
+	return result
+}
@@ -0,0 +1,725 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Packages --stdlib generates with the standard library only
+var stdlibPackages = []string{"logger", "config"}
+
+// Parses the comma-separated packages of --stdlib, returning whether the logger
+// and the config packages use the standard library only
+func parseStdlib(value string) (logger, config bool) {
+	if value == "" {
+		return false, false
+	}
+	for _, pkg := range strings.Split(value, ",") {
+		switch strings.TrimSpace(pkg) {
+		case "logger":
+			logger = true
+		case "config":
+			config = true
+		default:
+			fatalf(exitUsage, "Unsupported --stdlib value %q (supported: %s)", pkg, strings.Join(stdlibPackages, ", "))
+		}
+	}
+	return logger, config
+}
+
+// log/slog methods logging at each zerolog level. log/slog has no trace level
+// and does not exit, so Trace logs at the debug level and Fatal logs an error
+// followed by os.Exit(1).
+var slogLevels = map[string]string{"Trace": "Debug", "Debug": "Debug", "Info": "Info", "Warn": "Warn", "Error": "Error", "Fatal": "Error"}
+
+// zerolog fields taking a key and a value, logged as a key-value pair by log/slog
+var zerologPairFields = map[string]bool{
+	"Str": true, "Strs": true, "Stringer": true, "Bytes": true, "Int": true, "Int64": true, "Uint": true, "Uint64": true,
+	"Float64": true, "Bool": true, "Dur": true, "Time": true, "Any": true, "Interface": true, "IPAddr": true,
+}
+
+// Wrappers making a fragment of Go code a file that parses: the code inserted at
+// the markers of a file is statements or declarations
+var goFragmentWrappers = [][2]string{{"", ""}, {"package p\n", ""}, {"package p\nfunc _() {\n", "\n}"}}
+
+// Rewrites the zerolog code of the templates and components into log/slog, for
+// projects generated with --stdlib=logger (see slogRewrite)
+func slogSource(src string) string {
+	out, _ := slogRewrite(src)
+	return out
+}
+
+// Rewrites the zerolog code of the Go source src into log/slog, working on its
+// syntax tree: the logger type, the no-op loggers of the tests, and the chains
+// logging a message (Msg, Msgf or Send), on one line or several, whose fields
+// become key-value pairs. src is a file or a fragment inserted at a marker. The
+// standard library packages the rewritten code needs are imported into a file,
+// and returned for a fragment. Text that is not Go, such as the steps printed by
+// gogo add, is returned as is.
+func slogRewrite(src string) (string, []string) {
+	fset := token.NewFileSet()
+	var file *ast.File
+	var wrapper [2]string
+	for _, w := range goFragmentWrappers {
+		f, err := parser.ParseFile(fset, "", w[0]+src+w[1], parser.ParseComments)
+		if err == nil {
+			file, wrapper = f, w
+			break
+		}
+	}
+	if file == nil {
+		return src, nil
+	}
+	wrapped := wrapper[0] + src + wrapper[1]
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	text := func(n ast.Node) string { return wrapped[offset(n.Pos()):offset(n.End())] }
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	var imports []string
+	need := func(pkg string) {
+		if !slices.Contains(imports, pkg) {
+			imports = append(imports, pkg)
+		}
+	}
+
+	// Returns the log/slog call of the zerolog chain call, and whether it was
+	// logged at the fatal level
+	rewriteChain := func(call *ast.CallExpr) (string, bool, bool) {
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return "", false, false
+		}
+		var msg string
+		switch {
+		case sel.Sel.Name == "Msg" && len(call.Args) == 1:
+			msg = text(call.Args[0])
+		case sel.Sel.Name == "Msgf" && len(call.Args) > 0:
+			args := make([]string, len(call.Args))
+			for i, a := range call.Args {
+				args[i] = text(a)
+			}
+			msg = "fmt.Sprintf(" + strings.Join(args, ", ") + ")"
+		case sel.Sel.Name == "Send" && len(call.Args) == 0:
+			msg = `""`
+		default:
+			return "", false, false
+		}
+		var fields [][]string
+		for x := sel.X; ; {
+			c, ok := x.(*ast.CallExpr)
+			if !ok {
+				return "", false, false
+			}
+			s, ok := c.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return "", false, false
+			}
+			name := s.Sel.Name
+			if method, ok := slogLevels[name]; ok && len(c.Args) == 0 {
+				if sel.Sel.Name == "Msgf" {
+					need("fmt")
+				}
+				args := []string{msg}
+				for i := len(fields) - 1; i >= 0; i-- {
+					args = append(args, fields[i]...)
+				}
+				return text(s.X) + "." + method + "(" + strings.Join(args, ", ") + ")", name == "Fatal", true
+			}
+			switch {
+			case name == "Err" && len(c.Args) == 1:
+				fields = append(fields, []string{`"error"`, text(c.Args[0])})
+			case zerologPairFields[name] && len(c.Args) == 2:
+				fields = append(fields, []string{text(c.Args[0]), text(c.Args[1])})
+			case (name == "Stack" || name == "Caller") && len(c.Args) == 0:
+			default:
+				return "", false, false
+			}
+			x = s.X
+		}
+	}
+
+	hasSlog := slices.ContainsFunc(file.Imports, func(spec *ast.ImportSpec) bool { return spec.Path.Value == `"log/slog"` })
+	nops := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ImportSpec:
+			if n.Path.Value != `"github.com/rs/zerolog"` {
+				return true
+			}
+			if !hasSlog {
+				edits = append(edits, edit{offset(n.Path.Pos()), offset(n.Path.End()), `"log/slog"`})
+				return true
+			}
+			// Drops the line of the import, log/slog being imported already
+			start := strings.LastIndex(wrapped[:offset(n.Pos())], "\n") + 1
+			edits = append(edits, edit{start, offset(n.End()) + 1, ""})
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok && x.Name == "zerolog" && n.Sel.Name == "Logger" {
+				edits = append(edits, edit{offset(n.Pos()), offset(n.End()), "slog.Logger"})
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+				return true
+			}
+			call, ok := n.Rhs[0].(*ast.CallExpr)
+			if !ok || text(call.Fun) != "zerolog.Nop" {
+				return true
+			}
+			if id, ok := n.Lhs[0].(*ast.Ident); ok {
+				nops[id.Name] = true
+			}
+			need("io")
+			edits = append(edits, edit{offset(call.Pos()), offset(call.End()), "slog.New(slog.NewTextHandler(io.Discard, nil))"})
+			return false
+		case *ast.UnaryExpr:
+			// The no-op loggers were zerolog.Logger values, passed by address
+			if id, ok := n.X.(*ast.Ident); ok && n.Op == token.AND && nops[id.Name] {
+				edits = append(edits, edit{offset(n.Pos()), offset(n.End()), id.Name})
+			}
+		case *ast.ExprStmt:
+			call, ok := n.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			rewritten, fatal, ok := rewriteChain(call)
+			if !ok {
+				return true
+			}
+			if fatal {
+				line := strings.LastIndex(wrapped[:offset(n.Pos())], "\n") + 1
+				indent := wrapped[line:offset(n.Pos())]
+				rewritten += "\n" + indent[:len(indent)-len(strings.TrimLeft(indent, " \t"))] + "os.Exit(1)"
+				need("os")
+			}
+			edits = append(edits, edit{offset(n.Pos()), offset(n.End()), rewritten})
+			return false
+		case *ast.CallExpr:
+			if rewritten, _, ok := rewriteChain(n); ok {
+				edits = append(edits, edit{offset(n.Pos()), offset(n.End()), rewritten})
+				return false
+			}
+		}
+		return true
+	})
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := wrapped
+	for _, e := range edits {
+		out = out[:e.start] + e.text + out[e.end:]
+	}
+	if wrapper[0] != "" {
+		return strings.TrimSuffix(strings.TrimPrefix(out, wrapper[0]), wrapper[1]), imports
+	}
+	for _, pkg := range imports {
+		f, err := parser.ParseFile(fset, "", out, parser.ParseComments)
+		if err != nil {
+			return out, nil
+		}
+		if added, ok, _ := addGoImport(fset, f, out, pkg); ok {
+			out = added
+		}
+	}
+	return out, nil
+}
+
+// Rewrites the Go files and the steps of a component for a project logging with
+// log/slog (see slogRewrite)
+func slogComponent(files []generatedFile, steps []string) ([]generatedFile, []string) {
+	var imports []generatedFile
+	for i, f := range files {
+		if strings.HasSuffix(f.path, ".go") && f.marker != markerImports {
+			var needed []string
+			files[i].content, needed = slogRewrite(f.content)
+			// Code inserted into an existing file brings the imports it now needs
+			if f.marker != "" {
+				for _, pkg := range needed {
+					imports = append(imports, generatedFile{path: f.path, marker: markerImports, content: strconv.Quote(pkg)})
+				}
+			}
+		}
+		files[i].step = slogSource(f.step)
+	}
+	for i, step := range steps {
+		steps[i] = slogSource(step)
+	}
+	return append(files, imports...), steps
+}
+
+// Returns the content for pkg/logger/logger.go written with log/slog
+func slogLoggerGoContent(projectName string) string {
+	return fmt.Sprintf(`package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"%s/pkg/config"
+)
+
+// Levels of LOG_LEVEL, including the ones log/slog does not define
+var levels = map[string]slog.Level{
+	"trace":    slog.LevelDebug - 4,
+	"debug":    slog.LevelDebug,
+	"info":     slog.LevelInfo,
+	"warn":     slog.LevelWarn,
+	"error":    slog.LevelError,
+	"fatal":    slog.LevelError + 4,
+	"panic":    slog.LevelError + 8,
+	"disabled": math.MaxInt,
+}
+
+// level is the minimum level of the loggers of NewLogger, changed by SetLevel
+var level = new(slog.LevelVar)
+
+// NewLogger creates a new logger writing JSON lines to stdout and, unless it is
+// empty, appending them to the LOG_FILE of cfg. It becomes the default logger of
+// log/slog. The file is not rotated: leave that to the platform, e.g. logrotate,
+// or log to stdout only.
+func NewLogger(cfg *config.Config) (*slog.Logger, error) {
+	var out io.Writer = os.Stdout
+	if cfg.LogFile != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.LogFile), 0o755); err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		out = io.MultiWriter(os.Stdout, file)
+	}
+
+	logger := slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// SetLevel changes the level of the loggers (debug, info, warn, error, ...)
+func SetLevel(name string) error {
+	lvl, ok := levels[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown level %%q", name)
+	}
+	level.Set(lvl)
+	return nil
+}
+`, projectName)
+}
+
+// Returns the content for pkg/logger/context.go written with log/slog
+func slogLoggerContextGoContent() string {
+	return `package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+// WithContext returns a copy of ctx carrying l, which FromContext returns
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger of ctx, e.g. the one the ContextLogger HTTP
+// middleware scopes to each request with its request and trace IDs, or the
+// default logger when ctx has none. Handlers, services and repositories log
+// through it so that every line logged for a request can be correlated.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+`
+}
+
+// Returns the content for internal/middlewares/access_log.go written with log/slog
+func slogAccessLogGoContent() string {
+	_, recorder, _ := strings.Cut(accessLogGoContent(), "// statusRecorder captures")
+	return `package middlewares
+
+import (
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// AccessLogConfig controls which requests are written to the access log
+type AccessLogConfig struct {
+	// SampleRate is the fraction (0 to 1) of regular requests that are logged.
+	// Server errors and slow requests are always logged.
+	SampleRate float64
+	// SlowThreshold is the latency above which a request is logged as slow (0 disables it)
+	SlowThreshold time.Duration
+}
+
+// AccessLog writes one structured log line per request
+func AccessLog(logger *slog.Logger, cfg AccessLogConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			latency := time.Since(start)
+
+			slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+			var level slog.Level
+			switch {
+			case rec.status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case slow:
+				level = slog.LevelWarn
+			case rand.Float64() < cfg.SampleRate:
+				level = slog.LevelInfo
+			default:
+				return
+			}
+
+			logger.LogAttrs(r.Context(), level, "request",
+				slog.String("request_id", RequestIDFromContext(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Int("bytes", rec.bytes),
+				slog.Duration("latency", latency),
+				slog.Bool("slow", slow),
+				slog.String("user_agent", r.UserAgent()),
+			)
+		})
+	}
+}
+
+// statusRecorder captures` + recorder
+}
+
+// Returns the content for internal/middlewares/logger.go written with log/slog
+func slogContextLoggerGoContent(projectName string) string {
+	_, traceID, _ := strings.Cut(contextLoggerGoContent(projectName), "// traceID returns")
+	return fmt.Sprintf(`package middlewares
+
+import (
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"%s/pkg/logger"
+)
+
+// ContextLogger scopes a child of base to each request, carrying its request ID
+// and, for requests traced with a W3C traceparent header, its trace ID, and
+// stores it in the request context for logger.FromContext
+func ContextLogger(base *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := base.With("request_id", RequestIDFromContext(r.Context()))
+			if id := traceID(r.Header.Get("traceparent")); id != "" {
+				l = l.With("trace_id", id)
+			}
+			next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context(), l)))
+		})
+	}
+}
+
+// traceID returns`, projectName) + traceID
+}
+
+// Returns the content for internal/middlewares/logger_test.go written with log/slog
+func slogContextLoggerTestGoContent(projectName string) string {
+	return slogSource(strings.NewReplacer(
+		"base := zerolog.New(&logs)", "base := slog.New(slog.NewJSONHandler(&logs, nil))",
+		"ContextLogger(&base)", "ContextLogger(base)",
+	).Replace(contextLoggerTestGoContent(projectName)))
+}
+
+// Returns the content for pkg/httpclient/httpclient.go logging with log/slog
+func slogHTTPClientGoContent() string {
+	client, _, _ := strings.Cut(slogSource(httpClientGoContent()), "// LoggingHooks logs")
+	return client + `// LoggingHooks logs every outgoing request with its status and latency
+func LoggingHooks(logger *slog.Logger) Hooks {
+	return Hooks{
+		AfterResponse: func(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+			level := slog.LevelInfo
+			var attrs []slog.Attr
+			if err != nil {
+				level = slog.LevelError
+				attrs = append(attrs, slog.Any("error", err))
+			} else if resp.StatusCode >= http.StatusInternalServerError {
+				level = slog.LevelWarn
+			}
+			if resp != nil {
+				attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			}
+			attrs = append(attrs,
+				slog.String("method", req.Method),
+				slog.String("url", req.URL.Redacted()),
+				slog.Duration("duration", duration),
+			)
+			logger.LogAttrs(req.Context(), level, "outgoing request", attrs...)
+		},
+	}
+}
+`
+}
+
+// Returns the content for pkg/config/config.go written with the standard
+// library, with the API of the viper version: the same Config, flags and order of
+// precedence, reading the .env config files itself and polling them for changes
+func stdlibConfigGoTemplate() string {
+	return `package config
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+` + configTypesGo("env") + `// Settings given on the command line, by key
+var flagValues = map[string]string{}
+
+// LoadConfig reads, applies defaults to and validates the application configuration.
+// The config file is selected by APP_ENV (default dev).
+// Every setting is resolved in the following order of precedence:
+//  1. its command line flag (e.g. --server-port=9090)
+//  2. an environment variable with the setting's name (e.g. SERVER_PORT=9090)
+//  3. the config file of the current environment
+//  4. the default tag on the Config field
+//
+// The mapstructure tag of a field names its setting.
+// LoadConfig parses the command line with flag.Parse, after defining the flags
+// of the settings: main defines its other flags before calling it. --help lists
+// them all.
+func LoadConfig() *Config {
+	registerFlags(flag.CommandLine)
+	flag.Parse()
+	applyFlags(flag.CommandLine)
+
+	configFile := configFilePath()
+	cfg, err := load(configFile)
+	if err != nil {
+		log.Fatalf("Error loading config file %s: %v", configFile, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	return cfg
+}
+
+` + configChecksGo() + `// configFilePath returns the config file for the environment named by APP_ENV,
+// from its flag or environment variable
+func configFilePath() string {
+	env, ok := flagValues["APP_ENV"]
+	if !ok {
+		env = os.Getenv("APP_ENV")
+	}
+	if env == "" {
+		env = defaultEnv
+	}
+	return filepath.Join(configDir, env+"."+configExt)
+}
+
+// load resolves every Config field from its flag, its environment variable, the
+// config file at path and its default, in that order
+func load(path string) (*Config, error) {
+	file, err := readEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	v := reflect.ValueOf(&cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		value, ok := flagValues[key]
+		if !ok {
+			value, ok = lookupEnv(key)
+		}
+		// The env tag names a fallback variable, e.g. the port set by the platform
+		if alias, tagged := field.Tag.Lookup("env"); !ok && tagged {
+			value, ok = lookupEnv(alias)
+		}
+		if !ok {
+			value, ok = file[key]
+		}
+		if !ok {
+			value, ok = field.Tag.Lookup("default")
+		}
+		if !ok {
+			continue
+		}
+		if err := setField(v.Field(i), value); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// lookupEnv returns the environment variable name, an empty one counting as unset
+func lookupEnv(name string) (string, bool) {
+	value := os.Getenv(name)
+	return value, value != ""
+}
+
+// setField decodes value into field according to its type. An empty value is
+// the zero value.
+func setField(field reflect.Value, value string) error {
+	if value == "" {
+		field.SetZero()
+		return nil
+	}
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		items := strings.Split(value, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// readEnvFile returns the KEY=VALUE lines of the file at path, skipping blank
+// lines and # comments
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	settings := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE, got %q", path, n, line)
+		}
+		settings[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return settings, scanner.Err()
+}
+
+` + configFlagsGo() + `// applyFlags records the settings whose flag is set on the command line
+func applyFlags(fs *flag.FlagSet) {
+	fs.Visit(func(f *flag.Flag) {
+		if s, ok := f.Value.(*settingFlag); ok {
+			flagValues[s.key] = s.value
+		}
+	})
+}
+
+` + configSubscribeGo() + `// Watch starts watching the config file, checked every second, and notifies
+// subscribers when it changes. Reloaded configurations that fail validation are
+// logged and ignored.
+func Watch() {
+	configFile := configFilePath()
+	modTime := func() time.Time {
+		info, err := os.Stat(configFile)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+
+	go func() {
+		last := modTime()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			changed := modTime()
+			if changed.Equal(last) {
+				continue
+			}
+			last = changed
+
+			cfg, err := load(configFile)
+			if err != nil {
+				log.Printf("Error reloading config from %s: %v", configFile, err)
+				continue
+			}
+			if err := cfg.Validate(); err != nil {
+				log.Printf("Ignoring invalid config from %s:\n%v", configFile, err)
+				continue
+			}
+
+			mu.RLock()
+			subs := append([]func(*Config){}, subscribers...)
+			mu.RUnlock()
+
+			for _, fn := range subs {
+				fn(cfg)
+			}
+		}
+	}()
+}
+`
+}
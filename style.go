@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Returns the content for .editorconfig
+func editorconfigContent() string {
+	return `root = true
+
+[*]
+charset = utf-8
+end_of_line = lf
+insert_final_newline = true
+trim_trailing_whitespace = true
+
+[*.go]
+indent_style = tab
+indent_size = 4
+
+[{Makefile,*.mk}]
+indent_style = tab
+
+[*.{yml,yaml,json,toml,tf,tfvars,sql}]
+indent_style = space
+indent_size = 2
+
+[*.md]
+trim_trailing_whitespace = false
+`
+}
+
+// dependencyRule forbids the files matching its globs to import some packages of the module
+type dependencyRule struct {
+	name  string
+	files []string
+	deny  map[string]string // package, relative to the module, to the reason shown by the linter
+}
+
+// Returns the intended dependency directions of the archetype's layout, enforced by depguard
+func architectureRules(archetype, layout string) []dependencyRule {
+	// Only main packages and tests wire the transport layer
+	rules := []dependencyRule{{
+		name:  "handlers",
+		files: []string{"$all", "!**/cmd/**", "!**/internal/handlers/**", "!**/tests/**"},
+		deny:  map[string]string{"internal/handlers": "handlers are the outermost layer: only cmd/ and tests import them"},
+	}, {
+		name:  "pkg",
+		files: []string{"**/pkg/**"},
+		deny:  map[string]string{"internal": "pkg/ holds reusable packages that must not depend on the application"},
+	}}
+	switch archetype {
+	case "api":
+		rules = append(rules, dependencyRule{
+			name:  "services",
+			files: []string{"**/internal/services/**"},
+			deny:  map[string]string{"internal/middlewares": "services do not depend on HTTP concerns"},
+		}, dependencyRule{
+			name:  "repository",
+			files: []string{"**/internal/repository/**"},
+			deny: map[string]string{
+				"internal/services":    "dependencies go handlers -> services -> repository",
+				"internal/middlewares": "the repository does not depend on HTTP concerns",
+			},
+		})
+		if layout == "modular-monolith" {
+			rules = append(rules, dependencyRule{
+				name:  "module-repos",
+				files: []string{"**/internal/modules/*/repo/**"},
+				deny:  map[string]string{"internal/modules": "dependencies go handler -> service -> repo within a module, and the repo is the innermost layer"},
+			})
+		}
+	case "batch":
+		rules = append(rules, dependencyRule{
+			name:  "etl",
+			files: []string{"**/internal/etl/**"},
+			deny:  map[string]string{"internal/jobs": "the pipeline is generic: jobs plug into it from cmd/"},
+		})
+	}
+	return rules
+}
+
+// Returns the content for .golangci.yml (golangci-lint v2). The formatters match
+// make fmt: gofumpt, and goimports grouping the module's own imports last. With
+// --context-first, linters also require contexts to be passed down instead of
+// created, and errors to be wrapped with the standard library.
+func golangciConfigContent(opts options) string {
+	var rules strings.Builder
+	for _, rule := range architectureRules(opts.archetype, opts.layout) {
+		fmt.Fprintf(&rules, "        %s:\n          files:\n", rule.name)
+		for _, glob := range rule.files {
+			fmt.Fprintf(&rules, "            - %q\n", glob)
+		}
+		rules.WriteString("          deny:\n")
+		pkgs := make([]string, 0, len(rule.deny))
+		for pkg := range rule.deny {
+			pkgs = append(pkgs, pkg)
+		}
+		sort.Strings(pkgs)
+		for _, pkg := range pkgs {
+			fmt.Fprintf(&rules, "            - pkg: %q\n              desc: %q\n", opts.modulePath+"/"+pkg, rule.deny[pkg])
+		}
+	}
+
+	linters := "    - depguard\n"
+	settings := ""
+	exclusions := ""
+	if opts.contextFirst {
+		linters += "    - contextcheck\n    - forbidigo\n    - noctx\n"
+		rules.WriteString(`        errors:
+          files:
+            - "$all"
+          deny:
+            - pkg: "github.com/pkg/errors"
+              desc: "use errors and fmt.Errorf with %w from the standard library"
+`)
+		settings = `    # Contexts come from the caller (request, message or signal): only main
+    # packages and tests create root contexts
+    forbidigo:
+      analyze-types: true
+      forbid:
+        - pattern: ^context\.(Background|TODO)$
+          msg: "take a context.Context as the first parameter instead"
+`
+		exclusions = `  exclusions:
+    rules:
+      - path: (^|/)(cmd|tests)/|_test\.go$
+        linters:
+          - forbidigo
+`
+	}
+
+	return fmt.Sprintf(`version: "2"
+
+run:
+  timeout: 5m
+
+linters:
+  default: standard
+  enable:
+%[2]s  settings:
+    # Dependency directions of the project layout, checked by make arch-lint
+    depguard:
+      rules:
+%[3]s%[4]s%[5]s
+formatters:
+  enable:
+    - gofumpt
+    - goimports
+  settings:
+    gofumpt:
+      extra-rules: true
+    goimports:
+      local-prefixes:
+        - %[1]s
+`, opts.modulePath, linters, rules.String(), settings, exclusions)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// templateCondition includes paths of a template's files directory only when
+// a variable has a value, e.g. {"paths": ["deploy/helm/**"], "when": "k8s=helm"}
+type templateCondition struct {
+	Paths []string `json:"paths"` // globs, ** as the last element matching a whole tree
+	When  string   `json:"when"`  // name=value, name!=value, name (set and not false) or !name
+}
+
+// Splits the expression of c into its variable, operator (=, != or "" for a test
+// of the value) and value, negated for !name
+func (c templateCondition) parse() (name, op, value string, negated bool) {
+	when := strings.TrimSpace(c.When)
+	if name, value, ok := strings.Cut(when, "!="); ok {
+		return strings.TrimSpace(name), "!=", strings.TrimSpace(value), false
+	}
+	if name, value, ok := strings.Cut(when, "="); ok {
+		return strings.TrimSpace(name), "=", strings.TrimSpace(value), false
+	}
+	if name, ok := strings.CutPrefix(when, "!"); ok {
+		return strings.TrimSpace(name), "", "", true
+	}
+	return when, "", "", false
+}
+
+// Checks the declaration of c in a template manifest with the given variables
+func (c templateCondition) validate(variables []templateVariable) error {
+	name, _, _, _ := c.parse()
+	if !slices.ContainsFunc(variables, func(v templateVariable) bool { return v.Name == name }) {
+		return fmt.Errorf("condition %q refers to no variable", c.When)
+	}
+	if len(c.Paths) == 0 {
+		return fmt.Errorf("condition %q has no paths", c.When)
+	}
+	for _, pattern := range c.Paths {
+		elems := strings.Split(pattern, "/")
+		for i, elem := range elems {
+			if elem == "**" && i < len(elems)-1 {
+				return fmt.Errorf("path %s of condition %q: ** is only supported last", pattern, c.When)
+			}
+			if _, err := path.Match(elem, ""); err != nil {
+				return fmt.Errorf("path %s of condition %q: %v", pattern, c.When, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Reports whether c holds for the variable values
+func (c templateCondition) holds(vars map[string]string) bool {
+	name, op, value, negated := c.parse()
+	switch op {
+	case "=":
+		return vars[name] == value
+	case "!=":
+		return vars[name] != value
+	}
+	set := vars[name] != "" && vars[name] != "false"
+	return set != negated
+}
+
+// Returns the path patterns of the conditions not holding for the variable values
+func excludedPaths(conditions []templateCondition, vars map[string]string) []string {
+	var excluded []string
+	for _, c := range conditions {
+		if !c.holds(vars) {
+			excluded = append(excluded, c.Paths...)
+		}
+	}
+	return excluded
+}
+
+// Reports whether the slash-separated path rel matches pattern: a glob matched
+// element by element, where a last ** element matches the directory and
+// everything under it (deploy/helm/** matches deploy/helm and deploy/helm/values.yaml)
+func matchTreePattern(pattern, rel string) bool {
+	elems, parts := strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(rel, "/")
+	for i, elem := range elems {
+		if elem == "**" {
+			return true
+		}
+		if i >= len(parts) {
+			return false
+		}
+		if ok, _ := path.Match(elem, parts[i]); !ok {
+			return false
+		}
+	}
+	return len(parts) == len(elems)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// templateLayer is one template of the chain a --template resolves to: the
+// templates it extends, in order and each after its own, then itself. Their
+// files directories are laid over the generated project one after the other.
+type templateLayer struct {
+	name     string // as referred to: an installed template or a path
+	dir      string
+	local    bool // name is a path, the manifest naming the template
+	manifest templateManifest
+	excluded []string // paths of its files directory its conditions leave out
+}
+
+// Returns the directory of the template ref, an installed template or a path,
+// relative to the directory from of the template extending it ("" for one given
+// on the command line), and whether it is a path
+func templateRefDir(ref, from string) (string, bool) {
+	if !strings.ContainsAny(ref, `/\`) && ref != "." && ref != ".." {
+		return filepath.Join(templatesDir(), ref), false
+	}
+	if from != "" && !filepath.IsAbs(ref) {
+		return filepath.Join(from, ref), true
+	}
+	return ref, true
+}
+
+// Resolves the chain of the template name in dir, checked under its own name
+// unless local (then under the name of its manifest): the layers of the
+// templates it extends, each included once, then its own. Returns the layers
+// and their variables, where a later template redeclaring a variable replaces
+// the declaration, e.g. to change its default.
+func templateChain(name, dir string, local bool) ([]templateLayer, []templateVariable, error) {
+	var layers []templateLayer
+	done := map[string]bool{}
+	var visit func(name, dir string, local bool, stack []string) error
+	visit = func(name, dir string, local bool, stack []string) error {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if i := slices.Index(stack, abs); i >= 0 {
+			return fmt.Errorf("%s extends itself (%s)", name, strings.Join(append(stack[i:], abs), " -> "))
+		}
+		if done[abs] {
+			return nil
+		}
+		m, err := readTemplateManifest(dir)
+		if errors.Is(err, fs.ErrNotExist) && !local && len(stack) > 0 {
+			return fmt.Errorf("it extends %s, which is not installed: install it first with gogo template install", name)
+		}
+		if err != nil {
+			return err
+		}
+		for _, ref := range m.Extends {
+			refDir, refLocal := templateRefDir(ref, dir)
+			if err := visit(ref, refDir, refLocal, append(stack, abs)); err != nil {
+				return err
+			}
+		}
+		done[abs] = true
+		layers = append(layers, templateLayer{name: name, dir: dir, local: local, manifest: m})
+		return nil
+	}
+	if err := visit(name, dir, local, nil); err != nil {
+		return nil, nil, err
+	}
+
+	var variables []templateVariable
+	for _, l := range layers {
+		for _, v := range l.manifest.Variables {
+			if i := slices.IndexFunc(variables, func(w templateVariable) bool { return w.Name == v.Name }); i >= 0 {
+				variables[i] = v
+			} else {
+				variables = append(variables, v)
+			}
+		}
+	}
+	// Conditions may refer to the variables of the templates extended
+	for i, l := range layers {
+		checked, m := l.name, l.manifest
+		if l.local {
+			checked = m.Name
+		}
+		m.Variables = variables
+		if err := checkTemplate(checked, m); err != nil {
+			if i < len(layers)-1 {
+				return nil, nil, fmt.Errorf("extended template %s: %w", l.name, err)
+			}
+			return nil, nil, err
+		}
+	}
+	return layers, variables, nil
+}
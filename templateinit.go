@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Runs `gogo template init <dir> [--name=<name>]`: scaffolds a template with its
+// manifest, example files and a Makefile generating and compiling a project
+// with it, ready to be pushed and installed with gogo template install
+func initTemplate(args []string) {
+	flags := flag.NewFlagSet("gogo template init", flag.ExitOnError)
+	name := flags.String("name", "", "name of the template (default: the directory name)")
+	positional := parseFlags(flags, args)
+	if len(positional) != 1 {
+		fatalf(exitUsage, "Please provide the directory of the new template, e.g. gogo template init acme-api")
+	}
+	dir := positional[0]
+	if *name == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			fatalf(exitFailure, "Failed to resolve %s: %v", dir, err)
+		}
+		*name = filepath.Base(abs)
+	}
+	if !templateNamePattern.MatchString(*name) {
+		fatalf(exitUsage, "Template name %q must be lower case letters, digits, dots, dashes and underscores, please provide one with --name", *name)
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		fatalf(exitExists, "%s already exists and is not empty", dir)
+	}
+
+	createFile(filepath.Join(dir, templateManifestName), templateManifestContent(*name))
+	createFile(filepath.Join(dir, "files", "docs", "OWNERS.md.tmpl"), templateOwnersContent())
+	createFile(filepath.Join(dir, "files", "internal", "about", "about.go.tmpl"), templateAboutGoContent())
+	createFile(filepath.Join(dir, "Makefile"), templateMakefileContent())
+	createFile(filepath.Join(dir, ".gitignore"), "/.out/\n")
+	createFile(filepath.Join(dir, "README.md"), templateReadmeContent(*name))
+
+	printf("Template %s has been created in %s!\n", *name, dir)
+	fmt.Println(tr("Next steps:"))
+	printf("  1. Describe the template and its variables in %s\n", filepath.Join(dir, templateManifestName))
+	printf("  2. Add the files it lays over generated projects under %s\n", filepath.Join(dir, "files"))
+	printf("  3. Check that a project generated with it compiles: cd %s && make test\n", dir)
+	printf("  4. Push it to a Git repository and install it: gogo template install <git-url>\n")
+}
+
+// Returns the manifest of a new template
+func templateManifestContent(name string) string {
+	m := templateManifest{
+		Name:        name,
+		Description: "Describe what the template adds to generated projects",
+		Variables: []templateVariable{
+			{Name: "team", Description: "team owning the service", Required: true},
+			{Name: "tier", Description: "support tier of the service", Type: varSelect, Options: []string{"1", "2", "3"}, Default: "2"},
+		},
+	}
+	// Development builds have no release to require
+	if v := gogoVersion(); v != "" && !strings.ContainsAny(v, "-+") {
+		m.MinVersion = v
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		fatalf(exitFailure, "Failed to encode the manifest: %v", err)
+	}
+	return string(data) + "\n"
+}
+
+// Returns the content for the example docs/OWNERS.md.tmpl of a new template
+func templateOwnersContent() string {
+	return `# {{.ProjectName}}
+
+Owned by the {{.Vars.team}} team, support tier {{.Vars.tier}}.
+`
+}
+
+// Returns the content for the example internal/about/about.go.tmpl of a new template
+func templateAboutGoContent() string {
+	return `// Package about describes the service to operators
+package about
+
+// Service identifies {{.ProjectName}} ({{.ModulePath}})
+const (
+	Name = "{{.ProjectName}}"
+	Team = "{{.Vars.team}}"
+	Tier = "{{.Vars.tier}}"
+)
+`
+}
+
+// Returns the Makefile of a new template, generating and checking a project with it
+func templateMakefileContent() string {
+	return `# Variables given to the template by make test and make preview
+VARS ?= --var team=example
+# Flags the test project is generated with
+FLAGS ?=
+FILE ?= docs/OWNERS.md
+OUT := .out
+
+.PHONY: validate test preview clean
+
+# Check the manifest, render every file and parse the Go it produces
+validate:
+	gogo template validate $(VARS) $(CURDIR)
+
+# Generate a project with this template, then resolve its dependencies, build and vet it
+test: clean validate
+	mkdir -p $(OUT)
+	cd $(OUT) && gogo --open=none $(FLAGS) --template=$(CURDIR) $(VARS) demo
+	cd $(OUT)/demo && (test -f go.mod || go mod init demo) && go mod tidy && go build ./... && go vet ./...
+
+# Print one file of a project generated with this template: make preview FILE=Dockerfile
+preview:
+	@gogo preview $(FLAGS) --template=$(CURDIR) $(VARS) $(FILE)
+
+clean:
+	rm -rf $(OUT)
+`
+}
+
+// Returns the README of a new template
+func templateReadmeContent(name string) string {
+	return fmt.Sprintf("# %[1]s\n\n"+`A [gogo](https://github.com/parth-javiya/gogo) template: gogo generates its
+project, then lays the files of this template over it.
+
+## Usage
+
+`+"```"+`
+gogo template install <git-url of this repository>
+gogo --template=%[1]s --var team=payments <project-name>
+`+"```"+`
+
+## Layout
+
+- `+"`%[2]s`"+` names the template, describes it and declares its variables
+  (`+"`required`"+`, or with a `+"`default`"+`; of `+"`type`"+` `+"`string`"+`, `+"`int`"+`, `+"`bool`"+` or
+  `+"`select`"+` among `+"`options`"+`; matching a `+"`pattern`"+`) and the oldest gogo release it
+  supports (`+"`min_gogo_version`"+`). `+"`extends`"+` lists templates whose files are laid
+  first, installed ones or paths. gogo prompts for the variables not given with
+  `+"`--var`"+` when run in a terminal.
+- `+"`files/`"+` is copied into generated projects, replacing the files gogo generated at
+  the same paths. Files ending in `+"`.tmpl`"+` are rendered with Go's text/template and
+  written without the suffix, and paths can hold actions too, e.g.
+  `+"`files/docs/{{kebab .ProjectName}}.md`"+`. Templates see `+"`.ProjectName`"+`,
+  `+"`.ModulePath`"+`, `+"`.Archetype`"+`, `+"`.ConfigFormat`"+` and the variables as
+  `+"`.Vars.<name>`"+`, and the helpers `+"`snake`"+`, `+"`camel`"+`, `+"`pascal`"+`, `+"`kebab`"+`,
+  `+"`plural`"+`, `+"`singular`"+` and `+"`table`"+`.
+
+## Developing
+
+- `+"`make validate`"+` checks the manifest, renders every file with sample values of the
+  variables (the defaults, or the ones of `+"`VARS`"+`), parses the Go files it produces
+  and reports the variables nothing uses.
+- `+"`make test`"+` generates a project with this directory as its template and checks that
+  it builds and vets (`+"`FLAGS=\"--archetype=nats\"`"+` and `+"`VARS=\"--var team=x\"`"+` change
+  how it is generated).
+- `+"`make preview FILE=docs/OWNERS.md`"+` prints one file of such a project.
+`, name, templateManifestName)
+}
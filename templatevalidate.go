@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// References to template variables in .tmpl files and paths: {{.Vars.team}} or
+// {{index .Vars "team"}}
+var templateVarRefPattern = regexp.MustCompile(`\.Vars\.([A-Za-z_][A-Za-z0-9_]*)|index\s+\.Vars\s+"([^"]+)"`)
+
+// Runs `gogo template validate [dir] [--var name=value ...]`: checks the template
+// in dir (the current directory by default) without generating a project. The
+// manifest is checked, every file is rendered with sample values of the
+// variables, the Go files it produces are parsed and credentials looked for, and
+// the variables no file or condition uses are reported.
+func validateTemplate(args []string) {
+	flags := flag.NewFlagSet("gogo template validate", flag.ExitOnError)
+	given := templateVars{}
+	flags.Var(given, "var", "sample value of a template variable, name=value (repeatable)")
+	positional := parseFlags(flags, args)
+	dir := "."
+	switch len(positional) {
+	case 0:
+	case 1:
+		dir = positional[0]
+	default:
+		fatalf(exitUsage, "Please provide at most one template directory, e.g. gogo template validate acme-api")
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fatalf(exitUsage, "%s is not a directory", dir)
+	}
+
+	m, err := readTemplateManifest(dir)
+	if err != nil {
+		fatalf(exitTemplate, "Invalid template %s: %v", dir, err)
+	}
+	var problems []string
+	seen := map[string]bool{}
+	for _, v := range m.Variables {
+		if seen[v.Name] {
+			problems = append(problems, fmt.Sprintf("%s: variable %s is declared twice", templateManifestName, v.Name))
+		}
+		seen[v.Name] = true
+	}
+	// The templates it extends are checked with it, as projects get their files too
+	layers, variables, err := templateChain(m.Name, dir, true)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("%s: %v", templateManifestName, err))
+		layers, variables = []templateLayer{{name: dir, dir: dir, manifest: m}}, m.Variables
+	}
+	for key := range given {
+		if !slices.ContainsFunc(variables, func(v templateVariable) bool { return v.Name == key }) {
+			fatalf(exitUsage, "Template %s has no variable %q", dir, key)
+		}
+	}
+
+	vars, sampleProblems := sampleTemplateVars(variables, given)
+	problems = append(problems, sampleProblems...)
+	data := assetData{ProjectName: "myapp", ModulePath: "myapp", Archetype: "api", ConfigFormat: "env", Vars: vars}
+	used := map[string]bool{}
+	count := 0
+	for _, l := range layers {
+		prefix := ""
+		if len(layers) > 1 {
+			prefix = l.name + ": "
+		}
+		fileProblems, n := checkTemplateFiles(filepath.Join(l.dir, "files"), data, used)
+		for _, p := range fileProblems {
+			problems = append(problems, prefix+p)
+		}
+		count += n
+		for _, c := range l.manifest.Conditions {
+			name, _, _, _ := c.parse()
+			used[name] = true
+		}
+	}
+
+	if count == 0 {
+		problems = append(problems, "no files: the template adds nothing to generated projects")
+	}
+	for _, v := range variables {
+		if !used[v.Name] {
+			printStyled(styleYellow, "Warning: variable %s is used by no file, path or condition\n", v.Name)
+		}
+	}
+	if len(problems) > 0 {
+		fatalf(exitTemplate, "Template %s is invalid:\n  %s", dir, strings.Join(problems, "\n  "))
+	}
+	printStyled(styleGreen, "Template %s is valid: %d variables, %d files rendered.\n", m.Name, len(variables), count)
+}
+
+// Returns the values variables are rendered with: the --var values, then the
+// defaults, then a sample of their type. A string variable with a pattern and no
+// default needs a value given with --var.
+func sampleTemplateVars(variables []templateVariable, given map[string]string) (map[string]string, []string) {
+	vars := map[string]string{}
+	var problems []string
+	for _, v := range variables {
+		value, ok := given[v.Name]
+		switch {
+		case ok:
+		case v.Default != "":
+			value = v.Default
+		case v.typeName() == varInt:
+			value = "1"
+		case v.typeName() == varBool:
+			value = "true"
+		case v.typeName() == varSelect && len(v.Options) > 0:
+			value = v.Options[0]
+		default:
+			value = "sample"
+		}
+		parsed, err := v.parse(value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("variable %s: sample value %v, give one with --var %s=<value>", v.Name, err, v.Name))
+			parsed = value
+		}
+		vars[v.Name] = parsed
+	}
+	return vars, problems
+}
+
+// Renders the paths and .tmpl files of the files directory of a template with
+// data, conditions aside, and checks the result, adding the variables the files
+// and paths refer to to used. Returns the problems found and the number of files,
+// none when there is no files directory.
+func checkTemplateFiles(files string, data assetData, used map[string]bool) ([]string, int) {
+	var problems []string
+	count := 0
+	if _, err := os.Stat(files); err != nil {
+		return nil, 0
+	}
+	err := fs.WalkDir(os.DirFS(files), ".", func(rel string, d fs.DirEntry, err error) error {
+		if err != nil || rel == "." {
+			return err
+		}
+		markTemplateVars(used, rel)
+		out, err := renderAssetPath(rel, data)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", rel, err))
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || strings.HasSuffix(rel, symlinkSuffix) {
+			return nil
+		}
+		count++
+		text, content, err := renderedTemplateFile(files, rel, data)
+		if strings.HasSuffix(rel, templateSuffix) {
+			markTemplateVars(used, string(content))
+			out = strings.TrimSuffix(out, templateSuffix)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", rel, err))
+			return nil
+		}
+		if !isText(content) {
+			return nil
+		}
+		if strings.HasSuffix(out, ".go") {
+			if _, err := parser.ParseFile(token.NewFileSet(), out, text, parser.AllErrors); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: renders Go that does not parse: %v", rel, err))
+			}
+		}
+		for _, f := range scanSecrets(rel, text) {
+			problems = append(problems, f.String())
+		}
+		return nil
+	})
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems, count
+}
+
+// Adds the variables text refers to to used
+func markTemplateVars(used map[string]bool, text string) {
+	for _, m := range templateVarRefPattern.FindAllStringSubmatch(text, -1) {
+		used[m[1]+m[2]] = true
+	}
+}
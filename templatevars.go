@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Types of template variables
+const (
+	varString = "string"
+	varInt    = "int"
+	varBool   = "bool"
+	varSelect = "select" // one of the options
+)
+
+// Returns the type of v, string when the manifest leaves it out
+func (v templateVariable) typeName() string {
+	if v.Type == "" {
+		return varString
+	}
+	return v.Type
+}
+
+// Checks the declaration of v in a template manifest
+func (v templateVariable) validate() error {
+	if v.Name == "" {
+		return errors.New("a variable has no name")
+	}
+	switch v.typeName() {
+	case varString, varInt, varBool:
+		if len(v.Options) > 0 {
+			return fmt.Errorf("variable %s has options but is not a select", v.Name)
+		}
+	case varSelect:
+		if len(v.Options) == 0 {
+			return fmt.Errorf("select variable %s has no options", v.Name)
+		}
+	default:
+		return fmt.Errorf("variable %s has unsupported type %q (supported: string, int, bool, select)", v.Name, v.Type)
+	}
+	if v.Pattern != "" {
+		if _, err := regexp.Compile(v.Pattern); err != nil {
+			return fmt.Errorf("variable %s has an invalid pattern: %v", v.Name, err)
+		}
+	}
+	if v.Default != "" {
+		if _, err := v.parse(v.Default); err != nil {
+			return fmt.Errorf("variable %s has an invalid default: %v", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// Checks a value of v, returning it in canonical form: true or false for a bool
+func (v templateVariable) parse(value string) (string, error) {
+	switch v.typeName() {
+	case varInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("%q is not an integer", value)
+		}
+	case varBool:
+		switch strings.ToLower(value) {
+		case "y", "yes":
+			value = "true"
+		case "n", "no":
+			value = "false"
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not yes or no", value)
+		}
+		value = strconv.FormatBool(b)
+	case varSelect:
+		if !slices.Contains(v.Options, value) {
+			return "", fmt.Errorf("%q is not one of %s", value, strings.Join(v.Options, ", "))
+		}
+	}
+	if v.Pattern != "" && !regexp.MustCompile("^(?:"+v.Pattern+")$").MatchString(value) {
+		return "", fmt.Errorf("%q does not match %s", value, v.Pattern)
+	}
+	return value, nil
+}
+
+// Returns the values of the variables of template name: the --var values, then,
+// when interactive and gogo runs in a terminal, the answers to prompts, and the
+// defaults otherwise. The required variables left without a value are listed
+// together, with the flags giving them.
+func resolveTemplateVars(name string, variables []templateVariable, given map[string]string, interactive bool) map[string]string {
+	for key := range given {
+		if !slices.ContainsFunc(variables, func(v templateVariable) bool { return v.Name == key }) {
+			fatalf(exitUsage, "Template %s has no variable %q (see gogo template list)", name, key)
+		}
+	}
+
+	var in *bufio.Reader
+	if interactive && stdinIsTerminal() {
+		in = bufio.NewReader(os.Stdin)
+	}
+	vars := map[string]string{}
+	var missing []string
+	for _, v := range variables {
+		value, ok := given[v.Name]
+		switch {
+		case ok && value != "":
+			parsed, err := v.parse(value)
+			if err != nil {
+				fatalf(exitUsage, "Invalid --var %s: %v", v.Name, err)
+			}
+			value = parsed
+		case !ok && in != nil:
+			value = promptTemplateVar(in, v)
+		case !ok && v.Default != "":
+			value, _ = v.parse(v.Default)
+		}
+		if v.Required && value == "" {
+			missing = append(missing, fmt.Sprintf("--var %s=<value> (%s)", v.Name, v.Description))
+		}
+		vars[v.Name] = value
+	}
+	if len(missing) > 0 {
+		fatalf(exitUsage, "Template %s requires %s", name, strings.Join(missing, ", "))
+	}
+	return vars
+}
+
+// Asks for the value of v until a valid one is given, an empty answer taking the
+// default. Prompts go to stderr, keeping stdout to gogo preview's output.
+func promptTemplateVar(in *bufio.Reader, v templateVariable) string {
+	label := v.Name
+	if v.Description != "" {
+		label += " - " + v.Description
+	}
+	switch v.typeName() {
+	case varSelect:
+		label += " (" + strings.Join(v.Options, "/") + ")"
+	case varBool:
+		label += " (y/n)"
+	}
+	if v.Default != "" {
+		label += " [" + v.Default + "]"
+	}
+
+	for {
+		fmt.Fprintf(os.Stderr, "%s: ", label)
+		line, err := in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = v.Default
+		}
+		if answer == "" && (!v.Required || err != nil) {
+			return ""
+		}
+		if answer == "" {
+			fmt.Fprintln(os.Stderr, tr("  A value is required"))
+			continue
+		}
+		value, perr := v.parse(answer)
+		if perr == nil {
+			return value
+		}
+		if err != nil {
+			fatalf(exitUsage, "Invalid value for %s: %v", v.Name, perr)
+		}
+		fmt.Fprintf(os.Stderr, "  %v\n", perr)
+	}
+}
+
+// Reports whether gogo's standard input is a terminal someone can answer prompts on
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
@@ -0,0 +1,235 @@
+package main
+
+import "fmt"
+
+// Settings added to the config when a Temporal worker is generated
+var temporalConfigFields = []configField{
+	{goName: "TemporalHostPort", goType: "string", key: "TEMPORAL_HOST_PORT", value: "localhost:7233", comment: "Temporal frontend address, namespace and the task queue the worker polls"},
+	{goName: "TemporalNamespace", goType: "string", key: "TEMPORAL_NAMESPACE", value: "default"},
+	{goName: "TemporalTaskQueue", goType: "string", key: "TEMPORAL_TASK_QUEUE", value: "myapi"},
+}
+
+// Temporal server backed by the postgres service, for docker-compose.yml
+var temporalComposeService = composeService{
+	name: "temporal",
+	definition: `    image: temporalio/auto-setup:1.25
+    environment:
+      DB: postgres12
+      DB_PORT: 5432
+      POSTGRES_USER: root
+      POSTGRES_PWD: ${DB_PASSWORD:?DB_PASSWORD must be set, see .env.example}
+      POSTGRES_SEEDS: postgres
+    ports:
+      - "7233:7233"
+    depends_on:
+      - postgres
+`,
+	devEnv: []string{"TEMPORAL_HOST_PORT: temporal:7233"},
+}
+
+// Returns the Makefile targets for running the Temporal worker and starter
+func temporalMakefileContent() string {
+	return `
+.PHONY: worker start-workflow
+
+worker:
+	APP_ENV=$(APP_ENV) go run ./cmd/worker
+
+# Starts the sample workflow, e.g. make start-workflow NAME=Temporal
+start-workflow:
+	APP_ENV=$(APP_ENV) go run ./cmd/starter $(NAME)
+`
+}
+
+// Returns the content for cmd/worker/main.go
+func temporalWorkerMainGoContent(projectName string) string {
+	return fmt.Sprintf(`package main
+
+import (
+	"log"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+
+	"%[1]s/internal/workflows"
+	"%[1]s/pkg/config"
+	"%[1]s/pkg/logger"
+)
+
+func main() {
+	cfg := config.LoadConfig()
+
+	appLog, err := logger.NewLogger(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %%v", err)
+	}
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		appLog.Warn().Err(err).Msg("Invalid log level, keeping the default")
+	}
+
+	c, err := client.Dial(client.Options{
+		HostPort:  cfg.TemporalHostPort,
+		Namespace: cfg.TemporalNamespace,
+	})
+	if err != nil {
+		appLog.Fatal().Err(err).Msg("Failed to connect to Temporal")
+	}
+	defer c.Close()
+
+	w := worker.New(c, cfg.TemporalTaskQueue, worker.Options{})
+	w.RegisterWorkflow(workflows.GreetingWorkflow)
+	w.RegisterActivity(&workflows.Activities{})
+
+	appLog.Info().Str("task_queue", cfg.TemporalTaskQueue).Msg("Temporal worker started")
+	if err := w.Run(worker.InterruptCh()); err != nil {
+		appLog.Fatal().Err(err).Msg("Temporal worker failed")
+	}
+	appLog.Info().Msg("Temporal worker stopped")
+}
+`, projectName)
+}
+
+// Returns the content for cmd/starter/main.go
+func temporalStarterMainGoContent(projectName string) string {
+	return fmt.Sprintf(`package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"go.temporal.io/sdk/client"
+
+	"%[1]s/internal/workflows"
+	"%[1]s/pkg/config"
+)
+
+// Starts the greeting workflow for the name given as argument and waits for its result
+func main() {
+	cfg := config.LoadConfig()
+
+	name := "World"
+	if flag.NArg() > 0 {
+		name = flag.Arg(0)
+	}
+
+	c, err := client.Dial(client.Options{
+		HostPort:  cfg.TemporalHostPort,
+		Namespace: cfg.TemporalNamespace,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to Temporal: %%v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        "greeting-" + name,
+		TaskQueue: cfg.TemporalTaskQueue,
+	}, workflows.GreetingWorkflow, name)
+	if err != nil {
+		log.Fatalf("Failed to start workflow: %%v", err)
+	}
+
+	var greeting string
+	if err := run.Get(ctx, &greeting); err != nil {
+		log.Fatalf("Workflow %%s failed: %%v", run.GetID(), err)
+	}
+	fmt.Println(greeting)
+}
+`, projectName)
+}
+
+// Returns the content for internal/workflows/greeting.go
+func greetingWorkflowGoContent() string {
+	return `package workflows
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// GreetingWorkflow is a sample workflow calling a single activity. Workflow code must be
+// deterministic: do I/O in activities and use workflow.Now/workflow.Sleep instead of the time package.
+func GreetingWorkflow(ctx workflow.Context, name string) (string, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumAttempts:    5,
+		},
+	})
+
+	var a *Activities
+	var greeting string
+	if err := workflow.ExecuteActivity(ctx, a.ComposeGreeting, name).Get(ctx, &greeting); err != nil {
+		return "", err
+	}
+
+	workflow.GetLogger(ctx).Info("Greeting composed", "greeting", greeting)
+	return greeting, nil
+}
+`
+}
+
+// Returns the content for internal/workflows/activities.go
+func workflowActivitiesGoContent() string {
+	return `package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Activities holds the dependencies of the activities (clients, repositories, ...)
+// and is registered on the worker as a whole
+type Activities struct{}
+
+// ComposeGreeting builds the greeting for name
+func (a *Activities) ComposeGreeting(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+	return fmt.Sprintf("Hello, %s!", name), nil
+}
+`
+}
+
+// Returns the content for internal/workflows/greeting_test.go
+func greetingWorkflowTestGoContent() string {
+	return `package workflows
+
+import (
+	"testing"
+
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestGreetingWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&Activities{})
+
+	env.ExecuteWorkflow(GreetingWorkflow, "Temporal")
+
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("workflow did not complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var greeting string
+	if err := env.GetWorkflowResult(&greeting); err != nil {
+		t.Fatal(err)
+	}
+	if greeting != "Hello, Temporal!" {
+		t.Errorf("greeting = %q, want %q", greeting, "Hello, Temporal!")
+	}
+}
+`
+}
@@ -0,0 +1,957 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// terraformEnvironment holds the sizing of one environment folder under infra/environments
+type terraformEnvironment struct {
+	name               string
+	desiredCount       int
+	cpu, memory        string
+	dbTier             string
+	highAvailability   bool
+	deletionProtection bool
+}
+
+// Environments that get a Terraform root module, from the smallest to the production setup
+var terraformEnvironments = []terraformEnvironment{
+	{name: "dev", desiredCount: 1, cpu: "256", memory: "512", dbTier: "small"},
+	{name: "prod", desiredCount: 2, cpu: "512", memory: "1024", dbTier: "medium", highAvailability: true, deletionProtection: true},
+}
+
+// Returns the files of the Terraform configuration: a service module (container,
+// database and secrets) and one root module per environment calling it. The module
+// targets Google Cloud when the service is deployed to Cloud Run and AWS (ECS on
+// Fargate, RDS) otherwise.
+func terraformFiles(projectName, image, deploy string, extra []configField) map[string]string {
+	// Secrets are created empty, except for the generated database password
+	var secrets []string
+	for _, f := range extra {
+		if f.secret {
+			secrets = append(secrets, f.key)
+		}
+	}
+
+	module := filepath.Join("infra", "modules", "service")
+	files := map[string]string{}
+	if deploy == "cloudrun" {
+		files[filepath.Join(module, "versions.tf")] = terraformGoogleVersionsContent()
+		files[filepath.Join(module, "variables.tf")] = terraformGoogleVariablesContent()
+		files[filepath.Join(module, "main.tf")] = terraformGoogleMainContent()
+		files[filepath.Join(module, "outputs.tf")] = terraformGoogleOutputsContent()
+	} else {
+		files[filepath.Join(module, "versions.tf")] = terraformAWSVersionsContent()
+		files[filepath.Join(module, "variables.tf")] = terraformAWSVariablesContent()
+		files[filepath.Join(module, "main.tf")] = terraformAWSMainContent()
+		files[filepath.Join(module, "outputs.tf")] = terraformAWSOutputsContent()
+	}
+	for _, env := range terraformEnvironments {
+		dir := filepath.Join("infra", "environments", env.name)
+		if deploy == "cloudrun" {
+			files[filepath.Join(dir, "main.tf")] = terraformGoogleEnvironmentContent(projectName, env, secrets)
+		} else {
+			files[filepath.Join(dir, "main.tf")] = terraformAWSEnvironmentContent(projectName, env, secrets)
+		}
+		files[filepath.Join(dir, "terraform.tfvars.example")] = terraformTFVarsContent(projectName, image, deploy)
+	}
+	files[filepath.Join("infra", ".gitignore")] = terraformGitignoreContent()
+	return files
+}
+
+// Returns the Makefile targets running Terraform in one of the environment folders
+func terraformMakefileContent() string {
+	return `
+TF_ENV ?= dev
+TF_DIR := infra/environments/$(TF_ENV)
+
+.PHONY: tf-init tf-plan tf-apply
+
+tf-init:
+	terraform -chdir=$(TF_DIR) init
+
+tf-plan:
+	terraform -chdir=$(TF_DIR) plan $(if $(IMAGE),-var image=$(IMAGE)) -out=tfplan
+
+tf-apply:
+	terraform -chdir=$(TF_DIR) apply tfplan
+`
+}
+
+// Returns the content for infra/.gitignore
+func terraformGitignoreContent() string {
+	return `.terraform/
+*.tfstate
+*.tfstate.*
+tfplan
+terraform.tfvars
+`
+}
+
+// Formats a list of strings as an HCL list
+func hclList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// Returns the content for infra/environments/<env>/terraform.tfvars.example
+func terraformTFVarsContent(projectName, image, deploy string) string {
+	if deploy == "cloudrun" {
+		return fmt.Sprintf(`# Copy to terraform.tfvars; the image is usually passed by make tf-plan
+project_id = "my-gcp-project"
+region     = "europe-west1"
+image      = "europe-west1-docker.pkg.dev/my-gcp-project/%[1]s/%[2]s:v0.1.0"
+`, projectName, image)
+	}
+	return fmt.Sprintf(`# Copy to terraform.tfvars; the image is usually passed by make tf-plan
+region     = "eu-west-1"
+image      = "123456789012.dkr.ecr.eu-west-1.amazonaws.com/%[2]s:v0.1.0"
+vpc_id     = "vpc-0123456789abcdef0"
+subnet_ids = ["subnet-0123456789abcdef0", "subnet-0123456789abcdef1"]
+`, projectName, image)
+}
+
+// Returns the content for infra/modules/service/versions.tf (AWS)
+func terraformAWSVersionsContent() string {
+	return `terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.6"
+    }
+  }
+}
+`
+}
+
+// Returns the content for infra/modules/service/variables.tf (AWS)
+func terraformAWSVariablesContent() string {
+	return `variable "name" {
+  description = "Name of the service, used as a prefix for every resource"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment of the service, selecting its config file (APP_ENV)"
+  type        = string
+}
+
+variable "image" {
+  description = "Container image of the service"
+  type        = string
+}
+
+variable "container_port" {
+  description = "Port the service listens on (SERVER_PORT)"
+  type        = number
+  default     = 8080
+}
+
+variable "vpc_id" {
+  description = "VPC the service and its database run in"
+  type        = string
+}
+
+variable "subnet_ids" {
+  description = "Private subnets of the tasks and the database"
+  type        = list(string)
+}
+
+variable "desired_count" {
+  description = "Number of running tasks"
+  type        = number
+  default     = 1
+}
+
+variable "cpu" {
+  description = "CPU units of a task"
+  type        = string
+  default     = "256"
+}
+
+variable "memory" {
+  description = "Memory of a task, in MiB"
+  type        = string
+  default     = "512"
+}
+
+variable "target_group_arn" {
+  description = "Load balancer target group to register the tasks with, if any"
+  type        = string
+  default     = null
+}
+
+variable "db_instance_class" {
+  description = "Instance class of the Postgres database"
+  type        = string
+  default     = "db.t4g.micro"
+}
+
+variable "db_allocated_storage" {
+  description = "Storage of the database, in GiB"
+  type        = number
+  default     = 20
+}
+
+variable "db_multi_az" {
+  description = "Whether the database has a standby in another availability zone"
+  type        = bool
+  default     = false
+}
+
+variable "deletion_protection" {
+  description = "Whether the database is protected from deletion (and snapshotted when destroyed)"
+  type        = bool
+  default     = true
+}
+
+variable "secrets" {
+  description = "Settings read from Secrets Manager, created empty; set their values outside of Terraform"
+  type        = list(string)
+  default     = []
+}
+
+variable "log_retention_days" {
+  description = "Retention of the service logs"
+  type        = number
+  default     = 30
+}
+`
+}
+
+// Returns the content for infra/modules/service/main.tf (AWS)
+func terraformAWSMainContent() string {
+	return `locals {
+  prefix = "${var.name}-${var.environment}"
+  # The database name and user follow the DB_NAME and DB_USER settings
+  db_name = replace(var.name, "-", "_")
+}
+
+data "aws_region" "current" {}
+
+# --- Secrets ---
+
+resource "random_password" "db" {
+  length  = 32
+  special = false
+}
+
+resource "aws_secretsmanager_secret" "db_password" {
+  name = "${local.prefix}/DB_PASSWORD"
+}
+
+resource "aws_secretsmanager_secret_version" "db_password" {
+  secret_id     = aws_secretsmanager_secret.db_password.id
+  secret_string = random_password.db.result
+}
+
+resource "aws_secretsmanager_secret" "settings" {
+  for_each = toset(var.secrets)
+  name     = "${local.prefix}/${each.key}"
+}
+
+# --- Database ---
+
+resource "aws_security_group" "db" {
+  name   = "${local.prefix}-db"
+  vpc_id = var.vpc_id
+
+  ingress {
+    description     = "Postgres from the service"
+    from_port       = 5432
+    to_port         = 5432
+    protocol        = "tcp"
+    security_groups = [aws_security_group.service.id]
+  }
+}
+
+resource "aws_db_subnet_group" "db" {
+  name       = local.prefix
+  subnet_ids = var.subnet_ids
+}
+
+resource "aws_db_instance" "db" {
+  identifier                = local.prefix
+  engine                    = "postgres"
+  engine_version            = "16"
+  instance_class            = var.db_instance_class
+  allocated_storage         = var.db_allocated_storage
+  storage_encrypted         = true
+  db_name                   = local.db_name
+  username                  = local.db_name
+  password                  = random_password.db.result
+  multi_az                  = var.db_multi_az
+  db_subnet_group_name      = aws_db_subnet_group.db.name
+  vpc_security_group_ids    = [aws_security_group.db.id]
+  backup_retention_period   = 7
+  deletion_protection       = var.deletion_protection
+  skip_final_snapshot       = !var.deletion_protection
+  final_snapshot_identifier = var.deletion_protection ? "${local.prefix}-final" : null
+}
+
+# --- Service ---
+
+resource "aws_cloudwatch_log_group" "service" {
+  name              = "/ecs/${local.prefix}"
+  retention_in_days = var.log_retention_days
+}
+
+resource "aws_ecs_cluster" "main" {
+  name = local.prefix
+}
+
+data "aws_iam_policy_document" "assume_ecs_tasks" {
+  statement {
+    actions = ["sts:AssumeRole"]
+    principals {
+      type        = "Service"
+      identifiers = ["ecs-tasks.amazonaws.com"]
+    }
+  }
+}
+
+# Role used by ECS to pull the image, write logs and read the secrets
+resource "aws_iam_role" "execution" {
+  name               = "${local.prefix}-execution"
+  assume_role_policy = data.aws_iam_policy_document.assume_ecs_tasks.json
+}
+
+resource "aws_iam_role_policy_attachment" "execution" {
+  role       = aws_iam_role.execution.name
+  policy_arn = "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy"
+}
+
+data "aws_iam_policy_document" "read_secrets" {
+  statement {
+    actions   = ["secretsmanager:GetSecretValue"]
+    resources = concat([aws_secretsmanager_secret.db_password.arn], [for s in aws_secretsmanager_secret.settings : s.arn])
+  }
+}
+
+resource "aws_iam_role_policy" "read_secrets" {
+  name   = "read-secrets"
+  role   = aws_iam_role.execution.id
+  policy = data.aws_iam_policy_document.read_secrets.json
+}
+
+# Role assumed by the application itself; attach the policies it needs
+resource "aws_iam_role" "task" {
+  name               = "${local.prefix}-task"
+  assume_role_policy = data.aws_iam_policy_document.assume_ecs_tasks.json
+}
+
+resource "aws_security_group" "service" {
+  name   = "${local.prefix}-service"
+  vpc_id = var.vpc_id
+
+  ingress {
+    description = "HTTP within the VPC"
+    from_port   = var.container_port
+    to_port     = var.container_port
+    protocol    = "tcp"
+    cidr_blocks = [data.aws_vpc.main.cidr_block]
+  }
+
+  egress {
+    from_port   = 0
+    to_port     = 0
+    protocol    = "-1"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+
+data "aws_vpc" "main" {
+  id = var.vpc_id
+}
+
+resource "aws_ecs_task_definition" "service" {
+  family                   = local.prefix
+  requires_compatibilities = ["FARGATE"]
+  network_mode             = "awsvpc"
+  cpu                      = var.cpu
+  memory                   = var.memory
+  execution_role_arn       = aws_iam_role.execution.arn
+  task_role_arn            = aws_iam_role.task.arn
+
+  runtime_platform {
+    operating_system_family = "LINUX"
+    cpu_architecture        = "X86_64"
+  }
+
+  # Environment variables override the settings of configs/<APP_ENV>.*
+  container_definitions = jsonencode([{
+    name         = var.name
+    image        = var.image
+    essential    = true
+    portMappings = [{ containerPort = var.container_port, protocol = "tcp" }]
+    environment = [
+      { name = "APP_ENV", value = var.environment },
+      { name = "SERVER_PORT", value = tostring(var.container_port) },
+      { name = "DB_HOST", value = aws_db_instance.db.address },
+      { name = "DB_PORT", value = tostring(aws_db_instance.db.port) },
+      { name = "DB_NAME", value = local.db_name },
+      { name = "DB_USER", value = local.db_name },
+    ]
+    secrets = concat(
+      [{ name = "DB_PASSWORD", valueFrom = aws_secretsmanager_secret.db_password.arn }],
+      [for key, s in aws_secretsmanager_secret.settings : { name = key, valueFrom = s.arn }],
+    )
+    logConfiguration = {
+      logDriver = "awslogs"
+      options = {
+        awslogs-group         = aws_cloudwatch_log_group.service.name
+        awslogs-region        = data.aws_region.current.name
+        awslogs-stream-prefix = var.name
+      }
+    }
+  }])
+}
+
+resource "aws_ecs_service" "service" {
+  name            = var.name
+  cluster         = aws_ecs_cluster.main.id
+  task_definition = aws_ecs_task_definition.service.arn
+  desired_count   = var.desired_count
+  launch_type     = "FARGATE"
+
+  network_configuration {
+    subnets         = var.subnet_ids
+    security_groups = [aws_security_group.service.id]
+  }
+
+  dynamic "load_balancer" {
+    for_each = var.target_group_arn == null ? [] : [var.target_group_arn]
+    content {
+      target_group_arn = load_balancer.value
+      container_name   = var.name
+      container_port   = var.container_port
+    }
+  }
+
+  deployment_circuit_breaker {
+    enable   = true
+    rollback = true
+  }
+}
+`
+}
+
+// Returns the content for infra/modules/service/outputs.tf (AWS)
+func terraformAWSOutputsContent() string {
+	return `output "cluster_name" {
+  value = aws_ecs_cluster.main.name
+}
+
+output "service_name" {
+  value = aws_ecs_service.service.name
+}
+
+output "service_security_group_id" {
+  description = "Security group of the tasks, to open to a load balancer"
+  value       = aws_security_group.service.id
+}
+
+output "db_endpoint" {
+  value = aws_db_instance.db.endpoint
+}
+
+output "secret_arns" {
+  description = "Secrets Manager ARNs by setting"
+  value       = merge({ DB_PASSWORD = aws_secretsmanager_secret.db_password.arn }, { for key, s in aws_secretsmanager_secret.settings : key => s.arn })
+}
+`
+}
+
+// Returns the content for infra/environments/<env>/main.tf (AWS)
+func terraformAWSEnvironmentContent(projectName string, env terraformEnvironment, secrets []string) string {
+	instanceClass := map[string]string{"small": "db.t4g.micro", "medium": "db.t4g.medium"}[env.dbTier]
+	return fmt.Sprintf(`terraform {
+  required_version = ">= 1.5"
+
+  # Keep the state remotely, one key per environment:
+  # backend "s3" {
+  #   bucket = "my-terraform-state"
+  #   key    = "%[1]s/%[2]s.tfstate"
+  #   region = "eu-west-1"
+  # }
+}
+
+provider "aws" {
+  region = var.region
+
+  default_tags {
+    tags = {
+      Project     = "%[1]s"
+      Environment = "%[2]s"
+      ManagedBy   = "terraform"
+    }
+  }
+}
+
+variable "region" {
+  type    = string
+  default = "eu-west-1"
+}
+
+variable "image" {
+  description = "Container image to deploy"
+  type        = string
+}
+
+variable "vpc_id" {
+  type = string
+}
+
+variable "subnet_ids" {
+  type = list(string)
+}
+
+module "service" {
+  source = "../../modules/service"
+
+  name        = "%[1]s"
+  environment = "%[2]s"
+  image       = var.image
+  vpc_id      = var.vpc_id
+  subnet_ids  = var.subnet_ids
+  secrets     = %[3]s
+
+  desired_count       = %[4]d
+  cpu                 = "%[5]s"
+  memory              = "%[6]s"
+  db_instance_class   = "%[7]s"
+  db_multi_az         = %[8]t
+  deletion_protection = %[9]t
+}
+
+output "service" {
+  value = module.service
+}
+`, projectName, env.name, hclList(secrets), env.desiredCount, env.cpu, env.memory, instanceClass, env.highAvailability, env.deletionProtection)
+}
+
+// Returns the content for infra/modules/service/versions.tf (Google Cloud)
+func terraformGoogleVersionsContent() string {
+	return `terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.6"
+    }
+  }
+}
+`
+}
+
+// Returns the content for infra/modules/service/variables.tf (Google Cloud)
+func terraformGoogleVariablesContent() string {
+	return `variable "name" {
+  description = "Name of the service, matching deploy/cloudrun/service.yaml"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment of the service, selecting its config file (APP_ENV)"
+  type        = string
+}
+
+variable "project_id" {
+  description = "Google Cloud project of the environment"
+  type        = string
+}
+
+variable "region" {
+  description = "Region of the service and its database"
+  type        = string
+}
+
+variable "image" {
+  description = "Container image of the service"
+  type        = string
+}
+
+variable "min_instances" {
+  description = "Instances kept warm"
+  type        = number
+  default     = 0
+}
+
+variable "max_instances" {
+  description = "Upper bound of autoscaling"
+  type        = number
+  default     = 10
+}
+
+variable "cpu" {
+  description = "CPU limit of an instance"
+  type        = string
+  default     = "1"
+}
+
+variable "memory" {
+  description = "Memory limit of an instance"
+  type        = string
+  default     = "512Mi"
+}
+
+variable "public" {
+  description = "Whether unauthenticated requests are allowed"
+  type        = bool
+  default     = true
+}
+
+variable "db_tier" {
+  description = "Machine tier of the Cloud SQL instance"
+  type        = string
+  default     = "db-f1-micro"
+}
+
+variable "db_high_availability" {
+  description = "Whether the database has a standby in another zone"
+  type        = bool
+  default     = false
+}
+
+variable "deletion_protection" {
+  description = "Whether the database is protected from deletion"
+  type        = bool
+  default     = true
+}
+
+variable "secrets" {
+  description = "Settings read from Secret Manager, created empty; add a version to each before deploying"
+  type        = list(string)
+  default     = []
+}
+`
+}
+
+// Returns the content for infra/modules/service/main.tf (Google Cloud)
+func terraformGoogleMainContent() string {
+	return `locals {
+  # The database name and user follow the DB_NAME and DB_USER settings
+  db_name = replace(var.name, "-", "_")
+  # Secret IDs use the same naming as deploy/cloudrun/service.yaml
+  secret_ids = { for key in var.secrets : key => "${var.name}-${lower(replace(key, "_", "-"))}" }
+}
+
+resource "google_project_service" "apis" {
+  for_each           = toset(["run.googleapis.com", "sqladmin.googleapis.com", "secretmanager.googleapis.com", "artifactregistry.googleapis.com"])
+  project            = var.project_id
+  service            = each.key
+  disable_on_destroy = false
+}
+
+# Repository pushed to by make image
+resource "google_artifact_registry_repository" "images" {
+  project       = var.project_id
+  location      = var.region
+  repository_id = var.name
+  format        = "DOCKER"
+  depends_on    = [google_project_service.apis]
+}
+
+resource "google_service_account" "service" {
+  project      = var.project_id
+  account_id   = "${var.name}-run"
+  display_name = "${var.name} Cloud Run service"
+}
+
+resource "google_project_iam_member" "cloudsql_client" {
+  project = var.project_id
+  role    = "roles/cloudsql.client"
+  member  = "serviceAccount:${google_service_account.service.email}"
+}
+
+# --- Secrets ---
+
+resource "random_password" "db" {
+  length  = 32
+  special = false
+}
+
+resource "google_secret_manager_secret" "db_password" {
+  project   = var.project_id
+  secret_id = "${var.name}-db-password"
+  replication {
+    auto {}
+  }
+  depends_on = [google_project_service.apis]
+}
+
+resource "google_secret_manager_secret_version" "db_password" {
+  secret      = google_secret_manager_secret.db_password.id
+  secret_data = random_password.db.result
+}
+
+resource "google_secret_manager_secret" "settings" {
+  for_each  = local.secret_ids
+  project   = var.project_id
+  secret_id = each.value
+  replication {
+    auto {}
+  }
+  depends_on = [google_project_service.apis]
+}
+
+resource "google_secret_manager_secret_iam_member" "service" {
+  for_each  = merge({ DB_PASSWORD = google_secret_manager_secret.db_password.id }, { for key, s in google_secret_manager_secret.settings : key => s.id })
+  secret_id = each.value
+  role      = "roles/secretmanager.secretAccessor"
+  member    = "serviceAccount:${google_service_account.service.email}"
+}
+
+# --- Database ---
+
+resource "google_sql_database_instance" "db" {
+  project             = var.project_id
+  name                = var.name
+  region              = var.region
+  database_version    = "POSTGRES_16"
+  deletion_protection = var.deletion_protection
+
+  settings {
+    tier              = var.db_tier
+    availability_type = var.db_high_availability ? "REGIONAL" : "ZONAL"
+    backup_configuration {
+      enabled = true
+    }
+  }
+
+  depends_on = [google_project_service.apis]
+}
+
+resource "google_sql_database" "db" {
+  project  = var.project_id
+  instance = google_sql_database_instance.db.name
+  name     = local.db_name
+}
+
+resource "google_sql_user" "db" {
+  project  = var.project_id
+  instance = google_sql_database_instance.db.name
+  name     = local.db_name
+  password = random_password.db.result
+}
+
+# --- Service ---
+
+resource "google_cloud_run_v2_service" "service" {
+  project  = var.project_id
+  name     = var.name
+  location = var.region
+  ingress  = "INGRESS_TRAFFIC_ALL"
+
+  template {
+    service_account = google_service_account.service.email
+
+    scaling {
+      min_instance_count = var.min_instances
+      max_instance_count = var.max_instances
+    }
+
+    volumes {
+      name = "cloudsql"
+      cloud_sql_instance {
+        instances = [google_sql_database_instance.db.connection_name]
+      }
+    }
+
+    # Environment variables override the settings of configs/<APP_ENV>.*
+    containers {
+      image = var.image
+
+      ports {
+        container_port = 8080
+      }
+
+      resources {
+        limits = {
+          cpu    = var.cpu
+          memory = var.memory
+        }
+        startup_cpu_boost = true
+      }
+
+      env {
+        name  = "APP_ENV"
+        value = var.environment
+      }
+      env {
+        name  = "DB_HOST"
+        value = "/cloudsql/${google_sql_database_instance.db.connection_name}"
+      }
+      env {
+        name  = "DB_NAME"
+        value = local.db_name
+      }
+      env {
+        name  = "DB_USER"
+        value = local.db_name
+      }
+      env {
+        name = "DB_PASSWORD"
+        value_source {
+          secret_key_ref {
+            secret  = google_secret_manager_secret.db_password.secret_id
+            version = "latest"
+          }
+        }
+      }
+      dynamic "env" {
+        for_each = google_secret_manager_secret.settings
+        content {
+          name = env.key
+          value_source {
+            secret_key_ref {
+              secret  = env.value.secret_id
+              version = "latest"
+            }
+          }
+        }
+      }
+
+      volume_mounts {
+        name       = "cloudsql"
+        mount_path = "/cloudsql"
+      }
+
+      # Ready once the database is reachable, which is retried for up to
+      # STARTUP_TIMEOUT (2m)
+      startup_probe {
+        http_get {
+          path = "/readyz"
+        }
+        period_seconds    = 2
+        failure_threshold = 60
+      }
+      liveness_probe {
+        http_get {
+          path = "/healthz"
+        }
+        period_seconds = 30
+      }
+    }
+  }
+
+  # make deploy rolls out new images; Terraform only creates the service
+  lifecycle {
+    ignore_changes = [template[0].containers[0].image, client, client_version]
+  }
+
+  depends_on = [google_secret_manager_secret_iam_member.service, google_secret_manager_secret_version.db_password]
+}
+
+resource "google_cloud_run_v2_service_iam_member" "public" {
+  count    = var.public ? 1 : 0
+  project  = var.project_id
+  location = var.region
+  name     = google_cloud_run_v2_service.service.name
+  role     = "roles/run.invoker"
+  member   = "allUsers"
+}
+`
+}
+
+// Returns the content for infra/modules/service/outputs.tf (Google Cloud)
+func terraformGoogleOutputsContent() string {
+	return `output "url" {
+  value = google_cloud_run_v2_service.service.uri
+}
+
+output "image_repository" {
+  value = "${var.region}-docker.pkg.dev/${var.project_id}/${google_artifact_registry_repository.images.repository_id}"
+}
+
+output "db_connection_name" {
+  value = google_sql_database_instance.db.connection_name
+}
+
+output "service_account" {
+  value = google_service_account.service.email
+}
+`
+}
+
+// Returns the content for infra/environments/<env>/main.tf (Google Cloud)
+func terraformGoogleEnvironmentContent(projectName string, env terraformEnvironment, secrets []string) string {
+	dbTier := map[string]string{"small": "db-f1-micro", "medium": "db-custom-1-3840"}[env.dbTier]
+	cpu := map[string]string{"256": "1", "512": "2"}[env.cpu]
+	memory := env.memory + "Mi"
+	// Cloud Run scales to zero: the extra instances of the environment are kept warm
+	return fmt.Sprintf(`terraform {
+  required_version = ">= 1.5"
+
+  # Keep the state remotely, one prefix per environment:
+  # backend "gcs" {
+  #   bucket = "my-terraform-state"
+  #   prefix = "%[1]s/%[2]s"
+  # }
+}
+
+provider "google" {
+  project = var.project_id
+  region  = var.region
+
+  default_labels = {
+    project     = "%[1]s"
+    environment = "%[2]s"
+    managed-by  = "terraform"
+  }
+}
+
+variable "project_id" {
+  description = "Google Cloud project of the %[2]s environment"
+  type        = string
+}
+
+variable "region" {
+  type    = string
+  default = "europe-west1"
+}
+
+variable "image" {
+  description = "Container image to deploy"
+  type        = string
+}
+
+module "service" {
+  source = "../../modules/service"
+
+  name        = "%[1]s"
+  environment = "%[2]s"
+  project_id  = var.project_id
+  region      = var.region
+  image       = var.image
+  secrets     = %[3]s
+
+  min_instances        = %[4]d
+  cpu                  = "%[5]s"
+  memory               = "%[6]s"
+  db_tier              = "%[7]s"
+  db_high_availability = %[8]t
+  deletion_protection  = %[9]t
+}
+
+output "service" {
+  value = module.service
+}
+`, projectName, env.name, hclList(secrets), env.desiredCount-1, cpu, memory, dbTier, env.highAvailability, env.deletionProtection)
+}
@@ -0,0 +1,832 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Returns the files of the optional TOTP two-factor authentication module of the accounts component
+func totpFiles(p project) []generatedFile {
+	files := p.migration("create_account_totp", totpMigrationUpContent(), totpMigrationDownContent())
+	return append(files,
+		generatedFile{path: filepath.Join("internal", "repository", "totp_repository.go"), content: totpRepositoryGoContent()},
+		generatedFile{path: filepath.Join("internal", "services", "totp_service.go"), content: totpServiceGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "services", "totp_service_test.go"), content: totpServiceTestGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "middlewares", "totp.go"), content: totpMiddlewareGoContent(p.modulePath)},
+		generatedFile{path: filepath.Join("internal", "handlers", "totp_handler.go"), content: totpHandlerGoContent(p.modulePath)},
+	)
+}
+
+// Returns the wiring steps of the TOTP module
+func totpSteps() []string {
+	return []string{
+		"Fetch the TOTP dependency: go get " + goGetArgs("github.com/pquerna/otp") + " && go mod tidy",
+		"Generate a 32-byte key encrypting the TOTP secrets at rest (openssl rand -hex 32) and load it from the environment, never from the config files",
+		"Create the service: totpSvc, err := services.NewTOTPService(repository.NewTOTPRepository(db), \"MyApp\", key)",
+		"Set the signed-in account on the request context from your session layer with middlewares.WithAccount(ctx, id, secondFactorDone)",
+		"Mount the endpoints: handlers.NewTOTPHandler(accountSvc, totpSvc).Register(mux) and log in with POST /accounts/login/totp when an account has 2FA enabled",
+		"Protect sensitive routes with middlewares.RequireTOTP(totpSvc)",
+	}
+}
+
+// Returns the content for the account_totp up migration
+func totpMigrationUpContent() string {
+	return `-- TOTP secrets are encrypted by the application (AES-GCM) before being stored
+CREATE TABLE IF NOT EXISTS account_totp (
+    account_id     BIGINT PRIMARY KEY REFERENCES accounts (id) ON DELETE CASCADE,
+    key_sealed     BYTEA NOT NULL,
+    confirmed_at   TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS account_recovery_codes (
+    id         BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    code_hash  BYTEA NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS account_recovery_codes_account_idx ON account_recovery_codes (account_id);
+`
+}
+
+// Returns the content for the account_totp down migration
+func totpMigrationDownContent() string {
+	return `DROP TABLE IF EXISTS account_recovery_codes;
+DROP TABLE IF EXISTS account_totp;
+`
+}
+
+// Returns the content for internal/repository/totp_repository.go
+func totpRepositoryGoContent() string {
+	return `package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrTOTPAlreadyEnabled is returned when setting up TOTP for an account that already confirmed it
+var ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// TOTPEnrollment is the TOTP state of an account
+type TOTPEnrollment struct {
+	AccountID    int64
+	KeySealed    []byte
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+// TOTPRepository stores TOTP enrollments and recovery codes
+type TOTPRepository struct {
+	db *sql.DB
+}
+
+// NewTOTPRepository creates a new TOTPRepository
+func NewTOTPRepository(db *sql.DB) *TOTPRepository {
+	return &TOTPRepository{db: db}
+}
+
+// Get returns the enrollment of an account
+func (r *TOTPRepository) Get(ctx context.Context, accountID int64) (*TOTPEnrollment, error) {
+	var e TOTPEnrollment
+	err := r.db.QueryRowContext(ctx,
+		"SELECT account_id, key_sealed, confirmed_at, last_used_step FROM account_totp WHERE account_id = $1", accountID,
+	).Scan(&e.AccountID, &e.KeySealed, &e.ConfirmedAt, &e.LastUsedStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// SavePending stores a new, unconfirmed key, replacing any unconfirmed one. A
+// confirmed enrollment is left untouched.
+func (r *TOTPRepository) SavePending(ctx context.Context, accountID int64, keySealed []byte) error {
+	res, err := r.db.ExecContext(ctx, ` + "`" + `
+		INSERT INTO account_totp (account_id, key_sealed) VALUES ($1, $2)
+		ON CONFLICT (account_id) DO UPDATE SET key_sealed = EXCLUDED.key_sealed, last_used_step = 0, created_at = now()
+		WHERE account_totp.confirmed_at IS NULL` + "`" + `, accountID, keySealed)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+// Confirm enables TOTP and replaces the recovery codes, in one transaction
+func (r *TOTPRepository) Confirm(ctx context.Context, accountID, step int64, codeHashes [][]byte) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE account_totp SET confirmed_at = now(), last_used_step = $2 WHERE account_id = $1", accountID, step); err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(ctx, tx, accountID, codeHashes)
+	})
+}
+
+// UseStep records the time step of an accepted code. It fails with ErrNotFound when
+// the step is not newer than the last one used, so a code cannot be replayed.
+func (r *TOTPRepository) UseStep(ctx context.Context, accountID, step int64) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE account_totp SET last_used_step = $2 WHERE account_id = $1 AND last_used_step < $2", accountID, step)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used
+func (r *TOTPRepository) UseRecoveryCode(ctx context.Context, accountID int64, codeHash []byte) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE account_recovery_codes SET used_at = now() WHERE account_id = $1 AND code_hash = $2 AND used_at IS NULL",
+		accountID, codeHash)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes invalidates the recovery codes of an account and stores new ones
+func (r *TOTPRepository) ReplaceRecoveryCodes(ctx context.Context, accountID int64, codeHashes [][]byte) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, accountID, codeHashes)
+	})
+}
+
+// Delete disables TOTP and removes the recovery codes
+func (r *TOTPRepository) Delete(ctx context.Context, accountID int64) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM account_recovery_codes WHERE account_id = $1", accountID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM account_totp WHERE account_id = $1", accountID)
+		return err
+	})
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, accountID int64, codeHashes [][]byte) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM account_recovery_codes WHERE account_id = $1", accountID); err != nil {
+		return err
+	}
+	for _, h := range codeHashes {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO account_recovery_codes (account_id, code_hash) VALUES ($1, $2)", accountID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TOTPRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+`
+}
+
+// Returns the content for internal/services/totp_service.go
+func totpServiceGoContent(modulePath string) string {
+	return fmt.Sprintf(`package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+
+	dbmodels "%s/internal/models/db"
+	"%s/internal/repository"
+)
+
+const (
+	totpPeriod        = 30 * time.Second
+	recoveryCodeCount = 10
+)
+
+var (
+	// ErrInvalidTOTPCode is returned for wrong, expired or replayed codes
+	ErrInvalidTOTPCode = errors.New("invalid two-factor code")
+	// ErrTOTPNotEnabled is returned when the account has not confirmed TOTP
+	ErrTOTPNotEnabled = errors.New("two-factor authentication is not enabled")
+)
+
+// totpValidateOpts match what authenticator apps use; one step of skew is
+// accepted on each side for clock drift
+var totpValidateOpts = totp.ValidateOpts{Period: uint(totpPeriod.Seconds()), Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// TOTPService provisions and verifies time-based one-time passwords (RFC 6238)
+// and recovery codes
+type TOTPService struct {
+	repo   *repository.TOTPRepository
+	issuer string
+	aead   cipher.AEAD
+	now    func() time.Time
+}
+
+// NewTOTPService creates a new TOTPService. issuer is the name shown in
+// authenticator apps; key is the 32-byte AES key encrypting the secrets at rest.
+func NewTOTPService(repo *repository.TOTPRepository, issuer string, key []byte) (*TOTPService, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("totp encryption key: %%w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &TOTPService{repo: repo, issuer: issuer, aead: aead, now: time.Now}, nil
+}
+
+// Setup provisions a new secret for the account. It only becomes active once a
+// code generated from it is passed to Confirm.
+func (s *TOTPService) Setup(ctx context.Context, account *dbmodels.Account) (*otp.Key, error) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: s.issuer, AccountName: account.Email})
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.seal([]byte(key.URL()))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SavePending(ctx, account.ID, sealed); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// QRCode renders the pending or active key of the account as a PNG, to be scanned
+// by an authenticator app
+func (s *TOTPService) QRCode(ctx context.Context, accountID int64, size int) ([]byte, error) {
+	e, err := s.repo.Get(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.key(e)
+	if err != nil {
+		return nil, err
+	}
+	img, err := key.Image(size, size)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Confirm checks a code against the pending key, enables TOTP and returns the
+// recovery codes. They are only shown once; the database keeps their hashes.
+func (s *TOTPService) Confirm(ctx context.Context, accountID int64, code string) ([]string, error) {
+	e, err := s.repo.Get(ctx, accountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTOTPNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.ConfirmedAt != nil {
+		return nil, repository.ErrTOTPAlreadyEnabled
+	}
+	step, err := s.match(e, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Confirm(ctx, accountID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Enabled reports whether the account has confirmed TOTP
+func (s *TOTPService) Enabled(ctx context.Context, accountID int64) (bool, error) {
+	e, err := s.repo.Get(ctx, accountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return e.ConfirmedAt != nil, nil
+}
+
+// Verify checks a code, or else a recovery code, for an account with TOTP enabled.
+// Each code is accepted once.
+func (s *TOTPService) Verify(ctx context.Context, accountID int64, code string) error {
+	e, err := s.repo.Get(ctx, accountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrTOTPNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	if e.ConfirmedAt == nil {
+		return ErrTOTPNotEnabled
+	}
+
+	if step, err := s.match(e, code); err == nil {
+		err = s.repo.UseStep(ctx, accountID, step)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidTOTPCode
+		}
+		return err
+	}
+
+	err = s.repo.UseRecoveryCode(ctx, accountID, hashToken(normalizeRecoveryCode(code)))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidTOTPCode
+	}
+	return err
+}
+
+// RegenerateRecoveryCodes invalidates the existing recovery codes and returns new ones
+func (s *TOTPService) RegenerateRecoveryCodes(ctx context.Context, accountID int64) ([]string, error) {
+	if ok, err := s.Enabled(ctx, accountID); err != nil || !ok {
+		if err == nil {
+			err = ErrTOTPNotEnabled
+		}
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	return codes, s.repo.ReplaceRecoveryCodes(ctx, accountID, hashes)
+}
+
+// Disable turns TOTP off for the account
+func (s *TOTPService) Disable(ctx context.Context, accountID int64) error {
+	return s.repo.Delete(ctx, accountID)
+}
+
+// match returns the time step a code was generated for, within one step of skew
+func (s *TOTPService) match(e *repository.TOTPEnrollment, code string) (int64, error) {
+	key, err := s.key(e)
+	if err != nil {
+		return 0, err
+	}
+	now := s.now()
+	for _, skew := range []int64{0, -1, 1} {
+		t := now.Add(time.Duration(skew) * totpPeriod)
+		want, err := totp.GenerateCodeCustom(key.Secret(), t, totpValidateOpts)
+		if err != nil {
+			return 0, err
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return t.Unix() / int64(totpPeriod.Seconds()), nil
+		}
+	}
+	return 0, ErrInvalidTOTPCode
+}
+
+func (s *TOTPService) key(e *repository.TOTPEnrollment) (*otp.Key, error) {
+	url, err := s.open(e.KeySealed)
+	if err != nil {
+		return nil, err
+	}
+	return otp.NewKeyFromURL(string(url))
+}
+
+func (s *TOTPService) seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s *TOTPService) open(sealed []byte) ([]byte, error) {
+	n := s.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errors.New("totp: sealed key too short")
+	}
+	return s.aead.Open(nil, sealed[:n], sealed[n:], nil)
+}
+
+// newRecoveryCodes returns recovery codes formatted as xxxxx-xxxxx, and their hashes
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([][]byte, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		c := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+		codes[i] = c[:5] + "-" + c[5:]
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+`, modulePath, modulePath)
+}
+
+// Returns the content for internal/services/totp_service_test.go
+func totpServiceTestGoContent(modulePath string) string {
+	return fmt.Sprintf(`package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+
+	"%s/internal/repository"
+)
+
+func newTestTOTPService(t *testing.T, now time.Time) *TOTPService {
+	t.Helper()
+	svc, err := NewTOTPService(nil, "Test", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestTOTPMatch(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	svc := newTestTOTPService(t, now)
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "Test", AccountName: "user@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := svc.seal([]byte(key.URL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &repository.TOTPEnrollment{KeySealed: sealed}
+
+	for _, tc := range []struct {
+		name  string
+		at    time.Time
+		valid bool
+	}{
+		{"current step", now, true},
+		{"previous step", now.Add(-totpPeriod), true},
+		{"next step", now.Add(totpPeriod), true},
+		{"too old", now.Add(-3 * totpPeriod), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := totp.GenerateCodeCustom(key.Secret(), tc.at, totpValidateOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			step, err := svc.match(e, code)
+			if tc.valid && (err != nil || step != tc.at.Unix()/30) {
+				t.Errorf("match = %%d, %%v; want step %%d", step, err, tc.at.Unix()/30)
+			}
+			if !tc.valid && err != ErrInvalidTOTPCode {
+				t.Errorf("match error = %%v, want ErrInvalidTOTPCode", err)
+			}
+		})
+	}
+}
+
+func TestSealedKeyIsEncrypted(t *testing.T) {
+	svc := newTestTOTPService(t, time.Now())
+	sealed, err := svc.seal([]byte("otpauth://totp/secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Error("sealed key contains the plaintext")
+	}
+	opened, err := svc.open(sealed)
+	if err != nil || string(opened) != "otpauth://totp/secret" {
+		t.Errorf("open = %%q, %%v", opened, err)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %%d codes, want %%d", len(codes), recoveryCodeCount)
+	}
+	seen := map[string]bool{}
+	for i, c := range codes {
+		if len(c) != 11 || c[5] != '-' || seen[c] {
+			t.Errorf("code %%q is malformed or duplicated", c)
+		}
+		seen[c] = true
+		// Users may type the code without the dash, surrounded by spaces
+		typed := normalizeRecoveryCode(" " + c[:5] + c[6:] + " ")
+		if !bytes.Equal(hashToken(typed), hashes[i]) {
+			t.Errorf("hash of %%q does not match the stored one", c)
+		}
+	}
+}
+`, modulePath)
+}
+
+// Returns the content for internal/middlewares/totp.go
+func totpMiddlewareGoContent(modulePath string) string {
+	return fmt.Sprintf(`package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"%s/internal/services"
+)
+
+// TOTPHeader carries a one-time code for requests needing a fresh second factor
+const TOTPHeader = "X-TOTP-Code"
+
+type accountKey struct{}
+
+type accountAuth struct {
+	id           int64
+	secondFactor bool
+}
+
+// WithAccount marks the request as made by the signed-in account. The session layer
+// calls it, telling whether the session already passed the second factor.
+func WithAccount(ctx context.Context, accountID int64, secondFactor bool) context.Context {
+	return context.WithValue(ctx, accountKey{}, accountAuth{id: accountID, secondFactor: secondFactor})
+}
+
+// AccountIDFromContext returns the signed-in account, if any
+func AccountIDFromContext(ctx context.Context) (int64, bool) {
+	a, ok := ctx.Value(accountKey{}).(accountAuth)
+	return a.id, ok
+}
+
+// RequireTOTP rejects requests from accounts with TOTP enabled unless the session
+// passed the second factor or the request carries a valid code in the X-TOTP-Code
+// header. Requests without a signed-in account get 401 Unauthorized.
+func RequireTOTP(svc *services.TOTPService) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			a, ok := r.Context().Value(accountKey{}).(accountAuth)
+			if !ok {
+				writeTOTPError(w, http.StatusUnauthorized, "authentication required")
+				return
+			}
+			if a.secondFactor {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			enabled, err := svc.Enabled(r.Context(), a.id)
+			if err != nil {
+				writeTOTPError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			if enabled {
+				code := r.Header.Get(TOTPHeader)
+				if code == "" {
+					writeTOTPError(w, http.StatusUnauthorized, "two-factor code required")
+					return
+				}
+				if err := svc.Verify(r.Context(), a.id, code); err != nil {
+					if errors.Is(err, services.ErrInvalidTOTPCode) {
+						writeTOTPError(w, http.StatusUnauthorized, err.Error())
+					} else {
+						writeTOTPError(w, http.StatusInternalServerError, "internal error")
+					}
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeTOTPError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+`, modulePath)
+}
+
+// Returns the content for internal/handlers/totp_handler.go
+func totpHandlerGoContent(modulePath string) string {
+	return fmt.Sprintf(`package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"%[1]s/internal/middlewares"
+	"%[1]s/internal/repository"
+	"%[1]s/internal/services"
+)
+
+const totpQRCodeSize = 256
+
+// TOTPHandler exposes two-factor authentication over HTTP
+type TOTPHandler struct {
+	accounts *services.AccountService
+	totp     *services.TOTPService
+}
+
+// NewTOTPHandler creates a new TOTPHandler
+func NewTOTPHandler(accounts *services.AccountService, totp *services.TOTPService) *TOTPHandler {
+	return &TOTPHandler{accounts: accounts, totp: totp}
+}
+
+// Register mounts the 2FA routes on mux. All but the login route act on the
+// signed-in account.
+func (h *TOTPHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /accounts/login/totp", h.login)
+	mux.HandleFunc("POST /accounts/2fa/setup", h.setup)
+	mux.HandleFunc("GET /accounts/2fa/qr.png", h.qrCode)
+	mux.HandleFunc("POST /accounts/2fa/confirm", h.confirm)
+	mux.Handle("POST /accounts/2fa/recovery-codes", middlewares.RequireTOTP(h.totp)(http.HandlerFunc(h.recoveryCodes)))
+	mux.Handle("DELETE /accounts/2fa", middlewares.RequireTOTP(h.totp)(http.HandlerFunc(h.disable)))
+}
+
+type totpRequest struct {
+	Email    string `+"`"+`json:"email"`+"`"+`
+	Password string `+"`"+`json:"password"`+"`"+`
+	// Code is a one-time code from the authenticator app or a recovery code
+	Code string `+"`"+`json:"code"`+"`"+`
+}
+
+type totpSetupResponse struct {
+	Secret     string `+"`"+`json:"secret"`+"`"+`
+	OTPAuthURL string `+"`"+`json:"otpauth_url"`+"`"+`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `+"`"+`json:"recovery_codes"`+"`"+`
+}
+
+// login checks the password then the second factor
+func (h *TOTPHandler) login(w http.ResponseWriter, r *http.Request) {
+	var req totpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	account, err := h.accounts.Login(r.Context(), req.Email, req.Password)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	err = h.totp.Verify(r.Context(), account.ID, req.Code)
+	switch {
+	case errors.Is(err, services.ErrInvalidTOTPCode):
+		writeError(w, http.StatusUnauthorized, err.Error())
+	case err != nil && !errors.Is(err, services.ErrTOTPNotEnabled):
+		writeError(w, http.StatusInternalServerError, "internal error")
+	default:
+		// Issue a session that passed the second factor for the account here
+		writeJSON(w, http.StatusOK, toAccountResponse(account))
+	}
+}
+
+func (h *TOTPHandler) setup(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := h.accountID(w, r)
+	if !ok {
+		return
+	}
+	account, err := h.accounts.Get(r.Context(), accountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	key, err := h.totp.Setup(r.Context(), account)
+	if errors.Is(err, repository.ErrTOTPAlreadyEnabled) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(w, http.StatusCreated, totpSetupResponse{Secret: key.Secret(), OTPAuthURL: key.URL()})
+}
+
+func (h *TOTPHandler) qrCode(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := h.accountID(w, r)
+	if !ok {
+		return
+	}
+	img, err := h.totp.QRCode(r.Context(), accountID, totpQRCodeSize)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "two-factor authentication is not set up")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	// The image embeds the secret
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(img)
+}
+
+func (h *TOTPHandler) confirm(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := h.accountID(w, r)
+	if !ok {
+		return
+	}
+	var req totpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	codes, err := h.totp.Confirm(r.Context(), accountID, req.Code)
+	switch {
+	case errors.Is(err, services.ErrInvalidTOTPCode), errors.Is(err, services.ErrTOTPNotEnabled):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repository.ErrTOTPAlreadyEnabled):
+		writeError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	default:
+		writeJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+	}
+}
+
+func (h *TOTPHandler) recoveryCodes(w http.ResponseWriter, r *http.Request) {
+	accountID, _ := middlewares.AccountIDFromContext(r.Context())
+	codes, err := h.totp.RegenerateRecoveryCodes(r.Context(), accountID)
+	if errors.Is(err, services.ErrTOTPNotEnabled) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *TOTPHandler) disable(w http.ResponseWriter, r *http.Request) {
+	accountID, _ := middlewares.AccountIDFromContext(r.Context())
+	if err := h.totp.Disable(r.Context(), accountID); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TOTPHandler) accountID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, ok := middlewares.AccountIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "authentication required")
+	}
+	return id, ok
+}
+`, modulePath)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// layoutDir describes the purpose of the directories of generated projects whose
+// path matches pattern
+type layoutDir struct {
+	pattern     string // path.Match pattern of the slash path relative to the project
+	description string
+}
+
+// Purposes of the directories of generated projects, the first matching pattern
+// applying to a directory
+var layoutDirs = []layoutDir{
+	{"cmd", "entry points, one directory per binary"},
+	{"cmd/worker", "Temporal worker running the workflows"},
+	{"cmd/starter", "command starting a workflow"},
+	{"cmd/lambda-api", "HTTP API on AWS Lambda"},
+	{"cmd/lambda-sqs", "SQS consumer on AWS Lambda"},
+	{"cmd/*", "main package: config, wiring and lifecycle"},
+
+	{"internal", "application code, importable only by this module"},
+	{"internal/handlers", "transport layer: routes and handlers"},
+	{"internal/middlewares", "HTTP middlewares wrapped around every route"},
+	{"internal/services", "business rules, free of HTTP"},
+	{"internal/repository", "data layer: SQL queries"},
+	{"internal/models", "data structures shared by the layers"},
+	{"internal/models/api", "request and response bodies"},
+	{"internal/models/db", "database rows"},
+	{"internal/utils", "small helpers of the application"},
+	{"internal/modules", "modules of the monolith, one vertical slice each"},
+	{"internal/modules/*/handler", "HTTP layer of the module"},
+	{"internal/modules/*/service", "business rules of the module"},
+	{"internal/modules/*/repo", "storage of the module"},
+	{"internal/modules/*", "module: its public API and wiring"},
+	{"internal/domain", "domain model"},
+	{"internal/domain/*", "event-sourced aggregate"},
+	{"internal/cqrs", "command and query buses"},
+	{"internal/commands", "commands and their handlers"},
+	{"internal/queries", "queries and their handlers"},
+	{"internal/auth", "password hashing"},
+	{"internal/imports", "CSV imports"},
+	{"internal/search", "search engine client and documents"},
+	{"internal/payments", "payment provider settings"},
+	{"internal/workflows", "Temporal workflows and activities"},
+	{"internal/etl", "extract-transform-load pipeline"},
+	{"internal/jobs", "transform of the job"},
+	{"internal/messaging", "NATS JetStream setup"},
+	{"internal/consumers", "SQS message processing"},
+	{"internal/lambdahttp", "API Gateway to net/http adapter"},
+
+	{"pkg", "packages free of application logic, importable by other modules"},
+	{"pkg/config", "settings, loaded once in main"},
+	{"pkg/logger", "structured logger"},
+	{"pkg/httpclient", "client for other HTTP APIs"},
+	{"pkg/health", "health checks served on /healthz"},
+	{"pkg/bootstrap", "ordered startup and readiness (/readyz)"},
+	{"pkg/cache", "Redis client"},
+	{"pkg/ratelimit", "Redis-backed rate limiting"},
+	{"pkg/errorreport", "Sentry error reporting"},
+	{"pkg/profiling", "pprof and continuous profiling"},
+	{"pkg/mailer", "email sending"},
+	{"pkg/reports", "PDF, Excel and CSV reports"},
+	{"web", "static files embedded in the binary"},
+	{"web/static", "favicon.ico, robots.txt"},
+
+	{"tests", "tests spanning several packages"},
+	{"tests/golden", "expected responses of the handler tests"},
+	{"tests/unit", "unit tests kept apart from the code"},
+	{"tests/integration", "tests against real dependencies"},
+	{"tests/contract", "provider contract tests"},
+	{"tests/contract/pacts", "contracts recorded by the consumers"},
+	{"migrations", "SQL schema migrations (make migrate)"},
+	{"configs", "settings per environment, selected by APP_ENV"},
+	{"scripts", "development scripts"},
+	{"scripts/seed", "example rows (make seed)"},
+	{"data", "sample input of the job"},
+	{"docs", "documentation and request examples"},
+	{"docs/adr", "architecture decision records"},
+	{"docs/architecture", "C4 diagrams"},
+
+	{"deploy", "deployment manifests"},
+	{"deploy/cloudrun", "Cloud Run service"},
+	{"deploy/profiling", "Grafana Agent configuration"},
+	{"infra", "Terraform infrastructure"},
+	{"infra/modules", "reusable Terraform modules"},
+	{"infra/modules/*", "Terraform module of the service"},
+	{"infra/environments", "one Terraform root module per environment"},
+	{"infra/environments/*", "infrastructure of one environment"},
+	{".github", "GitHub settings and templates"},
+	{".github/workflows", "CI and release workflows"},
+	{".github/ISSUE_TEMPLATE", "issue forms"},
+	{".githooks", "Git hooks (make hooks)"},
+	{".chglog", "git-chglog configuration"},
+	{"bin", "built binaries (make build)"},
+}
+
+// Returns the purpose of the directory at the slash path rel, if layoutDirs has it
+func layoutDescription(rel string) string {
+	for _, d := range layoutDirs {
+		if ok, _ := path.Match(d.pattern, rel); ok {
+			return d.description
+		}
+	}
+	return ""
+}
+
+// treeEntry is a line of gogo tree
+type treeEntry struct {
+	prefix      string // branches drawn before the name
+	name        string
+	description string
+}
+
+// Runs `gogo tree [--files] [--depth=n] [dir]`: prints the directory tree of
+// the project in dir (the current directory by default) with the purpose of
+// each directory of the gogo layout
+func runTree(args []string) {
+	fs := flag.NewFlagSet("gogo tree", flag.ExitOnError)
+	files := fs.Bool("files", false, "list the files too")
+	depth := fs.Int("depth", 0, "levels of directories to print (0 for all)")
+	positional := parseFlags(fs, args)
+	dir := "."
+	switch len(positional) {
+	case 0:
+	case 1:
+		dir = positional[0]
+	default:
+		fatalf(exitUsage, "Please provide at most one directory, e.g. gogo tree myapp")
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fatalf(exitUsage, "%s is not a directory", dir)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		fatalf(exitFailure, "Failed to resolve %s: %v", dir, err)
+	}
+	entries := []treeEntry{{name: filepath.Base(abs) + "/"}}
+	entries = appendTree(entries, abs, "", "", *files, *depth)
+
+	width := 0
+	for _, e := range entries {
+		width = max(width, len([]rune(e.prefix+e.name)))
+	}
+	for _, e := range entries {
+		line := e.prefix + e.name
+		if e.description != "" {
+			line += strings.Repeat(" ", width-len([]rune(line))+2) + e.description
+		}
+		fmt.Println(line)
+	}
+}
+
+// Appends the entries of the directory dir, at the slash path rel of the project,
+// drawn after prefix
+func appendTree(entries []treeEntry, dir, rel, prefix string, files bool, depth int) []treeEntry {
+	children, err := os.ReadDir(dir)
+	if err != nil {
+		fatalf(exitFailure, "Failed to read %s: %v", dir, err)
+	}
+	var shown []os.DirEntry
+	for _, c := range children {
+		if c.Name() == ".git" || !c.IsDir() && !files {
+			continue
+		}
+		shown = append(shown, c)
+	}
+	// Directories first, as they carry the layout
+	sort.SliceStable(shown, func(i, j int) bool {
+		return shown[i].IsDir() && !shown[j].IsDir()
+	})
+
+	for i, c := range shown {
+		branch, next := "├── ", "│   "
+		if i == len(shown)-1 {
+			branch, next = "└── ", "    "
+		}
+		childRel := path.Join(rel, c.Name())
+		if !c.IsDir() {
+			entries = append(entries, treeEntry{prefix: prefix + branch, name: c.Name()})
+			continue
+		}
+		entries = append(entries, treeEntry{prefix: prefix + branch, name: c.Name() + "/", description: layoutDescription(childRel)})
+		if depth == 0 || strings.Count(childRel, "/")+1 < depth {
+			entries = appendTree(entries, filepath.Join(dir, c.Name()), childRel, prefix+next, files, depth)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Name of gogo's own config in gogoHome
+const gogoConfigName = "config.json"
+
+// gogoConfig is the config of gogo itself, holding defaults of its flags
+type gogoConfig struct {
+	Perm string            `json:"perm,omitempty"` // default of --perm, e.g. "0750"
+	Deps map[string]string `json:"deps,omitempty"` // versions of the modules of generated projects, overridden by --dep
+	Open string            `json:"open,omitempty"` // default of --open, e.g. "code"
+
+	ModulePrefix string `json:"module_prefix,omitempty"` // prefix of the module paths, e.g. "github.com/acme"
+	GoPrivate    string `json:"goprivate,omitempty"`     // default of --goprivate, e.g. "github.com/acme"
+}
+
+// Returns the directory of gogo's config and templates: $GOGO_HOME, ~/.gogo by default
+func gogoHome() string {
+	if home := os.Getenv("GOGO_HOME"); home != "" {
+		return home
+	}
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		fatalf(exitFailure, "Failed to locate the home directory: %v", err)
+	}
+	return filepath.Join(dir, ".gogo")
+}
+
+// Reads gogo's config, empty when there is none
+func loadGogoConfig() gogoConfig {
+	var c gogoConfig
+	path := filepath.Join(gogoHome(), gogoConfigName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c
+	}
+	if err != nil {
+		fatalf(exitFailure, "Failed to read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		fatalf(exitUsage, "Failed to parse %s: %v", path, err)
+	}
+	return c
+}